@@ -20,7 +20,25 @@ type AchievementReference struct {
 	VerifiedAt         *time.Time `json:"verified_at"`
 	VerifiedBy         *string    `json:"verified_by"`
 	RejectionNote      *string    `json:"rejection_note"`
+	// Revision bertambah setiap kali prestasi yang ditolak dibuka lagi
+	// untuk direvisi (rejected → draft).
+	Revision           int        `json:"revision"`
+	// CurrentStage adalah tahap verifikasi yang sedang menunggu keputusan.
+	CurrentStage       int        `json:"current_stage"`
 
 	CreatedAt          time.Time  `json:"created_at"`
 	UpdatedAt          time.Time  `json:"updated_at"`
 }
+
+// AchievementStatusHistory adalah satu baris log perubahan status sebuah
+// achievement reference. FromStatus nil untuk baris pembuatan draft.
+type AchievementStatusHistory struct {
+	ID          string    `json:"id"`
+	ReferenceID string    `json:"reference_id"`
+	FromStatus  *string   `json:"from_status"`
+	ToStatus    string    `json:"to_status"`
+	ActorID     *string   `json:"actor_id"`
+	ActorName   *string   `json:"actor_name,omitempty"`
+	Note        *string   `json:"note,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
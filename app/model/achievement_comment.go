@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// AchievementComment adalah satu komentar pada diskusi sebuah prestasi.
+// ParentID diisi untuk balasan. AttachmentURL menunjuk file_url salah satu
+// lampiran prestasi yang dibahas.
+type AchievementComment struct {
+	ID            string     `json:"id"`
+	ReferenceID   string     `json:"reference_id"`
+	ParentID      *string    `json:"parent_id"`
+	AuthorID      *string    `json:"author_id"`
+	AuthorName    *string    `json:"author_name"`
+	Body          string     `json:"body"`
+	AttachmentURL *string    `json:"attachment_url"`
+	CreatedAt     time.Time  `json:"created_at"`
+	EditedAt      *time.Time `json:"edited_at"`
+	DeletedAt     *time.Time `json:"deleted_at"`
+}
+
+type CreateAchievementCommentRequest struct {
+	Body          string  `json:"body"`
+	ParentID      *string `json:"parent_id"`
+	AttachmentURL *string `json:"attachment_url"`
+}
+
+type UpdateAchievementCommentRequest struct {
+	Body string `json:"body"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// AchievementOutboxEvent adalah satu perubahan status reference yang harus
+// disalin ke dokumen Mongo. ToStatus adalah status saat event ditulis; relay
+// tetap memakai status terkini reference sehingga urutan pemrosesan tidak
+// berpengaruh.
+type AchievementOutboxEvent struct {
+	ID                 int64      `json:"id"`
+	ReferenceID        string     `json:"reference_id"`
+	MongoAchievementID string     `json:"mongo_achievement_id"`
+	ToStatus           string     `json:"to_status"`
+	Attempts           int        `json:"attempts"`
+	LastError          *string    `json:"last_error"`
+	AvailableAt        time.Time  `json:"available_at"`
+	ProcessedAt        *time.Time `json:"processed_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// AchievementDocState adalah ringkasan dokumen Mongo untuk rekonsiliasi.
+type AchievementDocState struct {
+	ID        string    `bson:"-" json:"id"`
+	StudentID string    `bson:"studentId" json:"student_id"`
+	Status    string    `bson:"status" json:"status"`
+	IsDeleted bool      `bson:"isDeleted" json:"is_deleted"`
+	CreatedAt time.Time `bson:"createdAt" json:"created_at"`
+}
+
+// Jenis temuan rekonsiliasi.
+const (
+	ReconcileMissingDocument = "missing_document" // reference tanpa dokumen Mongo
+	ReconcileOrphanDocument  = "orphan_document"  // dokumen Mongo tanpa reference
+	ReconcileStatusMismatch  = "status_mismatch"  // status reference ≠ status dokumen
+)
+
+type ReconcileFinding struct {
+	Kind               string `json:"kind"`
+	ReferenceID        string `json:"reference_id,omitempty"`
+	MongoAchievementID string `json:"mongo_achievement_id"`
+	StudentID          string `json:"student_id"`
+	ReferenceStatus    string `json:"reference_status,omitempty"`
+	DocumentStatus     string `json:"document_status,omitempty"`
+	Repaired           bool   `json:"repaired"`
+	Error              string `json:"error,omitempty"`
+}
+
+// ReconcileReport adalah hasil satu kali rekonsiliasi Postgres ↔ Mongo.
+type ReconcileReport struct {
+	CheckedReferences int                `json:"checked_references"`
+	CheckedDocuments  int                `json:"checked_documents"`
+	Repair            bool               `json:"repair"`
+	Findings          []ReconcileFinding `json:"findings"`
+}
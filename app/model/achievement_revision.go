@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// AchievementRevision adalah salinan dokumen prestasi saat ditolak, disimpan
+// ketika mahasiswa membukanya lagi untuk direvisi. Revision adalah nomor
+// revisi yang direview (nilai AchievementReference.Revision saat ditolak).
+type AchievementRevision struct {
+	ID            string      `json:"id"`
+	ReferenceID   string      `json:"reference_id"`
+	Revision      int         `json:"revision"`
+	Snapshot      Achievement `json:"snapshot"`
+	RejectionNote *string     `json:"rejection_note"`
+	ReviewedBy    *string     `json:"reviewed_by"`
+	ReviewedAt    *time.Time  `json:"reviewed_at"`
+	CreatedBy     string      `json:"created_by"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// AchievementFieldChange adalah satu field yang berbeda antara dua versi
+// dokumen prestasi. Field memakai nama JSON bertitik, mis. "details.rank".
+type AchievementFieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// AchievementChanges adalah jawaban GET /achievements/:id/changes.
+type AchievementChanges struct {
+	Revision         int                      `json:"revision"`
+	ReviewedRevision int                      `json:"reviewed_revision"`
+	RejectionNote    *string                  `json:"rejection_note"`
+	ReviewedBy       *string                  `json:"reviewed_by"`
+	ReviewedAt       *time.Time               `json:"reviewed_at"`
+	Changes          []AchievementFieldChange `json:"changes"`
+}
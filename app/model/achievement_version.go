@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Alasan sebuah versi digantikan.
+const (
+	VersionReplacedByUpdate      = "update_draft"
+	VersionReplacedByAttachments = "update_attachments"
+	VersionReplacedByRestore     = "restore"
+)
+
+// AchievementVersion adalah isi dokumen prestasi sebelum diubah, disimpan di
+// koleksi achievement_versions. Version sama dengan Achievement.Version
+// dokumen saat itu.
+type AchievementVersion struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	AchievementID string             `bson:"achievementId" json:"achievement_id"`
+	Version       int                `bson:"version" json:"version"`
+	ReplacedBy    string             `bson:"replacedBy" json:"replaced_by"`
+	Snapshot      Achievement        `bson:"snapshot" json:"snapshot"`
+	ArchivedAt    time.Time          `bson:"archivedAt" json:"archived_at"`
+}
+
+// AchievementVersionSummary adalah satu baris di daftar versi. Versi yang
+// sedang berlaku ditandai Current dan tidak punya ReplacedBy.
+type AchievementVersionSummary struct {
+	Version    int        `json:"version"`
+	Title      string     `json:"title"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ReplacedBy string     `json:"replaced_by,omitempty"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	Current    bool       `json:"current"`
+}
+
+// AchievementVersionDiff adalah jawaban GET /achievements/:id/versions/diff.
+type AchievementVersionDiff struct {
+	From    int                      `json:"from"`
+	To      int                      `json:"to"`
+	Changes []AchievementFieldChange `json:"changes"`
+}
@@ -71,6 +71,10 @@ type Achievement struct {
 	Status    string `bson:"status" json:"status"`        // draft / deleted (FR-005)
 	IsDeleted bool   `bson:"isDeleted" json:"is_deleted"` // soft delete flag
 
+	// Version naik setiap isi draft berubah; isi sebelumnya disimpan di
+	// koleksi achievement_versions. Dokumen lama tanpa field ini bernilai 0.
+	Version int `bson:"version" json:"version"`
+
 	CreatedAt time.Time `bson:"createdAt" json:"created_at"`
 	UpdatedAt time.Time `bson:"updatedAt" json:"updated_at"`
 }
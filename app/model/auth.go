@@ -19,6 +19,8 @@ type LoginData struct {
 	Token        string    `json:"token"`
 	RefreshToken string    `json:"refreshToken"`
 	User         LoginUser `json:"user"`
+	// RecoveryCodes hanya terisi saat MFA baru diaktifkan lewat alur login.
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
 
 type LoginResponse struct {
@@ -31,5 +33,18 @@ type JWTClaims struct {
 	Username    string   `json:"username"`
 	RoleName    string   `json:"role_name"`
 	Permissions []string `json:"permissions"`
+	RoleID      string   `json:"role_id"`
+	UserVersion int      `json:"uver"`
+	RoleVersion int      `json:"rver"`
+	SessionID   string   `json:"sid,omitempty"`
+	// Actor terisi jika token adalah token impersonation: user di atas adalah
+	// target, Actor adalah admin yang sebenarnya memakai token.
+	Actor *Actor `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
+
+// Actor adalah identitas admin di balik token impersonation (klaim "act", RFC 8693).
+type Actor struct {
+	UserID   string `json:"sub"`
+	Username string `json:"username"`
+}
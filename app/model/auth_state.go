@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// AuthState adalah kondisi otorisasi terkini seorang user, dipakai middleware
+// untuk membandingkan dengan versi yang tertanam di JWT.
+type AuthState struct {
+	UserID      string
+	IsActive    bool
+	RoleID      string
+	RoleName    string
+	Permissions []string
+	UserVersion int
+	RoleVersion int
+	// SessionsRevokedAt: access token yang terbit sebelum waktu ini ditolak.
+	SessionsRevokedAt *time.Time
+}
+
+// AuthVersion adalah pasangan versi yang ditanam ke JWT saat token dibuat.
+type AuthVersion struct {
+	User int
+	Role int
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Impersonation adalah catatan audit satu kali admin "login sebagai" user lain.
+// ID-nya ditanam sebagai sid di token impersonation.
+type Impersonation struct {
+	ID           string     `json:"id"`
+	AdminID      string     `json:"admin_id"`
+	TargetUserID string     `json:"target_user_id"`
+	Reason       string     `json:"reason"`
+	IP           string     `json:"ip"`
+	UserAgent    string     `json:"user_agent"`
+	StartedAt    time.Time  `json:"started_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	EndedAt      *time.Time `json:"ended_at"`
+	EndedBy      *string    `json:"ended_by"`
+}
+
+// ImpersonationAction adalah satu request yang dibuat dengan token impersonation.
+type ImpersonationAction struct {
+	ID              string    `json:"id"`
+	ImpersonationID string    `json:"impersonation_id"`
+	Method          string    `json:"method"`
+	Path            string    `json:"path"`
+	Status          int       `json:"status"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type StartImpersonationRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ImpersonationToken adalah hasil memulai impersonation. Tidak ada refresh
+// token; setelah kedaluwarsa admin harus memulai impersonation baru.
+type ImpersonationToken struct {
+	Token           string    `json:"token"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	ImpersonationID string    `json:"impersonation_id"`
+	User            LoginUser `json:"user"`
+}
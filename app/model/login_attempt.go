@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Jenis kunci yang dihitung percobaan login gagalnya.
+const (
+	LoginAttemptAccount = "account" // key = user ID
+	LoginAttemptIP      = "ip"      // key = alamat IP client
+)
+
+// LoginAttempt mencatat percobaan login gagal untuk satu akun atau satu IP.
+type LoginAttempt struct {
+	Kind           string     `json:"kind"`
+	Key            string     `json:"key"`
+	Failures       int        `json:"failures"`
+	FirstFailureAt time.Time  `json:"first_failure_at"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	LockedUntil    *time.Time `json:"locked_until"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// LoginLockEvent dicatat setiap kali sebuah akun atau IP dikunci.
+type LoginLockEvent struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind"`
+	Key         string    `json:"key"`
+	UserID      *string   `json:"user_id"`
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"locked_until"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// UserMFA menyimpan secret TOTP user. EnabledAt nil berarti enrollment
+// belum dikonfirmasi dengan kode pertama.
+type UserMFA struct {
+	UserID       string     `json:"user_id"`
+	Secret       string     `json:"-"`
+	EnabledAt    *time.Time `json:"enabled_at"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// MFAChallengeData dikembalikan login jika password benar tetapi user masih
+// harus memasukkan kode TOTP.
+type MFAChallengeData struct {
+	MFAToken  string    `json:"mfa_token"`
+	Enrolled  bool      `json:"enrolled"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type MFAChallengeResponse struct {
+	Status string           `json:"status"`
+	Data   MFAChallengeData `json:"data"`
+}
+
+type MFAEnrollment struct {
+	Secret      string `json:"secret"`
+	OtpauthURI  string `json:"otpauth_uri"`
+	QRPayload   string `json:"qr_payload"`
+	Issuer      string `json:"issuer"`
+	AccountName string `json:"account_name"`
+}
+
+type MFASetupRequest struct {
+	MFAToken string `json:"mfa_token"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"` // kode TOTP atau kode pemulihan
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+type SetRoleMFARequest struct {
+	Required bool `json:"required"`
+}
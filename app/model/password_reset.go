@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// PasswordResetToken disimpan dalam bentuk hash dan hanya bisa dipakai sekali.
+type PasswordResetToken struct {
+	ID        string     `json:"id"`
+	TokenHash string     `json:"-"`
+	UserID    string     `json:"user_id"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type ForgotPasswordRequest struct {
+	Identifier string `json:"identifier"` // username, email, NIM atau NIP
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
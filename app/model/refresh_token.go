@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// RefreshToken disimpan dalam bentuk hash. Semua token hasil rotasi dari satu
+// login berbagi FamilyID yang sama.
+type RefreshToken struct {
+	ID         string     `json:"id"`
+	TokenHash  string     `json:"-"`
+	UserID     string     `json:"user_id"`
+	FamilyID   string     `json:"family_id"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	ConsumedAt *time.Time `json:"consumed_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// ServiceAccountRole adalah role_name untuk request yang diautentikasi dengan
+// API key. Aksesnya ditentukan oleh permission key dan aturan policy role ini.
+const ServiceAccountRole = "Service Account"
+
+// APIKeyPermissions adalah permission yang boleh dimiliki API key. Integrasi
+// hanya menarik data; permission pengelolaan (user:manage, role:manage) dan
+// penulisan prestasi tidak pernah berlaku untuk key, termasuk key lama.
+var APIKeyPermissions = []string{"achievement:read"}
+
+// APIKeyPermissionAllowed melaporkan apakah p termasuk APIKeyPermissions.
+func APIKeyPermissionAllowed(p string) bool {
+	for _, allowed := range APIKeyPermissions {
+		if p == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceAccount adalah identitas mesin (integrasi) pemilik API key.
+type ServiceAccount struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	IsActive    bool      `json:"is_active"`
+	CreatedBy   *string   `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// APIKey adalah kredensial service account. Key asli hanya ditampilkan sekali
+// saat dibuat; yang disimpan hanya hash-nya.
+type APIKey struct {
+	ID               string     `json:"id"`
+	ServiceAccountID string     `json:"service_account_id"`
+	Name             string     `json:"name"`
+	Prefix           string     `json:"prefix"`
+	KeyHash          string     `json:"-"`
+	Permissions      []string   `json:"permissions"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	LastUsedAt       *time.Time `json:"last_used_at"`
+	CreatedBy        *string    `json:"created_by"`
+	CreatedAt        time.Time  `json:"created_at"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+}
+
+type CreateServiceAccountRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	Permissions   []string `json:"permissions"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// IssuedAPIKey adalah response pembuatan key, satu-satunya saat Key terlihat.
+type IssuedAPIKey struct {
+	Key string `json:"key"`
+	APIKey
+}
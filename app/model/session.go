@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Session adalah satu login (perangkat). ID-nya sama dengan FamilyID refresh
+// token yang diterbitkan saat login tersebut, dan ikut ditanam di JWT (sid).
+type Session struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Current    bool       `json:"current"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// OIDCLoginState menyimpan state, nonce dan PKCE verifier satu percobaan
+// login SSO sampai IdP memanggil callback. State disimpan dalam bentuk hash.
+type OIDCLoginState struct {
+	StateHash    string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+type SSOAuthorizeData struct {
+	AuthorizationURL string    `json:"authorization_url"`
+	State            string    `json:"state"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+type SSOCallbackRequest struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+}
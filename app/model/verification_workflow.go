@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+const (
+	DecisionApproved = "approved"
+	DecisionRejected = "rejected"
+)
+
+// VerificationWorkflow menentukan tahap-tahap persetujuan untuk satu jenis
+// prestasi dan tingkat kompetisi. CompetitionLevel kosong berlaku untuk semua
+// tingkat jenis tersebut.
+type VerificationWorkflow struct {
+	ID               string              `json:"id"`
+	AchievementType  string              `json:"achievement_type"`
+	CompetitionLevel string              `json:"competition_level"`
+	Name             string              `json:"name"`
+	Stages           []VerificationStage `json:"stages"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+}
+
+// VerificationStage adalah satu tahap persetujuan. RoleName kosong berarti
+// role mana pun yang boleh memverifikasi menurut policy.
+type VerificationStage struct {
+	Position int    `json:"position"`
+	Name     string `json:"name"`
+	RoleName string `json:"role_name"`
+}
+
+// DefaultVerificationStages dipakai jika tidak ada workflow yang cocok:
+// satu tahap seperti alur verifikasi lama (Dosen Wali atau Admin).
+var DefaultVerificationStages = []VerificationStage{
+	{Position: 0, Name: "Verifikasi"},
+}
+
+type SaveVerificationWorkflowRequest struct {
+	AchievementType  string              `json:"achievement_type"`
+	CompetitionLevel string              `json:"competition_level"`
+	Name             string              `json:"name"`
+	Stages           []VerificationStage `json:"stages"`
+}
+
+// StageDecision adalah keputusan satu tahap verifikasi. Final menandai tahap
+// terakhir: approved di tahap ini membuat achievement verified.
+type StageDecision struct {
+	ID          string    `json:"id"`
+	ReferenceID string    `json:"reference_id"`
+	Revision    int       `json:"revision"`
+	Stage       int       `json:"stage"`
+	StageName   string    `json:"stage_name"`
+	RoleName    string    `json:"role_name"`
+	Decision    string    `json:"decision"`
+	ActorID     string    `json:"actor_id"`
+	Note        *string   `json:"note,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	Final       bool      `json:"-"`
+}
+
+// BulkReviewRequest adalah body bulk verify/reject: daftar Mongo achievement
+// ID. RejectionNote wajib untuk bulk reject dan dipakai untuk semua item.
+type BulkReviewRequest struct {
+	IDs           []string `json:"ids"`
+	RejectionNote string   `json:"rejection_note"`
+}
+
+// BulkReviewResult adalah hasil satu item bulk verify/reject. Code adalah
+// status HTTP yang akan didapat jika item itu diproses sendiri.
+type BulkReviewResult struct {
+	ID           string `json:"id"`
+	Success      bool   `json:"success"`
+	Code         int    `json:"code"`
+	Status       string `json:"status,omitempty"`
+	CurrentStage int    `json:"current_stage"`
+	Error        string `json:"error,omitempty"`
+}
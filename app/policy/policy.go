@@ -0,0 +1,288 @@
+// Package policy memusatkan aturan akses prestasi: siapa (subject) boleh
+// melakukan apa (action) terhadap prestasi milik mahasiswa tertentu.
+//
+// Aturan per role disimpan dalam tabel Rules, sehingga menambah role baru
+// (misal "Kaprodi") cukup dengan menambah satu baris aturan tanpa menyentuh
+// handler.
+package policy
+
+import (
+	"context"
+	"errors"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+)
+
+type Action string
+
+const (
+	ActionRead   Action = "read"
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	ActionSubmit Action = "submit"
+	ActionVerify Action = "verify"
+	// ActionComment menulis, mengubah dan menghapus komentar sendiri pada
+	// diskusi prestasi.
+	ActionComment Action = "comment"
+	// ActionModerateComment menghapus komentar user lain.
+	ActionModerateComment Action = "moderate-comment"
+	// ActionDecideAnyStage mengizinkan memutuskan tahap verifikasi mana pun,
+	// tidak terbatas pada tahap milik role sendiri.
+	ActionDecideAnyStage Action = "decide-any-stage"
+)
+
+// Scope menentukan prestasi mahasiswa mana yang terjangkau sebuah action.
+type Scope int
+
+const (
+	ScopeNone    Scope = iota
+	ScopeOwn           // hanya prestasi milik mahasiswa itu sendiri
+	ScopeAdvisee       // prestasi mahasiswa bimbingan
+	ScopeAll           // semua prestasi
+	ScopeStage         // semua prestasi, tetapi hanya pada tahap workflow milik role tersebut
+)
+
+// Rules memetakan nama role → action → scope. Action yang tidak tercantum berarti ScopeNone.
+type Rules map[string]map[Action]Scope
+
+// Allows mengembalikan true jika role punya akses apa pun untuk action,
+// tanpa melihat prestasi tertentu.
+func (r Rules) Allows(role string, action Action) bool {
+	return r[role][action] != ScopeNone
+}
+
+var DefaultRules = Rules{
+	"Admin": {
+		ActionRead:   ScopeAll,
+		ActionCreate: ScopeAll,
+		ActionUpdate: ScopeAll,
+		ActionDelete: ScopeAll,
+		ActionSubmit: ScopeAll,
+		ActionVerify: ScopeAll,
+
+		ActionComment:         ScopeAll,
+		ActionModerateComment: ScopeAll,
+		ActionDecideAnyStage:  ScopeAll,
+	},
+	"Mahasiswa": {
+		ActionRead:   ScopeOwn,
+		ActionCreate: ScopeOwn,
+		ActionUpdate: ScopeOwn,
+		ActionDelete: ScopeOwn,
+		ActionSubmit: ScopeOwn,
+
+		ActionComment: ScopeOwn,
+	},
+	"Dosen Wali": {
+		ActionRead:   ScopeAdvisee,
+		ActionVerify: ScopeAdvisee,
+
+		ActionComment: ScopeAdvisee,
+	},
+	// bagian kemahasiswaan menyetujui tahap akhir workflow verifikasi
+	"Kemahasiswaan": {
+		ActionRead:   ScopeAll,
+		ActionVerify: ScopeStage,
+
+		ActionComment: ScopeAll,
+	},
+	// integrasi lewat API key hanya menarik data (tidak ikut berdiskusi);
+	// permission key tetap dicek di route
+	models.ServiceAccountRole: {
+		ActionRead: ScopeAll,
+	},
+}
+
+// BuiltinRole melaporkan apakah name dipakai DefaultRules. Aturan policy,
+// pencarian profil dan tahap workflow verifikasi memakai nama role, jadi
+// role ini tidak boleh diganti namanya atau dihapus.
+func BuiltinRole(name string) bool {
+	_, ok := DefaultRules[name]
+	return ok
+}
+
+var (
+	ErrForbidden       = errors.New("forbidden")
+	ErrProfileNotFound = errors.New("profile not found")
+)
+
+// Subject adalah user yang sedang mengakses beserta profil yang sudah di-resolve.
+type Subject struct {
+	UserID     string
+	Role       string
+	StudentID  string // ID profil mahasiswa, kosong jika tidak punya
+	LecturerID string // ID profil dosen, kosong jika tidak punya
+
+	advisees map[string]bool
+}
+
+type Policy struct {
+	studentRepo  repository.StudentRepository
+	lecturerRepo repository.LecturerRepository
+	rules        Rules
+}
+
+func New(studentRepo repository.StudentRepository, lecturerRepo repository.LecturerRepository) *Policy {
+	return NewWithRules(studentRepo, lecturerRepo, DefaultRules)
+}
+
+func NewWithRules(
+	studentRepo repository.StudentRepository,
+	lecturerRepo repository.LecturerRepository,
+	rules Rules,
+) *Policy {
+	return &Policy{
+		studentRepo:  studentRepo,
+		lecturerRepo: lecturerRepo,
+		rules:        rules,
+	}
+}
+
+// Subject me-resolve profil mahasiswa/dosen yang dibutuhkan aturan role
+// tersebut, satu kali per request.
+func (p *Policy) Subject(ctx context.Context, userID, role string) (*Subject, error) {
+	sub := &Subject{UserID: userID, Role: role}
+
+	needStudent, needLecturer := false, false
+	for _, scope := range p.rules[role] {
+		switch scope {
+		case ScopeOwn:
+			needStudent = true
+		case ScopeAdvisee:
+			needLecturer = true
+		}
+	}
+
+	if needStudent {
+		student, err := p.studentRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if student != nil {
+			sub.StudentID = student.ID
+		}
+	}
+
+	if needLecturer {
+		lecturer, err := p.lecturerRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if lecturer != nil {
+			sub.LecturerID = lecturer.ID
+		}
+	}
+
+	return sub, nil
+}
+
+func (p *Policy) scope(sub *Subject, action Action) Scope {
+	return p.rules[sub.Role][action]
+}
+
+// Can mengembalikan true jika role subject punya akses apa pun untuk action tersebut.
+func (p *Policy) Can(sub *Subject, action Action) bool {
+	return p.scope(sub, action) != ScopeNone
+}
+
+// Authorize memastikan subject boleh melakukan action terhadap prestasi milik studentID.
+func (p *Policy) Authorize(ctx context.Context, sub *Subject, action Action, studentID string) error {
+	switch p.scope(sub, action) {
+	case ScopeAll, ScopeStage:
+		return nil
+
+	case ScopeOwn:
+		if sub.StudentID == "" {
+			return ErrProfileNotFound
+		}
+		if sub.StudentID != studentID {
+			return ErrForbidden
+		}
+		return nil
+
+	case ScopeAdvisee:
+		advisees, err := p.advisees(ctx, sub)
+		if err != nil {
+			return err
+		}
+		if !advisees[studentID] {
+			return ErrForbidden
+		}
+		return nil
+	}
+
+	return ErrForbidden
+}
+
+// AuthorizeStage memastikan subject boleh memutuskan tahap workflow yang
+// dipegang stageRole ("" untuk tahap tanpa role khusus). Role dengan
+// ScopeStage hanya boleh memutuskan tahap miliknya sendiri.
+func (p *Policy) AuthorizeStage(sub *Subject, stageRole string) error {
+	if p.Can(sub, ActionDecideAnyStage) {
+		return nil
+	}
+
+	if stageRole == "" {
+		if p.scope(sub, ActionVerify) == ScopeStage {
+			return ErrForbidden
+		}
+		return nil
+	}
+
+	if sub.Role != stageRole {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// StudentScope mengembalikan cakupan mahasiswa untuk query list.
+// all=true berarti tanpa filter; selain itu hanya studentIDs yang boleh diakses.
+func (p *Policy) StudentScope(ctx context.Context, sub *Subject, action Action) (all bool, studentIDs []string, err error) {
+	switch p.scope(sub, action) {
+	case ScopeAll, ScopeStage:
+		return true, nil, nil
+
+	case ScopeOwn:
+		if sub.StudentID == "" {
+			return false, nil, ErrProfileNotFound
+		}
+		return false, []string{sub.StudentID}, nil
+
+	case ScopeAdvisee:
+		advisees, err := p.advisees(ctx, sub)
+		if err != nil {
+			return false, nil, err
+		}
+		ids := make([]string, 0, len(advisees))
+		for id := range advisees {
+			ids = append(ids, id)
+		}
+		return false, ids, nil
+	}
+
+	return false, nil, ErrForbidden
+}
+
+// advisees memuat daftar mahasiswa bimbingan sekali lalu menyimpannya di subject.
+func (p *Policy) advisees(ctx context.Context, sub *Subject) (map[string]bool, error) {
+	if sub.advisees != nil {
+		return sub.advisees, nil
+	}
+	if sub.LecturerID == "" {
+		return nil, ErrProfileNotFound
+	}
+
+	students, err := p.studentRepo.GetByAdvisorID(ctx, sub.LecturerID)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.advisees = make(map[string]bool, len(students))
+	for _, st := range students {
+		sub.advisees[st.ID] = true
+	}
+
+	return sub.advisees, nil
+}
@@ -0,0 +1,241 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	models "achievement_backend/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// =======================================================
+// MOCK REPOSITORIES
+// =======================================================
+//
+
+type mockStudentRepo struct {
+	students       []models.Student
+	advisorQueries int
+}
+
+func (m *mockStudentRepo) GetAll(ctx context.Context) ([]models.Student, error) {
+	return m.students, nil
+}
+
+func (m *mockStudentRepo) GetByID(ctx context.Context, id string) (*models.Student, error) {
+	for i := range m.students {
+		if m.students[i].ID == id {
+			return &m.students[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockStudentRepo) GetByStudentID(ctx context.Context, studentID string) (*models.Student, error) {
+	return nil, nil
+}
+
+func (m *mockStudentRepo) GetByUserID(ctx context.Context, userID string) (*models.Student, error) {
+	for i := range m.students {
+		if m.students[i].UserID == userID {
+			return &m.students[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockStudentRepo) GetByAdvisorID(ctx context.Context, advisorID string) ([]models.Student, error) {
+	m.advisorQueries++
+	var out []models.Student
+	for _, s := range m.students {
+		if s.AdvisorID != nil && *s.AdvisorID == advisorID {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStudentRepo) Create(ctx context.Context, req models.CreateStudentRequest) (*models.Student, error) {
+	return nil, nil
+}
+
+func (m *mockStudentRepo) Update(ctx context.Context, id string, req models.UpdateStudentRequest) (*models.Student, error) {
+	return nil, nil
+}
+
+func (m *mockStudentRepo) UpdateAdvisor(ctx context.Context, id string, advisorID string) error {
+	return nil
+}
+
+type mockLecturerRepo struct {
+	lecturers []models.Lecturer
+}
+
+func (m *mockLecturerRepo) GetAll(ctx context.Context) ([]models.Lecturer, error) {
+	return m.lecturers, nil
+}
+
+func (m *mockLecturerRepo) GetByID(ctx context.Context, id string) (*models.Lecturer, error) {
+	return nil, nil
+}
+
+func (m *mockLecturerRepo) GetByUserID(ctx context.Context, userID string) (*models.Lecturer, error) {
+	for i := range m.lecturers {
+		if m.lecturers[i].UserID == userID {
+			return &m.lecturers[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockLecturerRepo) Create(ctx context.Context, req models.CreateLecturerRequest) (*models.Lecturer, error) {
+	return nil, nil
+}
+
+func (m *mockLecturerRepo) Update(ctx context.Context, id string, req models.UpdateLecturerRequest) (*models.Lecturer, error) {
+	return nil, nil
+}
+
+func (m *mockLecturerRepo) GetByLecturerID(ctx context.Context, lecturerID string) (*models.Lecturer, error) {
+	return nil, nil
+}
+
+func setupPolicy() (*Policy, *mockStudentRepo) {
+	advisor := "lect-1"
+	students := &mockStudentRepo{
+		students: []models.Student{
+			{ID: "stu-1", UserID: "user-stu-1", AdvisorID: &advisor},
+			{ID: "stu-2", UserID: "user-stu-2"},
+		},
+	}
+	lecturers := &mockLecturerRepo{
+		lecturers: []models.Lecturer{{ID: "lect-1", UserID: "user-lect-1"}},
+	}
+
+	return New(students, lecturers), students
+}
+
+//
+// =======================================================
+// AUTHORIZE
+// =======================================================
+//
+
+func TestPolicy_Admin_FullAccess(t *testing.T) {
+	p, _ := setupPolicy()
+
+	sub, err := p.Subject(context.Background(), "user-admin", "Admin")
+	assert.NoError(t, err)
+
+	assert.NoError(t, p.Authorize(context.Background(), sub, ActionVerify, "stu-2"))
+	assert.NoError(t, p.Authorize(context.Background(), sub, ActionDelete, "stu-1"))
+}
+
+func TestPolicy_Mahasiswa_OwnOnly(t *testing.T) {
+	p, _ := setupPolicy()
+
+	sub, err := p.Subject(context.Background(), "user-stu-1", "Mahasiswa")
+	assert.NoError(t, err)
+	assert.Equal(t, "stu-1", sub.StudentID)
+
+	assert.NoError(t, p.Authorize(context.Background(), sub, ActionUpdate, "stu-1"))
+	assert.ErrorIs(t, p.Authorize(context.Background(), sub, ActionUpdate, "stu-2"), ErrForbidden)
+	assert.ErrorIs(t, p.Authorize(context.Background(), sub, ActionVerify, "stu-1"), ErrForbidden)
+}
+
+func TestPolicy_Mahasiswa_WithoutProfile(t *testing.T) {
+	p, _ := setupPolicy()
+
+	sub, err := p.Subject(context.Background(), "user-unknown", "Mahasiswa")
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, p.Authorize(context.Background(), sub, ActionRead, "stu-1"), ErrProfileNotFound)
+}
+
+func TestPolicy_DosenWali_AdviseeOnly(t *testing.T) {
+	p, students := setupPolicy()
+
+	sub, err := p.Subject(context.Background(), "user-lect-1", "Dosen Wali")
+	assert.NoError(t, err)
+	assert.Equal(t, "lect-1", sub.LecturerID)
+
+	assert.NoError(t, p.Authorize(context.Background(), sub, ActionVerify, "stu-1"))
+	assert.ErrorIs(t, p.Authorize(context.Background(), sub, ActionVerify, "stu-2"), ErrForbidden)
+	assert.ErrorIs(t, p.Authorize(context.Background(), sub, ActionDelete, "stu-1"), ErrForbidden)
+
+	// daftar bimbingan hanya dimuat sekali per subject
+	assert.Equal(t, 1, students.advisorQueries)
+}
+
+func TestPolicy_UnknownRole_Denied(t *testing.T) {
+	p, _ := setupPolicy()
+
+	sub, err := p.Subject(context.Background(), "user-x", "Tamu")
+	assert.NoError(t, err)
+
+	assert.False(t, p.Can(sub, ActionRead))
+	assert.ErrorIs(t, p.Authorize(context.Background(), sub, ActionRead, "stu-1"), ErrForbidden)
+}
+
+func TestPolicy_CustomRules(t *testing.T) {
+	_, students := setupPolicy()
+	p := NewWithRules(students, &mockLecturerRepo{}, Rules{
+		"Kaprodi": {ActionRead: ScopeAll},
+	})
+
+	sub, err := p.Subject(context.Background(), "user-kaprodi", "Kaprodi")
+	assert.NoError(t, err)
+
+	assert.NoError(t, p.Authorize(context.Background(), sub, ActionRead, "stu-2"))
+	assert.ErrorIs(t, p.Authorize(context.Background(), sub, ActionVerify, "stu-2"), ErrForbidden)
+}
+
+func TestPolicy_AuthorizeStage(t *testing.T) {
+	p, _ := setupPolicy()
+
+	admin, _ := p.Subject(context.Background(), "user-admin", "Admin")
+	assert.NoError(t, p.AuthorizeStage(admin, "Kemahasiswaan"))
+	assert.NoError(t, p.AuthorizeStage(admin, ""))
+
+	lect, _ := p.Subject(context.Background(), "user-lect-1", "Dosen Wali")
+	assert.NoError(t, p.AuthorizeStage(lect, ""))
+	assert.NoError(t, p.AuthorizeStage(lect, "Dosen Wali"))
+	assert.ErrorIs(t, p.AuthorizeStage(lect, "Kemahasiswaan"), ErrForbidden)
+
+	// kemahasiswaan hanya memutuskan tahap miliknya, bukan tahap umum
+	kmh, _ := p.Subject(context.Background(), "user-kmh", "Kemahasiswaan")
+	assert.NoError(t, p.Authorize(context.Background(), kmh, ActionVerify, "stu-2"))
+	assert.NoError(t, p.AuthorizeStage(kmh, "Kemahasiswaan"))
+	assert.ErrorIs(t, p.AuthorizeStage(kmh, ""), ErrForbidden)
+	assert.ErrorIs(t, p.AuthorizeStage(kmh, "Dosen Wali"), ErrForbidden)
+}
+
+//
+// =======================================================
+// STUDENT SCOPE
+// =======================================================
+//
+
+func TestPolicy_StudentScope(t *testing.T) {
+	p, _ := setupPolicy()
+
+	admin, _ := p.Subject(context.Background(), "user-admin", "Admin")
+	all, ids, err := p.StudentScope(context.Background(), admin, ActionRead)
+	assert.NoError(t, err)
+	assert.True(t, all)
+	assert.Nil(t, ids)
+
+	stu, _ := p.Subject(context.Background(), "user-stu-1", "Mahasiswa")
+	all, ids, err = p.StudentScope(context.Background(), stu, ActionRead)
+	assert.NoError(t, err)
+	assert.False(t, all)
+	assert.Equal(t, []string{"stu-1"}, ids)
+
+	lect, _ := p.Subject(context.Background(), "user-lect-1", "Dosen Wali")
+	all, ids, err = p.StudentScope(context.Background(), lect, ActionRead)
+	assert.NoError(t, err)
+	assert.False(t, all)
+	assert.Equal(t, []string{"stu-1"}, ids)
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	models "achievement_backend/app/model"
+
+	"github.com/google/uuid"
+)
+
+type AchievementCommentStore interface {
+	Create(ctx context.Context, cm *models.AchievementComment) error
+	// Get mengembalikan sql.ErrNoRows jika komentar tidak ada.
+	Get(ctx context.Context, id string) (*models.AchievementComment, error)
+	// List mengembalikan semua komentar sebuah prestasi, termasuk yang sudah
+	// dihapus, urut dari yang paling lama.
+	List(ctx context.Context, referenceID string) ([]models.AchievementComment, error)
+	// UpdateBody mengembalikan sql.ErrNoRows jika komentar tidak ada atau
+	// sudah dihapus.
+	UpdateBody(ctx context.Context, id, body string, at time.Time) error
+	// SoftDelete mengembalikan sql.ErrNoRows jika komentar tidak ada atau
+	// sudah dihapus.
+	SoftDelete(ctx context.Context, id string, at time.Time) error
+}
+
+// ================= POSTGRES =================
+
+type achievementCommentRepository struct {
+	db *sql.DB
+}
+
+func NewAchievementCommentRepository(db *sql.DB) AchievementCommentStore {
+	return &achievementCommentRepository{db: db}
+}
+
+func (r *achievementCommentRepository) Create(ctx context.Context, cm *models.AchievementComment) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO achievement_comments (reference_id, parent_id, author_id, body, attachment_url, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING id, created_at
+	`, cm.ReferenceID, cm.ParentID, cm.AuthorID, cm.Body, cm.AttachmentURL).Scan(&cm.ID, &cm.CreatedAt)
+}
+
+const achievementCommentColumns = `
+	c.id, c.reference_id, c.parent_id, c.author_id, u.full_name,
+	c.body, c.attachment_url, c.created_at, c.edited_at, c.deleted_at
+`
+
+func scanAchievementComment(row interface{ Scan(...interface{}) error }) (*models.AchievementComment, error) {
+	var cm models.AchievementComment
+	err := row.Scan(
+		&cm.ID, &cm.ReferenceID, &cm.ParentID, &cm.AuthorID, &cm.AuthorName,
+		&cm.Body, &cm.AttachmentURL, &cm.CreatedAt, &cm.EditedAt, &cm.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &cm, nil
+}
+
+func (r *achievementCommentRepository) Get(ctx context.Context, id string) (*models.AchievementComment, error) {
+	return scanAchievementComment(conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT `+achievementCommentColumns+`
+		FROM achievement_comments c
+		LEFT JOIN users u ON u.id = c.author_id
+		WHERE c.id = $1
+	`, id))
+}
+
+func (r *achievementCommentRepository) List(ctx context.Context, referenceID string) ([]models.AchievementComment, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+achievementCommentColumns+`
+		FROM achievement_comments c
+		LEFT JOIN users u ON u.id = c.author_id
+		WHERE c.reference_id = $1
+		ORDER BY c.created_at ASC, c.id ASC
+	`, referenceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.AchievementComment{}
+	for rows.Next() {
+		cm, err := scanAchievementComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *cm)
+	}
+
+	return list, rows.Err()
+}
+
+func (r *achievementCommentRepository) UpdateBody(ctx context.Context, id, body string, at time.Time) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE achievement_comments
+		SET body = $2, edited_at = $3
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id, body, at)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *achievementCommentRepository) SoftDelete(ctx context.Context, id string, at time.Time) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE achievement_comments
+		SET deleted_at = $2
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id, at)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ================= IN-MEMORY (testing) =================
+
+type memoryAchievementCommentStore struct {
+	mu       sync.Mutex
+	comments map[string]models.AchievementComment
+}
+
+func NewMemoryAchievementCommentStore() AchievementCommentStore {
+	return &memoryAchievementCommentStore{comments: make(map[string]models.AchievementComment)}
+}
+
+func (m *memoryAchievementCommentStore) Create(ctx context.Context, cm *models.AchievementComment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cm.ID = uuid.New().String()
+	cm.CreatedAt = time.Now()
+	m.comments[cm.ID] = *cm
+	return nil
+}
+
+func (m *memoryAchievementCommentStore) Get(ctx context.Context, id string) (*models.AchievementComment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cm, ok := m.comments[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &cm, nil
+}
+
+func (m *memoryAchievementCommentStore) List(ctx context.Context, referenceID string) ([]models.AchievementComment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := []models.AchievementComment{}
+	for _, cm := range m.comments {
+		if cm.ReferenceID == referenceID {
+			list = append(list, cm)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+
+	return list, nil
+}
+
+func (m *memoryAchievementCommentStore) UpdateBody(ctx context.Context, id, body string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cm, ok := m.comments[id]
+	if !ok || cm.DeletedAt != nil {
+		return sql.ErrNoRows
+	}
+
+	cm.Body, cm.EditedAt = body, &at
+	m.comments[id] = cm
+	return nil
+}
+
+func (m *memoryAchievementCommentStore) SoftDelete(ctx context.Context, id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cm, ok := m.comments[id]
+	if !ok || cm.DeletedAt != nil {
+		return sql.ErrNoRows
+	}
+
+	cm.DeletedAt = &at
+	m.comments[id] = cm
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	models "achievement_backend/app/model"
 )
@@ -22,19 +23,47 @@ type MongoAchievementRepository interface {
 	GetByID(ctx context.Context, id string) (*models.Achievement, error)
 	GetByStudentID(ctx context.Context, studentID string) ([]models.Achievement, error)
 
+	// UpdateDraft, UpdateAttachments dan RestoreVersion tidak memeriksa status
+	// dokumen; pemanggil memastikan reference di Postgres masih draft.
 	UpdateDraft(ctx context.Context, id string, req *models.UpdateAchievementRequest, points int) (*models.Achievement, error)
 	UpdateAttachments(ctx context.Context, id string, attachments []models.Attachment) (*models.Achievement, error)
 
 	SoftDelete(ctx context.Context, id string) error
 
 	GetManyByIDs(ctx context.Context, ids []string) (map[string]models.Achievement, error)
+	// GetManyDeletedByIDs sama dengan GetManyByIDs tetapi hanya untuk
+	// dokumen yang sudah dihapus (recycle bin).
+	GetManyDeletedByIDs(ctx context.Context, ids []string) (map[string]models.Achievement, error)
 	UpdateStatus(ctx context.Context, id string, status string) error
+
+	// ListVersions mengembalikan versi lama sebuah prestasi, urut dari yang
+	// paling lama. Versi yang sedang berlaku adalah dokumen itu sendiri.
+	ListVersions(ctx context.Context, id string) ([]models.AchievementVersion, error)
+	// GetVersion mengembalikan nil jika versi tidak ada.
+	GetVersion(ctx context.Context, id string, version int) (*models.AchievementVersion, error)
+	// RestoreVersion mengembalikan isi draft ke versi lama. Isi sebelum
+	// restore ikut disimpan sebagai versi sehingga restore bisa dibatalkan.
+	RestoreVersion(ctx context.Context, id string, version int) (*models.Achievement, error)
+
+	// Delete menghapus dokumen beserta versinya secara permanen, untuk
+	// membatalkan CreateDraft yang reference-nya gagal dibuat.
+	Delete(ctx context.Context, id string) error
+	// ListStates mengembalikan ringkasan semua dokumen, termasuk yang sudah
+	// dihapus, untuk rekonsiliasi dengan Postgres.
+	ListStates(ctx context.Context) ([]models.AchievementDocState, error)
+	// ListDeletedBefore mengembalikan ID dokumen yang sudah dihapus dan
+	// terakhir diubah sebelum before, paling lama lebih dulu.
+	ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]string, error)
 }
 
+// ErrMongoAchievementNotFound dikembalikan jika dokumen tidak ada sama sekali.
+var ErrMongoAchievementNotFound = errors.New("achievement not found")
+
 // ================= STRUCT =================
 
 type mongoAchievementRepository struct {
 	collection *mongo.Collection
+	versions   *mongo.Collection
 }
 
 // ================= CONSTRUCTOR =================
@@ -42,6 +71,7 @@ type mongoAchievementRepository struct {
 func NewMongoAchievementRepository(db *mongo.Database) MongoAchievementRepository {
 	return &mongoAchievementRepository{
 		collection: db.Collection("achievements"),
+		versions:   db.Collection("achievement_versions"),
 	}
 }
 
@@ -109,6 +139,7 @@ func (r *mongoAchievementRepository) CreateDraft(
         Tags:            req.Tags,
         Points:          &p,  // <-- FIX UTAMA
         Status:          models.StatusDraft,
+        Version:         1,
         IsDeleted:       false,
         CreatedAt:       time.Now(),
         UpdatedAt:       time.Now(),
@@ -184,40 +215,107 @@ func (r *mongoAchievementRepository) GetByStudentID(ctx context.Context, student
 	return list, nil
 }
 
-// ================= UPDATE DRAFT =================
+// ================= VERSIONING =================
 
-func (r *mongoAchievementRepository) UpdateDraft(ctx context.Context, id string, req *models.UpdateAchievementRequest, points int) (*models.Achievement, error) {
-	objID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return nil, err
+var errDraftModified = errors.New("prestasi sedang diubah di tempat lain, muat ulang lalu coba lagi")
+
+// versionFilter mencocokkan nomor versi dokumen; dokumen lama tanpa field
+// version dianggap versi 0.
+func versionFilter(v int) interface{} {
+	if v == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
 	}
+	return v
+}
 
-	filter := bson.M{
+// findEditable mengambil dokumen yang belum dihapus. Status dokumen hanya
+// salinan status di Postgres yang disinkronkan lewat outbox, jadi apakah
+// prestasi masih draft diperiksa pemanggil pada reference-nya.
+func (r *mongoAchievementRepository) findEditable(ctx context.Context, objID primitive.ObjectID) (*models.Achievement, error) {
+	var existing models.Achievement
+	err := r.collection.FindOne(ctx, bson.M{
 		"_id":       objID,
-		"status":    models.StatusDraft,
 		"isDeleted": false,
+	}).Decode(&existing)
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errors.New("achievement not found")
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	update := bson.M{
-		"$set": bson.M{
-			"achievementType": req.AchievementType,
-			"title":           req.Title,
-			"description":     req.Description,
-			"details":         req.Details,
-			"attachments":     req.Attachments,
-			"tags":            req.Tags,
-			"points":          points,
-			"updatedAt":       time.Now(),
+	return &existing, nil
+}
+
+// updateVersioned menyimpan existing sebagai versi lama lalu menerapkan set
+// dan menaikkan nomor versi. Update hanya berlaku jika dokumen masih di versi
+// yang sama dengan existing, sehingga tidak ada perubahan tanpa salinan
+// versi sebelumnya. Salinan disimpan dengan upsert per (achievementId,
+// version) sehingga percobaan ulang tidak membuat duplikat.
+func (r *mongoAchievementRepository) updateVersioned(
+	ctx context.Context,
+	existing *models.Achievement,
+	replacedBy string,
+	set bson.M,
+) error {
+	key := bson.M{"achievementId": existing.ID.Hex(), "version": existing.Version}
+	_, err := r.versions.UpdateOne(ctx, key, bson.M{
+		"$setOnInsert": models.AchievementVersion{
+			AchievementID: existing.ID.Hex(),
+			Version:       existing.Version,
+			ReplacedBy:    replacedBy,
+			Snapshot:      *existing,
+			ArchivedAt:    time.Now(),
 		},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+
+	set["updatedAt"] = time.Now()
+	res, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":       existing.ID,
+		"isDeleted": false,
+		"version":   versionFilter(existing.Version),
+	}, bson.M{
+		"$set": set,
+		"$inc": bson.M{"version": 1},
+	})
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return errDraftModified
+	}
+	return nil
+}
+
+// ================= UPDATE DRAFT =================
+
+func (r *mongoAchievementRepository) UpdateDraft(ctx context.Context, id string, req *models.UpdateAchievementRequest, points int) (*models.Achievement, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
 	}
 
-	result, err := r.collection.UpdateOne(ctx, filter, update)
+	existing, err := r.findEditable(ctx, objID)
 	if err != nil {
 		return nil, err
 	}
 
-	if result.ModifiedCount == 0 {
-		return nil, errors.New("prestasi hanya dapat diubah jika status masih draft")
+	err = r.updateVersioned(ctx, existing, models.VersionReplacedByUpdate, bson.M{
+		"achievementType": req.AchievementType,
+		"title":           req.Title,
+		"description":     req.Description,
+		"details":         req.Details,
+		"attachments":     req.Attachments,
+		"tags":            req.Tags,
+		"points":          points,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return r.GetByID(ctx, id)
@@ -231,29 +329,86 @@ func (r *mongoAchievementRepository) UpdateAttachments(ctx context.Context, id s
 		return nil, err
 	}
 
-	var existing models.Achievement
-	err = r.collection.FindOne(ctx, bson.M{
-		"_id":       objID,
-		"isDeleted": false,
-	}).Decode(&existing)
+	existing, err := r.findEditable(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.updateVersioned(ctx, existing, models.VersionReplacedByAttachments, bson.M{
+		"attachments": attachments,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// ================= VERSIONS =================
+
+func (r *mongoAchievementRepository) ListVersions(ctx context.Context, id string) ([]models.AchievementVersion, error) {
+	cursor, err := r.versions.Find(ctx,
+		bson.M{"achievementId": id},
+		options.Find().SetSort(bson.D{{Key: "version", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	list := []models.AchievementVersion{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (r *mongoAchievementRepository) GetVersion(ctx context.Context, id string, version int) (*models.AchievementVersion, error) {
+	var v models.AchievementVersion
+	err := r.versions.FindOne(ctx, bson.M{
+		"achievementId": id,
+		"version":       version,
+	}).Decode(&v)
 
 	if errors.Is(err, mongo.ErrNoDocuments) {
-		return nil, errors.New("achievement not found")
+		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Draft only
-	if existing.Status != models.StatusDraft {
-		return nil, errors.New("attachments hanya dapat diubah jika masih draft")
+	return &v, nil
+}
+
+func (r *mongoAchievementRepository) RestoreVersion(ctx context.Context, id string, version int) (*models.Achievement, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
 	}
 
-	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{
-		"$set": bson.M{
-			"attachments": attachments,
-			"updatedAt":   time.Now(),
-		},
+	existing, err := r.findEditable(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := r.GetVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	if old == nil {
+		return nil, errors.New("version not found")
+	}
+
+	snap := old.Snapshot
+	err = r.updateVersioned(ctx, existing, models.VersionReplacedByRestore, bson.M{
+		"achievementType": snap.AchievementType,
+		"title":           snap.Title,
+		"description":     snap.Description,
+		"details":         snap.Details,
+		"attachments":     snap.Attachments,
+		"tags":            snap.Tags,
+		"points":          snap.Points,
 	})
 	if err != nil {
 		return nil, err
@@ -298,6 +453,14 @@ func (r *mongoAchievementRepository) SoftDelete(ctx context.Context, id string)
 // ================= GET MANY BY IDS =================
 
 func (r *mongoAchievementRepository) GetManyByIDs(ctx context.Context, ids []string) (map[string]models.Achievement, error) {
+	return r.getMany(ctx, ids, false)
+}
+
+func (r *mongoAchievementRepository) GetManyDeletedByIDs(ctx context.Context, ids []string) (map[string]models.Achievement, error) {
+	return r.getMany(ctx, ids, true)
+}
+
+func (r *mongoAchievementRepository) getMany(ctx context.Context, ids []string, deleted bool) (map[string]models.Achievement, error) {
 	objIDs := []primitive.ObjectID{}
 	for _, id := range ids {
 		objID, e := primitive.ObjectIDFromHex(id)
@@ -308,7 +471,7 @@ func (r *mongoAchievementRepository) GetManyByIDs(ctx context.Context, ids []str
 
 	filter := bson.M{
 		"_id":       bson.M{"$in": objIDs},
-		"isDeleted": false,
+		"isDeleted": deleted,
 	}
 
 	cursor, err := r.collection.Find(ctx, filter)
@@ -330,6 +493,8 @@ func (r *mongoAchievementRepository) GetManyByIDs(ctx context.Context, ids []str
 
 // ================= UPDATE STATUS =================
 
+// UpdateStatus menyalin status dari Postgres. Status deleted juga menandai
+// isDeleted, status lain mengembalikannya ke false.
 func (r *mongoAchievementRepository) UpdateStatus(ctx context.Context, id string, status string) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -339,6 +504,7 @@ func (r *mongoAchievementRepository) UpdateStatus(ctx context.Context, id string
 	update := bson.M{
 		"$set": bson.M{
 			"status":    status,
+			"isDeleted": status == models.StatusDeleted,
 			"updatedAt": time.Now(),
 		},
 	}
@@ -349,8 +515,90 @@ func (r *mongoAchievementRepository) UpdateStatus(ctx context.Context, id string
 	}
 
 	if res.MatchedCount == 0 {
-		return errors.New("achievement not found")
+		return ErrMongoAchievementNotFound
 	}
 
 	return nil
 }
+
+// ================= DELETE =================
+
+func (r *mongoAchievementRepository) Delete(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": objID}); err != nil {
+		return err
+	}
+
+	_, err = r.versions.DeleteMany(ctx, bson.M{"achievementId": id})
+	return err
+}
+
+// ================= LIST DELETED =================
+
+func (r *mongoAchievementRepository) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"isDeleted": true,
+		"updatedAt": bson.M{"$lt": before},
+	}, options.Find().
+		SetProjection(bson.M{"_id": 1}).
+		SetSort(bson.D{{Key: "updatedAt", Value: 1}}).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	ids := []string{}
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ids = append(ids, doc.ID.Hex())
+	}
+
+	return ids, cursor.Err()
+}
+
+// ================= LIST STATES =================
+
+func (r *mongoAchievementRepository) ListStates(ctx context.Context) ([]models.AchievementDocState, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{
+		"studentId": 1,
+		"status":    1,
+		"isDeleted": 1,
+		"createdAt": 1,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	list := []models.AchievementDocState{}
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID                         interface{} `bson:"_id"`
+			models.AchievementDocState `bson:",inline"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+
+		state := doc.AchievementDocState
+		switch id := doc.ID.(type) {
+		case primitive.ObjectID:
+			state.ID = id.Hex()
+		case string:
+			state.ID = id
+		}
+		list = append(list, state)
+	}
+
+	return list, cursor.Err()
+}
@@ -33,10 +33,12 @@ func setupMongoTest(t *testing.T) {
 
 	// clean collection
 	_ = testDB.Collection("achievements").Drop(context.Background())
+	_ = testDB.Collection("achievement_versions").Drop(context.Background())
 }
 
 func teardownMongoTest() {
 	_ = testDB.Collection("achievements").Drop(context.Background())
+	_ = testDB.Collection("achievement_versions").Drop(context.Background())
 }
 
 // =======================================================
@@ -232,3 +234,70 @@ func TestMongoAchievement_UpdateDraft(t *testing.T) {
 	assert.Equal(t, "After", updated.Title)
 	assert.WithinDuration(t, time.Now(), updated.UpdatedAt, time.Second)
 }
+
+// =======================================================
+// TEST: Versions
+// =======================================================
+
+func TestMongoAchievement_VersionsAndRestore(t *testing.T) {
+	setupMongoTest(t)
+	defer teardownMongoTest()
+
+	ctx := context.Background()
+	created, _ := testRepo.CreateDraft(ctx, "student-1", &models.CreateAchievementRequest{
+		AchievementType: "competition",
+		Title:           "Before",
+	}, 20)
+	id := created.ID.Hex()
+
+	updated, err := testRepo.UpdateDraft(ctx, id, &models.UpdateAchievementRequest{
+		AchievementType: "competition",
+		Title:           "After",
+	}, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+
+	versions, err := testRepo.ListVersions(ctx, id)
+	assert.NoError(t, err)
+	assert.Len(t, versions, 1)
+	assert.Equal(t, 1, versions[0].Version)
+	assert.Equal(t, "Before", versions[0].Snapshot.Title)
+
+	restored, err := testRepo.RestoreVersion(ctx, id, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Before", restored.Title)
+	assert.Equal(t, 3, restored.Version)
+
+	v2, err := testRepo.GetVersion(ctx, id, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "After", v2.Snapshot.Title)
+	assert.Equal(t, models.VersionReplacedByRestore, v2.ReplacedBy)
+}
+
+func TestMongoAchievement_StatesAndDelete(t *testing.T) {
+	setupMongoTest(t)
+	defer teardownMongoTest()
+
+	ctx := context.Background()
+	created, _ := testRepo.CreateDraft(ctx, "student-1", &models.CreateAchievementRequest{
+		AchievementType: "competition",
+		Title:           "Lomba",
+	}, 20)
+	id := created.ID.Hex()
+
+	assert.NoError(t, testRepo.UpdateStatus(ctx, id, models.StatusDeleted))
+
+	states, err := testRepo.ListStates(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, states, 1) {
+		assert.Equal(t, id, states[0].ID)
+		assert.Equal(t, "student-1", states[0].StudentID)
+		assert.True(t, states[0].IsDeleted)
+	}
+
+	assert.NoError(t, testRepo.Delete(ctx, id))
+	assert.ErrorIs(t, testRepo.UpdateStatus(ctx, id, models.StatusDraft), ErrMongoAchievementNotFound)
+
+	states, _ = testRepo.ListStates(ctx)
+	assert.Empty(t, states)
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	models "achievement_backend/app/model"
+)
+
+type AchievementOutboxStore interface {
+	// Enqueue menjadwalkan sinkronisasi status di luar transaksi perubahan
+	// status, mis. saat rekonsiliasi menemukan status yang tidak sama.
+	Enqueue(ctx context.Context, referenceID, mongoID, status string) error
+	// Claim mengambil event yang belum diproses, sudah jatuh tempo dan belum
+	// melewati maxAttempts, terlama lebih dulu, lalu memundurkan available_at-nya
+	// ke leaseUntil dalam satu statement. Relay lain (replika atau sweeper) tidak
+	// melihat event itu selama lease; jika pemroses mati sebelum MarkProcessed
+	// atau MarkFailed, event muncul lagi setelah lease habis.
+	Claim(ctx context.Context, now, leaseUntil time.Time, maxAttempts, limit int) ([]models.AchievementOutboxEvent, error)
+	MarkProcessed(ctx context.Context, id int64, at time.Time) error
+	// MarkFailed menaikkan attempts dan menunda event sampai retryAt.
+	MarkFailed(ctx context.Context, id int64, lastError string, retryAt time.Time) error
+	// DeleteProcessed menghapus event yang sudah diproses sebelum before.
+	DeleteProcessed(ctx context.Context, before time.Time) (int64, error)
+}
+
+// enqueueStatusSync menulis event outbox di dalam transaksi perubahan status.
+func enqueueStatusSync(ctx context.Context, tx *sql.Tx, referenceID, status string, at time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO achievement_outbox (reference_id, mongo_achievement_id, to_status, available_at, created_at)
+		SELECT id, mongo_achievement_id, $2, $3, $3
+		FROM achievement_references
+		WHERE id = $1
+	`, referenceID, status, at)
+
+	return err
+}
+
+// ================= POSTGRES =================
+
+type achievementOutboxRepository struct {
+	db *sql.DB
+}
+
+func NewAchievementOutboxRepository(db *sql.DB) AchievementOutboxStore {
+	return &achievementOutboxRepository{db: db}
+}
+
+func (r *achievementOutboxRepository) Enqueue(ctx context.Context, referenceID, mongoID, status string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO achievement_outbox (reference_id, mongo_achievement_id, to_status, available_at, created_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+	`, referenceID, mongoID, status)
+
+	return err
+}
+
+func (r *achievementOutboxRepository) Claim(ctx context.Context, now, leaseUntil time.Time, maxAttempts, limit int) ([]models.AchievementOutboxEvent, error) {
+	// SKIP LOCKED: baris yang sedang diklaim transaksi lain dilewati, bukan ditunggu
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		UPDATE achievement_outbox o
+		SET available_at = $2
+		FROM (
+			SELECT id
+			FROM achievement_outbox
+			WHERE processed_at IS NULL
+			  AND available_at <= $1
+			  AND attempts < $3
+			ORDER BY id ASC
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		) due
+		WHERE o.id = due.id
+		RETURNING o.id, o.reference_id, o.mongo_achievement_id, o.to_status, o.attempts,
+		          o.last_error, o.available_at, o.processed_at, o.created_at
+	`, now, leaseUntil, maxAttempts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.AchievementOutboxEvent{}
+	for rows.Next() {
+		var ev models.AchievementOutboxEvent
+		if err := rows.Scan(
+			&ev.ID, &ev.ReferenceID, &ev.MongoAchievementID, &ev.ToStatus, &ev.Attempts,
+			&ev.LastError, &ev.AvailableAt, &ev.ProcessedAt, &ev.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING tidak menjamin urutan
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+func (r *achievementOutboxRepository) MarkProcessed(ctx context.Context, id int64, at time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE achievement_outbox SET processed_at = $2 WHERE id = $1
+	`, id, at)
+
+	return err
+}
+
+func (r *achievementOutboxRepository) MarkFailed(ctx context.Context, id int64, lastError string, retryAt time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE achievement_outbox
+		SET attempts = attempts + 1,
+		    last_error = $2,
+		    available_at = $3
+		WHERE id = $1
+	`, id, lastError, retryAt)
+
+	return err
+}
+
+func (r *achievementOutboxRepository) DeleteProcessed(ctx context.Context, before time.Time) (int64, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		DELETE FROM achievement_outbox WHERE processed_at IS NOT NULL AND processed_at < $1
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// ================= IN-MEMORY (testing) =================
+
+type memoryAchievementOutboxStore struct {
+	mu     sync.Mutex
+	seq    int64
+	events map[int64]models.AchievementOutboxEvent
+}
+
+func NewMemoryAchievementOutboxStore() AchievementOutboxStore {
+	return &memoryAchievementOutboxStore{events: make(map[int64]models.AchievementOutboxEvent)}
+}
+
+func (m *memoryAchievementOutboxStore) Enqueue(ctx context.Context, referenceID, mongoID, status string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.seq++
+	m.events[m.seq] = models.AchievementOutboxEvent{
+		ID:                 m.seq,
+		ReferenceID:        referenceID,
+		MongoAchievementID: mongoID,
+		ToStatus:           status,
+		AvailableAt:        now,
+		CreatedAt:          now,
+	}
+	return nil
+}
+
+func (m *memoryAchievementOutboxStore) Claim(ctx context.Context, now, leaseUntil time.Time, maxAttempts, limit int) ([]models.AchievementOutboxEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := []models.AchievementOutboxEvent{}
+	for _, ev := range m.events {
+		if ev.ProcessedAt == nil && !ev.AvailableAt.After(now) && ev.Attempts < maxAttempts {
+			list = append(list, ev)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	for i := range list {
+		list[i].AvailableAt = leaseUntil
+		m.events[list[i].ID] = list[i]
+	}
+	return list, nil
+}
+
+func (m *memoryAchievementOutboxStore) MarkProcessed(ctx context.Context, id int64, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if ev, ok := m.events[id]; ok {
+		ev.ProcessedAt = &at
+		m.events[id] = ev
+	}
+	return nil
+}
+
+func (m *memoryAchievementOutboxStore) MarkFailed(ctx context.Context, id int64, lastError string, retryAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if ev, ok := m.events[id]; ok {
+		ev.Attempts++
+		ev.LastError = &lastError
+		ev.AvailableAt = retryAt
+		m.events[id] = ev
+	}
+	return nil
+}
+
+func (m *memoryAchievementOutboxStore) DeleteProcessed(ctx context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	for id, ev := range m.events {
+		if ev.ProcessedAt != nil && ev.ProcessedAt.Before(before) {
+			delete(m.events, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAchievementOutbox_Claim_SkipsLockedAndLeases(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAchievementOutboxRepository(db)
+	now := time.Now()
+	lease := now.Add(5 * time.Minute)
+
+	rows := sqlmock.NewRows([]string{
+		"id", "reference_id", "mongo_achievement_id", "to_status", "attempts",
+		"last_error", "available_at", "processed_at", "created_at",
+	}).
+		AddRow(int64(7), "r7", "m7", "verified", 0, nil, lease, nil, now).
+		AddRow(int64(3), "r3", "m3", "submitted", 1, "mongo down", lease, nil, now)
+
+	mock.ExpectQuery(`UPDATE achievement_outbox o\s+SET available_at = \$2.*FOR UPDATE SKIP LOCKED`).
+		WithArgs(now, lease, 10, 100).
+		WillReturnRows(rows)
+
+	events, err := repo.Claim(context.Background(), now, lease, 10, 100)
+
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, int64(3), events[0].ID)
+		assert.Equal(t, "mongo down", *events[0].LastError)
+		assert.Equal(t, int64(7), events[1].ID)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	models "achievement_backend/app/model"
+	"context"
 	"database/sql"
 	"time"
 
@@ -9,19 +10,61 @@ import (
 )
 
 type AchievementReferenceRepository interface {
-	GetAll() ([]models.AchievementReference, error)
-	GetByID(id string) (*models.AchievementReference, error)
-	GetByStudentID(studentID string) ([]models.AchievementReference, error)
-	GetByMongoAchievementID(mongoID string) (*models.AchievementReference, error)
-
-	GetByAdviseesWithPagination(studentIDs []string, limit int, offset int) ([]models.AchievementReference, int64, error)
-	GetAllWithPagination(limit, offset int) ([]models.AchievementReference, int64, error)
-
-	Create(studentID string, mongoID string) (*models.AchievementReference, error)
-	Submit(id string) error
-	Verify(id string, verifierID string) error
-	Reject(id string, verifierID string, note string) error
-	SoftDelete(id string) error
+	GetAll(ctx context.Context) ([]models.AchievementReference, error)
+	GetByID(ctx context.Context, id string) (*models.AchievementReference, error)
+	GetByStudentID(ctx context.Context, studentID string) ([]models.AchievementReference, error)
+	GetByMongoAchievementID(ctx context.Context, mongoID string) (*models.AchievementReference, error)
+	// LockByMongoAchievementID sama dengan GetByMongoAchievementID tetapi
+	// mengunci baris (FOR UPDATE) sampai transaksi UnitOfWork selesai, sehingga
+	// transisi status menunggu perubahan draft yang sedang berjalan.
+	LockByMongoAchievementID(ctx context.Context, mongoID string) (*models.AchievementReference, error)
+
+	GetByAdviseesWithPagination(ctx context.Context, studentIDs []string, limit int, offset int) ([]models.AchievementReference, int64, error)
+	GetAllWithPagination(ctx context.Context, limit, offset int) ([]models.AchievementReference, int64, error)
+
+	// Create, Submit, Decide dan SoftDelete mencatat perubahan status ke
+	// achievement_status_history dalam transaksi yang sama. actorID adalah
+	// user yang melakukan perubahan.
+	Create(ctx context.Context, studentID string, mongoID string, actorID string) (*models.AchievementReference, error)
+	Submit(ctx context.Context, id string, actorID string) error
+	SoftDelete(ctx context.Context, id string, actorID string) error
+
+	// Decide mencatat keputusan satu tahap verifikasi. Approved di tahap
+	// non-final memajukan current_stage; approved di tahap final membuat
+	// verified; rejected di tahap mana pun membuat rejected. sql.ErrNoRows jika
+	// achievement tidak lagi submitted atau tahapnya sudah diputuskan.
+	Decide(ctx context.Context, id string, d *models.StageDecision) error
+	// ListDecisions mengembalikan keputusan tahap, terlama lebih dulu.
+	ListDecisions(ctx context.Context, referenceID string) ([]models.StageDecision, error)
+	// Revise membuka lagi prestasi yang ditolak sebagai draft dan menaikkan
+	// nomor revisi. rejection_note dibiarkan agar tetap terlihat saat revisi.
+	Revise(ctx context.Context, id string, actorID string) error
+	// Withdraw menarik kembali prestasi yang sudah disubmit tetapi belum
+	// direview menjadi draft. sql.ErrNoRows jika tahap pertama workflow sudah
+	// diputuskan, agar keputusan tahap yang tercatat tidak terbuang.
+	Withdraw(ctx context.Context, id string, actorID string) error
+
+	// DeleteOrphan menandai reference yang dokumen Mongo-nya hilang sebagai
+	// deleted. from adalah status yang diharapkan; sql.ErrNoRows jika sudah
+	// berubah.
+	DeleteOrphan(ctx context.Context, id, from string, note string) error
+
+	// ListDeleted mengembalikan isi recycle bin, yang terakhir dihapus lebih
+	// dulu. studentIDs nil berarti semua mahasiswa.
+	ListDeleted(ctx context.Context, studentIDs []string, limit, offset int) ([]models.AchievementReference, int64, error)
+	// ListDeletedBefore mengembalikan reference yang dihapus sebelum before,
+	// yang terlama lebih dulu.
+	ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]models.AchievementReference, error)
+	// Restore mengembalikan prestasi yang dihapus menjadi draft.
+	// sql.ErrNoRows jika status-nya bukan deleted.
+	Restore(ctx context.Context, id string, actorID string) error
+	// Purge menghapus reference yang berstatus deleted secara permanen,
+	// beserta history, revisi, keputusan, komentar dan outbox-nya (cascade).
+	// sql.ErrNoRows jika status-nya bukan deleted.
+	Purge(ctx context.Context, id string) error
+
+	// ListHistory mengembalikan log status reference, terlama lebih dulu.
+	ListHistory(ctx context.Context, referenceID string) ([]models.AchievementStatusHistory, error)
 }
 
 type achievementReferenceRepository struct {
@@ -33,11 +76,11 @@ func NewAchievementReferenceRepository(db *sql.DB) AchievementReferenceRepositor
 }
 
 // ================= GET ALL =================
-func (r *achievementReferenceRepository) GetAll() ([]models.AchievementReference, error) {
-	rows, err := r.db.Query(`
+func (r *achievementReferenceRepository) GetAll(ctx context.Context) ([]models.AchievementReference, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT id, student_id, mongo_achievement_id, status,
 		       submitted_at, verified_at, verified_by,
-		       rejection_note, created_at, updated_at
+		       rejection_note, revision, current_stage, created_at, updated_at
 		FROM achievement_references
 		ORDER BY created_at DESC
 	`)
@@ -53,7 +96,7 @@ func (r *achievementReferenceRepository) GetAll() ([]models.AchievementReference
 		if err := rows.Scan(
 			&a.ID, &a.StudentID, &a.MongoAchievementID, &a.Status,
 			&a.SubmittedAt, &a.VerifiedAt, &a.VerifiedBy,
-			&a.RejectionNote, &a.CreatedAt, &a.UpdatedAt,
+			&a.RejectionNote, &a.Revision, &a.CurrentStage, &a.CreatedAt, &a.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -63,19 +106,19 @@ func (r *achievementReferenceRepository) GetAll() ([]models.AchievementReference
 }
 
 // ================= GET BY ID =================
-func (r *achievementReferenceRepository) GetByID(id string) (*models.AchievementReference, error) {
+func (r *achievementReferenceRepository) GetByID(ctx context.Context, id string) (*models.AchievementReference, error) {
 	var a models.AchievementReference
 
-	err := r.db.QueryRow(`
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT id, student_id, mongo_achievement_id, status,
 		       submitted_at, verified_at, verified_by,
-		       rejection_note, created_at, updated_at
+		       rejection_note, revision, current_stage, created_at, updated_at
 		FROM achievement_references
 		WHERE id=$1
 	`, id).Scan(
 		&a.ID, &a.StudentID, &a.MongoAchievementID, &a.Status,
 		&a.SubmittedAt, &a.VerifiedAt, &a.VerifiedBy,
-		&a.RejectionNote, &a.CreatedAt, &a.UpdatedAt,
+		&a.RejectionNote, &a.Revision, &a.CurrentStage, &a.CreatedAt, &a.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -89,11 +132,11 @@ func (r *achievementReferenceRepository) GetByID(id string) (*models.Achievement
 }
 
 // ================= GET BY STUDENT =================
-func (r *achievementReferenceRepository) GetByStudentID(studentID string) ([]models.AchievementReference, error) {
-	rows, err := r.db.Query(`
+func (r *achievementReferenceRepository) GetByStudentID(ctx context.Context, studentID string) ([]models.AchievementReference, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT id, student_id, mongo_achievement_id, status,
 		       submitted_at, verified_at, verified_by,
-		       rejection_note, created_at, updated_at
+		       rejection_note, revision, current_stage, created_at, updated_at
 		FROM achievement_references
 		WHERE student_id=$1
 		ORDER BY created_at DESC
@@ -109,7 +152,7 @@ func (r *achievementReferenceRepository) GetByStudentID(studentID string) ([]mod
 		if err := rows.Scan(
 			&a.ID, &a.StudentID, &a.MongoAchievementID, &a.Status,
 			&a.SubmittedAt, &a.VerifiedAt, &a.VerifiedBy,
-			&a.RejectionNote, &a.CreatedAt, &a.UpdatedAt,
+			&a.RejectionNote, &a.Revision, &a.CurrentStage, &a.CreatedAt, &a.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -119,19 +162,27 @@ func (r *achievementReferenceRepository) GetByStudentID(studentID string) ([]mod
 }
 
 // ================= GET BY MONGO ID =================
-func (r *achievementReferenceRepository) GetByMongoAchievementID(mongoID string) (*models.AchievementReference, error) {
+func (r *achievementReferenceRepository) GetByMongoAchievementID(ctx context.Context, mongoID string) (*models.AchievementReference, error) {
+	return r.getByMongoAchievementID(ctx, mongoID, "")
+}
+
+func (r *achievementReferenceRepository) LockByMongoAchievementID(ctx context.Context, mongoID string) (*models.AchievementReference, error) {
+	return r.getByMongoAchievementID(ctx, mongoID, "FOR UPDATE")
+}
+
+func (r *achievementReferenceRepository) getByMongoAchievementID(ctx context.Context, mongoID string, lock string) (*models.AchievementReference, error) {
 	var a models.AchievementReference
 
-	err := r.db.QueryRow(`
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT id, student_id, mongo_achievement_id, status,
 		       submitted_at, verified_at, verified_by,
-		       rejection_note, created_at, updated_at
+		       rejection_note, revision, current_stage, created_at, updated_at
 		FROM achievement_references
 		WHERE mongo_achievement_id=$1
-	`, mongoID).Scan(
+		`+lock, mongoID).Scan(
 		&a.ID, &a.StudentID, &a.MongoAchievementID, &a.Status,
 		&a.SubmittedAt, &a.VerifiedAt, &a.VerifiedBy,
-		&a.RejectionNote, &a.CreatedAt, &a.UpdatedAt,
+		&a.RejectionNote, &a.Revision, &a.CurrentStage, &a.CreatedAt, &a.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -144,112 +195,380 @@ func (r *achievementReferenceRepository) GetByMongoAchievementID(mongoID string)
 	return &a, nil
 }
 
+// ================= HISTORY =================
+
+// recordHistory menulis satu baris log status di dalam transaksi yang sama
+// dengan perubahan status-nya, beserta event outbox agar status dokumen
+// Mongo ikut disinkronkan. actorID kosong disimpan sebagai NULL.
+func recordHistory(ctx context.Context, tx *sql.Tx, referenceID string, from *string, to, actorID string, note *string, at time.Time) error {
+	var actor *string
+	if actorID != "" {
+		actor = &actorID
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO achievement_status_history (
+			reference_id, from_status, to_status, actor_id, note, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6)
+	`, referenceID, from, to, actor, note, at)
+	if err != nil {
+		return err
+	}
+
+	return enqueueStatusSync(ctx, tx, referenceID, to, at)
+}
+
+// transition menjalankan UPDATE status lalu mencatat history-nya dalam satu
+// transaksi. sql.ErrNoRows jika tidak ada baris yang berubah (status awal
+// tidak sesuai).
+func (r *achievementReferenceRepository) transition(ctx context.Context, id, from, to, actorID string, note *string, at time.Time, query string, args ...interface{}) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		affected, _ := res.RowsAffected()
+		if affected == 0 {
+			return sql.ErrNoRows
+		}
+
+		return recordHistory(ctx, tx, id, &from, to, actorID, note, at)
+	})
+}
+
+func (r *achievementReferenceRepository) ListHistory(ctx context.Context, referenceID string) ([]models.AchievementStatusHistory, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT h.id, h.reference_id, h.from_status, h.to_status,
+		       h.actor_id, u.full_name, h.note, h.created_at
+		FROM achievement_status_history h
+		LEFT JOIN users u ON u.id = h.actor_id
+		WHERE h.reference_id=$1
+		ORDER BY h.created_at ASC, h.id ASC
+	`, referenceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.AchievementStatusHistory{}
+
+	for rows.Next() {
+		var h models.AchievementStatusHistory
+		if err := rows.Scan(
+			&h.ID, &h.ReferenceID, &h.FromStatus, &h.ToStatus,
+			&h.ActorID, &h.ActorName, &h.Note, &h.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, h)
+	}
+
+	return list, rows.Err()
+}
+
 // ================= CREATE =================
-func (r *achievementReferenceRepository) Create(studentID string, mongoID string) (*models.AchievementReference, error) {
+func (r *achievementReferenceRepository) Create(ctx context.Context, studentID string, mongoID string, actorID string) (*models.AchievementReference, error) {
 	now := time.Now()
 	var id string
 
-	err := r.db.QueryRow(`
-		INSERT INTO achievement_references (
-			student_id, mongo_achievement_id, status,
-			created_at, updated_at
-		) VALUES (
-			$1, $2, 'draft', $3, $3
-		) RETURNING id
-	`, studentID, mongoID, now).Scan(&id)
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO achievement_references (
+				student_id, mongo_achievement_id, status,
+				created_at, updated_at
+			) VALUES (
+				$1, $2, 'draft', $3, $3
+			) RETURNING id
+		`, studentID, mongoID, now).Scan(&id)
+		if err != nil {
+			return err
+		}
 
+		return recordHistory(ctx, tx, id, nil, models.StatusDraft, actorID, nil, now)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return r.GetByID(id)
+	return r.GetByID(ctx, id)
 }
 
 // ================= SUBMIT =================
-func (r *achievementReferenceRepository) Submit(id string) error {
+func (r *achievementReferenceRepository) Submit(ctx context.Context, id string, actorID string) error {
 	now := time.Now()
 
-	res, err := r.db.Exec(`
+	return r.transition(ctx, id, models.StatusDraft, models.StatusSubmitted, actorID, nil, now, `
 		UPDATE achievement_references
 		SET status='submitted',
 		    submitted_at=$1,
+		    current_stage=0,
 		    updated_at=$1
 		WHERE id=$2
 		  AND status='draft'
 	`, now, id)
+}
+
+// ================= DECIDE =================
+func (r *achievementReferenceRepository) Decide(ctx context.Context, id string, d *models.StageDecision) error {
+	now := time.Now()
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var res sql.Result
+		var err error
+		var to string
+		switch {
+		case d.Decision == models.DecisionRejected:
+			to = models.StatusRejected
+			res, err = tx.ExecContext(ctx, `
+				UPDATE achievement_references
+				SET status='rejected',
+				    verified_at=$1,
+				    verified_by=$2,
+				    rejection_note=$3,
+				    updated_at=$1
+				WHERE id=$4 AND status='submitted' AND current_stage=$5
+			`, now, d.ActorID, d.Note, id, d.Stage)
+		case d.Final:
+			to = models.StatusVerified
+			res, err = tx.ExecContext(ctx, `
+				UPDATE achievement_references
+				SET status='verified',
+				    verified_at=$1,
+				    verified_by=$2,
+				    rejection_note=NULL,
+				    updated_at=$1
+				WHERE id=$3 AND status='submitted' AND current_stage=$4
+			`, now, d.ActorID, id, d.Stage)
+		default:
+			res, err = tx.ExecContext(ctx, `
+				UPDATE achievement_references
+				SET current_stage=current_stage+1,
+				    updated_at=$1
+				WHERE id=$2 AND status='submitted' AND current_stage=$3
+			`, now, id, d.Stage)
+		}
+		if err != nil {
+			return err
+		}
+
+		affected, _ := res.RowsAffected()
+		if affected == 0 {
+			return sql.ErrNoRows
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO achievement_stage_decisions (
+				reference_id, revision, stage, stage_name, role_name,
+				decision, actor_id, note, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING id
+		`, id, d.Revision, d.Stage, d.StageName, d.RoleName,
+			d.Decision, d.ActorID, d.Note, now).Scan(&d.ID)
+		if err != nil {
+			return err
+		}
+
+		if to != "" {
+			from := models.StatusSubmitted
+			if err := recordHistory(ctx, tx, id, &from, to, d.ActorID, d.Note, now); err != nil {
+				return err
+			}
+		}
+
+		d.ReferenceID = id
+		d.CreatedAt = now
+		return nil
+	})
+}
+
+func (r *achievementReferenceRepository) ListDecisions(ctx context.Context, referenceID string) ([]models.StageDecision, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, reference_id, revision, stage, stage_name, role_name,
+		       decision, COALESCE(actor_id::text, ''), note, created_at
+		FROM achievement_stage_decisions
+		WHERE reference_id=$1
+		ORDER BY created_at ASC, id ASC
+	`, referenceID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	affected, _ := res.RowsAffected()
-	if affected == 0 {
-		return sql.ErrNoRows
+	list := []models.StageDecision{}
+
+	for rows.Next() {
+		var d models.StageDecision
+		if err := rows.Scan(
+			&d.ID, &d.ReferenceID, &d.Revision, &d.Stage, &d.StageName, &d.RoleName,
+			&d.Decision, &d.ActorID, &d.Note, &d.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, d)
 	}
 
-	return nil
+	return list, rows.Err()
 }
 
-// ================= VERIFY =================
-func (r *achievementReferenceRepository) Verify(id string, verifierID string) error {
+// ================= SOFT DELETE =================
+func (r *achievementReferenceRepository) SoftDelete(ctx context.Context, id string, actorID string) error {
 	now := time.Now()
 
-	res, err := r.db.Exec(`
+	return r.transition(ctx, id, models.StatusDraft, models.StatusDeleted, actorID, nil, now, `
 		UPDATE achievement_references
-		SET status='verified',
-		    verified_at=$1,
-		    verified_by=$2,
-		    rejection_note=NULL,
+		SET status='deleted',
 		    updated_at=$1
-		WHERE id=$3 AND status='submitted'
-	`, now, verifierID, id)
-	if err != nil {
-		return err
-	}
+		WHERE id=$2
+		  AND status='draft'
+	`, now, id)
+}
 
-	affected, _ := res.RowsAffected()
-	if affected == 0 {
-		return sql.ErrNoRows
-	}
+// ================= REVISE =================
+func (r *achievementReferenceRepository) Revise(ctx context.Context, id string, actorID string) error {
+	now := time.Now()
 
-	return nil
+	return r.transition(ctx, id, models.StatusRejected, models.StatusDraft, actorID, nil, now, `
+		UPDATE achievement_references
+		SET status='draft',
+		    revision=revision+1,
+		    updated_at=$1
+		WHERE id=$2
+		  AND status='rejected'
+	`, now, id)
+}
+
+// ================= WITHDRAW =================
+func (r *achievementReferenceRepository) Withdraw(ctx context.Context, id string, actorID string) error {
+	now := time.Now()
+
+	return r.transition(ctx, id, models.StatusSubmitted, models.StatusDraft, actorID, nil, now, `
+		UPDATE achievement_references
+		SET status='draft',
+		    submitted_at=NULL,
+		    updated_at=$1
+		WHERE id=$2
+		  AND status='submitted'
+		  AND current_stage=0
+	`, now, id)
 }
 
-// ================= REJECT =================
-func (r *achievementReferenceRepository) Reject(id string, verifierID string, note string) error {
+// ================= DELETE ORPHAN =================
+func (r *achievementReferenceRepository) DeleteOrphan(ctx context.Context, id, from string, note string) error {
 	now := time.Now()
 
-	res, err := r.db.Exec(`
+	return r.transition(ctx, id, from, models.StatusDeleted, "", &note, now, `
 		UPDATE achievement_references
-		SET status='rejected',
-		    verified_at=$1,
-		    verified_by=$2,
-		    rejection_note=$3,
+		SET status='deleted',
 		    updated_at=$1
-		WHERE id=$4 AND status='submitted'
-	`, now, verifierID, note, id)
+		WHERE id=$2
+		  AND status=$3
+	`, now, id, from)
+}
+
+// ================= RECYCLE BIN =================
+func (r *achievementReferenceRepository) ListDeleted(ctx context.Context, studentIDs []string, limit, offset int) ([]models.AchievementReference, int64, error) {
+	// pq.Array(nil) dikirim sebagai NULL sehingga filter mahasiswa dilewati
+	students := pq.Array(studentIDs)
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, student_id, mongo_achievement_id, status,
+		       submitted_at, verified_at, verified_by,
+		       rejection_note, revision, current_stage, created_at, updated_at
+		FROM achievement_references
+		WHERE status='deleted'
+		  AND ($1::uuid[] IS NULL OR student_id = ANY($1::uuid[]))
+		ORDER BY updated_at DESC
+		LIMIT $2 OFFSET $3
+	`, students, limit, offset)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
+	defer rows.Close()
 
-	affected, _ := res.RowsAffected()
-	if affected == 0 {
-		return sql.ErrNoRows
+	list := []models.AchievementReference{}
+
+	for rows.Next() {
+		var a models.AchievementReference
+		if err := rows.Scan(
+			&a.ID, &a.StudentID, &a.MongoAchievementID, &a.Status,
+			&a.SubmittedAt, &a.VerifiedAt, &a.VerifiedBy,
+			&a.RejectionNote, &a.Revision, &a.CurrentStage, &a.CreatedAt, &a.UpdatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		list = append(list, a)
 	}
 
-	return nil
+	var total int64
+	err = conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM achievement_references
+		WHERE status='deleted'
+		  AND ($1::uuid[] IS NULL OR student_id = ANY($1::uuid[]))
+	`, students).Scan(&total)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return list, total, nil
 }
 
-// ================= SOFT DELETE =================
-func (r *achievementReferenceRepository) SoftDelete(id string) error {
+func (r *achievementReferenceRepository) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]models.AchievementReference, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, student_id, mongo_achievement_id, status,
+		       submitted_at, verified_at, verified_by,
+		       rejection_note, revision, current_stage, created_at, updated_at
+		FROM achievement_references
+		WHERE status='deleted'
+		  AND updated_at < $1
+		ORDER BY updated_at ASC
+		LIMIT $2
+	`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.AchievementReference{}
+
+	for rows.Next() {
+		var a models.AchievementReference
+		if err := rows.Scan(
+			&a.ID, &a.StudentID, &a.MongoAchievementID, &a.Status,
+			&a.SubmittedAt, &a.VerifiedAt, &a.VerifiedBy,
+			&a.RejectionNote, &a.Revision, &a.CurrentStage, &a.CreatedAt, &a.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+
+	return list, rows.Err()
+}
+
+// ================= RESTORE =================
+func (r *achievementReferenceRepository) Restore(ctx context.Context, id string, actorID string) error {
 	now := time.Now()
 
-	res, err := r.db.Exec(`
+	return r.transition(ctx, id, models.StatusDeleted, models.StatusDraft, actorID, nil, now, `
 		UPDATE achievement_references
-		SET status='deleted',
+		SET status='draft',
+		    submitted_at=NULL,
 		    updated_at=$1
 		WHERE id=$2
-		  AND status='draft'
+		  AND status='deleted'
 	`, now, id)
+}
+
+// ================= PURGE =================
+func (r *achievementReferenceRepository) Purge(ctx context.Context, id string) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		DELETE FROM achievement_references
+		WHERE id=$1
+		  AND status='deleted'
+	`, id)
 	if err != nil {
 		return err
 	}
@@ -263,16 +582,16 @@ func (r *achievementReferenceRepository) SoftDelete(id string) error {
 }
 
 // ================= PAGINATION BY ADVISEES =================
-func (r *achievementReferenceRepository) GetByAdviseesWithPagination(studentIDs []string, limit int, offset int) ([]models.AchievementReference, int64, error) {
+func (r *achievementReferenceRepository) GetByAdviseesWithPagination(ctx context.Context, studentIDs []string, limit int, offset int) ([]models.AchievementReference, int64, error) {
 
 	if len(studentIDs) == 0 {
 		return []models.AchievementReference{}, 0, nil
 	}
 
-	rows, err := r.db.Query(`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT id, student_id, mongo_achievement_id, status,
 		       submitted_at, verified_at, verified_by,
-		       rejection_note, created_at, updated_at
+		       rejection_note, revision, current_stage, created_at, updated_at
 		FROM achievement_references
 		WHERE student_id = ANY($1::uuid[])
 		ORDER BY created_at DESC
@@ -291,7 +610,7 @@ func (r *achievementReferenceRepository) GetByAdviseesWithPagination(studentIDs
 		if err := rows.Scan(
 			&a.ID, &a.StudentID, &a.MongoAchievementID, &a.Status,
 			&a.SubmittedAt, &a.VerifiedAt, &a.VerifiedBy,
-			&a.RejectionNote, &a.CreatedAt, &a.UpdatedAt,
+			&a.RejectionNote, &a.Revision, &a.CurrentStage, &a.CreatedAt, &a.UpdatedAt,
 		); err != nil {
 			return nil, 0, err
 		}
@@ -299,7 +618,7 @@ func (r *achievementReferenceRepository) GetByAdviseesWithPagination(studentIDs
 	}
 
 	var total int64
-	err = r.db.QueryRow(`
+	err = conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT COUNT(*)
 		FROM achievement_references
 		WHERE student_id = ANY($1::uuid[])
@@ -313,12 +632,12 @@ func (r *achievementReferenceRepository) GetByAdviseesWithPagination(studentIDs
 }
 
 // ================= GET ALL WITH PAGINATION =================
-func (r *achievementReferenceRepository) GetAllWithPagination(limit, offset int) ([]models.AchievementReference, int64, error) {
+func (r *achievementReferenceRepository) GetAllWithPagination(ctx context.Context, limit, offset int) ([]models.AchievementReference, int64, error) {
 
-	rows, err := r.db.Query(`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT id, student_id, mongo_achievement_id, status,
 		       submitted_at, verified_at, verified_by,
-		       rejection_note, created_at, updated_at
+		       rejection_note, revision, current_stage, created_at, updated_at
 		FROM achievement_references
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -336,7 +655,7 @@ func (r *achievementReferenceRepository) GetAllWithPagination(limit, offset int)
 		if err := rows.Scan(
 			&a.ID, &a.StudentID, &a.MongoAchievementID, &a.Status,
 			&a.SubmittedAt, &a.VerifiedAt, &a.VerifiedBy,
-			&a.RejectionNote, &a.CreatedAt, &a.UpdatedAt,
+			&a.RejectionNote, &a.Revision, &a.CurrentStage, &a.CreatedAt, &a.UpdatedAt,
 		); err != nil {
 			return nil, 0, err
 		}
@@ -344,7 +663,7 @@ func (r *achievementReferenceRepository) GetAllWithPagination(limit, offset int)
 	}
 
 	var total int64
-	err = r.db.QueryRow(`
+	err = conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT COUNT(*) FROM achievement_references
 	`).Scan(&total)
 
@@ -1,12 +1,13 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"regexp"
 	"testing"
 	"time"
 
-	// models "achievement_backend/app/model"
+	models "achievement_backend/app/model"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
@@ -29,34 +30,65 @@ func TestAchievementReference_GetByID_Success(t *testing.T) {
 	rows := sqlmock.NewRows([]string{
 		"id", "student_id", "mongo_achievement_id", "status",
 		"submitted_at", "verified_at", "verified_by",
-		"rejection_note", "created_at", "updated_at",
+		"rejection_note", "revision", "current_stage", "created_at", "updated_at",
 	}).AddRow(
 		"1", "434231016", "2345678909876543256", "draft",
 		nil, nil, nil,
-		nil, now, now,
+		nil, 0, 0, now, now,
 	)
 
 	mock.ExpectQuery(regexp.QuoteMeta(`
 		SELECT id, student_id, mongo_achievement_id, status,
 		       submitted_at, verified_at, verified_by,
-		       rejection_note, created_at, updated_at
+		       rejection_note, revision, current_stage, created_at, updated_at
 		FROM achievement_references
 		WHERE id=$1
 	`)).WithArgs("1").WillReturnRows(rows)
 
-	res, err := repo.GetByID("1")
+	res, err := repo.GetByID(context.Background(), "1")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, res)
 	assert.Equal(t, "draft", res.Status)
 }
 
+func TestAchievementReference_LockByMongoAchievementID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAchievementReferenceRepository(db)
+	uow := NewUnitOfWork(db)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`WHERE mongo_achievement_id=\$1\s+FOR UPDATE`).
+		WithArgs("m1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "student_id", "mongo_achievement_id", "status",
+			"submitted_at", "verified_at", "verified_by",
+			"rejection_note", "revision", "current_stage", "created_at", "updated_at",
+		}).AddRow("1", "s1", "m1", "submitted", now, nil, nil, nil, 0, 0, now, now))
+	mock.ExpectCommit()
+
+	var ref *models.AchievementReference
+	err = uow.Do(context.Background(), func(ctx context.Context) error {
+		ref, err = repo.LockByMongoAchievementID(ctx, "m1")
+		return err
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusSubmitted, ref.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestAchievementReference_Create(t *testing.T) {
 	db, mock, repo := setupAchievementRefRepo(t)
 	defer db.Close()
 
 	now := time.Now()
 
+	mock.ExpectBegin()
 	mock.ExpectQuery(regexp.QuoteMeta(`
 		INSERT INTO achievement_references (
 			student_id, mongo_achievement_id, status,
@@ -67,19 +99,26 @@ func TestAchievementReference_Create(t *testing.T) {
 	`)).
 		WithArgs("434231016", "2345678909876543256", sqlmock.AnyArg()).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_status_history`)).
+		WithArgs("1", nil, "draft", "USR001", nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_outbox`)).
+		WithArgs("1", "draft", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	// GetByID dipanggil setelah insert
 	mock.ExpectQuery("SELECT id, student_id").
 		WillReturnRows(sqlmock.NewRows([]string{
 			"id", "student_id", "mongo_achievement_id", "status",
 			"submitted_at", "verified_at", "verified_by",
-			"rejection_note", "created_at", "updated_at",
+			"rejection_note", "revision", "current_stage", "created_at", "updated_at",
 		}).AddRow(
 			"123456sd-jhgf34567-jhg45678", "434231016", "2345678909876543256", "draft",
-			nil, nil, nil, nil, now, now,
+			nil, nil, nil, nil, 0, 0, now, now,
 		))
 
-	res, err := repo.Create("434231016", "2345678909876543256")
+	res, err := repo.Create(context.Background(), "434231016", "2345678909876543256", "USR001")
 
 	assert.NoError(t, err)
 	assert.Equal(t, "draft", res.Status)
@@ -89,79 +128,373 @@ func TestAchievementReference_Submit(t *testing.T) {
 	db, mock, repo := setupAchievementRefRepo(t)
 	defer db.Close()
 
+	mock.ExpectBegin()
 	mock.ExpectExec(regexp.QuoteMeta(`
 		UPDATE achievement_references
 		SET status='submitted',
 		    submitted_at=$1,
+		    current_stage=0,
 		    updated_at=$1
 		WHERE id=$2
 		  AND status='draft'
 	`)).
 		WithArgs(sqlmock.AnyArg(), "1").
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_status_history`)).
+		WithArgs("1", "draft", "submitted", "USR001", nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_outbox`)).
+		WithArgs("1", "submitted", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Submit(context.Background(), "1", "USR001")
+
+	assert.NoError(t, err)
+}
+
+func TestAchievementReference_Decide_FinalApproval(t *testing.T) {
+	db, mock, repo := setupAchievementRefRepo(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+			UPDATE achievement_references
+			SET status='verified',
+			    verified_at=$1,
+			    verified_by=$2,
+			    rejection_note=NULL,
+			    updated_at=$1
+			WHERE id=$3 AND status='submitted' AND current_stage=$4
+		`)).
+		WithArgs(sqlmock.AnyArg(), "DSN002", "1", 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO achievement_stage_decisions`)).
+		WithArgs("1", 0, 0, "Verifikasi", "Dosen Wali", "approved", "DSN002", nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("d1"))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_status_history`)).
+		WithArgs("1", "submitted", "verified", "DSN002", nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_outbox`)).
+		WithArgs("1", "verified", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	d := &models.StageDecision{
+		Stage: 0, StageName: "Verifikasi", RoleName: "Dosen Wali",
+		Decision: models.DecisionApproved, ActorID: "DSN002", Final: true,
+	}
+	err := repo.Decide(context.Background(), "1", d)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "d1", d.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAchievementReference_Decide_IntermediateStageKeepsStatus(t *testing.T) {
+	db, mock, repo := setupAchievementRefRepo(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+			UPDATE achievement_references
+			SET current_stage=current_stage+1,
+			    updated_at=$1
+			WHERE id=$2 AND status='submitted' AND current_stage=$3
+		`)).
+		WithArgs(sqlmock.AnyArg(), "1", 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO achievement_stage_decisions`)).
+		WithArgs("1", 0, 0, "Pemeriksaan dosen wali", "Dosen Wali", "approved", "DSN002", nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("d1"))
+	mock.ExpectCommit()
 
-	err := repo.Submit("1")
+	err := repo.Decide(context.Background(), "1", &models.StageDecision{
+		Stage: 0, StageName: "Pemeriksaan dosen wali", RoleName: "Dosen Wali",
+		Decision: models.DecisionApproved, ActorID: "DSN002",
+	})
 
 	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestAchievementReference_Verify(t *testing.T) {
+func TestAchievementReference_Decide_Reject(t *testing.T) {
 	db, mock, repo := setupAchievementRefRepo(t)
 	defer db.Close()
 
+	note := "note"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+			UPDATE achievement_references
+			SET status='rejected',
+			    verified_at=$1,
+			    verified_by=$2,
+			    rejection_note=$3,
+			    updated_at=$1
+			WHERE id=$4 AND status='submitted' AND current_stage=$5
+		`)).
+		WithArgs(sqlmock.AnyArg(), "DSN002", "note", "1", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO achievement_stage_decisions`)).
+		WithArgs("1", 0, 1, "Persetujuan kemahasiswaan", "Kemahasiswaan", "rejected", "DSN002", "note", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("d2"))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_status_history`)).
+		WithArgs("1", "submitted", "rejected", "DSN002", "note", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_outbox`)).
+		WithArgs("1", "rejected", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Decide(context.Background(), "1", &models.StageDecision{
+		Stage: 1, StageName: "Persetujuan kemahasiswaan", RoleName: "Kemahasiswaan",
+		Decision: models.DecisionRejected, ActorID: "DSN002", Note: &note,
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAchievementReference_Decide_StageAlreadyDecided(t *testing.T) {
+	db, mock, repo := setupAchievementRefRepo(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE achievement_references`)).
+		WithArgs(sqlmock.AnyArg(), "1", 0).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.Decide(context.Background(), "1", &models.StageDecision{Decision: models.DecisionApproved, ActorID: "DSN002"})
+
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAchievementReference_SoftDelete(t *testing.T) {
+	db, mock, repo := setupAchievementRefRepo(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
 	mock.ExpectExec(regexp.QuoteMeta(`
 		UPDATE achievement_references
-		SET status='verified',
-		    verified_at=$1,
-		    verified_by=$2,
-		    rejection_note=NULL,
+		SET status='deleted',
 		    updated_at=$1
-		WHERE id=$3 AND status='submitted'
+		WHERE id=$2
+		  AND status='draft'
 	`)).
-		WithArgs(sqlmock.AnyArg(), "DSN002", "1").
+		WithArgs(sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_status_history`)).
+		WithArgs("1", "draft", "deleted", "USR001", nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_outbox`)).
+		WithArgs("1", "deleted", sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
-	err := repo.Verify("1", "DSN002")
+	err := repo.SoftDelete(context.Background(), "1", "USR001")
 
 	assert.NoError(t, err)
 }
 
-func TestAchievementReference_Reject(t *testing.T) {
+func TestAchievementReference_Submit_WrongStatusWritesNoHistory(t *testing.T) {
 	db, mock, repo := setupAchievementRefRepo(t)
 	defer db.Close()
 
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE achievement_references`)).
+		WithArgs(sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.Submit(context.Background(), "1", "USR001")
+
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAchievementReference_ListHistory(t *testing.T) {
+	db, mock, repo := setupAchievementRefRepo(t)
+	defer db.Close()
+
+	now := time.Now()
+	note := "bukti kurang"
+
+	rows := sqlmock.NewRows([]string{
+		"id", "reference_id", "from_status", "to_status",
+		"actor_id", "full_name", "note", "created_at",
+	}).
+		AddRow("h1", "1", nil, "draft", "USR001", "Budi", nil, now).
+		AddRow("h2", "1", "draft", "submitted", "USR001", "Budi", nil, now).
+		AddRow("h3", "1", "submitted", "rejected", "DSN002", "Dosen", note, now)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM achievement_status_history h`)).
+		WithArgs("1").
+		WillReturnRows(rows)
+
+	list, err := repo.ListHistory(context.Background(), "1")
+
+	assert.NoError(t, err)
+	assert.Len(t, list, 3)
+	assert.Nil(t, list[0].FromStatus)
+	assert.Equal(t, "rejected", list[2].ToStatus)
+	assert.Equal(t, note, *list[2].Note)
+}
+
+func TestAchievementReference_Revise(t *testing.T) {
+	db, mock, repo := setupAchievementRefRepo(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
 	mock.ExpectExec(regexp.QuoteMeta(`
 		UPDATE achievement_references
-		SET status='rejected',
-		    verified_at=$1,
-		    verified_by=$2,
-		    rejection_note=$3,
+		SET status='draft',
+		    revision=revision+1,
 		    updated_at=$1
-		WHERE id=$4 AND status='submitted'
+		WHERE id=$2
+		  AND status='rejected'
 	`)).
-		WithArgs(sqlmock.AnyArg(), "DSN002", "note", "1").
+		WithArgs(sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_status_history`)).
+		WithArgs("1", "rejected", "draft", "USR001", nil, sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_outbox`)).
+		WithArgs("1", "draft", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
-	err := repo.Reject("1", "DSN002", "note")
+	err := repo.Revise(context.Background(), "1", "USR001")
 
 	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestAchievementReference_SoftDelete(t *testing.T) {
+func TestAchievementReference_Withdraw(t *testing.T) {
 	db, mock, repo := setupAchievementRefRepo(t)
 	defer db.Close()
 
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		UPDATE achievement_references
+		SET status='draft',
+		    submitted_at=NULL,
+		    updated_at=$1
+		WHERE id=$2
+		  AND status='submitted'
+		  AND current_stage=0
+	`)).
+		WithArgs(sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_status_history`)).
+		WithArgs("1", "submitted", "draft", "USR001", nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_outbox`)).
+		WithArgs("1", "draft", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Withdraw(context.Background(), "1", "USR001")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Tahap pertama sudah disetujui: UPDATE tidak mengenai baris apa pun dan
+// tidak ada history yang ditulis.
+func TestAchievementReference_Withdraw_AfterStageDecision(t *testing.T) {
+	db, mock, repo := setupAchievementRefRepo(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`AND current_stage=0`)).
+		WithArgs(sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.Withdraw(context.Background(), "1", "USR001")
+
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAchievementReference_DeleteOrphan(t *testing.T) {
+	db, mock, repo := setupAchievementRefRepo(t)
+	defer db.Close()
+
+	note := "reconcile: dokumen Mongo tidak ditemukan"
+
+	mock.ExpectBegin()
 	mock.ExpectExec(regexp.QuoteMeta(`
 		UPDATE achievement_references
 		SET status='deleted',
 		    updated_at=$1
 		WHERE id=$2
-		  AND status='draft'
+		  AND status=$3
+	`)).
+		WithArgs(sqlmock.AnyArg(), "1", "verified").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_status_history`)).
+		WithArgs("1", "verified", "deleted", nil, note, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_outbox`)).
+		WithArgs("1", "deleted", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.DeleteOrphan(context.Background(), "1", "verified", note)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAchievementReference_Restore(t *testing.T) {
+	db, mock, repo := setupAchievementRefRepo(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		UPDATE achievement_references
+		SET status='draft',
+		    submitted_at=NULL,
+		    updated_at=$1
+		WHERE id=$2
+		  AND status='deleted'
 	`)).
 		WithArgs(sqlmock.AnyArg(), "1").
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_status_history`)).
+		WithArgs("1", "deleted", "draft", "user-1", nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_outbox`)).
+		WithArgs("1", "draft", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
-	err := repo.SoftDelete("1")
+	err := repo.Restore(context.Background(), "1", "user-1")
 
 	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAchievementReference_Purge(t *testing.T) {
+	db, mock, repo := setupAchievementRefRepo(t)
+	defer db.Close()
+
+	query := regexp.QuoteMeta(`
+		DELETE FROM achievement_references
+		WHERE id=$1
+		  AND status='deleted'
+	`)
+
+	mock.ExpectExec(query).WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.Purge(context.Background(), "1"))
+
+	// sudah di-restore: tidak ada baris yang dihapus
+	mock.ExpectExec(query).WithArgs("2").WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, repo.Purge(context.Background(), "2"), sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
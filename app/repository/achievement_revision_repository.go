@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	models "achievement_backend/app/model"
+
+	"github.com/google/uuid"
+)
+
+type AchievementRevisionStore interface {
+	// Save menyimpan salinan revisi. Revisi yang sudah ada untuk reference
+	// yang sama tidak ditimpa.
+	Save(ctx context.Context, rev *models.AchievementRevision) error
+	// Latest mengembalikan revisi terakhir; sql.ErrNoRows jika belum ada.
+	Latest(ctx context.Context, referenceID string) (*models.AchievementRevision, error)
+}
+
+// ================= POSTGRES =================
+
+type achievementRevisionRepository struct {
+	db *sql.DB
+}
+
+func NewAchievementRevisionRepository(db *sql.DB) AchievementRevisionStore {
+	return &achievementRevisionRepository{db: db}
+}
+
+func (r *achievementRevisionRepository) Save(ctx context.Context, rev *models.AchievementRevision) error {
+	snapshot, err := json.Marshal(rev.Snapshot)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO achievement_revisions (
+			reference_id, revision, snapshot, rejection_note,
+			reviewed_by, reviewed_at, created_by, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (reference_id, revision) DO NOTHING
+	`, rev.ReferenceID, rev.Revision, snapshot, rev.RejectionNote,
+		rev.ReviewedBy, rev.ReviewedAt, rev.CreatedBy)
+
+	return err
+}
+
+func (r *achievementRevisionRepository) Latest(ctx context.Context, referenceID string) (*models.AchievementRevision, error) {
+	var rev models.AchievementRevision
+	var snapshot []byte
+	var createdBy sql.NullString
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, reference_id, revision, snapshot, rejection_note,
+		       reviewed_by, reviewed_at, created_by, created_at
+		FROM achievement_revisions
+		WHERE reference_id = $1
+		ORDER BY revision DESC
+		LIMIT 1
+	`, referenceID).Scan(
+		&rev.ID, &rev.ReferenceID, &rev.Revision, &snapshot, &rev.RejectionNote,
+		&rev.ReviewedBy, &rev.ReviewedAt, &createdBy, &rev.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(snapshot, &rev.Snapshot); err != nil {
+		return nil, err
+	}
+	rev.CreatedBy = createdBy.String
+
+	return &rev, nil
+}
+
+// ================= IN-MEMORY (testing) =================
+
+type memoryAchievementRevisionStore struct {
+	mu   sync.Mutex
+	revs map[string][]models.AchievementRevision
+}
+
+func NewMemoryAchievementRevisionStore() AchievementRevisionStore {
+	return &memoryAchievementRevisionStore{revs: make(map[string][]models.AchievementRevision)}
+}
+
+func (m *memoryAchievementRevisionStore) Save(ctx context.Context, rev *models.AchievementRevision) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range m.revs[rev.ReferenceID] {
+		if r.Revision == rev.Revision {
+			return nil
+		}
+	}
+
+	rev.ID = uuid.New().String()
+	rev.CreatedAt = time.Now()
+	m.revs[rev.ReferenceID] = append(m.revs[rev.ReferenceID], *rev)
+	return nil
+}
+
+func (m *memoryAchievementRevisionStore) Latest(ctx context.Context, referenceID string) (*models.AchievementRevision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var latest *models.AchievementRevision
+	for i, r := range m.revs[referenceID] {
+		if latest == nil || r.Revision > latest.Revision {
+			latest = &m.revs[referenceID][i]
+		}
+	}
+	if latest == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	cp := *latest
+	return &cp, nil
+}
@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	models "achievement_backend/app/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type APIKeyStore interface {
+	CreateServiceAccount(ctx context.Context, sa *models.ServiceAccount) error
+	// GetServiceAccount mengembalikan sql.ErrNoRows jika tidak ada.
+	GetServiceAccount(ctx context.Context, id string) (*models.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context) ([]models.ServiceAccount, error)
+
+	CreateKey(ctx context.Context, k *models.APIKey) error
+	// GetKeyByPrefix mengembalikan sql.ErrNoRows jika tidak ada.
+	GetKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	ListKeys(ctx context.Context, serviceAccountID string) ([]models.APIKey, error)
+	// RevokeKey mengembalikan sql.ErrNoRows jika key tidak ada atau sudah dicabut.
+	RevokeKey(ctx context.Context, serviceAccountID, keyID string) error
+	TouchKey(ctx context.Context, id string, at time.Time) error
+}
+
+// ================= POSTGRES =================
+
+type apiKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) APIKeyStore {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) CreateServiceAccount(ctx context.Context, sa *models.ServiceAccount) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO service_accounts (name, description, is_active, created_by, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, created_at
+	`, sa.Name, sa.Description, sa.IsActive, sa.CreatedBy).Scan(&sa.ID, &sa.CreatedAt)
+}
+
+func (r *apiKeyRepository) GetServiceAccount(ctx context.Context, id string) (*models.ServiceAccount, error) {
+	var sa models.ServiceAccount
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, name, description, is_active, created_by, created_at
+		FROM service_accounts
+		WHERE id = $1
+	`, id).Scan(&sa.ID, &sa.Name, &sa.Description, &sa.IsActive, &sa.CreatedBy, &sa.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &sa, nil
+}
+
+func (r *apiKeyRepository) ListServiceAccounts(ctx context.Context) ([]models.ServiceAccount, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, name, description, is_active, created_by, created_at
+		FROM service_accounts
+		ORDER BY name ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.ServiceAccount
+	for rows.Next() {
+		var sa models.ServiceAccount
+		if err := rows.Scan(&sa.ID, &sa.Name, &sa.Description, &sa.IsActive, &sa.CreatedBy, &sa.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, sa)
+	}
+
+	return list, rows.Err()
+}
+
+const apiKeyColumns = `id, service_account_id, name, prefix, key_hash, permissions,
+	expires_at, last_used_at, created_by, created_at, revoked_at`
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (*models.APIKey, error) {
+	var k models.APIKey
+	err := row.Scan(
+		&k.ID, &k.ServiceAccountID, &k.Name, &k.Prefix, &k.KeyHash, pq.Array(&k.Permissions),
+		&k.ExpiresAt, &k.LastUsedAt, &k.CreatedBy, &k.CreatedAt, &k.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *apiKeyRepository) CreateKey(ctx context.Context, k *models.APIKey) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO api_keys (service_account_id, name, prefix, key_hash, permissions, expires_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		RETURNING id, created_at
+	`, k.ServiceAccountID, k.Name, k.Prefix, k.KeyHash, pq.Array(k.Permissions), k.ExpiresAt, k.CreatedBy).
+		Scan(&k.ID, &k.CreatedAt)
+}
+
+func (r *apiKeyRepository) GetKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	return scanAPIKey(conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE prefix = $1
+	`, prefix))
+}
+
+func (r *apiKeyRepository) ListKeys(ctx context.Context, serviceAccountID string) ([]models.APIKey, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE service_account_id = $1
+		ORDER BY created_at DESC
+	`, serviceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *k)
+	}
+
+	return list, rows.Err()
+}
+
+func (r *apiKeyRepository) RevokeKey(ctx context.Context, serviceAccountID, keyID string) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE api_keys
+		SET revoked_at = NOW()
+		WHERE id = $1 AND service_account_id = $2 AND revoked_at IS NULL
+	`, keyID, serviceAccountID)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) TouchKey(ctx context.Context, id string, at time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, at)
+	return err
+}
+
+// ================= IN-MEMORY (testing) =================
+
+type memoryAPIKeyStore struct {
+	mu       sync.Mutex
+	accounts map[string]*models.ServiceAccount
+	keys     map[string]*models.APIKey // by id
+}
+
+func NewMemoryAPIKeyStore() APIKeyStore {
+	return &memoryAPIKeyStore{
+		accounts: make(map[string]*models.ServiceAccount),
+		keys:     make(map[string]*models.APIKey),
+	}
+}
+
+func (m *memoryAPIKeyStore) CreateServiceAccount(ctx context.Context, sa *models.ServiceAccount) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sa.ID = uuid.New().String()
+	sa.CreatedAt = time.Now()
+
+	cp := *sa
+	m.accounts[sa.ID] = &cp
+	return nil
+}
+
+func (m *memoryAPIKeyStore) GetServiceAccount(ctx context.Context, id string) (*models.ServiceAccount, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sa, ok := m.accounts[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	cp := *sa
+	return &cp, nil
+}
+
+func (m *memoryAPIKeyStore) ListServiceAccounts(ctx context.Context) ([]models.ServiceAccount, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []models.ServiceAccount
+	for _, sa := range m.accounts {
+		list = append(list, *sa)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+func (m *memoryAPIKeyStore) CreateKey(ctx context.Context, k *models.APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k.ID = uuid.New().String()
+	k.CreatedAt = time.Now()
+
+	cp := *k
+	m.keys[k.ID] = &cp
+	return nil
+}
+
+func (m *memoryAPIKeyStore) GetKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range m.keys {
+		if k.Prefix == prefix {
+			cp := *k
+			return &cp, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *memoryAPIKeyStore) ListKeys(ctx context.Context, serviceAccountID string) ([]models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []models.APIKey
+	for _, k := range m.keys {
+		if k.ServiceAccountID == serviceAccountID {
+			list = append(list, *k)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	return list, nil
+}
+
+func (m *memoryAPIKeyStore) RevokeKey(ctx context.Context, serviceAccountID, keyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k, ok := m.keys[keyID]
+	if !ok || k.ServiceAccountID != serviceAccountID || k.RevokedAt != nil {
+		return sql.ErrNoRows
+	}
+	now := time.Now()
+	k.RevokedAt = &now
+	return nil
+}
+
+func (m *memoryAPIKeyStore) TouchKey(ctx context.Context, id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if k, ok := m.keys[id]; ok {
+		k.LastUsedAt = &at
+	}
+	return nil
+}
@@ -1,12 +1,17 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	models "achievement_backend/app/model"
 )
 
+// ErrAmbiguousLogin dikembalikan jika identifier cocok dengan lebih dari satu user.
+var ErrAmbiguousLogin = errors.New("login identifier matches more than one user")
+
 type AuthRepository interface {
-	GetForLogin(identifier string) (*models.User, error)
+	GetForLogin(ctx context.Context, identifier string) (*models.User, error)
 }
 
 type authRepository struct {
@@ -17,33 +22,58 @@ func NewAuthRepository(db *sql.DB) AuthRepository {
 	return &authRepository{db: db}
 }
 
-func (r *authRepository) GetForLogin(identifier string) (*models.User, error) {
-	row := r.db.QueryRow(`
-		SELECT 
-			id, username, email, password_hash, full_name, 
-			role_id, is_active, created_at, updated_at
-		FROM users
-		WHERE username = $1 OR email = $1
-		LIMIT 1
+// GetForLogin mencari user berdasarkan username, email (case-insensitive),
+// NIM mahasiswa atau NIP dosen. Jika tidak ada yang cocok dikembalikan
+// sql.ErrNoRows; jika cocok dengan lebih dari satu user dikembalikan
+// ErrAmbiguousLogin.
+func (r *authRepository) GetForLogin(ctx context.Context, identifier string) (*models.User, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT DISTINCT
+			u.id, u.username, u.email, u.password_hash, u.full_name,
+			u.role_id, u.is_active, u.created_at, u.updated_at
+		FROM users u
+		LEFT JOIN students s ON s.user_id = u.id
+		LEFT JOIN lecturers l ON l.user_id = u.id
+		WHERE u.username = $1
+		   OR LOWER(u.email) = LOWER($1)
+		   OR s.student_id = $1
+		   OR l.lecturer_id = $1
+		LIMIT 2
 	`, identifier)
-
-	var u models.User
-
-	err := row.Scan(
-		&u.ID,
-		&u.Username,
-		&u.Email,
-		&u.PasswordHash,
-		&u.FullName,
-		&u.RoleID,
-		&u.IsActive,
-		&u.CreatedAt,
-		&u.UpdatedAt,
-	)
-
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var u models.User
+		err := rows.Scan(
+			&u.ID,
+			&u.Username,
+			&u.Email,
+			&u.PasswordHash,
+			&u.FullName,
+			&u.RoleID,
+			&u.IsActive,
+			&u.CreatedAt,
+			&u.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	switch len(users) {
+	case 0:
+		return nil, sql.ErrNoRows
+	case 1:
+		return &users[0], nil
+	}
 
-	return &u, nil
+	return nil, ErrAmbiguousLogin
 }
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 	"time"
@@ -21,8 +22,22 @@ func setupAuthRepo(t *testing.T) (*sql.DB, sqlmock.Sqlmock, AuthRepository) {
 	return db, mock, repo
 }
 
+const loginQuery = `SELECT DISTINCT(.|\n)*FROM users u(.|\n)*s.student_id = \$1(.|\n)*l.lecturer_id = \$1(.|\n)*LIMIT 2`
+
+var loginColumns = []string{
+	"id",
+	"username",
+	"email",
+	"password_hash",
+	"full_name",
+	"role_id",
+	"is_active",
+	"created_at",
+	"updated_at",
+}
+
 // =======================================================
-// TEST SUCCESS LOGIN (USERNAME / EMAIL)
+// TEST SUCCESS LOGIN (USERNAME / EMAIL / NIM / NIP)
 // =======================================================
 
 func TestAuthRepository_GetForLogin_Success(t *testing.T) {
@@ -31,17 +46,7 @@ func TestAuthRepository_GetForLogin_Success(t *testing.T) {
 
 	now := time.Now()
 
-	rows := sqlmock.NewRows([]string{
-		"id",
-		"username",
-		"email",
-		"password_hash",
-		"full_name",
-		"role_id",
-		"is_active",
-		"created_at",
-		"updated_at",
-	}).AddRow(
+	rows := sqlmock.NewRows(loginColumns).AddRow(
 		"1",
 		"cindy",
 		"cindy@mail.com",
@@ -53,16 +58,9 @@ func TestAuthRepository_GetForLogin_Success(t *testing.T) {
 		now,
 	)
 
-	mock.ExpectQuery(`
-		SELECT 
-			id, username, email, password_hash, full_name, 
-			role_id, is_active, created_at, updated_at
-		FROM users
-		WHERE username = \$1 OR email = \$1
-		LIMIT 1
-	`).WithArgs("cindy").WillReturnRows(rows)
+	mock.ExpectQuery(loginQuery).WithArgs("cindy").WillReturnRows(rows)
 
-	user, err := repo.GetForLogin("cindy")
+	user, err := repo.GetForLogin(context.Background(), "cindy")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
@@ -81,19 +79,36 @@ func TestAuthRepository_GetForLogin_NotFound(t *testing.T) {
 	db, mock, repo := setupAuthRepo(t)
 	defer db.Close()
 
-	mock.ExpectQuery(`
-		SELECT 
-			id, username, email, password_hash, full_name, 
-			role_id, is_active, created_at, updated_at
-		FROM users
-		WHERE username = \$1 OR email = \$1
-		LIMIT 1
-	`).WithArgs("unknown").
-		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(loginQuery).WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows(loginColumns))
+
+	user, err := repo.GetForLogin(context.Background(), "unknown")
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Nil(t, user)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// =======================================================
+// TEST IDENTIFIER AMBIGU
+// =======================================================
+
+func TestAuthRepository_GetForLogin_Ambiguous(t *testing.T) {
+	db, mock, repo := setupAuthRepo(t)
+	defer db.Close()
+
+	now := time.Now()
+
+	rows := sqlmock.NewRows(loginColumns).
+		AddRow("1", "2021001", "a@mail.com", "hash", "User A", "role-1", true, now, now).
+		AddRow("2", "budi", "b@mail.com", "hash", "User B", "role-2", true, now, now)
+
+	mock.ExpectQuery(loginQuery).WithArgs("2021001").WillReturnRows(rows)
 
-	user, err := repo.GetForLogin("unknown")
+	user, err := repo.GetForLogin(context.Background(), "2021001")
 
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrAmbiguousLogin)
 	assert.Nil(t, user)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	models "achievement_backend/app/model"
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+type AuthStateRepository interface {
+	GetAuthState(ctx context.Context, userID string) (*models.AuthState, error)
+}
+
+type authStateRepository struct {
+	db *sql.DB
+}
+
+func NewAuthStateRepository(db *sql.DB) AuthStateRepository {
+	return &authStateRepository{db: db}
+}
+
+func (r *authStateRepository) GetAuthState(ctx context.Context, userID string) (*models.AuthState, error) {
+	var st models.AuthState
+	var roleID sql.NullString
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT u.id, u.is_active, u.role_id, COALESCE(r.name, ''),
+		       u.auth_version, COALESCE(r.permission_version, 0),
+		       u.sessions_revoked_at
+		FROM users u
+		LEFT JOIN roles r ON r.id = u.role_id
+		WHERE u.id = $1
+	`, userID).Scan(
+		&st.UserID, &st.IsActive, &roleID, &st.RoleName,
+		&st.UserVersion, &st.RoleVersion,
+		&st.SessionsRevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	st.RoleID = roleID.String
+	if !roleID.Valid {
+		return &st, nil
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT p.name
+		FROM permissions p
+		INNER JOIN role_permissions rp ON rp.permission_id = p.id
+		WHERE rp.role_id = $1
+		ORDER BY p.name ASC
+	`, st.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		st.Permissions = append(st.Permissions, name)
+	}
+
+	return &st, nil
+}
+
+// ================= CACHE =================
+
+// AuthStateCache adalah AuthStateRepository yang menyimpan hasil per user.
+type AuthStateCache interface {
+	AuthStateRepository
+	// Invalidate membuang cache satu user setelah state-nya diubah proses ini.
+	Invalidate(userID string)
+	// InvalidateAll membuang seluruh cache, mis. setelah permission role berubah.
+	InvalidateAll()
+}
+
+type cachedAuthState struct {
+	userID    string
+	state     *models.AuthState
+	fetchedAt time.Time
+}
+
+type cachedAuthStateRepository struct {
+	inner      AuthStateRepository
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // value: *cachedAuthState
+	lru     *list.List               // depan = paling baru dipakai
+}
+
+// NewCachedAuthStateRepository membungkus repository lain dengan cache per
+// user berukuran paling banyak maxEntries (LRU). Perubahan yang tidak lewat
+// Invalidate (proses lain, SQL manual) paling lambat terlihat setelah ttl.
+func NewCachedAuthStateRepository(inner AuthStateRepository, ttl time.Duration, maxEntries int) AuthStateCache {
+	return &cachedAuthStateRepository{
+		inner:      inner,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+func (r *cachedAuthStateRepository) GetAuthState(ctx context.Context, userID string) (*models.AuthState, error) {
+	r.mu.Lock()
+	if el, ok := r.entries[userID]; ok {
+		e := el.Value.(*cachedAuthState)
+		if time.Since(e.fetchedAt) < r.ttl {
+			r.lru.MoveToFront(el)
+			r.mu.Unlock()
+			return e.state, nil
+		}
+		r.remove(el)
+	}
+	r.mu.Unlock()
+
+	st, err := r.inner.GetAuthState(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if el, ok := r.entries[userID]; ok {
+		r.remove(el)
+	}
+	r.entries[userID] = r.lru.PushFront(&cachedAuthState{userID: userID, state: st, fetchedAt: time.Now()})
+	for r.lru.Len() > r.maxEntries {
+		r.remove(r.lru.Back())
+	}
+
+	return st, nil
+}
+
+func (r *cachedAuthStateRepository) remove(el *list.Element) {
+	r.lru.Remove(el)
+	delete(r.entries, el.Value.(*cachedAuthState).userID)
+}
+
+func (r *cachedAuthStateRepository) Invalidate(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if el, ok := r.entries[userID]; ok {
+		r.remove(el)
+	}
+}
+
+func (r *cachedAuthStateRepository) InvalidateAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = make(map[string]*list.Element)
+	r.lru.Init()
+}
+
+// ================= INVALIDASI =================
+
+// Repository di bawah membungkus repository yang mengubah kolom auth state
+// (role, status aktif, sessions_revoked_at, permission role) dan membuang
+// cache setelah perubahan berhasil, sehingga proses yang sama langsung
+// melihat state baru tanpa menunggu ttl. Di dalam unit of work cache dibuang
+// lagi setelah commit: request lain yang membaca di antara perubahan dan
+// commit masih melihat state lama dan bisa mengisi cache dengannya.
+
+type authStateInvalidatingUserRepository struct {
+	UserRepository
+	cache AuthStateCache
+}
+
+func NewAuthStateInvalidatingUserRepository(inner UserRepository, cache AuthStateCache) UserRepository {
+	return &authStateInvalidatingUserRepository{UserRepository: inner, cache: cache}
+}
+
+func (r *authStateInvalidatingUserRepository) invalidate(ctx context.Context, userID string) {
+	r.cache.Invalidate(userID)
+	AfterCommit(ctx, func() { r.cache.Invalidate(userID) })
+}
+
+func invalidateAll(ctx context.Context, cache AuthStateCache) {
+	cache.InvalidateAll()
+	AfterCommit(ctx, cache.InvalidateAll)
+}
+
+func (r *authStateInvalidatingUserRepository) UpdatePartial(ctx context.Context, u *models.User) (*models.User, error) {
+	updated, err := r.UserRepository.UpdatePartial(ctx, u)
+	if err == nil {
+		r.invalidate(ctx, u.ID)
+	}
+	return updated, err
+}
+
+func (r *authStateInvalidatingUserRepository) RevokeSessions(ctx context.Context, id string) error {
+	err := r.UserRepository.RevokeSessions(ctx, id)
+	if err == nil {
+		r.invalidate(ctx, id)
+	}
+	return err
+}
+
+func (r *authStateInvalidatingUserRepository) Delete(ctx context.Context, id string) error {
+	err := r.UserRepository.Delete(ctx, id)
+	if err == nil {
+		r.invalidate(ctx, id)
+	}
+	return err
+}
+
+type authStateInvalidatingRoleRepository struct {
+	RoleRepository
+	cache AuthStateCache
+}
+
+func NewAuthStateInvalidatingRoleRepository(inner RoleRepository, cache AuthStateCache) RoleRepository {
+	return &authStateInvalidatingRoleRepository{RoleRepository: inner, cache: cache}
+}
+
+func (r *authStateInvalidatingRoleRepository) Update(ctx context.Context, id string, req models.UpdateRoleRequest) (*models.Role, error) {
+	role, err := r.RoleRepository.Update(ctx, id, req)
+	if err == nil {
+		invalidateAll(ctx, r.cache)
+	}
+	return role, err
+}
+
+type authStateInvalidatingRolePermissionRepository struct {
+	RolePermissionRepository
+	cache AuthStateCache
+}
+
+func NewAuthStateInvalidatingRolePermissionRepository(inner RolePermissionRepository, cache AuthStateCache) RolePermissionRepository {
+	return &authStateInvalidatingRolePermissionRepository{RolePermissionRepository: inner, cache: cache}
+}
+
+func (r *authStateInvalidatingRolePermissionRepository) AssignPermission(ctx context.Context, roleID string, permissionID string) error {
+	err := r.RolePermissionRepository.AssignPermission(ctx, roleID, permissionID)
+	if err == nil {
+		invalidateAll(ctx, r.cache)
+	}
+	return err
+}
+
+func (r *authStateInvalidatingRolePermissionRepository) RemovePermission(ctx context.Context, roleID string, permissionID string) error {
+	err := r.RolePermissionRepository.RemovePermission(ctx, roleID, permissionID)
+	if err == nil {
+		invalidateAll(ctx, r.cache)
+	}
+	return err
+}
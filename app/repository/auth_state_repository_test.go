@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	models "achievement_backend/app/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// =======================================================
+// GET AUTH STATE
+// =======================================================
+
+func TestAuthStateRepository_GetAuthState(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAuthStateRepository(db)
+
+	mock.ExpectQuery(`FROM users u`).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "is_active", "role_id", "name", "auth_version", "permission_version", "sessions_revoked_at",
+		}).AddRow("user-1", true, "role-1", "Mahasiswa", 2, 5, nil))
+
+	mock.ExpectQuery(`FROM permissions p`).
+		WithArgs("role-1").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).
+			AddRow("achievement:create").
+			AddRow("achievement:read"))
+
+	st, err := repo.GetAuthState(context.Background(), "user-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Mahasiswa", st.RoleName)
+	assert.Equal(t, 2, st.UserVersion)
+	assert.Equal(t, 5, st.RoleVersion)
+	assert.Equal(t, []string{"achievement:create", "achievement:read"}, st.Permissions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// =======================================================
+// CACHE
+// =======================================================
+
+type countingAuthStateRepo struct {
+	calls int
+}
+
+func (r *countingAuthStateRepo) GetAuthState(ctx context.Context, userID string) (*models.AuthState, error) {
+	r.calls++
+	return &models.AuthState{UserID: userID, UserVersion: r.calls}, nil
+}
+
+func TestCachedAuthStateRepository_ExpiresAfterTTL(t *testing.T) {
+	inner := &countingAuthStateRepo{}
+	cache := NewCachedAuthStateRepository(inner, 20*time.Millisecond, 10)
+
+	first, _ := cache.GetAuthState(context.Background(), "u1")
+	second, _ := cache.GetAuthState(context.Background(), "u1")
+	assert.Equal(t, 1, inner.calls)
+	assert.Equal(t, first.UserVersion, second.UserVersion)
+
+	time.Sleep(30 * time.Millisecond)
+
+	third, _ := cache.GetAuthState(context.Background(), "u1")
+	assert.Equal(t, 2, inner.calls)
+	assert.Equal(t, 2, third.UserVersion)
+}
+
+func TestCachedAuthStateRepository_EvictsLeastRecentlyUsed(t *testing.T) {
+	inner := &countingAuthStateRepo{}
+	cache := NewCachedAuthStateRepository(inner, time.Minute, 2)
+	ctx := context.Background()
+
+	cache.GetAuthState(ctx, "u1")
+	cache.GetAuthState(ctx, "u2")
+	cache.GetAuthState(ctx, "u1") // u2 jadi yang paling lama
+	cache.GetAuthState(ctx, "u3")
+	assert.Equal(t, 3, inner.calls)
+
+	cache.GetAuthState(ctx, "u1")
+	assert.Equal(t, 3, inner.calls)
+
+	cache.GetAuthState(ctx, "u2")
+	assert.Equal(t, 4, inner.calls)
+}
+
+type revokingUserRepo struct {
+	UserRepository
+}
+
+func (r *revokingUserRepo) RevokeSessions(ctx context.Context, id string) error {
+	return nil
+}
+
+func TestAuthStateInvalidatingUserRepository_RevokeSessions(t *testing.T) {
+	inner := &countingAuthStateRepo{}
+	cache := NewCachedAuthStateRepository(inner, time.Minute, 10)
+	users := NewAuthStateInvalidatingUserRepository(&revokingUserRepo{}, cache)
+	ctx := context.Background()
+
+	cache.GetAuthState(ctx, "u1")
+	cache.GetAuthState(ctx, "u2")
+
+	assert.NoError(t, users.RevokeSessions(ctx, "u1"))
+
+	st, _ := cache.GetAuthState(ctx, "u1")
+	assert.Equal(t, 3, st.UserVersion)
+	cache.GetAuthState(ctx, "u2")
+	assert.Equal(t, 3, inner.calls)
+
+	cache.InvalidateAll()
+	cache.GetAuthState(ctx, "u2")
+	assert.Equal(t, 4, inner.calls)
+}
+
+func TestAuthStateInvalidatingUserRepository_InvalidatesAfterCommit(t *testing.T) {
+	inner := &countingAuthStateRepo{}
+	cache := NewCachedAuthStateRepository(inner, time.Minute, 10)
+	users := NewAuthStateInvalidatingUserRepository(&revokingUserRepo{}, cache)
+	ctx := context.Background()
+
+	var stale *models.AuthState
+	err := NewMemoryUnitOfWork().Do(ctx, func(txCtx context.Context) error {
+		if err := users.RevokeSessions(txCtx, "u1"); err != nil {
+			return err
+		}
+		// request lain membaca sebelum commit dan mengisi cache dengan state lama
+		stale, _ = cache.GetAuthState(ctx, "u1")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stale.UserVersion)
+
+	st, _ := cache.GetAuthState(ctx, "u1")
+	assert.Equal(t, 2, st.UserVersion)
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	models "achievement_backend/app/model"
+
+	"github.com/google/uuid"
+)
+
+type ImpersonationStore interface {
+	Create(ctx context.Context, i *models.Impersonation) error
+	// Get mengembalikan sql.ErrNoRows jika tidak ada.
+	Get(ctx context.Context, id string) (*models.Impersonation, error)
+	// End menandai impersonation selesai; sql.ErrNoRows jika tidak ada atau sudah selesai.
+	End(ctx context.Context, id, endedBy string, at time.Time) error
+	// List mengembalikan catatan terbaru; filter kosong berarti semua.
+	List(ctx context.Context, adminID, targetUserID string, limit int) ([]models.Impersonation, error)
+
+	RecordAction(ctx context.Context, a *models.ImpersonationAction) error
+	ListActions(ctx context.Context, impersonationID string, limit int) ([]models.ImpersonationAction, error)
+}
+
+// ================= POSTGRES =================
+
+type impersonationRepository struct {
+	db *sql.DB
+}
+
+func NewImpersonationRepository(db *sql.DB) ImpersonationStore {
+	return &impersonationRepository{db: db}
+}
+
+const impersonationColumns = `id, admin_id, target_user_id, reason, ip, user_agent,
+	started_at, expires_at, ended_at, ended_by`
+
+func scanImpersonation(row interface{ Scan(...interface{}) error }) (*models.Impersonation, error) {
+	var i models.Impersonation
+	err := row.Scan(
+		&i.ID, &i.AdminID, &i.TargetUserID, &i.Reason, &i.IP, &i.UserAgent,
+		&i.StartedAt, &i.ExpiresAt, &i.EndedAt, &i.EndedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func (r *impersonationRepository) Create(ctx context.Context, i *models.Impersonation) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO impersonations (admin_id, target_user_id, reason, ip, user_agent, started_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), $6)
+		RETURNING id, started_at
+	`, i.AdminID, i.TargetUserID, i.Reason, i.IP, i.UserAgent, i.ExpiresAt).Scan(&i.ID, &i.StartedAt)
+}
+
+func (r *impersonationRepository) Get(ctx context.Context, id string) (*models.Impersonation, error) {
+	return scanImpersonation(conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT `+impersonationColumns+`
+		FROM impersonations
+		WHERE id = $1
+	`, id))
+}
+
+func (r *impersonationRepository) End(ctx context.Context, id, endedBy string, at time.Time) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE impersonations
+		SET ended_at = $3, ended_by = $2
+		WHERE id = $1 AND ended_at IS NULL
+	`, id, endedBy, at)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *impersonationRepository) List(ctx context.Context, adminID, targetUserID string, limit int) ([]models.Impersonation, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+impersonationColumns+`
+		FROM impersonations
+		WHERE ($1 = '' OR admin_id::text = $1)
+		  AND ($2 = '' OR target_user_id::text = $2)
+		ORDER BY started_at DESC
+		LIMIT $3
+	`, adminID, targetUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.Impersonation
+	for rows.Next() {
+		i, err := scanImpersonation(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *i)
+	}
+
+	return list, rows.Err()
+}
+
+func (r *impersonationRepository) RecordAction(ctx context.Context, a *models.ImpersonationAction) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO impersonation_actions (impersonation_id, method, path, status, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, created_at
+	`, a.ImpersonationID, a.Method, a.Path, a.Status).Scan(&a.ID, &a.CreatedAt)
+}
+
+func (r *impersonationRepository) ListActions(ctx context.Context, impersonationID string, limit int) ([]models.ImpersonationAction, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, impersonation_id, method, path, status, created_at
+		FROM impersonation_actions
+		WHERE impersonation_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, impersonationID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.ImpersonationAction
+	for rows.Next() {
+		var a models.ImpersonationAction
+		if err := rows.Scan(&a.ID, &a.ImpersonationID, &a.Method, &a.Path, &a.Status, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+
+	return list, rows.Err()
+}
+
+// ================= IN-MEMORY (testing) =================
+
+type memoryImpersonationStore struct {
+	mu      sync.Mutex
+	records map[string]*models.Impersonation
+	actions []models.ImpersonationAction
+}
+
+func NewMemoryImpersonationStore() ImpersonationStore {
+	return &memoryImpersonationStore{records: make(map[string]*models.Impersonation)}
+}
+
+func (m *memoryImpersonationStore) Create(ctx context.Context, i *models.Impersonation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i.ID = uuid.New().String()
+	i.StartedAt = time.Now()
+
+	cp := *i
+	m.records[i.ID] = &cp
+	return nil
+}
+
+func (m *memoryImpersonationStore) Get(ctx context.Context, id string) (*models.Impersonation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, ok := m.records[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	cp := *i
+	return &cp, nil
+}
+
+func (m *memoryImpersonationStore) End(ctx context.Context, id, endedBy string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, ok := m.records[id]
+	if !ok || i.EndedAt != nil {
+		return sql.ErrNoRows
+	}
+	i.EndedAt = &at
+	i.EndedBy = &endedBy
+	return nil
+}
+
+func (m *memoryImpersonationStore) List(ctx context.Context, adminID, targetUserID string, limit int) ([]models.Impersonation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []models.Impersonation
+	for _, i := range m.records {
+		if (adminID == "" || i.AdminID == adminID) && (targetUserID == "" || i.TargetUserID == targetUserID) {
+			list = append(list, *i)
+		}
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].StartedAt.After(list[b].StartedAt) })
+
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (m *memoryImpersonationStore) RecordAction(ctx context.Context, a *models.ImpersonationAction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a.ID = uuid.New().String()
+	a.CreatedAt = time.Now()
+	m.actions = append(m.actions, *a)
+	return nil
+}
+
+func (m *memoryImpersonationStore) ListActions(ctx context.Context, impersonationID string, limit int) ([]models.ImpersonationAction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []models.ImpersonationAction
+	for i := len(m.actions) - 1; i >= 0 && len(list) < limit; i-- {
+		if m.actions[i].ImpersonationID == impersonationID {
+			list = append(list, m.actions[i])
+		}
+	}
+	return list, nil
+}
@@ -1,18 +1,19 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	models "achievement_backend/app/model"
 	"time"
 )
 
 type LecturerRepository interface {
-	GetAll() ([]models.Lecturer, error)
-	GetByID(id string) (*models.Lecturer, error)
-	GetByUserID(userID string) (*models.Lecturer, error)
-	Create(req models.CreateLecturerRequest) (*models.Lecturer, error)
-	Update(id string, req models.UpdateLecturerRequest) (*models.Lecturer, error)
-	GetByLecturerID(lecturerID string) (*models.Lecturer, error)
+	GetAll(ctx context.Context) ([]models.Lecturer, error)
+	GetByID(ctx context.Context, id string) (*models.Lecturer, error)
+	GetByUserID(ctx context.Context, userID string) (*models.Lecturer, error)
+	Create(ctx context.Context, req models.CreateLecturerRequest) (*models.Lecturer, error)
+	Update(ctx context.Context, id string, req models.UpdateLecturerRequest) (*models.Lecturer, error)
+	GetByLecturerID(ctx context.Context, lecturerID string) (*models.Lecturer, error)
 }
 
 type lecturerRepository struct {
@@ -23,8 +24,8 @@ func NewLecturerRepository(db *sql.DB) LecturerRepository {
 	return &lecturerRepository{db: db}
 }
 
-func (r *lecturerRepository) GetAll() ([]models.Lecturer, error) {
-	rows, err := r.db.Query(`
+func (r *lecturerRepository) GetAll(ctx context.Context) ([]models.Lecturer, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT id, user_id, lecturer_id, department, created_at
 		FROM lecturers
 		ORDER BY created_at DESC
@@ -53,10 +54,10 @@ func (r *lecturerRepository) GetAll() ([]models.Lecturer, error) {
 	return list, nil
 }
 
-func (r *lecturerRepository) GetByID(id string) (*models.Lecturer, error) {
+func (r *lecturerRepository) GetByID(ctx context.Context, id string) (*models.Lecturer, error) {
 	var l models.Lecturer
 
-	row := r.db.QueryRow(`
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT id, user_id, lecturer_id, department, created_at
 		FROM lecturers
 		WHERE id = $1
@@ -76,10 +77,10 @@ func (r *lecturerRepository) GetByID(id string) (*models.Lecturer, error) {
 	return &l, nil
 }
 
-func (r *lecturerRepository) GetByUserID(userID string) (*models.Lecturer, error) {
+func (r *lecturerRepository) GetByUserID(ctx context.Context, userID string) (*models.Lecturer, error) {
 	var l models.Lecturer
 
-	row := r.db.QueryRow(`
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT id, user_id, lecturer_id, department, created_at
 		FROM lecturers
 		WHERE user_id = $1
@@ -104,11 +105,11 @@ func (r *lecturerRepository) GetByUserID(userID string) (*models.Lecturer, error
 	return &l, nil
 }
 
-func (r *lecturerRepository) Create(req models.CreateLecturerRequest) (*models.Lecturer, error) {
+func (r *lecturerRepository) Create(ctx context.Context, req models.CreateLecturerRequest) (*models.Lecturer, error) {
 	var id string
 	var createdAt time.Time
 
-	err := r.db.QueryRow(`
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
 		INSERT INTO lecturers (user_id, lecturer_id, department)
 		VALUES ($1, $2, $3)
 		RETURNING id, created_at
@@ -117,11 +118,11 @@ func (r *lecturerRepository) Create(req models.CreateLecturerRequest) (*models.L
 		return nil, err
 	}
 
-	return r.GetByID(id)
+	return r.GetByID(ctx, id)
 }
 
-func (r *lecturerRepository) Update(id string, req models.UpdateLecturerRequest) (*models.Lecturer, error) {
-	_, err := r.db.Exec(`
+func (r *lecturerRepository) Update(ctx context.Context, id string, req models.UpdateLecturerRequest) (*models.Lecturer, error) {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
 		UPDATE lecturers
 		SET lecturer_id=$1, department=$2
 		WHERE id = $3
@@ -131,13 +132,13 @@ func (r *lecturerRepository) Update(id string, req models.UpdateLecturerRequest)
 		return nil, err
 	}
 
-	return r.GetByID(id)
+	return r.GetByID(ctx, id)
 }
 
-func (r *lecturerRepository) GetByLecturerID(lecturerID string) (*models.Lecturer, error) {
+func (r *lecturerRepository) GetByLecturerID(ctx context.Context, lecturerID string) (*models.Lecturer, error) {
 	var l models.Lecturer
 
-	row := r.db.QueryRow(`
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT id, user_id, lecturer_id, department, created_at
 		FROM lecturers
 		WHERE lecturer_id = $1
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"regexp"
 	"testing"
 	"time"
@@ -26,7 +27,7 @@ func TestLecturerRepository_GetAll(t *testing.T) {
 		WillReturnRows(rows)
 
 	repo := NewLecturerRepository(db)
-	result, err := repo.GetAll()
+	result, err := repo.GetAll(context.Background())
 	assert.NoError(t, err)
 	assert.Len(t, result, 1)
 	assert.Equal(t, uuidUser, result[0].UserID)
@@ -49,7 +50,7 @@ func TestLecturerRepository_GetByID(t *testing.T) {
 			AddRow(uuidLecturer, uuidUser, "DSN001", "TI", createdAt))
 
 	repo := NewLecturerRepository(db)
-	l, err := repo.GetByID(uuidLecturer)
+	l, err := repo.GetByID(context.Background(), uuidLecturer)
 	assert.NoError(t, err)
 	assert.Equal(t, "DSN001", l.LecturerID)
 	assert.Equal(t, uuidUser, l.UserID)
@@ -70,7 +71,7 @@ func TestLecturerRepository_GetByUserID(t *testing.T) {
 			AddRow(uuidLecturer, uuidUser, "DSN001", "TI", createdAt))
 
 	repo := NewLecturerRepository(db)
-	l, err := repo.GetByUserID(uuidUser)
+	l, err := repo.GetByUserID(context.Background(), uuidUser)
 	assert.NoError(t, err)
 	assert.Equal(t, "TI", l.Department)
 	assert.Equal(t, uuidUser, l.UserID)
@@ -95,7 +96,7 @@ func TestLecturerRepository_Create(t *testing.T) {
 			AddRow(uuidLecturer, uuidUser, "lect-1", "TI", time.Now()))
 
 	repo := NewLecturerRepository(db)
-	l, err := repo.Create(models.CreateLecturerRequest{
+	l, err := repo.Create(context.Background(), models.CreateLecturerRequest{
 		UserID:     uuidUser,
 		LecturerID: "lect-1",
 		Department: "TI",
@@ -124,7 +125,7 @@ func TestLecturerRepository_Update(t *testing.T) {
 			AddRow(uuidLecturer, uuidUser, "lect-2", "SI", time.Now()))
 
 	repo := NewLecturerRepository(db)
-	l, err := repo.Update(uuidLecturer, models.UpdateLecturerRequest{
+	l, err := repo.Update(context.Background(), uuidLecturer, models.UpdateLecturerRequest{
 		LecturerID: "lect-2",
 		Department: "SI",
 	})
@@ -148,7 +149,7 @@ func TestLecturerRepository_GetByLecturerID(t *testing.T) {
 			AddRow(uuidLecturer, uuidUser, "DSN001", "TI", createdAt))
 
 	repo := NewLecturerRepository(db)
-	l, err := repo.GetByLecturerID("DSN001")
+	l, err := repo.GetByLecturerID(context.Background(), "DSN001")
 	assert.NoError(t, err)
 	assert.Equal(t, "DSN001", l.LecturerID)
 	assert.Equal(t, uuidLecturer, l.ID)
@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	models "achievement_backend/app/model"
+
+	"github.com/google/uuid"
+)
+
+type LoginAttemptStore interface {
+	// RegisterFailure menambah hitungan login gagal. Jika kegagalan pertama
+	// terjadi sebelum windowStart, hitungan dimulai ulang dari 1.
+	RegisterFailure(ctx context.Context, kind, key string, now, windowStart time.Time) (*models.LoginAttempt, error)
+	// SetBlock menyimpan kapan percobaan berikutnya diizinkan dan/atau sampai kapan dikunci.
+	SetBlock(ctx context.Context, kind, key string, nextAttemptAt, lockedUntil *time.Time) error
+	// Get mengembalikan nil, nil jika belum ada percobaan gagal.
+	Get(ctx context.Context, kind, key string) (*models.LoginAttempt, error)
+	// Clear menghapus hitungan dan kunci; sql.ErrNoRows jika tidak ada.
+	Clear(ctx context.Context, kind, key string) error
+	ListLocked(ctx context.Context, now time.Time) ([]models.LoginAttempt, error)
+
+	RecordLockEvent(ctx context.Context, e *models.LoginLockEvent) error
+	// ListLockEvents mengembalikan event terbaru; userID kosong berarti semua.
+	ListLockEvents(ctx context.Context, userID string, limit int) ([]models.LoginLockEvent, error)
+}
+
+// ================= POSTGRES =================
+
+type loginAttemptRepository struct {
+	db *sql.DB
+}
+
+func NewLoginAttemptRepository(db *sql.DB) LoginAttemptStore {
+	return &loginAttemptRepository{db: db}
+}
+
+func scanLoginAttempt(row interface{ Scan(...interface{}) error }) (*models.LoginAttempt, error) {
+	var a models.LoginAttempt
+	err := row.Scan(
+		&a.Kind,
+		&a.Key,
+		&a.Failures,
+		&a.FirstFailureAt,
+		&a.NextAttemptAt,
+		&a.LockedUntil,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *loginAttemptRepository) RegisterFailure(ctx context.Context, kind, key string, now, windowStart time.Time) (*models.LoginAttempt, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO login_attempts (kind, key, failures, first_failure_at, updated_at)
+		VALUES ($1, $2, 1, $3, $3)
+		ON CONFLICT (kind, key) DO UPDATE SET
+			failures = CASE
+				WHEN login_attempts.first_failure_at < $4 THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			first_failure_at = CASE
+				WHEN login_attempts.first_failure_at < $4 THEN $3
+				ELSE login_attempts.first_failure_at
+			END,
+			updated_at = $3
+		RETURNING kind, key, failures, first_failure_at, next_attempt_at, locked_until, updated_at
+	`, kind, key, now, windowStart)
+
+	return scanLoginAttempt(row)
+}
+
+func (r *loginAttemptRepository) SetBlock(ctx context.Context, kind, key string, nextAttemptAt, lockedUntil *time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE login_attempts
+		SET next_attempt_at = $3, locked_until = $4, updated_at = NOW()
+		WHERE kind = $1 AND key = $2
+	`, kind, key, nextAttemptAt, lockedUntil)
+
+	return err
+}
+
+func (r *loginAttemptRepository) Get(ctx context.Context, kind, key string) (*models.LoginAttempt, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT kind, key, failures, first_failure_at, next_attempt_at, locked_until, updated_at
+		FROM login_attempts
+		WHERE kind = $1 AND key = $2
+	`, kind, key)
+
+	a, err := scanLoginAttempt(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+func (r *loginAttemptRepository) Clear(ctx context.Context, kind, key string) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM login_attempts WHERE kind = $1 AND key = $2`, kind, key)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *loginAttemptRepository) ListLocked(ctx context.Context, now time.Time) ([]models.LoginAttempt, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT kind, key, failures, first_failure_at, next_attempt_at, locked_until, updated_at
+		FROM login_attempts
+		WHERE locked_until > $1
+		ORDER BY locked_until DESC
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.LoginAttempt{}
+	for rows.Next() {
+		a, err := scanLoginAttempt(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *a)
+	}
+	return list, rows.Err()
+}
+
+func (r *loginAttemptRepository) RecordLockEvent(ctx context.Context, e *models.LoginLockEvent) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO login_lock_events (kind, key, user_id, failures, locked_until, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING id, created_at
+	`, e.Kind, e.Key, e.UserID, e.Failures, e.LockedUntil).Scan(&e.ID, &e.CreatedAt)
+}
+
+func (r *loginAttemptRepository) ListLockEvents(ctx context.Context, userID string, limit int) ([]models.LoginLockEvent, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, kind, key, user_id, failures, locked_until, created_at
+		FROM login_lock_events
+		WHERE ($1 = '' OR user_id::text = $1)
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.LoginLockEvent{}
+	for rows.Next() {
+		var e models.LoginLockEvent
+		if err := rows.Scan(
+			&e.ID,
+			&e.Kind,
+			&e.Key,
+			&e.UserID,
+			&e.Failures,
+			&e.LockedUntil,
+			&e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// ================= IN-MEMORY (testing) =================
+
+type memoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]*models.LoginAttempt
+	events   []models.LoginLockEvent
+}
+
+func NewMemoryLoginAttemptStore() LoginAttemptStore {
+	return &memoryLoginAttemptStore{attempts: make(map[string]*models.LoginAttempt)}
+}
+
+func (m *memoryLoginAttemptStore) RegisterFailure(ctx context.Context, kind, key string, now, windowStart time.Time) (*models.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.attempts[kind+":"+key]
+	if !ok {
+		a = &models.LoginAttempt{Kind: kind, Key: key}
+		m.attempts[kind+":"+key] = a
+	}
+
+	if !ok || a.FirstFailureAt.Before(windowStart) {
+		a.Failures = 1
+		a.FirstFailureAt = now
+	} else {
+		a.Failures++
+	}
+	a.UpdatedAt = now
+
+	cp := *a
+	return &cp, nil
+}
+
+func (m *memoryLoginAttemptStore) SetBlock(ctx context.Context, kind, key string, nextAttemptAt, lockedUntil *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if a, ok := m.attempts[kind+":"+key]; ok {
+		a.NextAttemptAt = nextAttemptAt
+		a.LockedUntil = lockedUntil
+	}
+	return nil
+}
+
+func (m *memoryLoginAttemptStore) Get(ctx context.Context, kind, key string) (*models.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.attempts[kind+":"+key]
+	if !ok {
+		return nil, nil
+	}
+	cp := *a
+	return &cp, nil
+}
+
+func (m *memoryLoginAttemptStore) Clear(ctx context.Context, kind, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.attempts[kind+":"+key]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(m.attempts, kind+":"+key)
+	return nil
+}
+
+func (m *memoryLoginAttemptStore) ListLocked(ctx context.Context, now time.Time) ([]models.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := []models.LoginAttempt{}
+	for _, a := range m.attempts {
+		if a.LockedUntil != nil && a.LockedUntil.After(now) {
+			list = append(list, *a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LockedUntil.After(*list[j].LockedUntil)
+	})
+	return list, nil
+}
+
+func (m *memoryLoginAttemptStore) RecordLockEvent(ctx context.Context, e *models.LoginLockEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e.ID = uuid.New().String()
+	e.CreatedAt = time.Now()
+	m.events = append(m.events, *e)
+	return nil
+}
+
+func (m *memoryLoginAttemptStore) ListLockEvents(ctx context.Context, userID string, limit int) ([]models.LoginLockEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := []models.LoginLockEvent{}
+	for i := len(m.events) - 1; i >= 0 && len(list) < limit; i-- {
+		e := m.events[i]
+		if userID == "" || (e.UserID != nil && *e.UserID == userID) {
+			list = append(list, e)
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	models "achievement_backend/app/model"
+)
+
+type MFAStore interface {
+	// Get mengembalikan nil, nil jika user belum pernah enroll.
+	Get(ctx context.Context, userID string) (*models.UserMFA, error)
+	// SaveSecret menyimpan secret enrollment baru yang belum aktif.
+	SaveSecret(ctx context.Context, userID, secret string) error
+	// Enable mengaktifkan MFA dan mengganti kode pemulihan dengan codeHashes.
+	Enable(ctx context.Context, userID string, step int64, codeHashes []string) error
+	// Disable menghapus secret dan semua kode pemulihan user.
+	Disable(ctx context.Context, userID string) error
+	// UseStep mencatat langkah TOTP yang dipakai; false jika langkah tersebut
+	// (atau yang lebih baru) sudah pernah dipakai.
+	UseStep(ctx context.Context, userID string, step int64) (bool, error)
+
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error
+	// UseRecoveryCode menandai kode pemulihan terpakai; false jika tidak ada atau sudah dipakai.
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID string) (int, error)
+
+	RoleRequiresMFA(ctx context.Context, roleID string) (bool, error)
+	SetRoleRequiresMFA(ctx context.Context, roleID string, required bool) error
+}
+
+// ================= POSTGRES =================
+
+type mfaRepository struct {
+	db *sql.DB
+}
+
+func NewMFARepository(db *sql.DB) MFAStore {
+	return &mfaRepository{db: db}
+}
+
+func (r *mfaRepository) Get(ctx context.Context, userID string) (*models.UserMFA, error) {
+	var m models.UserMFA
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT user_id, secret, enabled_at, last_used_step, created_at
+		FROM user_mfa
+		WHERE user_id = $1
+	`, userID).Scan(&m.UserID, &m.Secret, &m.EnabledAt, &m.LastUsedStep, &m.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func (r *mfaRepository) SaveSecret(ctx context.Context, userID, secret string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO user_mfa (user_id, secret, enabled_at, last_used_step, created_at)
+		VALUES ($1, $2, NULL, 0, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret,
+		    enabled_at = NULL,
+		    last_used_step = 0,
+		    created_at = NOW()
+	`, userID, secret)
+
+	return err
+}
+
+func (r *mfaRepository) Enable(ctx context.Context, userID string, step int64, codeHashes []string) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE user_mfa
+			SET enabled_at = NOW(), last_used_step = $2
+			WHERE user_id = $1
+		`, userID, step)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return sql.ErrNoRows
+		}
+
+		return replaceRecoveryCodes(ctx, tx, userID, codeHashes)
+	})
+}
+
+func (r *mfaRepository) Disable(ctx context.Context, userID string) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID)
+		return err
+	})
+}
+
+func (r *mfaRepository) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE user_mfa
+		SET last_used_step = $2
+		WHERE user_id = $1 AND last_used_step < $2
+	`, userID, step)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID string, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	for _, h := range codeHashes {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO mfa_recovery_codes (user_id, code_hash, created_at)
+			VALUES ($1, $2, NOW())
+		`, userID, h)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return replaceRecoveryCodes(ctx, tx, userID, codeHashes)
+	})
+}
+
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE mfa_recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *mfaRepository) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM mfa_recovery_codes
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID).Scan(&n)
+
+	return n, err
+}
+
+func (r *mfaRepository) RoleRequiresMFA(ctx context.Context, roleID string) (bool, error) {
+	var required bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT mfa_required FROM roles WHERE id = $1`, roleID).Scan(&required)
+	return required, err
+}
+
+func (r *mfaRepository) SetRoleRequiresMFA(ctx context.Context, roleID string, required bool) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE roles SET mfa_required = $2 WHERE id = $1`, roleID, required)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ================= IN-MEMORY (testing) =================
+
+type memoryMFAStore struct {
+	mu        sync.Mutex
+	users     map[string]*models.UserMFA
+	codes     map[string]map[string]bool // userID → hash → sudah dipakai
+	roleFlags map[string]bool
+}
+
+func NewMemoryMFAStore() MFAStore {
+	return &memoryMFAStore{
+		users:     make(map[string]*models.UserMFA),
+		codes:     make(map[string]map[string]bool),
+		roleFlags: make(map[string]bool),
+	}
+}
+
+func (m *memoryMFAStore) Get(ctx context.Context, userID string) (*models.UserMFA, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	cp := *u
+	return &cp, nil
+}
+
+func (m *memoryMFAStore) SaveSecret(ctx context.Context, userID, secret string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.users[userID] = &models.UserMFA{UserID: userID, Secret: secret, CreatedAt: time.Now()}
+	return nil
+}
+
+func (m *memoryMFAStore) Enable(ctx context.Context, userID string, step int64, codeHashes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	now := time.Now()
+	u.EnabledAt = &now
+	u.LastUsedStep = step
+
+	m.setCodes(userID, codeHashes)
+	return nil
+}
+
+func (m *memoryMFAStore) Disable(ctx context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.users, userID)
+	delete(m.codes, userID)
+	return nil
+}
+
+func (m *memoryMFAStore) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok || u.LastUsedStep >= step {
+		return false, nil
+	}
+	u.LastUsedStep = step
+	return true, nil
+}
+
+func (m *memoryMFAStore) setCodes(userID string, codeHashes []string) {
+	codes := make(map[string]bool, len(codeHashes))
+	for _, h := range codeHashes {
+		codes[h] = false
+	}
+	m.codes[userID] = codes
+}
+
+func (m *memoryMFAStore) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.setCodes(userID, codeHashes)
+	return nil
+}
+
+func (m *memoryMFAStore) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	used, ok := m.codes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	m.codes[userID][codeHash] = true
+	return true, nil
+}
+
+func (m *memoryMFAStore) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, used := range m.codes[userID] {
+		if !used {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memoryMFAStore) RoleRequiresMFA(ctx context.Context, roleID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.roleFlags[roleID], nil
+}
+
+func (m *memoryMFAStore) SetRoleRequiresMFA(ctx context.Context, roleID string, required bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.roleFlags[roleID] = required
+	return nil
+}
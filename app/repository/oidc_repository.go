@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	models "achievement_backend/app/model"
+)
+
+type OIDCStore interface {
+	SaveState(ctx context.Context, s *models.OIDCLoginState) error
+	// ConsumeState menghapus state lalu mengembalikannya; sql.ErrNoRows jika
+	// tidak ada, sudah dipakai, atau kedaluwarsa.
+	ConsumeState(ctx context.Context, stateHash string, now time.Time) (*models.OIDCLoginState, error)
+
+	// GetIdentity mengembalikan user ID yang terhubung; sql.ErrNoRows jika belum.
+	GetIdentity(ctx context.Context, issuer, subject string) (string, error)
+	LinkIdentity(ctx context.Context, issuer, subject, userID string) error
+}
+
+// ================= POSTGRES =================
+
+type oidcRepository struct {
+	db *sql.DB
+}
+
+func NewOIDCRepository(db *sql.DB) OIDCStore {
+	return &oidcRepository{db: db}
+}
+
+func (r *oidcRepository) SaveState(ctx context.Context, s *models.OIDCLoginState) error {
+	// state yang tidak pernah kembali dari IdP dibersihkan sekalian
+	if _, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM oidc_login_states WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO oidc_login_states (state_hash, nonce, code_verifier, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+	`, s.StateHash, s.Nonce, s.CodeVerifier, s.ExpiresAt)
+	return err
+}
+
+func (r *oidcRepository) ConsumeState(ctx context.Context, stateHash string, now time.Time) (*models.OIDCLoginState, error) {
+	s := models.OIDCLoginState{StateHash: stateHash}
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		DELETE FROM oidc_login_states
+		WHERE state_hash = $1
+		RETURNING nonce, code_verifier, expires_at
+	`, stateHash).Scan(&s.Nonce, &s.CodeVerifier, &s.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if !now.Before(s.ExpiresAt) {
+		return nil, sql.ErrNoRows
+	}
+	return &s, nil
+}
+
+func (r *oidcRepository) GetIdentity(ctx context.Context, issuer, subject string) (string, error) {
+	var userID string
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT user_id FROM user_identities
+		WHERE issuer = $1 AND subject = $2
+	`, issuer, subject).Scan(&userID)
+	return userID, err
+}
+
+func (r *oidcRepository) LinkIdentity(ctx context.Context, issuer, subject, userID string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO user_identities (issuer, subject, user_id, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (issuer, subject) DO NOTHING
+	`, issuer, subject, userID)
+	return err
+}
+
+// ================= IN-MEMORY (testing) =================
+
+type memoryOIDCStore struct {
+	mu         sync.Mutex
+	states     map[string]models.OIDCLoginState
+	identities map[[2]string]string
+}
+
+func NewMemoryOIDCStore() OIDCStore {
+	return &memoryOIDCStore{
+		states:     make(map[string]models.OIDCLoginState),
+		identities: make(map[[2]string]string),
+	}
+}
+
+func (m *memoryOIDCStore) SaveState(ctx context.Context, s *models.OIDCLoginState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.states[s.StateHash] = *s
+	return nil
+}
+
+func (m *memoryOIDCStore) ConsumeState(ctx context.Context, stateHash string, now time.Time) (*models.OIDCLoginState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.states[stateHash]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	delete(m.states, stateHash)
+
+	if !now.Before(s.ExpiresAt) {
+		return nil, sql.ErrNoRows
+	}
+	return &s, nil
+}
+
+func (m *memoryOIDCStore) GetIdentity(ctx context.Context, issuer, subject string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	userID, ok := m.identities[[2]string{issuer, subject}]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return userID, nil
+}
+
+func (m *memoryOIDCStore) LinkIdentity(ctx context.Context, issuer, subject, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := [2]string{issuer, subject}
+	if _, ok := m.identities[key]; !ok {
+		m.identities[key] = userID
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+type PasswordHistoryStore interface {
+	Add(ctx context.Context, userID, passwordHash string) error
+	// Recent mengembalikan maksimal limit hash terakhir user, terbaru lebih dulu.
+	Recent(ctx context.Context, userID string, limit int) ([]string, error)
+}
+
+// ================= POSTGRES =================
+
+type passwordHistoryRepository struct {
+	db *sql.DB
+}
+
+func NewPasswordHistoryRepository(db *sql.DB) PasswordHistoryStore {
+	return &passwordHistoryRepository{db: db}
+}
+
+func (r *passwordHistoryRepository) Add(ctx context.Context, userID, passwordHash string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO password_history (user_id, password_hash, created_at)
+		VALUES ($1, $2, NOW())
+	`, userID, passwordHash)
+
+	return err
+}
+
+func (r *passwordHistoryRepository) Recent(ctx context.Context, userID string, limit int) ([]string, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT password_hash
+		FROM password_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+	}
+
+	return hashes, rows.Err()
+}
+
+// ================= IN-MEMORY (testing) =================
+
+type memoryPasswordHistoryStore struct {
+	mu     sync.Mutex
+	hashes map[string][]string
+}
+
+func NewMemoryPasswordHistoryStore() PasswordHistoryStore {
+	return &memoryPasswordHistoryStore{hashes: make(map[string][]string)}
+}
+
+func (m *memoryPasswordHistoryStore) Add(ctx context.Context, userID, passwordHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hashes[userID] = append(m.hashes[userID], passwordHash)
+	return nil
+}
+
+func (m *memoryPasswordHistoryStore) Recent(ctx context.Context, userID string, limit int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	all := m.hashes[userID]
+	var res []string
+	for i := len(all) - 1; i >= 0 && len(res) < limit; i-- {
+		res = append(res, all[i])
+	}
+	return res, nil
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	models "achievement_backend/app/model"
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PasswordResetStore interface {
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	// GetValid mengembalikan token yang belum dipakai dan belum kadaluarsa
+	// tanpa memakainya, atau sql.ErrNoRows.
+	GetValid(ctx context.Context, tokenHash string, now time.Time) (*models.PasswordResetToken, error)
+	// Consume menandai token dipakai dan mengembalikannya. Mengembalikan
+	// sql.ErrNoRows jika token tidak ada, sudah dipakai, atau kadaluarsa.
+	Consume(ctx context.Context, tokenHash string, now time.Time) (*models.PasswordResetToken, error)
+	// InvalidateByUser membatalkan semua token user yang belum dipakai.
+	InvalidateByUser(ctx context.Context, userID string) error
+}
+
+// ================= POSTGRES =================
+
+type passwordResetRepository struct {
+	db *sql.DB
+}
+
+func NewPasswordResetRepository(db *sql.DB) PasswordResetStore {
+	return &passwordResetRepository{db: db}
+}
+
+func (r *passwordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO password_reset_tokens (token_hash, user_id, expires_at, created_at)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING id, created_at
+	`, token.TokenHash, token.UserID, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+}
+
+func (r *passwordResetRepository) GetValid(ctx context.Context, tokenHash string, now time.Time) (*models.PasswordResetToken, error) {
+	var t models.PasswordResetToken
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, token_hash, user_id, expires_at, used_at, created_at
+		FROM password_reset_tokens
+		WHERE token_hash = $1
+		  AND used_at IS NULL
+		  AND expires_at > $2
+	`, tokenHash, now).Scan(
+		&t.ID, &t.TokenHash, &t.UserID, &t.ExpiresAt, &t.UsedAt, &t.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (r *passwordResetRepository) Consume(ctx context.Context, tokenHash string, now time.Time) (*models.PasswordResetToken, error) {
+	var t models.PasswordResetToken
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		UPDATE password_reset_tokens
+		SET used_at = $2
+		WHERE token_hash = $1
+		  AND used_at IS NULL
+		  AND expires_at > $2
+		RETURNING id, token_hash, user_id, expires_at, used_at, created_at
+	`, tokenHash, now).Scan(
+		&t.ID, &t.TokenHash, &t.UserID, &t.ExpiresAt, &t.UsedAt, &t.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (r *passwordResetRepository) InvalidateByUser(ctx context.Context, userID string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE password_reset_tokens
+		SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID)
+
+	return err
+}
+
+// ================= IN-MEMORY (testing) =================
+
+type memoryPasswordResetStore struct {
+	mu     sync.Mutex
+	tokens map[string]*models.PasswordResetToken
+}
+
+func NewMemoryPasswordResetStore() PasswordResetStore {
+	return &memoryPasswordResetStore{tokens: make(map[string]*models.PasswordResetToken)}
+}
+
+func (m *memoryPasswordResetStore) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token.ID = uuid.New().String()
+	token.CreatedAt = time.Now()
+
+	cp := *token
+	m.tokens[token.TokenHash] = &cp
+	return nil
+}
+
+func (m *memoryPasswordResetStore) GetValid(ctx context.Context, tokenHash string, now time.Time) (*models.PasswordResetToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tokens[tokenHash]
+	if !ok || t.UsedAt != nil || !now.Before(t.ExpiresAt) {
+		return nil, sql.ErrNoRows
+	}
+
+	cp := *t
+	return &cp, nil
+}
+
+func (m *memoryPasswordResetStore) Consume(ctx context.Context, tokenHash string, now time.Time) (*models.PasswordResetToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tokens[tokenHash]
+	if !ok || t.UsedAt != nil || !now.Before(t.ExpiresAt) {
+		return nil, sql.ErrNoRows
+	}
+
+	t.UsedAt = &now
+	cp := *t
+	return &cp, nil
+}
+
+func (m *memoryPasswordResetStore) InvalidateByUser(ctx context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, t := range m.tokens {
+		if t.UserID == userID && t.UsedAt == nil {
+			t.UsedAt = &now
+		}
+	}
+	return nil
+}
@@ -1,13 +1,14 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	models "achievement_backend/app/model"
 )
 
 type PermissionRepository interface {
-	GetAll() ([]models.Permission, error)
-	GetByID(id string) (*models.Permission, error)
+	GetAll(ctx context.Context) ([]models.Permission, error)
+	GetByID(ctx context.Context, id string) (*models.Permission, error)
 }
 
 type permissionRepository struct {
@@ -18,8 +19,8 @@ func NewPermissionRepository(db *sql.DB) PermissionRepository {
 	return &permissionRepository{db: db}
 }
 
-func (r *permissionRepository) GetAll() ([]models.Permission, error) {
-	rows, err := r.db.Query(`
+func (r *permissionRepository) GetAll(ctx context.Context) ([]models.Permission, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT id, name, resource, action, description
 		FROM permissions
 		ORDER BY name ASC
@@ -47,10 +48,10 @@ func (r *permissionRepository) GetAll() ([]models.Permission, error) {
 	return list, nil
 }
 
-func (r *permissionRepository) GetByID(id string) (*models.Permission, error) {
+func (r *permissionRepository) GetByID(ctx context.Context, id string) (*models.Permission, error) {
 	var p models.Permission
 
-	row := r.db.QueryRow(`
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT id, name, resource, action, description
 		FROM permissions
 		WHERE id = $1
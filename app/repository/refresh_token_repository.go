@@ -0,0 +1,174 @@
+package repository
+
+import (
+	models "achievement_backend/app/model"
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RefreshTokenStore interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	// Consume menandai token sudah dipakai. Mengembalikan sql.ErrNoRows jika
+	// token sudah pernah dipakai atau sudah dicabut.
+	Consume(ctx context.Context, tokenHash string) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeByUser(ctx context.Context, userID string) error
+}
+
+// ================= POSTGRES =================
+
+type refreshTokenRepository struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenRepository(db *sql.DB) RefreshTokenStore {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO refresh_tokens (token_hash, user_id, family_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, created_at
+	`, token.TokenHash, token.UserID, token.FamilyID, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+}
+
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var t models.RefreshToken
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, token_hash, user_id, family_id, expires_at,
+		       consumed_at, revoked_at, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`, tokenHash).Scan(
+		&t.ID, &t.TokenHash, &t.UserID, &t.FamilyID, &t.ExpiresAt,
+		&t.ConsumedAt, &t.RevokedAt, &t.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (r *refreshTokenRepository) Consume(ctx context.Context, tokenHash string) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET consumed_at = NOW()
+		WHERE token_hash = $1
+		  AND consumed_at IS NULL
+		  AND revoked_at IS NULL
+	`, tokenHash)
+	if err != nil {
+		return err
+	}
+
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`, familyID)
+
+	return err
+}
+
+func (r *refreshTokenRepository) RevokeByUser(ctx context.Context, userID string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+
+	return err
+}
+
+// ================= IN-MEMORY (testing) =================
+
+type memoryRefreshTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]*models.RefreshToken
+}
+
+func NewMemoryRefreshTokenStore() RefreshTokenStore {
+	return &memoryRefreshTokenStore{tokens: make(map[string]*models.RefreshToken)}
+}
+
+func (m *memoryRefreshTokenStore) Create(ctx context.Context, token *models.RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token.ID = uuid.New().String()
+	token.CreatedAt = time.Now()
+
+	cp := *token
+	m.tokens[token.TokenHash] = &cp
+	return nil
+}
+
+func (m *memoryRefreshTokenStore) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tokens[tokenHash]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	cp := *t
+	return &cp, nil
+}
+
+func (m *memoryRefreshTokenStore) Consume(ctx context.Context, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tokens[tokenHash]
+	if !ok || t.ConsumedAt != nil || t.RevokedAt != nil {
+		return sql.ErrNoRows
+	}
+
+	now := time.Now()
+	t.ConsumedAt = &now
+	return nil
+}
+
+func (m *memoryRefreshTokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, t := range m.tokens {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *memoryRefreshTokenStore) RevokeByUser(ctx context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, t := range m.tokens {
+		if t.UserID == userID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	models "achievement_backend/app/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRefreshTokenRepo(t *testing.T) (*sql.DB, sqlmock.Sqlmock, RefreshTokenStore) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	return db, mock, NewRefreshTokenRepository(db)
+}
+
+// =======================================================
+// GET BY HASH
+// =======================================================
+
+func TestRefreshTokenRepository_GetByHash(t *testing.T) {
+	db, mock, repo := setupRefreshTokenRepo(t)
+	defer db.Close()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{
+		"id", "token_hash", "user_id", "family_id", "expires_at",
+		"consumed_at", "revoked_at", "created_at",
+	}).AddRow("rt-1", "hash", "user-1", "fam-1", now.Add(time.Hour), nil, nil, now)
+
+	mock.ExpectQuery(`FROM refresh_tokens`).
+		WithArgs("hash").
+		WillReturnRows(rows)
+
+	token, err := repo.GetByHash(context.Background(), "hash")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "fam-1", token.FamilyID)
+	assert.Nil(t, token.ConsumedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// =======================================================
+// CONSUME (SUDAH DIPAKAI)
+// =======================================================
+
+func TestRefreshTokenRepository_Consume_AlreadyConsumed(t *testing.T) {
+	db, mock, repo := setupRefreshTokenRepo(t)
+	defer db.Close()
+
+	mock.ExpectExec(`UPDATE refresh_tokens`).
+		WithArgs("hash").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Consume(context.Background(), "hash")
+
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// =======================================================
+// IN-MEMORY STORE
+// =======================================================
+
+func TestMemoryRefreshTokenStore_RevokeFamily(t *testing.T) {
+	store := NewMemoryRefreshTokenStore()
+
+	exp := time.Now().Add(time.Hour)
+	_ = store.Create(context.Background(), &models.RefreshToken{TokenHash: "h1", UserID: "u1", FamilyID: "fam-1", ExpiresAt: exp})
+	_ = store.Create(context.Background(), &models.RefreshToken{TokenHash: "h2", UserID: "u1", FamilyID: "fam-2", ExpiresAt: exp})
+
+	assert.NoError(t, store.RevokeFamily(context.Background(), "fam-1"))
+
+	t1, _ := store.GetByHash(context.Background(), "h1")
+	t2, _ := store.GetByHash(context.Background(), "h2")
+	assert.NotNil(t, t1.RevokedAt)
+	assert.Nil(t, t2.RevokedAt)
+
+	assert.Equal(t, sql.ErrNoRows, store.Consume(context.Background(), "h1"))
+	assert.NoError(t, store.Consume(context.Background(), "h2"))
+	assert.Equal(t, sql.ErrNoRows, store.Consume(context.Background(), "h2"))
+}
@@ -1,15 +1,16 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	models "achievement_backend/app/model"
 )
 
 type RolePermissionRepository interface {
-	AssignPermission(roleID string, permissionID string) error
-	RemovePermission(roleID string, permissionID string) error
-	GetPermissionsByRole(roleID string) ([]models.Permission, error)
-	GetRolesByPermission(permissionID string) ([]models.Role, error)
+	AssignPermission(ctx context.Context, roleID string, permissionID string) error
+	RemovePermission(ctx context.Context, roleID string, permissionID string) error
+	GetPermissionsByRole(ctx context.Context, roleID string) ([]models.Permission, error)
+	GetRolesByPermission(ctx context.Context, permissionID string) ([]models.Role, error)
 }
 
 type rolePermissionRepository struct {
@@ -20,20 +21,32 @@ func NewRolePermissionRepository(db *sql.DB) RolePermissionRepository {
 	return &rolePermissionRepository{db: db}
 }
 
-func (r *rolePermissionRepository) AssignPermission(roleID string, permissionID string) error {
-	_, err := r.db.Exec(`
-		INSERT INTO role_permissions (role_id, permission_id)
-		VALUES ($1, $2)
-		ON CONFLICT DO NOTHING
+// AssignPermission dan RemovePermission menaikkan roles.permission_version
+// di statement yang sama agar token lama dievaluasi ulang oleh middleware.
+func (r *rolePermissionRepository) AssignPermission(ctx context.Context, roleID string, permissionID string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		WITH ins AS (
+			INSERT INTO role_permissions (role_id, permission_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+			RETURNING role_id
+		)
+		UPDATE roles SET permission_version = permission_version + 1
+		WHERE id IN (SELECT role_id FROM ins)
 	`, roleID, permissionID)
 
 	return err
 }
 
-func (r *rolePermissionRepository) RemovePermission(roleID string, permissionID string) error {
-	result, err := r.db.Exec(`
-		DELETE FROM role_permissions
-		WHERE role_id = $1 AND permission_id = $2
+func (r *rolePermissionRepository) RemovePermission(ctx context.Context, roleID string, permissionID string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `
+		WITH del AS (
+			DELETE FROM role_permissions
+			WHERE role_id = $1 AND permission_id = $2
+			RETURNING role_id
+		)
+		UPDATE roles SET permission_version = permission_version + 1
+		WHERE id IN (SELECT role_id FROM del)
 	`, roleID, permissionID)
 
 	if err != nil {
@@ -48,8 +61,8 @@ func (r *rolePermissionRepository) RemovePermission(roleID string, permissionID
 	return nil
 }
 
-func (r *rolePermissionRepository) GetPermissionsByRole(roleID string) ([]models.Permission, error) {
-	rows, err := r.db.Query(`
+func (r *rolePermissionRepository) GetPermissionsByRole(ctx context.Context, roleID string) ([]models.Permission, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT p.id, p.name, p.resource, p.action, p.description
 		FROM permissions p
 		INNER JOIN role_permissions rp ON rp.permission_id = p.id
@@ -81,8 +94,8 @@ func (r *rolePermissionRepository) GetPermissionsByRole(roleID string) ([]models
 	return list, nil
 }
 
-func (r *rolePermissionRepository) GetRolesByPermission(permissionID string) ([]models.Role, error) {
-	rows, err := r.db.Query(`
+func (r *rolePermissionRepository) GetRolesByPermission(ctx context.Context, permissionID string) ([]models.Role, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT r.id, r.name, r.description, r.created_at
 		FROM roles r
 		INNER JOIN role_permissions rp ON rp.role_id = r.id
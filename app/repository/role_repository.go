@@ -1,19 +1,28 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	models "achievement_backend/app/model"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type RoleRepository interface {
-	GetAll() ([]models.Role, error)
-	GetByID(id string) (*models.Role, error)
-	Create(req models.CreateRoleRequest) (*models.Role, error)
-	Update(id string, req models.UpdateRoleRequest) (*models.Role, error)
-	Delete(id string) error
+	GetAll(ctx context.Context) ([]models.Role, error)
+	GetByID(ctx context.Context, id string) (*models.Role, error)
+	Create(ctx context.Context, req models.CreateRoleRequest) (*models.Role, error)
+	Update(ctx context.Context, id string, req models.UpdateRoleRequest) (*models.Role, error)
+	// Delete mengembalikan ErrRoleInUse jika role masih dipakai user.
+	Delete(ctx context.Context, id string) error
+	CountUsers(ctx context.Context, id string) (int, error)
 }
 
+// ErrRoleInUse dikembalikan Delete jika role masih dipakai user.
+var ErrRoleInUse = errors.New("role still assigned to users")
+
 type roleRepository struct {
 	db *sql.DB
 }
@@ -22,8 +31,8 @@ func NewRoleRepository(db *sql.DB) RoleRepository {
 	return &roleRepository{db: db}
 }
 
-func (r *roleRepository) GetAll() ([]models.Role, error) {
-	rows, err := r.db.Query(`
+func (r *roleRepository) GetAll(ctx context.Context) ([]models.Role, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT id, name, description, created_at 
 		FROM roles
 		ORDER BY created_at DESC
@@ -50,10 +59,10 @@ func (r *roleRepository) GetAll() ([]models.Role, error) {
 	return roles, nil
 }
 
-func (r *roleRepository) GetByID(id string) (*models.Role, error) {
+func (r *roleRepository) GetByID(ctx context.Context, id string) (*models.Role, error) {
 	var role models.Role
 
-	row := r.db.QueryRow(`
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT id, name, description, created_at
 		FROM roles
 		WHERE id = $1
@@ -73,10 +82,10 @@ func (r *roleRepository) GetByID(id string) (*models.Role, error) {
 	return &role, nil
 }
 
-func (r *roleRepository) Create(req models.CreateRoleRequest) (*models.Role, error) {
+func (r *roleRepository) Create(ctx context.Context, req models.CreateRoleRequest) (*models.Role, error) {
 	var id string
 
-	err := r.db.QueryRow(`
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
 		INSERT INTO roles (name, description, created_at)
 		VALUES ($1, $2, $3)
 		RETURNING id
@@ -86,13 +95,13 @@ func (r *roleRepository) Create(req models.CreateRoleRequest) (*models.Role, err
 		return nil, err
 	}
 
-	return r.GetByID(id)
+	return r.GetByID(ctx, id)
 }
 
-func (r *roleRepository) Update(id string, req models.UpdateRoleRequest) (*models.Role, error) {
-	result, err := r.db.Exec(`
+func (r *roleRepository) Update(ctx context.Context, id string, req models.UpdateRoleRequest) (*models.Role, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `
 		UPDATE roles 
-		SET name=$1, description=$2
+		SET name=$1, description=$2, permission_version = permission_version + 1
 		WHERE id = $3
 	`, req.Name, req.Description, id)
 
@@ -105,19 +114,50 @@ func (r *roleRepository) Update(id string, req models.UpdateRoleRequest) (*model
 		return nil, sql.ErrNoRows
 	}
 
-	return r.GetByID(id)
+	return r.GetByID(ctx, id)
 }
 
-func (r *roleRepository) Delete(id string) error {
-	result, err := r.db.Exec(`DELETE FROM roles WHERE id=$1`, id)
+// Delete memeriksa pemakaian dan menghapus dalam satu statement, sehingga
+// user yang diberi role ini di antara keduanya tidak terlewat; foreign key
+// users.role_id menangkap penugasan yang belum ter-commit.
+func (r *roleRepository) Delete(ctx context.Context, id string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `
+		DELETE FROM roles
+		WHERE id=$1
+		  AND NOT EXISTS (SELECT 1 FROM users WHERE role_id=$1)
+	`, id)
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Table == "users" {
+			return ErrRoleInUse
+		}
 		return err
 	}
 
 	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		return sql.ErrNoRows
+	if rows > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = conn(ctx, r.db).QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM roles WHERE id=$1)`, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrRoleInUse
+	}
+
+	return sql.ErrNoRows
+}
+
+func (r *roleRepository) CountUsers(ctx context.Context, id string) (int, error) {
+	var total int
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE role_id=$1`, id).Scan(&total)
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
+	return total, nil
 }
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 	"time"
@@ -8,6 +9,7 @@ import (
 	models "achievement_backend/app/model"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -44,7 +46,7 @@ func TestRoleRepository_GetAll(t *testing.T) {
 	mock.ExpectQuery(`SELECT id, name, description, created_at FROM roles`).
 		WillReturnRows(rows)
 
-	roles, err := repo.GetAll()
+	roles, err := repo.GetAll(context.Background())
 
 	assert.NoError(t, err)
 	assert.Len(t, roles, 1)
@@ -71,7 +73,7 @@ func TestRoleRepository_GetByID(t *testing.T) {
 		WithArgs("1").
 		WillReturnRows(rows)
 
-	role, err := repo.GetByID("1")
+	role, err := repo.GetByID(context.Background(), "1")
 
 	assert.NoError(t, err)
 	assert.Equal(t, "Student", role.Name)
@@ -101,7 +103,7 @@ func TestRoleRepository_Create(t *testing.T) {
 		WithArgs("10").
 		WillReturnRows(rows)
 
-	role, err := repo.Create(models.CreateRoleRequest{
+	role, err := repo.Create(context.Background(), models.CreateRoleRequest{
 		Name:        "Lecturer",
 		Description: "Dosen",
 	})
@@ -134,7 +136,7 @@ func TestRoleRepository_Update(t *testing.T) {
 		WithArgs("1").
 		WillReturnRows(rows)
 
-	role, err := repo.Update("1", models.UpdateRoleRequest{
+	role, err := repo.Update(context.Background(), "1", models.UpdateRoleRequest{
 		Name:        "Admin Updated",
 		Description: "Updated Desc",
 	})
@@ -153,11 +155,58 @@ func TestRoleRepository_Delete(t *testing.T) {
 	db, mock, repo := setupRoleRepoTest(t)
 	defer db.Close()
 
-	mock.ExpectExec(`DELETE FROM roles WHERE id=\$1`).
+	mock.ExpectExec(`DELETE FROM roles\s+WHERE id=\$1\s+AND NOT EXISTS \(SELECT 1 FROM users WHERE role_id=\$1\)`).
 		WithArgs("1").
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	err := repo.Delete("1")
+	err := repo.Delete(context.Background(), "1")
 
 	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRoleRepository_Delete_InUse(t *testing.T) {
+	db, mock, repo := setupRoleRepoTest(t)
+	defer db.Close()
+
+	mock.ExpectExec(`DELETE FROM roles`).
+		WithArgs("1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM roles WHERE id=\$1\)`).
+		WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	err := repo.Delete(context.Background(), "1")
+
+	assert.ErrorIs(t, err, ErrRoleInUse)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRoleRepository_Delete_ForeignKeyViolation(t *testing.T) {
+	db, mock, repo := setupRoleRepoTest(t)
+	defer db.Close()
+
+	mock.ExpectExec(`DELETE FROM roles`).
+		WithArgs("1").
+		WillReturnError(&pq.Error{Code: "23503", Table: "users"})
+
+	err := repo.Delete(context.Background(), "1")
+
+	assert.ErrorIs(t, err, ErrRoleInUse)
+}
+
+func TestRoleRepository_Delete_NotFound(t *testing.T) {
+	db, mock, repo := setupRoleRepoTest(t)
+	defer db.Close()
+
+	mock.ExpectExec(`DELETE FROM roles`).
+		WithArgs("1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	err := repo.Delete(context.Background(), "1")
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
 }
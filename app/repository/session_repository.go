@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	models "achievement_backend/app/model"
+)
+
+type SessionStore interface {
+	Create(ctx context.Context, s *models.Session) error
+	// Get mengembalikan sql.ErrNoRows jika sesi tidak ada.
+	Get(ctx context.Context, id string) (*models.Session, error)
+	// Touch mencatat pemakaian terakhir (refresh) beserta IP dan masa berlaku baru.
+	Touch(ctx context.Context, id, ip string, at, expiresAt time.Time) error
+	// ListActive mengembalikan sesi yang belum dicabut dan belum kadaluarsa, terbaru lebih dulu.
+	ListActive(ctx context.Context, userID string, now time.Time) ([]models.Session, error)
+	// Revoke mencabut satu sesi; sql.ErrNoRows jika tidak ada atau sudah dicabut.
+	Revoke(ctx context.Context, id string) error
+	// RevokeByUser mencabut semua sesi aktif user kecuali exceptID (boleh
+	// kosong) dan mengembalikan ID sesi yang dicabut.
+	RevokeByUser(ctx context.Context, userID, exceptID string) ([]string, error)
+}
+
+// ================= POSTGRES =================
+
+type sessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) SessionStore {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, s *models.Session) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO sessions (id, user_id, user_agent, ip, created_at, last_used_at, expires_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW(), $5)
+		RETURNING created_at, last_used_at
+	`, s.ID, s.UserID, s.UserAgent, s.IP, s.ExpiresAt).Scan(&s.CreatedAt, &s.LastUsedAt)
+}
+
+const sessionColumns = `id, user_id, user_agent, ip, created_at, last_used_at, expires_at, revoked_at`
+
+func scanSession(row interface{ Scan(...interface{}) error }) (*models.Session, error) {
+	var s models.Session
+	err := row.Scan(
+		&s.ID, &s.UserID, &s.UserAgent, &s.IP,
+		&s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *sessionRepository) Get(ctx context.Context, id string) (*models.Session, error) {
+	return scanSession(conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE id = $1`, id))
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id, ip string, at, expiresAt time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE sessions
+		SET ip = $2, last_used_at = $3, expires_at = $4
+		WHERE id = $1
+	`, id, ip, at, expiresAt)
+
+	return err
+}
+
+func (r *sessionRepository) ListActive(ctx context.Context, userID string, now time.Time) ([]models.Session, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+sessionColumns+`
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_used_at DESC
+	`, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.Session
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *s)
+	}
+
+	return list, rows.Err()
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, id string) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *sessionRepository) RevokeByUser(ctx context.Context, userID, exceptID string) ([]string, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL AND id::text <> $2
+		RETURNING id
+	`, userID, exceptID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// ================= IN-MEMORY (testing) =================
+
+type memorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*models.Session
+}
+
+func NewMemorySessionStore() SessionStore {
+	return &memorySessionStore{sessions: make(map[string]*models.Session)}
+}
+
+func (m *memorySessionStore) Create(ctx context.Context, s *models.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	s.CreatedAt = now
+	s.LastUsedAt = now
+
+	cp := *s
+	m.sessions[s.ID] = &cp
+	return nil
+}
+
+func (m *memorySessionStore) Get(ctx context.Context, id string) (*models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	cp := *s
+	return &cp, nil
+}
+
+func (m *memorySessionStore) Touch(ctx context.Context, id, ip string, at, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.sessions[id]; ok {
+		s.IP = ip
+		s.LastUsedAt = at
+		s.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+func (m *memorySessionStore) ListActive(ctx context.Context, userID string, now time.Time) ([]models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []models.Session
+	for _, s := range m.sessions {
+		if s.UserID == userID && s.RevokedAt == nil && s.ExpiresAt.After(now) {
+			list = append(list, *s)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].LastUsedAt.After(list[j].LastUsedAt) })
+	return list, nil
+}
+
+func (m *memorySessionStore) Revoke(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok || s.RevokedAt != nil {
+		return sql.ErrNoRows
+	}
+	now := time.Now()
+	s.RevokedAt = &now
+	return nil
+}
+
+func (m *memorySessionStore) RevokeByUser(ctx context.Context, userID, exceptID string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var ids []string
+	for _, s := range m.sessions {
+		if s.UserID == userID && s.RevokedAt == nil && s.ID != exceptID {
+			s.RevokedAt = &now
+			ids = append(ids, s.ID)
+		}
+	}
+	return ids, nil
+}
@@ -2,19 +2,20 @@ package repository
 
 import (
 	models "achievement_backend/app/model"
+	"context"
 	"database/sql"
 	"time"
 )
 
 type StudentRepository interface {
-	GetAll() ([]models.Student, error)
-	GetByID(id string) (*models.Student, error)
-	GetByStudentID(studentID string) (*models.Student, error)
-	GetByUserID(userID string) (*models.Student, error)
-	GetByAdvisorID(advisorID string) ([]models.Student, error)
-	Create(req models.CreateStudentRequest) (*models.Student, error)
-	Update(id string, req models.UpdateStudentRequest) (*models.Student, error)
-	UpdateAdvisor(id string, advisorID string) error
+	GetAll(ctx context.Context) ([]models.Student, error)
+	GetByID(ctx context.Context, id string) (*models.Student, error)
+	GetByStudentID(ctx context.Context, studentID string) (*models.Student, error)
+	GetByUserID(ctx context.Context, userID string) (*models.Student, error)
+	GetByAdvisorID(ctx context.Context, advisorID string) ([]models.Student, error)
+	Create(ctx context.Context, req models.CreateStudentRequest) (*models.Student, error)
+	Update(ctx context.Context, id string, req models.UpdateStudentRequest) (*models.Student, error)
+	UpdateAdvisor(ctx context.Context, id string, advisorID string) error
 }
 
 type studentRepository struct {
@@ -25,8 +26,8 @@ func NewStudentRepository(db *sql.DB) StudentRepository {
 	return &studentRepository{db: db}
 }
 
-func (r *studentRepository) GetAll() ([]models.Student, error) {
-	rows, err := r.db.Query(`
+func (r *studentRepository) GetAll(ctx context.Context) ([]models.Student, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT 
 			s.id,
 			s.user_id,
@@ -66,10 +67,10 @@ func (r *studentRepository) GetAll() ([]models.Student, error) {
 	return list, nil
 }
 
-func (r *studentRepository) GetByID(id string) (*models.Student, error) {
+func (r *studentRepository) GetByID(ctx context.Context, id string) (*models.Student, error) {
 	var s models.Student
 
-	row := r.db.QueryRow(`
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT
 			s.id,
 			s.user_id,
@@ -105,10 +106,10 @@ func (r *studentRepository) GetByID(id string) (*models.Student, error) {
 	return &s, nil
 }
 
-func (r *studentRepository) GetByStudentID(studentID string) (*models.Student, error) {
+func (r *studentRepository) GetByStudentID(ctx context.Context, studentID string) (*models.Student, error) {
 	var s models.Student
 
-	row := r.db.QueryRow(`
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT
 			s.id,
 			s.user_id,
@@ -144,10 +145,10 @@ func (r *studentRepository) GetByStudentID(studentID string) (*models.Student, e
 	return &s, nil
 }
 
-func (r *studentRepository) GetByUserID(userID string) (*models.Student, error) {
+func (r *studentRepository) GetByUserID(ctx context.Context, userID string) (*models.Student, error) {
 	var s models.Student
 
-	row := r.db.QueryRow(`
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT
 			s.id,
 			s.user_id,
@@ -183,8 +184,8 @@ func (r *studentRepository) GetByUserID(userID string) (*models.Student, error)
 	return &s, nil
 }
 
-func (r *studentRepository) GetByAdvisorID(advisorID string) ([]models.Student, error) {
-	rows, err := r.db.Query(`
+func (r *studentRepository) GetByAdvisorID(ctx context.Context, advisorID string) ([]models.Student, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT 
 			s.id,
 			s.user_id,
@@ -229,10 +230,10 @@ func (r *studentRepository) GetByAdvisorID(advisorID string) ([]models.Student,
 	return list, nil
 }
 
-func (r *studentRepository) Create(req models.CreateStudentRequest) (*models.Student, error) {
+func (r *studentRepository) Create(ctx context.Context, req models.CreateStudentRequest) (*models.Student, error) {
 	var id string
 
-	err := r.db.QueryRow(`
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
 		INSERT INTO students (user_id, student_id, program_study, academic_year, advisor_id, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id
@@ -242,11 +243,11 @@ func (r *studentRepository) Create(req models.CreateStudentRequest) (*models.Stu
 		return nil, err
 	}
 
-	return r.GetByID(id)
+	return r.GetByID(ctx, id)
 }
 
-func (r *studentRepository) Update(id string, req models.UpdateStudentRequest) (*models.Student, error) {
-	result, err := r.db.Exec(`
+func (r *studentRepository) Update(ctx context.Context, id string, req models.UpdateStudentRequest) (*models.Student, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `
 		UPDATE students
 		SET user_id=$1, student_id=$2, program_study=$3, academic_year=$4
 		WHERE id = $5
@@ -261,11 +262,11 @@ func (r *studentRepository) Update(id string, req models.UpdateStudentRequest) (
 		return nil, sql.ErrNoRows
 	}
 
-	return r.GetByID(id)
+	return r.GetByID(ctx, id)
 }
 
-func (r *studentRepository) UpdateAdvisor(id string, advisorID string) error {
-	result, err := r.db.Exec(`
+func (r *studentRepository) UpdateAdvisor(ctx context.Context, id string, advisorID string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `
 		UPDATE students
 		SET advisor_id = $1
 		WHERE id = $2
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	models "achievement_backend/app/model"
 	"database/sql"
 	"testing"
@@ -52,7 +53,7 @@ func TestStudentRepository_GetByID(t *testing.T) {
 		WithArgs("student-uuid").
 		WillReturnRows(rows)
 
-	result, err := repo.GetByID("student-uuid")
+	result, err := repo.GetByID(context.Background(), "student-uuid")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
@@ -112,7 +113,7 @@ func TestStudentRepository_Create(t *testing.T) {
 			now,
 		))
 
-	result, err := repo.Create(req)
+	result, err := repo.Create(context.Background(), req)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
@@ -167,7 +168,7 @@ func TestStudentRepository_Update(t *testing.T) {
 			now,
 		))
 
-	result, err := repo.Update("student-uuid", req)
+	result, err := repo.Update(context.Background(), "student-uuid", req)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
@@ -185,7 +186,7 @@ func TestStudentRepository_UpdateAdvisor(t *testing.T) {
 		WithArgs(advisorID, "student-uuid").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	err := repo.UpdateAdvisor("student-uuid", advisorID)
+	err := repo.UpdateAdvisor(context.Background(), "student-uuid", advisorID)
 
 	assert.NoError(t, err)
 }
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+type TokenRevocationStore interface {
+	// Revoke mencabut access token dengan jti tertentu sampai token tersebut kadaluarsa.
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	// DeleteExpired menghapus entri yang token-nya sudah kadaluarsa.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// ================= POSTGRES =================
+
+type tokenRevocationRepository struct {
+	db *sql.DB
+}
+
+func NewTokenRevocationRepository(db *sql.DB) TokenRevocationStore {
+	return &tokenRevocationRepository{db: db}
+}
+
+func (r *tokenRevocationRepository) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO revoked_tokens (jti, expires_at, revoked_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (jti) DO NOTHING
+	`, jti, expiresAt)
+
+	return err
+}
+
+func (r *tokenRevocationRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var exists bool
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM revoked_tokens
+			WHERE jti = $1 AND expires_at > NOW()
+		)
+	`, jti).Scan(&exists)
+
+	return exists, err
+}
+
+func (r *tokenRevocationRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// ================= IN-MEMORY (testing) =================
+
+type memoryTokenRevocationStore struct {
+	mu      sync.RWMutex
+	entries map[string]time.Time
+}
+
+func NewMemoryTokenRevocationStore() TokenRevocationStore {
+	return &memoryTokenRevocationStore{entries: make(map[string]time.Time)}
+}
+
+func (m *memoryTokenRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[jti] = expiresAt
+	return nil
+}
+
+func (m *memoryTokenRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	exp, ok := m.entries[jti]
+	return ok && time.Now().Before(exp), nil
+}
+
+func (m *memoryTokenRevocationStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	for jti, exp := range m.entries {
+		if !now.Before(exp) {
+			delete(m.entries, jti)
+			n++
+		}
+	}
+	return n, nil
+}
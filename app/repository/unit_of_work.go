@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// dbConn adalah method yang dipakai repository, dipenuhi *sql.DB maupun *sql.Tx.
+type dbConn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+type afterCommitKey struct{}
+
+// AfterCommit menjadwalkan fn setelah unit of work yang dibawa ctx berhasil
+// di-commit, mis. membuang cache agar request lain tidak mengisinya ulang
+// dengan state sebelum commit. Di luar unit of work fn langsung dijalankan;
+// jika transaksi di-rollback fn tidak dijalankan.
+func AfterCommit(ctx context.Context, fn func()) {
+	if hooks, ok := ctx.Value(afterCommitKey{}).(*[]func()); ok {
+		*hooks = append(*hooks, fn)
+		return
+	}
+	fn()
+}
+
+func runAfterCommit(hooks []func()) {
+	for _, fn := range hooks {
+		fn()
+	}
+}
+
+// conn mengembalikan transaksi unit of work yang dibawa ctx, atau db jika
+// repository dipanggil di luar unit of work.
+func conn(ctx context.Context, db *sql.DB) dbConn {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// inTx menjalankan fn dalam transaksi. Di dalam unit of work fn ikut
+// transaksi tersebut (commit/rollback diserahkan ke unit of work); di luar
+// itu transaksi baru dibuka dan di-commit jika fn berhasil.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(tx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UnitOfWork menjalankan beberapa operasi repository secara atomik.
+type UnitOfWork interface {
+	// Do menjalankan fn dalam satu transaksi. Repository yang dipanggil
+	// dengan ctx milik fn ikut transaksi itu; transaksi di-commit jika fn
+	// mengembalikan nil dan di-rollback jika tidak. Do yang bersarang ikut
+	// transaksi terluar. Fungsi yang didaftarkan lewat AfterCommit dijalankan
+	// setelah commit transaksi terluar.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// ================= POSTGRES =================
+
+type unitOfWork struct {
+	db *sql.DB
+}
+
+func NewUnitOfWork(db *sql.DB) UnitOfWork {
+	return &unitOfWork{db: db}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var hooks []func()
+	ctx = context.WithValue(ctx, txKey{}, tx)
+	ctx = context.WithValue(ctx, afterCommitKey{}, &hooks)
+
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	runAfterCommit(hooks)
+	return nil
+}
+
+// ================= IN-MEMORY (testing) =================
+
+// memoryUnitOfWork langsung menjalankan fn; repository in-memory dan mock
+// tidak punya transaksi. AfterCommit tetap ditunda sampai fn terluar berhasil
+// agar urutannya sama dengan unit of work Postgres.
+type memoryUnitOfWork struct{}
+
+func NewMemoryUnitOfWork() UnitOfWork {
+	return memoryUnitOfWork{}
+}
+
+func (memoryUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(afterCommitKey{}).(*[]func()); ok {
+		return fn(ctx)
+	}
+
+	var hooks []func()
+	if err := fn(context.WithValue(ctx, afterCommitKey{}, &hooks)); err != nil {
+		return err
+	}
+	runAfterCommit(hooks)
+	return nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitOfWork_CommitsAcrossRepositories(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	users := NewUserRepository(db)
+	refs := NewAchievementReferenceRepository(db)
+
+	// satu BEGIN/COMMIT; Submit ikut transaksi unit of work
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET password_hash=$1`)).
+		WithArgs("hash", "USR001").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE achievement_references`)).
+		WithArgs(sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_status_history`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO achievement_outbox`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = NewUnitOfWork(db).Do(context.Background(), func(ctx context.Context) error {
+		if err := users.UpdatePassword(ctx, "USR001", "hash"); err != nil {
+			return err
+		}
+		return refs.Submit(ctx, "1", "USR001")
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnitOfWork_RollsBackOnError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	users := NewUserRepository(db)
+	refs := NewAchievementReferenceRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET password_hash=$1`)).
+		WithArgs("hash", "USR001").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE achievement_references`)).
+		WithArgs(sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = NewUnitOfWork(db).Do(context.Background(), func(ctx context.Context) error {
+		if err := users.UpdatePassword(ctx, "USR001", "hash"); err != nil {
+			return err
+		}
+		return refs.Submit(ctx, "1", "USR001")
+	})
+
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnitOfWork_CanceledContext(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	err = NewUnitOfWork(db).Do(ctx, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.False(t, called)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnitOfWork_StoresJoinTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	resets := NewPasswordResetRepository(db)
+	refreshTokens := NewRefreshTokenRepository(db)
+
+	// token reset terpakai ikut di-rollback saat pencabutan refresh token gagal
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE password_reset_tokens`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE refresh_tokens`)).
+		WithArgs("USR001").
+		WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	err = NewUnitOfWork(db).Do(context.Background(), func(ctx context.Context) error {
+		if err := resets.InvalidateByUser(ctx, "USR001"); err != nil {
+			return err
+		}
+		return refreshTokens.RevokeByUser(ctx, "USR001")
+	})
+
+	assert.Equal(t, sql.ErrConnDone, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnitOfWork_AfterCommit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	uow := NewUnitOfWork(db)
+
+	// hook ditunda sampai commit transaksi terluar
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	var calls []string
+	err = uow.Do(context.Background(), func(ctx context.Context) error {
+		AfterCommit(ctx, func() { calls = append(calls, "outer") })
+		return uow.Do(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func() { calls = append(calls, "nested") })
+			assert.Empty(t, calls)
+			return nil
+		})
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"outer", "nested"}, calls)
+
+	// tidak dijalankan saat rollback
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	called := false
+	err = uow.Do(context.Background(), func(ctx context.Context) error {
+		AfterCommit(ctx, func() { called = true })
+		return sql.ErrConnDone
+	})
+
+	assert.Equal(t, sql.ErrConnDone, err)
+	assert.False(t, called)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// di luar unit of work langsung dijalankan
+	AfterCommit(context.Background(), func() { called = true })
+	assert.True(t, called)
+}
@@ -1,20 +1,23 @@
 package repository
 
 import (
+	"context"
 	models "achievement_backend/app/model"
 	"database/sql"
 	"time"
 )
 
 type UserRepository interface {
-	GetAll() ([]models.User, error)
-	GetByID(id string) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	GetByUsername(username string) (*models.User, error)
-	Create(req models.CreateUserRequest) (*models.User, error)
-	UpdatePartial(u *models.User) (*models.User, error)
-	UpdatePassword(id string, passwordHash string) error
-	Delete(id string) error
+	GetAll(ctx context.Context) ([]models.User, error)
+	GetByID(ctx context.Context, id string) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	Create(ctx context.Context, req models.CreateUserRequest) (*models.User, error)
+	UpdatePartial(ctx context.Context, u *models.User) (*models.User, error)
+	UpdatePassword(ctx context.Context, id string, passwordHash string) error
+	// RevokeSessions membuat semua access token user yang sudah terbit ditolak.
+	RevokeSessions(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string) error
 }
 
 type userRepository struct {
@@ -25,8 +28,8 @@ func NewUserRepository(db *sql.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) GetAll() ([]models.User, error) {
-	rows, err := r.db.Query(`
+func (r *userRepository) GetAll(ctx context.Context) ([]models.User, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT u.id, u.username, u.email, u.password_hash, u.full_name,
 			u.role_id, COALESCE(r.name, ''), u.is_active, u.created_at, u.updated_at
 		FROM users u
@@ -54,8 +57,8 @@ func (r *userRepository) GetAll() ([]models.User, error) {
 	return list, nil
 }
 
-func (r *userRepository) GetByID(id string) (*models.User, error) {
-	row := r.db.QueryRow(`
+func (r *userRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT u.id, u.username, u.email, u.password_hash, u.full_name,
 			u.role_id, COALESCE(r.name, ''), u.is_active, u.created_at, u.updated_at
 		FROM users u
@@ -76,8 +79,8 @@ func (r *userRepository) GetByID(id string) (*models.User, error) {
 	return &u, nil
 }
 
-func (r *userRepository) GetByEmail(email string) (*models.User, error) {
-	row := r.db.QueryRow(`
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT u.id, u.username, u.email, u.password_hash, u.full_name,
 			u.role_id, COALESCE(r.name, ''), u.is_active, u.created_at, u.updated_at
 		FROM users u
@@ -98,8 +101,8 @@ func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 	return &u, nil
 }
 
-func (r *userRepository) GetByUsername(username string) (*models.User, error) {
-	row := r.db.QueryRow(`
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT u.id, u.username, u.email, u.password_hash, u.full_name,
 			u.role_id, COALESCE(r.name, ''), u.is_active, u.created_at, u.updated_at
 		FROM users u
@@ -120,12 +123,12 @@ func (r *userRepository) GetByUsername(username string) (*models.User, error) {
 	return &u, nil
 }
 
-func (r *userRepository) Create(req models.CreateUserRequest) (*models.User, error) {
+func (r *userRepository) Create(ctx context.Context, req models.CreateUserRequest) (*models.User, error) {
     var id string
     var isActive bool
     var createdAt, updatedAt time.Time
 
-    err := r.db.QueryRow(`
+    err := conn(ctx, r.db).QueryRowContext(ctx, `
         INSERT INTO users (username, email, password_hash, full_name)
         VALUES ($1,$2,$3,$4)
         RETURNING id, is_active, created_at, updated_at`,
@@ -153,9 +156,12 @@ func (r *userRepository) Create(req models.CreateUserRequest) (*models.User, err
 }
 
 
-func (r *userRepository) UpdatePartial(u *models.User) (*models.User, error) {
-	_, err := r.db.Exec(`
+func (r *userRepository) UpdatePartial(ctx context.Context, u *models.User) (*models.User, error) {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
 		UPDATE users SET 
+			auth_version = auth_version + CASE
+				WHEN role_id IS DISTINCT FROM $4 OR is_active <> $5 THEN 1 ELSE 0
+			END,
 			username=$1, email=$2, full_name=$3, role_id=$4,
 			is_active=$5, updated_at=NOW()
 		WHERE id=$6
@@ -168,11 +174,11 @@ func (r *userRepository) UpdatePartial(u *models.User) (*models.User, error) {
 		return nil, err
 	}
 
-	return r.GetByID(u.ID)
+	return r.GetByID(ctx, u.ID)
 }
 
-func (r *userRepository) UpdatePassword(id string, passwordHash string) error {
-	_, err := r.db.Exec(`
+func (r *userRepository) UpdatePassword(ctx context.Context, id string, passwordHash string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
 		UPDATE users SET password_hash=$1, updated_at=NOW()
 		WHERE id=$2
 	`, passwordHash, id)
@@ -180,7 +186,16 @@ func (r *userRepository) UpdatePassword(id string, passwordHash string) error {
 	return err
 }
 
-func (r *userRepository) Delete(id string) error {
-	_, err := r.db.Exec(`DELETE FROM users WHERE id=$1`, id)
+func (r *userRepository) RevokeSessions(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE users SET sessions_revoked_at=NOW()
+		WHERE id=$1
+	`, id)
+
+	return err
+}
+
+func (r *userRepository) Delete(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM users WHERE id=$1`, id)
 	return err
 }
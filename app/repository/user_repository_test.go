@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 	"time"
@@ -41,7 +42,7 @@ func TestUserRepository_GetByID_Success(t *testing.T) {
 		WithArgs("uuid-cindy-1").
 		WillReturnRows(rows)
 
-	user, err := repo.GetByID("uuid-cindy-1")
+	user, err := repo.GetByID(context.Background(), "uuid-cindy-1")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
@@ -57,7 +58,7 @@ func TestUserRepository_GetByID_NotFound(t *testing.T) {
 		WithArgs("invalid-id").
 		WillReturnError(sql.ErrNoRows)
 
-	user, err := repo.GetByID("invalid-id")
+	user, err := repo.GetByID(context.Background(), "invalid-id")
 
 	assert.Error(t, err)
 	assert.Nil(t, user)
@@ -83,7 +84,7 @@ func TestUserRepository_Create_Success(t *testing.T) {
 				AddRow("uuid-cindy-2", true, time.Now(), time.Now()),
 		)
 
-	user, err := repo.Create(req)
+	user, err := repo.Create(context.Background(), req)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
@@ -132,7 +133,7 @@ func TestUserRepository_UpdatePartial_Success(t *testing.T) {
 		WithArgs(user.ID).
 		WillReturnRows(rows)
 
-	result, err := repo.UpdatePartial(user)
+	result, err := repo.UpdatePartial(context.Background(), user)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "cindy_updated", result.Username)
@@ -149,11 +150,27 @@ func TestUserRepository_UpdatePassword_Success(t *testing.T) {
 		WithArgs("newhash", "uuid-cindy-4").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	err := repo.UpdatePassword("uuid-cindy-4", "newhash")
+	err := repo.UpdatePassword(context.Background(), "uuid-cindy-4", "newhash")
 
 	assert.NoError(t, err)
 }
 
+// ==================== REVOKE SESSIONS ====================
+
+func TestUserRepository_RevokeSessions_Success(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectExec(`UPDATE users SET sessions_revoked_at`).
+		WithArgs("uuid-cindy-4").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.RevokeSessions(context.Background(), "uuid-cindy-4")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 // ==================== DELETE ====================
 
 func TestUserRepository_Delete_Success(t *testing.T) {
@@ -164,7 +181,7 @@ func TestUserRepository_Delete_Success(t *testing.T) {
 		WithArgs("uuid-cindy-5").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	err := repo.Delete("uuid-cindy-5")
+	err := repo.Delete(context.Background(), "uuid-cindy-5")
 
 	assert.NoError(t, err)
 }
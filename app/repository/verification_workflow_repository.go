@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	models "achievement_backend/app/model"
+
+	"github.com/google/uuid"
+)
+
+type VerificationWorkflowStore interface {
+	List(ctx context.Context) ([]models.VerificationWorkflow, error)
+	// Get dan GetByKey mengembalikan sql.ErrNoRows jika tidak ada. GetByKey
+	// mencocokkan jenis dan tingkat persis, tanpa fallback.
+	Get(ctx context.Context, id string) (*models.VerificationWorkflow, error)
+	GetByKey(ctx context.Context, achievementType, competitionLevel string) (*models.VerificationWorkflow, error)
+	Create(ctx context.Context, w *models.VerificationWorkflow) error
+	// Update mengganti nama dan seluruh tahap; sql.ErrNoRows jika tidak ada.
+	Update(ctx context.Context, w *models.VerificationWorkflow) error
+	Delete(ctx context.Context, id string) error
+}
+
+// ================= POSTGRES =================
+
+type verificationWorkflowRepository struct {
+	db *sql.DB
+}
+
+func NewVerificationWorkflowRepository(db *sql.DB) VerificationWorkflowStore {
+	return &verificationWorkflowRepository{db: db}
+}
+
+func (r *verificationWorkflowRepository) stages(ctx context.Context, workflowID string) ([]models.VerificationStage, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT position, name, role_name
+		FROM verification_stages
+		WHERE workflow_id = $1
+		ORDER BY position
+	`, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.VerificationStage{}
+	for rows.Next() {
+		var st models.VerificationStage
+		if err := rows.Scan(&st.Position, &st.Name, &st.RoleName); err != nil {
+			return nil, err
+		}
+		list = append(list, st)
+	}
+
+	return list, rows.Err()
+}
+
+func (r *verificationWorkflowRepository) get(ctx context.Context, where string, args ...interface{}) (*models.VerificationWorkflow, error) {
+	var w models.VerificationWorkflow
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, achievement_type, competition_level, name, created_at, updated_at
+		FROM verification_workflows
+		WHERE `+where, args...).Scan(
+		&w.ID, &w.AchievementType, &w.CompetitionLevel, &w.Name, &w.CreatedAt, &w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if w.Stages, err = r.stages(ctx, w.ID); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *verificationWorkflowRepository) List(ctx context.Context) ([]models.VerificationWorkflow, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, achievement_type, competition_level, name, created_at, updated_at
+		FROM verification_workflows
+		ORDER BY achievement_type, competition_level
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []models.VerificationWorkflow{}
+	for rows.Next() {
+		var w models.VerificationWorkflow
+		if err := rows.Scan(&w.ID, &w.AchievementType, &w.CompetitionLevel, &w.Name, &w.CreatedAt, &w.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		list = append(list, w)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range list {
+		if list[i].Stages, err = r.stages(ctx, list[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return list, nil
+}
+
+func (r *verificationWorkflowRepository) Get(ctx context.Context, id string) (*models.VerificationWorkflow, error) {
+	return r.get(ctx, `id = $1`, id)
+}
+
+func (r *verificationWorkflowRepository) GetByKey(ctx context.Context, achievementType, competitionLevel string) (*models.VerificationWorkflow, error) {
+	return r.get(ctx, `achievement_type = $1 AND competition_level = $2`, achievementType, competitionLevel)
+}
+
+func insertStages(ctx context.Context, tx *sql.Tx, workflowID string, stages []models.VerificationStage) error {
+	for i, st := range stages {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO verification_stages (workflow_id, position, name, role_name)
+			VALUES ($1, $2, $3, $4)
+		`, workflowID, i, st.Name, st.RoleName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *verificationWorkflowRepository) Create(ctx context.Context, w *models.VerificationWorkflow) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO verification_workflows (achievement_type, competition_level, name, created_at, updated_at)
+			VALUES ($1, $2, $3, NOW(), NOW())
+			RETURNING id, created_at, updated_at
+		`, w.AchievementType, w.CompetitionLevel, w.Name).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		return insertStages(ctx, tx, w.ID, w.Stages)
+	})
+}
+
+func (r *verificationWorkflowRepository) Update(ctx context.Context, w *models.VerificationWorkflow) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			UPDATE verification_workflows
+			SET name = $2, updated_at = NOW()
+			WHERE id = $1
+			RETURNING updated_at
+		`, w.ID, w.Name).Scan(&w.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM verification_stages WHERE workflow_id = $1`, w.ID); err != nil {
+			return err
+		}
+		return insertStages(ctx, tx, w.ID, w.Stages)
+	})
+}
+
+func (r *verificationWorkflowRepository) Delete(ctx context.Context, id string) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM verification_workflows WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ================= IN-MEMORY (testing) =================
+
+type memoryVerificationWorkflowStore struct {
+	mu        sync.Mutex
+	workflows map[string]*models.VerificationWorkflow
+}
+
+func NewMemoryVerificationWorkflowStore() VerificationWorkflowStore {
+	return &memoryVerificationWorkflowStore{workflows: make(map[string]*models.VerificationWorkflow)}
+}
+
+func copyWorkflow(w *models.VerificationWorkflow) *models.VerificationWorkflow {
+	cp := *w
+	cp.Stages = append([]models.VerificationStage(nil), w.Stages...)
+	return &cp
+}
+
+func (m *memoryVerificationWorkflowStore) List(ctx context.Context) ([]models.VerificationWorkflow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := []models.VerificationWorkflow{}
+	for _, w := range m.workflows {
+		list = append(list, *copyWorkflow(w))
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].AchievementType != list[b].AchievementType {
+			return list[a].AchievementType < list[b].AchievementType
+		}
+		return list[a].CompetitionLevel < list[b].CompetitionLevel
+	})
+	return list, nil
+}
+
+func (m *memoryVerificationWorkflowStore) Get(ctx context.Context, id string) (*models.VerificationWorkflow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.workflows[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return copyWorkflow(w), nil
+}
+
+func (m *memoryVerificationWorkflowStore) GetByKey(ctx context.Context, achievementType, competitionLevel string) (*models.VerificationWorkflow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, w := range m.workflows {
+		if w.AchievementType == achievementType && w.CompetitionLevel == competitionLevel {
+			return copyWorkflow(w), nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *memoryVerificationWorkflowStore) Create(ctx context.Context, w *models.VerificationWorkflow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.ID = uuid.New().String()
+	w.CreatedAt = time.Now()
+	w.UpdatedAt = w.CreatedAt
+	for i := range w.Stages {
+		w.Stages[i].Position = i
+	}
+	m.workflows[w.ID] = copyWorkflow(w)
+	return nil
+}
+
+func (m *memoryVerificationWorkflowStore) Update(ctx context.Context, w *models.VerificationWorkflow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cur, ok := m.workflows[w.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	w.UpdatedAt = time.Now()
+	for i := range w.Stages {
+		w.Stages[i].Position = i
+	}
+	cur.Name, cur.Stages, cur.UpdatedAt = w.Name, append([]models.VerificationStage(nil), w.Stages...), w.UpdatedAt
+	return nil
+}
+
+func (m *memoryVerificationWorkflowStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.workflows[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(m.workflows, id)
+	return nil
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/policy"
+	"achievement_backend/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// commentMaxLength membatasi panjang isi komentar (dalam karakter).
+const commentMaxLength = 2000
+
+var (
+	errAchievementDeleted = errors.New("achievement deleted")
+	errCommentNotFound    = errors.New("comment not found")
+)
+
+// AchievementCommentService mengelola diskusi pada setiap prestasi. Siapa
+// yang boleh membaca komentar mengikuti aturan GetDetail; menulis butuh
+// policy.ActionComment sehingga service account hanya bisa membaca.
+type AchievementCommentService struct {
+	refRepo    repository.AchievementReferenceRepository
+	mongoRepo  repository.MongoAchievementRepository
+	comments   repository.AchievementCommentStore
+	authz      *policy.Policy
+	editWindow time.Duration
+}
+
+// NewAchievementCommentService membuat service komentar. editWindow adalah
+// batas waktu sejak komentar dibuat selama penulisnya masih boleh mengubah isi.
+func NewAchievementCommentService(
+	refRepo repository.AchievementReferenceRepository,
+	mongoRepo repository.MongoAchievementRepository,
+	studentRepo repository.StudentRepository,
+	lecturerRepo repository.LecturerRepository,
+	comments repository.AchievementCommentStore,
+	editWindow time.Duration,
+) *AchievementCommentService {
+	return &AchievementCommentService{
+		refRepo:    refRepo,
+		mongoRepo:  mongoRepo,
+		comments:   comments,
+		authz:      policy.New(studentRepo, lecturerRepo),
+		editWindow: editWindow,
+	}
+}
+
+// commentStatus memetakan error akses diskusi ke status HTTP dan pesan error.
+func commentStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, errReferenceNotFound):
+		return 404, "achievement not found"
+	case errors.Is(err, errCommentNotFound):
+		return 404, err.Error()
+	case errors.Is(err, errAchievementDeleted):
+		return 410, err.Error()
+	case errors.Is(err, errUnauthenticated), errors.Is(err, policy.ErrForbidden), errors.Is(err, policy.ErrProfileNotFound):
+		return policyStatus(err)
+	}
+
+	return 500, "failed to fetch achievement"
+}
+
+func commentError(c *fiber.Ctx, err error) error {
+	code, msg := commentStatus(err)
+	return c.Status(code).JSON(fiber.Map{"error": msg})
+}
+
+// thread memuat prestasi yang diskusinya diakses dan memeriksa action
+// (policy.ActionRead seperti GetDetail, atau policy.ActionComment untuk menulis).
+func (s *AchievementCommentService) thread(c *fiber.Ctx, action policy.Action) (*policy.Subject, *models.AchievementReference, *models.Achievement, error) {
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	mongoID := c.Params("id")
+	ref, err := s.refRepo.GetByMongoAchievementID(c.UserContext(), mongoID)
+	if err != nil || ref == nil {
+		return nil, nil, nil, errReferenceNotFound
+	}
+
+	if err := s.authz.Authorize(c.UserContext(), sub, action, ref.StudentID); err != nil {
+		return nil, nil, nil, err
+	}
+
+	item, err := s.mongoRepo.GetByID(c.UserContext(), mongoID)
+	if err != nil {
+		log.Printf("[Comments] mongoRepo.GetByID error: %v", err)
+		return nil, nil, nil, err
+	}
+	if item == nil {
+		return nil, nil, nil, errReferenceNotFound
+	}
+	if item.IsDeleted {
+		return nil, nil, nil, errAchievementDeleted
+	}
+
+	return sub, ref, item, nil
+}
+
+// comment memuat komentar yang belum dihapus pada diskusi ref.
+func (s *AchievementCommentService) comment(ctx context.Context, ref *models.AchievementReference, id string) (*models.AchievementComment, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errCommentNotFound
+	}
+
+	cm, err := s.comments.Get(ctx, id)
+	if err == sql.ErrNoRows || (err == nil && (cm.ReferenceID != ref.ID || cm.DeletedAt != nil)) {
+		return nil, errCommentNotFound
+	}
+	return cm, err
+}
+
+// redact mengosongkan isi komentar yang sudah dihapus; barisnya tetap
+// dikirim agar balasannya tidak kehilangan induk.
+func redact(cm *models.AchievementComment) {
+	if cm.DeletedAt != nil {
+		cm.Body = ""
+		cm.AttachmentURL = nil
+	}
+}
+
+func hasAttachment(item *models.Achievement, url string) bool {
+	for _, a := range item.Attachments {
+		if a.FileURL == url {
+			return true
+		}
+	}
+	return false
+}
+
+// ListComments godoc
+// @Summary Daftar komentar prestasi
+// @Description Semua komentar pada diskusi prestasi, urut dari yang paling lama. Balasan ditandai parent_id. Komentar yang dihapus tetap muncul dengan isi kosong dan deleted_at terisi.
+// @Description Akses sama dengan detail prestasi: Admin semua, Mahasiswa miliknya, Dosen Wali mahasiswa bimbingan.
+// @Tags Achievement Comments
+// @Produce json
+// @Param id path string true "ID Prestasi di MongoDB"
+// @Success 200 {object} map[string]interface{} "Daftar komentar"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Achievement not found"
+// @Failure 410 {object} map[string]interface{} "Achievement deleted"
+// @Security Bearer
+// @Router /api/v1/achievements/{id}/comments [get]
+func (s *AchievementCommentService) List(c *fiber.Ctx) error {
+	_, ref, _, err := s.thread(c, policy.ActionRead)
+	if err != nil {
+		return commentError(c, err)
+	}
+
+	list, err := s.comments.List(c.UserContext(), ref.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch comments"})
+	}
+	for i := range list {
+		redact(&list[i])
+	}
+
+	return c.JSON(fiber.Map{"success": true, "data": list})
+}
+
+// CreateComment godoc
+// @Summary Menulis komentar pada prestasi
+// @Description Menambah komentar atau balasan (parent_id) pada diskusi prestasi. attachment_url opsional dan harus sama dengan file_url salah satu lampiran prestasi.
+// @Tags Achievement Comments
+// @Accept json
+// @Produce json
+// @Param id path string true "ID Prestasi di MongoDB"
+// @Param body body models.CreateAchievementCommentRequest true "Isi komentar"
+// @Success 201 {object} map[string]interface{} "Komentar dibuat"
+// @Failure 400 {object} map[string]interface{} "Input tidak valid"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Achievement not found"
+// @Failure 410 {object} map[string]interface{} "Achievement deleted"
+// @Security Bearer
+// @Router /api/v1/achievements/{id}/comments [post]
+func (s *AchievementCommentService) Create(c *fiber.Ctx) error {
+	sub, ref, item, err := s.thread(c, policy.ActionComment)
+	if err != nil {
+		return commentError(c, err)
+	}
+	// komentar selalu atas nama user; API key integrasi hanya membaca
+	if sub.Role == models.ServiceAccountRole {
+		return policyError(c, policy.ErrForbidden)
+	}
+
+	var req models.CreateAchievementCommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "body is required"})
+	}
+	if len([]rune(body)) > commentMaxLength {
+		return c.Status(400).JSON(fiber.Map{"error": "body is too long"})
+	}
+
+	if req.ParentID != nil {
+		if _, err := s.comment(c.UserContext(), ref, *req.ParentID); err != nil {
+			if errors.Is(err, errCommentNotFound) {
+				return c.Status(400).JSON(fiber.Map{"error": "parent comment not found"})
+			}
+			return c.Status(500).JSON(fiber.Map{"error": "failed to create comment"})
+		}
+	}
+
+	if req.AttachmentURL != nil && !hasAttachment(item, *req.AttachmentURL) {
+		return c.Status(400).JSON(fiber.Map{"error": "attachment not found on this achievement"})
+	}
+
+	cm := &models.AchievementComment{
+		ReferenceID:   ref.ID,
+		ParentID:      req.ParentID,
+		AuthorID:      &sub.UserID,
+		Body:          body,
+		AttachmentURL: req.AttachmentURL,
+	}
+	if err := s.comments.Create(c.UserContext(), cm); err != nil {
+		log.Printf("[Comments] create error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "failed to create comment"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{"success": true, "data": cm})
+}
+
+// UpdateComment godoc
+// @Summary Mengubah isi komentar
+// @Description Hanya penulis komentar, dan hanya selama batas waktu edit sejak komentar dibuat.
+// @Tags Achievement Comments
+// @Accept json
+// @Produce json
+// @Param id path string true "ID Prestasi di MongoDB"
+// @Param commentId path string true "Comment ID"
+// @Param body body models.UpdateAchievementCommentRequest true "Isi baru"
+// @Success 200 {object} map[string]interface{} "Komentar diubah"
+// @Failure 400 {object} map[string]interface{} "Input tidak valid"
+// @Failure 403 {object} map[string]interface{} "Bukan penulis atau batas waktu edit lewat"
+// @Failure 404 {object} map[string]interface{} "Comment not found"
+// @Security Bearer
+// @Router /api/v1/achievements/{id}/comments/{commentId} [put]
+func (s *AchievementCommentService) Update(c *fiber.Ctx) error {
+	sub, ref, _, err := s.thread(c, policy.ActionComment)
+	if err != nil {
+		return commentError(c, err)
+	}
+
+	cm, err := s.comment(c.UserContext(), ref, c.Params("commentId"))
+	if err != nil {
+		return commentError(c, err)
+	}
+
+	if cm.AuthorID == nil || *cm.AuthorID != sub.UserID {
+		return c.Status(403).JSON(fiber.Map{"error": "only the author can edit this comment"})
+	}
+	now := time.Now()
+	if now.Sub(cm.CreatedAt) > s.editWindow {
+		return c.Status(403).JSON(fiber.Map{"error": "edit window has passed"})
+	}
+
+	var req models.UpdateAchievementCommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "body is required"})
+	}
+	if len([]rune(body)) > commentMaxLength {
+		return c.Status(400).JSON(fiber.Map{"error": "body is too long"})
+	}
+
+	if err := s.comments.UpdateBody(c.UserContext(), cm.ID, body, now); err != nil {
+		if err == sql.ErrNoRows {
+			return commentError(c, errCommentNotFound)
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed to update comment"})
+	}
+
+	cm.Body, cm.EditedAt = body, &now
+	return c.JSON(fiber.Map{"success": true, "data": cm})
+}
+
+// DeleteComment godoc
+// @Summary Menghapus komentar
+// @Description Soft delete oleh penulis komentar atau moderator (Admin). Balasan komentar tetap tampil.
+// @Tags Achievement Comments
+// @Produce json
+// @Param id path string true "ID Prestasi di MongoDB"
+// @Param commentId path string true "Comment ID"
+// @Success 200 {object} map[string]interface{} "Komentar dihapus"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Comment not found"
+// @Security Bearer
+// @Router /api/v1/achievements/{id}/comments/{commentId} [delete]
+func (s *AchievementCommentService) Delete(c *fiber.Ctx) error {
+	sub, ref, _, err := s.thread(c, policy.ActionComment)
+	if err != nil {
+		return commentError(c, err)
+	}
+
+	cm, err := s.comment(c.UserContext(), ref, c.Params("commentId"))
+	if err != nil {
+		return commentError(c, err)
+	}
+
+	isAuthor := cm.AuthorID != nil && *cm.AuthorID == sub.UserID
+	if !isAuthor && s.authz.Authorize(c.UserContext(), sub, policy.ActionModerateComment, ref.StudentID) != nil {
+		return c.Status(403).JSON(fiber.Map{"error": "only the author or a moderator can delete this comment"})
+	}
+
+	if err := s.comments.SoftDelete(c.UserContext(), cm.ID, time.Now()); err != nil {
+		if err == sql.ErrNoRows {
+			return commentError(c, errCommentNotFound)
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed to delete comment"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "comment deleted"})
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupComments memakai header X-Role dan X-User sebagai user yang login.
+func setupComments(editWindow time.Duration) (*fiber.App, *mockAchievementRefRepo, *mockMongoAchievementRepo) {
+	ref := &mockAchievementRefRepo{ref: &models.AchievementReference{
+		ID:                 "ref-1",
+		StudentID:          "student-1",
+		MongoAchievementID: "mongo-1",
+		Status:             models.StatusSubmitted,
+	}}
+	mongo := &mockMongoAchievementRepo{doc: &models.Achievement{
+		Title:       "Juara 1",
+		Attachments: []models.Attachment{{FileName: "sertifikat.pdf", FileURL: "/uploads/sertifikat.pdf"}},
+	}}
+
+	service := NewAchievementCommentService(
+		ref,
+		mongo,
+		&mockAchievementStudentRepo{},
+		&mockAchievementLecturerRepo{},
+		repository.NewMemoryAchievementCommentStore(),
+		editWindow,
+	)
+
+	app := fiber.New()
+	app.Use(headerUser)
+	app.Get("/achievements/:id/comments", service.List)
+	app.Post("/achievements/:id/comments", service.Create)
+	app.Put("/achievements/:id/comments/:commentId", service.Update)
+	app.Delete("/achievements/:id/comments/:commentId", service.Delete)
+
+	return app, ref, mongo
+}
+
+func postComment(t *testing.T, app *fiber.App, role, user string, req models.CreateAchievementCommentRequest) (*http.Response, models.AchievementComment) {
+	resp := doRequest(t, app, http.MethodPost, "/achievements/mongo-1/comments", req, as(role, user))
+
+	var body struct {
+		Data models.AchievementComment `json:"data"`
+	}
+	if resp.StatusCode == fiber.StatusCreated {
+		decodeBody(t, resp, &body)
+	}
+	return resp, body.Data
+}
+
+func listComments(t *testing.T, app *fiber.App, role, user string) []models.AchievementComment {
+	resp := doRequest(t, app, http.MethodGet, "/achievements/mongo-1/comments", nil, as(role, user))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data []models.AchievementComment `json:"data"`
+	}
+	decodeBody(t, resp, &body)
+	return body.Data
+}
+
+//
+// =======================================================
+// THREAD
+// =======================================================
+//
+
+func TestComments_AdvisorAsksStudentReplies(t *testing.T) {
+	app, repo, _ := setupComments(15 * time.Minute)
+
+	resp, question := postComment(t, app, "Dosen Wali", "user-lecturer", models.CreateAchievementCommentRequest{
+		Body:          "Bisa upload sertifikat resminya?",
+		AttachmentURL: ptr("/uploads/sertifikat.pdf"),
+	})
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	resp, _ = postComment(t, app, "Mahasiswa", "user-student", models.CreateAchievementCommentRequest{
+		Body:     "Sudah saya ganti, Pak.",
+		ParentID: &question.ID,
+	})
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	// menanggapi komentar tidak mengubah status prestasi
+	assert.Equal(t, models.StatusSubmitted, repo.ref.Status)
+
+	list := listComments(t, app, "Admin", "user-admin")
+	assert.Len(t, list, 2)
+	assert.Equal(t, "/uploads/sertifikat.pdf", *list[0].AttachmentURL)
+	assert.Equal(t, question.ID, *list[1].ParentID)
+}
+
+func TestComments_Validation(t *testing.T) {
+	app, _, _ := setupComments(15 * time.Minute)
+
+	resp, _ := postComment(t, app, "Mahasiswa", "user-student", models.CreateAchievementCommentRequest{Body: "   "})
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = postComment(t, app, "Mahasiswa", "user-student", models.CreateAchievementCommentRequest{
+		Body:          "lihat file ini",
+		AttachmentURL: ptr("/uploads/lain.pdf"),
+	})
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = postComment(t, app, "Mahasiswa", "user-student", models.CreateAchievementCommentRequest{
+		Body:     "balasan",
+		ParentID: ptr("not-a-uuid"),
+	})
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+//
+// =======================================================
+// AKSES
+// =======================================================
+//
+
+func TestComments_SameAccessAsDetail(t *testing.T) {
+	app, repo, mongoRepo := setupComments(15 * time.Minute)
+
+	// prestasi mahasiswa lain, bukan bimbingan dosen ini
+	repo.ref.StudentID = "student-2"
+
+	resp := doRequest(t, app, http.MethodGet, "/achievements/mongo-1/comments", nil, as("Mahasiswa", "user-student"))
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	resp, _ = postComment(t, app, "Dosen Wali", "user-lecturer", models.CreateAchievementCommentRequest{Body: "halo"})
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	repo.ref.StudentID = "student-1"
+	mongoRepo.doc.IsDeleted = true
+
+	resp = doRequest(t, app, http.MethodGet, "/achievements/mongo-1/comments", nil, as("Mahasiswa", "user-student"))
+	assert.Equal(t, fiber.StatusGone, resp.StatusCode)
+}
+
+// Service account (API key) hanya menarik data: boleh membaca diskusi,
+// tidak boleh menulis meski key-nya diberi permission komentar.
+func TestComments_ServiceAccountReadOnly(t *testing.T) {
+	app, _, _ := setupComments(15 * time.Minute)
+
+	resp := doRequest(t, app, http.MethodGet, "/achievements/mongo-1/comments", nil, as(models.ServiceAccountRole, "key-1"))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp, _ = postComment(t, app, models.ServiceAccountRole, "key-1", models.CreateAchievementCommentRequest{Body: "halo"})
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+//
+// =======================================================
+// EDIT & HAPUS
+// =======================================================
+//
+
+func TestComments_EditOnlyByAuthorWithinWindow(t *testing.T) {
+	app, _, _ := setupComments(15 * time.Minute)
+
+	_, cm := postComment(t, app, "Mahasiswa", "user-student", models.CreateAchievementCommentRequest{Body: "typo"})
+	path := "/achievements/mongo-1/comments/" + cm.ID
+
+	resp := doRequest(t, app, http.MethodPut, path, models.UpdateAchievementCommentRequest{Body: "bukan punya saya"}, as("Admin", "user-admin"))
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodPut, path, models.UpdateAchievementCommentRequest{Body: "sudah diperbaiki"}, as("Mahasiswa", "user-student"))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	list := listComments(t, app, "Mahasiswa", "user-student")
+	assert.Equal(t, "sudah diperbaiki", list[0].Body)
+	assert.NotNil(t, list[0].EditedAt)
+}
+
+func TestComments_EditWindowExpired(t *testing.T) {
+	app, _, _ := setupComments(0)
+
+	_, cm := postComment(t, app, "Mahasiswa", "user-student", models.CreateAchievementCommentRequest{Body: "typo"})
+
+	resp := doRequest(t, app, http.MethodPut, "/achievements/mongo-1/comments/"+cm.ID,
+		models.UpdateAchievementCommentRequest{Body: "terlambat"}, as("Mahasiswa", "user-student"))
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+func TestComments_ModeratorDeletesOthers(t *testing.T) {
+	app, _, _ := setupComments(15 * time.Minute)
+
+	_, cm := postComment(t, app, "Mahasiswa", "user-student", models.CreateAchievementCommentRequest{Body: "spam"})
+	path := "/achievements/mongo-1/comments/" + cm.ID
+
+	// kemahasiswaan boleh berkomentar tetapi bukan moderator
+	resp := doRequest(t, app, http.MethodDelete, path, nil, as("Kemahasiswaan", "user-kmh"))
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodDelete, path, nil, as("Admin", "user-admin"))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestComments_SoftDeleteKeepsReplies(t *testing.T) {
+	app, _, _ := setupComments(15 * time.Minute)
+
+	_, question := postComment(t, app, "Dosen Wali", "user-lecturer", models.CreateAchievementCommentRequest{Body: "pertanyaan"})
+	postComment(t, app, "Mahasiswa", "user-student", models.CreateAchievementCommentRequest{Body: "jawaban", ParentID: &question.ID})
+	path := "/achievements/mongo-1/comments/" + question.ID
+
+	// mahasiswa tidak bisa menghapus komentar dosen
+	resp := doRequest(t, app, http.MethodDelete, path, nil, as("Mahasiswa", "user-student"))
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodDelete, path, nil, as("Dosen Wali", "user-lecturer"))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodDelete, path, nil, as("Admin", "user-admin"))
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	list := listComments(t, app, "Mahasiswa", "user-student")
+	assert.Len(t, list, 2)
+	assert.Empty(t, list[0].Body)
+	assert.NotNil(t, list[0].DeletedAt)
+	assert.Equal(t, "jawaban", list[1].Body)
+}
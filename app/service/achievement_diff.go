@@ -0,0 +1,98 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	models "achievement_backend/app/model"
+)
+
+// diffIgnoredFields adalah field yang berubah karena workflow, bukan karena
+// isi prestasi diedit.
+var diffIgnoredFields = map[string]bool{
+	"id":         true,
+	"student_id": true,
+	"status":     true,
+	"is_deleted": true,
+	"created_at": true,
+	"updated_at": true,
+	"version":    true,
+}
+
+// flattenAchievement mengubah dokumen menjadi peta "field.bertitik" → nilai
+// JSON. Objek diturunkan, array dibandingkan utuh.
+func flattenAchievement(a *models.Achievement) (map[string]interface{}, error) {
+	raw, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	out := map[string]interface{}{}
+	var walk func(prefix string, v map[string]interface{})
+	walk = func(prefix string, v map[string]interface{}) {
+		for k, val := range v {
+			if prefix == "" && diffIgnoredFields[k] {
+				continue
+			}
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			if nested, ok := val.(map[string]interface{}); ok {
+				walk(key, nested)
+				continue
+			}
+			out[key] = val
+		}
+	}
+	walk("", doc)
+
+	return out, nil
+}
+
+// sameJSON membandingkan dua nilai JSON; null dan array kosong dianggap sama
+// karena slice nil dan slice kosong dari Mongo tidak bisa dibedakan user.
+func sameJSON(a, b interface{}) bool {
+	empty := func(v interface{}) bool {
+		arr, ok := v.([]interface{})
+		return v == nil || (ok && len(arr) == 0)
+	}
+	if empty(a) && empty(b) {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// diffAchievements mengembalikan field yang berbeda antara prev dan cur,
+// urut berdasarkan nama field.
+func diffAchievements(prev, cur *models.Achievement) ([]models.AchievementFieldChange, error) {
+	a, err := flattenAchievement(prev)
+	if err != nil {
+		return nil, err
+	}
+	b, err := flattenAchievement(cur)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []models.AchievementFieldChange{}
+	for k, old := range a {
+		if !sameJSON(old, b[k]) {
+			changes = append(changes, models.AchievementFieldChange{Field: k, Old: old, New: b[k]})
+		}
+	}
+	for k, nv := range b {
+		if _, ok := a[k]; !ok && !sameJSON(nil, nv) {
+			changes = append(changes, models.AchievementFieldChange{Field: k, New: nv})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
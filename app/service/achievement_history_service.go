@@ -1,8 +1,8 @@
 package service
 
 import (
+	"achievement_backend/app/policy"
 	"achievement_backend/app/repository"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,6 +12,7 @@ type AchievementHistoryService struct {
 	mongoRepo    repository.MongoAchievementRepository
 	studentRepo  repository.StudentRepository
 	lecturerRepo repository.LecturerRepository
+	authz        *policy.Policy
 }
 
 func NewAchievementHistoryService(
@@ -25,20 +26,14 @@ func NewAchievementHistoryService(
 		mongoRepo:    mongoRepo,
 		studentRepo:  studentRepo,
 		lecturerRepo: lecturerRepo,
+		authz:        policy.New(studentRepo, lecturerRepo),
 	}
 }
 
-type HistoryEvent struct {
-	Status        string    `json:"status"`
-	Timestamp     time.Time `json:"timestamp"`
-	VerifiedBy    *string   `json:"verified_by,omitempty"`
-	RejectionNote *string   `json:"rejection_note,omitempty"`
-	Description   string    `json:"description"`
-}
-
 // GetHistory godoc
 // @Summary Mendapatkan riwayat perubahan status prestasi
-// @Description Mendapatkan riwayat perubahan status dari sebuah prestasi berdasarkan ID prestasi di MongoDB
+// @Description Mendapatkan riwayat perubahan status dari sebuah prestasi berdasarkan ID prestasi di MongoDB.
+// @Description Setiap transisi (dari status, ke status, aktor, waktu, catatan) diambil dari log achievement_status_history, termasuk penolakan sebelumnya.
 // @Description Akses:
 // @Description - Admin: semua data
 // @Description - Mahasiswa: hanya achievement miliknya
@@ -57,20 +52,13 @@ type HistoryEvent struct {
 func (s *AchievementHistoryService) GetHistory(c *fiber.Ctx) error {
 	mongoAchievementID := c.Params("id")
 
-	role := c.Locals("role_name")
-	userID := c.Locals("user_id")
-
-	if role == nil || userID == nil {
-		return c.Status(401).JSON(fiber.Map{
-			"error": "unauthorized",
-		})
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
 	}
 
-	r := role.(string)
-	uid := userID.(string)
-
 	// ================= GET REFERENCE =================
-	ref, err := s.refRepo.GetByMongoAchievementID(mongoAchievementID)
+	ref, err := s.refRepo.GetByMongoAchievementID(c.UserContext(), mongoAchievementID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed to fetch achievement",
@@ -84,102 +72,63 @@ func (s *AchievementHistoryService) GetHistory(c *fiber.Ctx) error {
 	}
 
 	// ================= AUTHORIZATION =================
-	switch r {
-
-	case "Admin":
-		// full access
-		break
-
-	case "Mahasiswa":
-		student, err := s.studentRepo.GetByUserID(uid)
-		if err != nil || student == nil {
-			return c.Status(403).JSON(fiber.Map{
-				"error": "student not found",
-			})
-		}
-
-		if ref.StudentID != student.ID {
-			return c.Status(403).JSON(fiber.Map{
-				"error": "forbidden: not your achievement",
-			})
-		}
-
-	case "Dosen Wali":
-		lecturer, err := s.lecturerRepo.GetByUserID(uid)
-		if err != nil || lecturer == nil {
-			return c.Status(403).JSON(fiber.Map{
-				"error": "lecturer not found",
-			})
-		}
-
-		student, err := s.studentRepo.GetByID(ref.StudentID)
-		if err != nil || student == nil {
-			return c.Status(404).JSON(fiber.Map{
-				"error": "student not found",
-			})
-		}
-
-		if student.AdvisorID == nil || *student.AdvisorID != lecturer.ID {
-			return c.Status(403).JSON(fiber.Map{
-				"error": "forbidden: not your advisee",
-			})
-		}
-
-	default:
-		return c.Status(403).JSON(fiber.Map{
-			"error": "forbidden role",
-		})
+	if err := s.authz.Authorize(c.UserContext(), sub, policy.ActionRead, ref.StudentID); err != nil {
+		return policyError(c, err)
 	}
 
-	// ================= BUILD HISTORY =================
-	history := []HistoryEvent{}
+	// ================= HISTORY =================
+	history, err := s.refRepo.ListHistory(c.UserContext(), ref.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to fetch history",
+		})
+	}
 
-	history = append(history, HistoryEvent{
-		Status:      "draft",
-		Timestamp:   ref.CreatedAt,
-		Description: "Achievement created as draft",
+	return c.JSON(fiber.Map{
+		"success": true,
+		"current": ref.Status,
+		"data":    history,
 	})
+}
 
-	if ref.SubmittedAt != nil {
-		history = append(history, HistoryEvent{
-			Status:      "submitted",
-			Timestamp:   *ref.SubmittedAt,
-			Description: "Achievement submitted for verification",
-		})
+// GetDecisions godoc
+// @Summary Keputusan tiap tahap verifikasi prestasi
+// @Description Mendapatkan keputusan (approved/rejected) setiap tahap workflow verifikasi beserta aktor, role, revisi dan catatannya. Akses sama dengan riwayat status.
+// @Tags Achievement History
+// @Produce json
+// @Param id path string true "ID Prestasi di MongoDB"
+// @Success 200 {object} map[string]interface{} "Keputusan tahap verifikasi"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Achievement not found"
+// @Security Bearer
+// @Router /api/v1/achievements/{id}/decisions [get]
+func (s *AchievementHistoryService) GetDecisions(c *fiber.Ctx) error {
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
 	}
 
-	if ref.VerifiedAt != nil {
-		if ref.Status == "verified" {
-			history = append(history, HistoryEvent{
-				Status:      "verified",
-				Timestamp:   *ref.VerifiedAt,
-				VerifiedBy:  ref.VerifiedBy,
-				Description: "Achievement verified",
-			})
-		}
-
-		if ref.Status == "rejected" {
-			history = append(history, HistoryEvent{
-				Status:        "rejected",
-				Timestamp:     *ref.VerifiedAt,
-				VerifiedBy:    ref.VerifiedBy,
-				RejectionNote: ref.RejectionNote,
-				Description:   "Achievement rejected",
-			})
-		}
+	ref, err := s.refRepo.GetByMongoAchievementID(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch achievement"})
+	}
+	if ref == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
 	}
 
-	if ref.Status == "deleted" {
-		history = append(history, HistoryEvent{
-			Status:      "deleted",
-			Timestamp:   ref.UpdatedAt,
-			Description: "Achievement was deleted",
-		})
+	if err := s.authz.Authorize(c.UserContext(), sub, policy.ActionRead, ref.StudentID); err != nil {
+		return policyError(c, err)
+	}
+
+	decisions, err := s.refRepo.ListDecisions(c.UserContext(), ref.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch decisions"})
 	}
 
 	return c.JSON(fiber.Map{
-		"success": true,
-		"current": ref.Status,
-		"data":    history,
+		"success":       true,
+		"current":       ref.Status,
+		"current_stage": ref.CurrentStage,
+		"data":          decisions,
 	})
 }
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"mime/multipart"
@@ -9,6 +11,7 @@ import (
 	"time"
 
 	models "achievement_backend/app/model"
+	"achievement_backend/app/policy"
 	"achievement_backend/app/repository"
 
 	"github.com/gofiber/fiber/v2"
@@ -19,10 +22,41 @@ type AchievementMongoService struct {
 	refRepo      repository.AchievementReferenceRepository
 	studentRepo  repository.StudentRepository
 	lecturerRepo repository.LecturerRepository
+	authz        *policy.Policy
+	uow          repository.UnitOfWork
 }
 
-func isAdmin(c *fiber.Ctx) bool {
-	return c.Locals("role_name") == "Admin"
+// errNotDraft dikembalikan editDraft jika prestasi tidak lagi berstatus draft.
+var errNotDraft = errors.New("achievement is not a draft")
+
+// editDraft menjalankan fn (perubahan isi dokumen Mongo) selama baris
+// reference prestasi terkunci, dan hanya jika status di Postgres masih draft.
+// Status dokumen Mongo baru menyusul lewat outbox sehingga hanya dipakai untuk
+// dokumen lama tanpa reference. Submit dan transisi lain menunggu kunci ini,
+// jadi isi dan lampiran tidak bisa berubah setelah prestasi diajukan.
+func editDraft(
+	ctx context.Context,
+	uow repository.UnitOfWork,
+	refRepo repository.AchievementReferenceRepository,
+	item *models.Achievement,
+	fn func(ctx context.Context) error,
+) error {
+	return uow.Do(ctx, func(ctx context.Context) error {
+		ref, err := refRepo.LockByMongoAchievementID(ctx, item.ID.Hex())
+		if err != nil {
+			return fmt.Errorf("lock reference: %w", err)
+		}
+
+		status := item.Status
+		if ref != nil {
+			status = ref.Status
+		}
+		if status != models.StatusDraft {
+			return errNotDraft
+		}
+
+		return fn(ctx)
+	})
 }
 
 func CalculatePoints(req *models.CreateAchievementRequest) int {
@@ -87,12 +121,15 @@ func NewAchievementMongoService(
 	ref repository.AchievementReferenceRepository,
 	student repository.StudentRepository,
 	lecturer repository.LecturerRepository,
+	uow repository.UnitOfWork,
 ) *AchievementMongoService {
 	return &AchievementMongoService{
 		mongoRepo:    mongo,
 		refRepo:      ref,
 		studentRepo:  student,
 		lecturerRepo: lecturer,
+		authz:        policy.New(student, lecturer),
+		uow:          uow,
 	}
 }
 
@@ -114,144 +151,69 @@ func NewAchievementMongoService(
 // @Security Bearer
 // @Router /api/v1/achievements [get]
 func (s *AchievementMongoService) ListByRole(c *fiber.Ctx) error {
-    userID := c.Locals("user_id")
-    roleName := c.Locals("role_name")
-    if userID == nil || roleName == nil {
-        return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
-    }
-
-    uid := userID.(string)
-    role := roleName.(string)
-    ctx := c.Context()
-
-    page := c.QueryInt("page", 1)
-    limit := c.QueryInt("limit", 10)
-    offset := (page - 1) * limit
-
-    switch role {
-
-    // ======================= ADMIN ===========================
-    case "Admin":
-        refs, total, err := s.refRepo.GetAllWithPagination(limit, offset)
-        if err != nil {
-            return c.Status(500).JSON(fiber.Map{"error": "failed to fetch references"})
-        }
-
-        mongoIDs := []string{}
-        for _, r := range refs {
-            mongoIDs = append(mongoIDs, r.MongoAchievementID)
-        }
-
-        mDetails, _ := s.mongoRepo.GetManyByIDs(ctx, mongoIDs)
-
-        out := []fiber.Map{}
-        for _, r := range refs {
-            out = append(out, fiber.Map{
-                "reference": r,
-                "detail":    mDetails[r.MongoAchievementID],
-            })
-        }
-
-        return c.JSON(fiber.Map{
-            "success": true,
-            "data":    out,
-            "pagination": fiber.Map{
-                "page":  page,
-                "limit": limit,
-                "total": total,
-            },
-        })
-
-    // ======================= DOSEN WALI ===========================
-    case "Dosen Wali":
-        lecturer, err := s.lecturerRepo.GetByUserID(uid)
-        if err != nil || lecturer == nil {
-            return c.Status(404).JSON(fiber.Map{"error": "lecturer not found"})
-        }
-
-        students, err := s.studentRepo.GetByAdvisorID(lecturer.ID)
-        if err != nil {
-            return c.Status(500).JSON(fiber.Map{"error": "failed to load advisees"})
-        }
-
-        if len(students) == 0 {
-            return c.JSON(fiber.Map{
-                "success": true,
-                "data":    []interface{}{},
-                "pagination": fiber.Map{
-                    "page":  page,
-                    "limit": limit,
-                    "total": 0,
-                },
-            })
-        }
-
-        studentIDs := []string{}
-        for _, st := range students {
-            studentIDs = append(studentIDs, st.ID)
-        }
-
-        refs, total, err := s.refRepo.GetByAdviseesWithPagination(studentIDs, limit, offset)
-        if err != nil {
-            return c.Status(500).JSON(fiber.Map{"error": "failed to fetch achievements"})
-        }
-
-        mongoIDs := []string{}
-        for _, r := range refs {
-            mongoIDs = append(mongoIDs, r.MongoAchievementID)
-        }
-
-        mDetails, _ := s.mongoRepo.GetManyByIDs(ctx, mongoIDs)
-
-        out := []fiber.Map{}
-        for _, r := range refs {
-            out = append(out, fiber.Map{
-                "reference": r,
-                "detail":    mDetails[r.MongoAchievementID],
-            })
-        }
-
-        return c.JSON(fiber.Map{
-            "success": true,
-            "data":    out,
-            "pagination": fiber.Map{
-                "page":  page,
-                "limit": limit,
-                "total": total,
-            },
-        })
-
-    // ======================= MAHASISWA ===========================
-    case "Mahasiswa":
-        student, err := s.studentRepo.GetByUserID(uid)
-        if err != nil || student == nil {
-            return c.Status(404).JSON(fiber.Map{"error": "student not found"})
-        }
-
-        refs, err := s.refRepo.GetByStudentID(student.ID)
-        if err != nil {
-            return c.Status(500).JSON(fiber.Map{"error": "failed to fetch achievements"})
-        }
-
-        mongoIDs := []string{}
-        for _, r := range refs {
-            mongoIDs = append(mongoIDs, r.MongoAchievementID)
-        }
-
-        mDetails, _ := s.mongoRepo.GetManyByIDs(ctx, mongoIDs)
-
-        out := []fiber.Map{}
-        for _, r := range refs {
-            out = append(out, fiber.Map{
-                "reference": r,
-                "detail":    mDetails[r.MongoAchievementID],
-            })
-        }
-
-        return c.JSON(fiber.Map{"success": true, "data": out})
-    }
-
-    return c.Status(403).JSON(fiber.Map{"error": "invalid role"})
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
+	}
+
+	all, studentIDs, err := s.authz.StudentScope(c.UserContext(), sub, policy.ActionRead)
+	if err != nil {
+		return policyError(c, err)
+	}
+
+	ctx := c.UserContext()
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+	offset := (page - 1) * limit
+
+	if !all && len(studentIDs) == 0 {
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    []interface{}{},
+			"pagination": fiber.Map{
+				"page":  page,
+				"limit": limit,
+				"total": 0,
+			},
+		})
+	}
+
+	var refs []models.AchievementReference
+	var total int64
+	if all {
+		refs, total, err = s.refRepo.GetAllWithPagination(ctx, limit, offset)
+	} else {
+		refs, total, err = s.refRepo.GetByAdviseesWithPagination(ctx, studentIDs, limit, offset)
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch achievements"})
+	}
+
+	mongoIDs := []string{}
+	for _, r := range refs {
+		mongoIDs = append(mongoIDs, r.MongoAchievementID)
+	}
+
+	mDetails, _ := s.mongoRepo.GetManyByIDs(ctx, mongoIDs)
+
+	out := []fiber.Map{}
+	for _, r := range refs {
+		out = append(out, fiber.Map{
+			"reference": r,
+			"detail":    mDetails[r.MongoAchievementID],
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    out,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
 }
 
 // GetAchievementDetail godoc
@@ -274,65 +236,23 @@ func (s *AchievementMongoService) ListByRole(c *fiber.Ctx) error {
 // @Router /api/v1/achievements/{id} [get]
 func (s *AchievementMongoService) GetDetail(c *fiber.Ctx) error {
 	mongoID := c.Params("id")
-	ctx := c.Context()
+	ctx := c.UserContext()
 
-	userID := c.Locals("user_id")
-	roleName := c.Locals("role_name")
-
-	if userID == nil || roleName == nil {
-		return c.Status(fiber.StatusUnauthorized).
-			JSON(fiber.Map{"error": "unauthorized"})
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
 	}
 
-	uid := userID.(string)
-	role := roleName.(string)
-
 	// ===== Ambil reference (WAJIB untuk RBAC) =====
-	ref, err := s.refRepo.GetByMongoAchievementID(mongoID)
+	ref, err := s.refRepo.GetByMongoAchievementID(ctx, mongoID)
 	if err != nil || ref == nil {
 		return c.Status(404).
 			JSON(fiber.Map{"error": "achievement not found"})
 	}
 
 	// ===== RBAC CHECK =====
-	switch role {
-
-	case "Admin":
-		// full access
-
-	case "Mahasiswa":
-		student, err := s.studentRepo.GetByUserID(uid)
-		if err != nil || student == nil {
-			return c.Status(404).
-				JSON(fiber.Map{"error": "student not found"})
-		}
-
-		if ref.StudentID != student.ID {
-			return c.Status(fiber.StatusForbidden).
-				JSON(fiber.Map{"error": "access denied"})
-		}
-
-	case "Dosen Wali":
-		lecturer, err := s.lecturerRepo.GetByUserID(uid)
-		if err != nil || lecturer == nil {
-			return c.Status(404).
-				JSON(fiber.Map{"error": "lecturer not found"})
-		}
-
-		student, err := s.studentRepo.GetByID(ref.StudentID)
-		if err != nil || student == nil {
-			return c.Status(404).
-				JSON(fiber.Map{"error": "student not found"})
-		}
-
-		if student.AdvisorID == nil || *student.AdvisorID != lecturer.ID {
-			return c.Status(fiber.StatusForbidden).
-				JSON(fiber.Map{"error": "access denied"})
-		}
-
-	default:
-		return c.Status(fiber.StatusForbidden).
-			JSON(fiber.Map{"error": "invalid role"})
+	if err := s.authz.Authorize(ctx, sub, policy.ActionRead, ref.StudentID); err != nil {
+		return policyError(c, err)
 	}
 
 	// ===== Ambil detail dari MongoDB =====
@@ -381,22 +301,24 @@ func (s *AchievementMongoService) CreateDraft(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
 	}
 
-	uid := c.Locals("user_id").(string)
-	ctx := c.Context()
+	ctx := c.UserContext()
 
-	var studentID string
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
+	}
 
-	if isAdmin(c) {
-		if req.StudentID == nil {
-			return c.Status(400).JSON(fiber.Map{"error": "student_id required"})
-		}
+	// mahasiswa membuat untuk dirinya sendiri, role lain wajib menyebut student_id
+	studentID := sub.StudentID
+	if req.StudentID != nil {
 		studentID = *req.StudentID
-	} else {
-		student, _ := s.studentRepo.GetByUserID(uid)
-		if student == nil {
-			return c.Status(403).JSON(fiber.Map{"error": "student not found"})
-		}
-		studentID = student.ID
+	}
+	if studentID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "student_id required"})
+	}
+
+	if err := s.authz.Authorize(ctx, sub, policy.ActionCreate, studentID); err != nil {
+		return policyError(c, err)
 	}
 
 	points := CalculatePoints(&req)
@@ -406,7 +328,16 @@ func (s *AchievementMongoService) CreateDraft(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "failed to create"})
 	}
 
-	ref, _ := s.refRepo.Create(studentID, created.ID.Hex())
+	// saga: dokumen Mongo dibuat lebih dulu, lalu dibatalkan jika reference
+	// gagal dibuat. Dokumen yang gagal dibatalkan ditemukan rekonsiliasi.
+	ref, err := s.refRepo.Create(ctx, studentID, created.ID.Hex(), sub.UserID)
+	if err != nil {
+		log.Printf("[CreateDraft] create reference for %s error: %v", created.ID.Hex(), err)
+		if err := s.mongoRepo.Delete(ctx, created.ID.Hex()); err != nil {
+			log.Printf("[CreateDraft] compensate mongo %s error: %v", created.ID.Hex(), err)
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed to create"})
+	}
 
 	return c.Status(201).JSON(fiber.Map{
 		"success":   true,
@@ -432,41 +363,25 @@ func (s *AchievementMongoService) CreateDraft(c *fiber.Ctx) error {
 // @Router /api/v1/achievements/{id} [put]
 func (s *AchievementMongoService) UpdateDraft(c *fiber.Ctx) error {
 	id := c.Params("id")
-	role := c.Locals("role_name").(string)
-	uid := c.Locals("user_id").(string)
+
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
+	}
 
 	var req models.UpdateAchievementRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
 	}
 
-	item, err := s.mongoRepo.GetByID(c.Context(), id)
+	item, err := s.mongoRepo.GetByID(c.UserContext(), id)
 	if err != nil || item == nil {
 		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
 	}
 
-	// ===== hanya draft =====
-	if item.Status != models.StatusDraft {
-		return c.Status(400).JSON(fiber.Map{"error": "only draft can be updated"})
-	}
-
 	// ===== RBAC =====
-	switch role {
-
-	case "Admin":
-		// full akses → lanjut
-
-	case "Mahasiswa":
-		student, err := s.studentRepo.GetByUserID(uid)
-		if err != nil || student == nil {
-			return c.Status(403).JSON(fiber.Map{"error": "student not found"})
-		}
-		if item.StudentID != student.ID {
-			return c.Status(403).JSON(fiber.Map{"error": "not owner"})
-		}
-
-	default:
-		return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
+	if err := s.authz.Authorize(c.UserContext(), sub, policy.ActionUpdate, item.StudentID); err != nil {
+		return policyError(c, err)
 	}
 
 	// ===== hitung ulang points =====
@@ -480,9 +395,21 @@ func (s *AchievementMongoService) UpdateDraft(c *fiber.Ctx) error {
 	}
 	points := CalculatePoints(&recalc)
 
-	updated, err := s.mongoRepo.UpdateDraft(c.Context(), id, &req, points)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	// ===== hanya draft (status di Postgres) =====
+	var updated *models.Achievement
+	var editErr error
+	err = editDraft(c.UserContext(), s.uow, s.refRepo, item, func(ctx context.Context) error {
+		updated, editErr = s.mongoRepo.UpdateDraft(ctx, id, &req, points)
+		return editErr
+	})
+	switch {
+	case errors.Is(err, errNotDraft):
+		return c.Status(400).JSON(fiber.Map{"error": "only draft can be updated"})
+	case editErr != nil:
+		return c.Status(400).JSON(fiber.Map{"error": editErr.Error()})
+	case err != nil:
+		log.Printf("[UpdateDraft] update %s error: %v", id, err)
+		return c.Status(500).JSON(fiber.Map{"error": "failed to update achievement"})
 	}
 
 	return c.JSON(fiber.Map{
@@ -506,10 +433,12 @@ func (s *AchievementMongoService) UpdateDraft(c *fiber.Ctx) error {
 // @Router /api/v1/achievements/{id} [delete]
 func (s *AchievementMongoService) SoftDelete(c *fiber.Ctx) error {
 	id := c.Params("id")
-	ctx := c.Context()
+	ctx := c.UserContext()
 
-	role := c.Locals("role_name").(string)
-	userID := c.Locals("user_id").(string)
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
+	}
 
 	item, err := s.mongoRepo.GetByID(ctx, id)
 	if err != nil || item == nil {
@@ -518,45 +447,37 @@ func (s *AchievementMongoService) SoftDelete(c *fiber.Ctx) error {
 		})
 	}
 
-	// ================= ADMIN =================
-	if role == "Admin" {
-		goto DELETE
-	}
-
-	// ================= MAHASISWA =================
-	if role == "Mahasiswa" {
-		student, err := s.studentRepo.GetByUserID(userID)
-		if err != nil || student == nil {
-			return c.Status(403).JSON(fiber.Map{
-				"error": "student not found",
-			})
-		}
-
-		if item.StudentID != student.ID {
-			return c.Status(403).JSON(fiber.Map{
-				"error": "forbidden: not your achievement",
-			})
-		}
-
-		goto DELETE
+	if err := s.authz.Authorize(ctx, sub, policy.ActionDelete, item.StudentID); err != nil {
+		return policyError(c, err)
 	}
 
-	// ================= OTHERS (DOSEN WALI, DLL) =================
-	return c.Status(403).JSON(fiber.Map{
-		"error": "forbidden: role not allowed",
-	})
-
-DELETE:
-	// Soft delete Mongo
-	if err := s.mongoRepo.SoftDelete(ctx, id); err != nil {
+	ref, err := s.refRepo.GetByMongoAchievementID(ctx, id)
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed to delete achievement",
 		})
 	}
 
-	ref, err := s.refRepo.GetByMongoAchievementID(id)
-	if err == nil && ref != nil {
-		_ = s.refRepo.SoftDelete(ref.ID)
+	if ref == nil {
+		// dokumen lama tanpa reference: hanya ada di Mongo
+		if err := s.mongoRepo.SoftDelete(ctx, id); err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "failed to delete achievement",
+			})
+		}
+	} else {
+		// status di Postgres lebih dulu; Mongo menyusul lewat outbox
+		if err := s.refRepo.SoftDelete(ctx, ref.ID, sub.UserID); err != nil {
+			if err == sql.ErrNoRows {
+				return c.Status(400).JSON(fiber.Map{
+					"error": "only draft achievements can be deleted",
+				})
+			}
+			return c.Status(500).JSON(fiber.Map{
+				"error": "failed to delete achievement",
+			})
+		}
+		syncMongoStatus(ctx, s.mongoRepo, id, models.StatusDeleted)
 	}
 
 	return c.JSON(fiber.Map{
@@ -582,10 +503,12 @@ DELETE:
 // @Router /api/v1/achievements/{id}/attachments [post]
 func (s *AchievementMongoService) UpdateAttachments(c *fiber.Ctx) error {
 	id := c.Params("id")
-	ctx := c.Context()
+	ctx := c.UserContext()
 
-	role := c.Locals("role_name").(string)
-	uid := c.Locals("user_id").(string)
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
+	}
 
 	// ===== ambil achievement =====
 	item, err := s.mongoRepo.GetByID(ctx, id)
@@ -594,34 +517,13 @@ func (s *AchievementMongoService) UpdateAttachments(c *fiber.Ctx) error {
 	}
 
 	// ===== RBAC =====
-	switch role {
-
-	// ================= ADMIN FULL ACCESS =================
-	case "Admin":
-		// langsung lanjut, tanpa cek owner
-
-	// ================= MAHASISWA =================
-	case "Mahasiswa":
-		student, err := s.studentRepo.GetByUserID(uid)
-		if err != nil || student == nil {
-			return c.Status(403).JSON(fiber.Map{"error": "student not found"})
-		}
-		if item.StudentID != student.ID {
-			return c.Status(403).JSON(fiber.Map{"error": "not owner"})
-		}
-
-	// ================= ROLE LAIN =================
-	default:
-		return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
-	}
-
-	// ===== hanya draft yang boleh diubah =====
-	if item.Status != models.StatusDraft {
-		return c.Status(400).JSON(fiber.Map{"error": "only draft achievement can be updated"})
+	if err := s.authz.Authorize(ctx, sub, policy.ActionUpdate, item.StudentID); err != nil {
+		return policyError(c, err)
 	}
 
 	// ===== proses attachments =====
 	var attachments []models.Attachment
+	var files []*multipart.FileHeader
 
 	contentType := c.Get("Content-Type")
 	isMultipart := c.Is("multipart/form-data")
@@ -633,7 +535,6 @@ func (s *AchievementMongoService) UpdateAttachments(c *fiber.Ctx) error {
 			return c.Status(400).JSON(fiber.Map{"error": "invalid multipart form"})
 		}
 
-		var files []*multipart.FileHeader
 		for _, key := range []string{"attachments", "file", "files"} {
 			if f, ok := form.File[key]; ok {
 				files = f
@@ -641,6 +542,19 @@ func (s *AchievementMongoService) UpdateAttachments(c *fiber.Ctx) error {
 			}
 		}
 
+	} else {
+		var req models.UpdateAchievementAttachmentsRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid body"})
+		}
+		attachments = req.Attachments
+	}
+
+	// ===== hanya draft (status di Postgres) yang boleh diubah =====
+	var res *models.Achievement
+	var editErr error
+	err = editDraft(ctx, s.uow, s.refRepo, item, func(ctx context.Context) error {
+		// file baru disimpan setelah status dipastikan draft
 		for _, fh := range files {
 			dstName := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(fh.Filename))
 			dst := filepath.Join("uploads", dstName)
@@ -654,18 +568,17 @@ func (s *AchievementMongoService) UpdateAttachments(c *fiber.Ctx) error {
 			})
 		}
 
-	} else {
-		var req models.UpdateAchievementAttachmentsRequest
-		if err := c.BodyParser(&req); err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "invalid body"})
-		}
-		attachments = req.Attachments
-	}
-
-	// ===== update mongo =====
-	res, err := s.mongoRepo.UpdateAttachments(ctx, id, attachments)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		res, editErr = s.mongoRepo.UpdateAttachments(ctx, id, attachments)
+		return editErr
+	})
+	switch {
+	case errors.Is(err, errNotDraft):
+		return c.Status(400).JSON(fiber.Map{"error": "only draft achievement can be updated"})
+	case editErr != nil:
+		return c.Status(400).JSON(fiber.Map{"error": editErr.Error()})
+	case err != nil:
+		log.Printf("[UpdateAttachments] update %s error: %v", id, err)
+		return c.Status(500).JSON(fiber.Map{"error": "failed to update attachments"})
 	}
 
 	return c.JSON(fiber.Map{
@@ -676,7 +589,7 @@ func (s *AchievementMongoService) UpdateAttachments(c *fiber.Ctx) error {
 
 // GetAchievementsByStudent godoc
 // @Summary Mendapatkan prestasi berdasarkan mahasiswa
-// @Description Admin atau Dosen Wali melihat prestasi mahasiswa tertentu
+// @Description Admin melihat semua, Dosen Wali hanya mahasiswa bimbingan, Mahasiswa hanya miliknya sendiri
 // @Tags Student
 // @Accept json
 // @Produce json
@@ -689,34 +602,25 @@ func (s *AchievementMongoService) UpdateAttachments(c *fiber.Ctx) error {
 // @Router /api/v1/students/{id}/achievements [get]
 func (s *AchievementMongoService) GetByStudent(c *fiber.Ctx) error {
 	studentID := c.Params("id")
-	ctx := c.Context()
-	role := c.Locals("role_name").(string)
-	userID := c.Locals("user_id").(string)
+	ctx := c.UserContext()
 
-	// ================= ADMIN =================
-	if role != "Admin" && role != "Dosen Wali" {
-		return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
 	}
 
-	// ================= DOSEN WALI =================
-	if role == "Dosen Wali" {
-		lecturer, err := s.lecturerRepo.GetByUserID(userID)
-		if err != nil || lecturer == nil {
-			return c.Status(403).JSON(fiber.Map{"error": "lecturer not found"})
-		}
-
-		student, err := s.studentRepo.GetByID(studentID)
-		if err != nil || student == nil {
-			return c.Status(404).JSON(fiber.Map{"error": "student not found"})
-		}
+	student, err := s.studentRepo.GetByID(ctx, studentID)
+	if err != nil || student == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "student not found"})
+	}
 
-		if student.AdvisorID == nil || *student.AdvisorID != lecturer.ID {
-			return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
-		}
+	// ================= RBAC =================
+	if err := s.authz.Authorize(ctx, sub, policy.ActionRead, studentID); err != nil {
+		return policyError(c, err)
 	}
 
 	// ================= FETCH DATA =================
-	refs, err := s.refRepo.GetByStudentID(studentID)
+	refs, err := s.refRepo.GetByStudentID(ctx, studentID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch references"})
 	}
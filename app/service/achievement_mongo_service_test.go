@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
@@ -23,7 +25,21 @@ import (
 //
 
 type mockAchMongoRepo struct {
-	item *models.Achievement
+	item     *models.Achievement
+	versions []models.AchievementVersion
+}
+
+// archive meniru repository Mongo: isi sebelum perubahan disimpan sebagai
+// versi lalu nomor versi dokumen dinaikkan.
+func (m *mockAchMongoRepo) archive(replacedBy string) {
+	m.versions = append(m.versions, models.AchievementVersion{
+		AchievementID: m.item.ID.Hex(),
+		Version:       m.item.Version,
+		ReplacedBy:    replacedBy,
+		Snapshot:      *m.item,
+		ArchivedAt:    time.Now(),
+	})
+	m.item.Version++
 }
 
 func (m *mockAchMongoRepo) GetAll(ctx context.Context) ([]models.Achievement, error) {
@@ -70,8 +86,11 @@ func (m *mockAchMongoRepo) UpdateDraft(
 	points int,
 ) (*models.Achievement, error) {
 
+	m.archive(models.VersionReplacedByUpdate)
+
 	p := float64(points)
 	m.item.Title = req.Title
+	m.item.Description = req.Description
 	m.item.Points = &p
 	m.item.UpdatedAt = time.Now()
 	return m.item, nil
@@ -83,6 +102,8 @@ func (m *mockAchMongoRepo) UpdateAttachments(
 	attachments []models.Attachment,
 ) (*models.Achievement, error) {
 
+	m.archive(models.VersionReplacedByAttachments)
+
 	m.item.Attachments = attachments
 	return m.item, nil
 }
@@ -103,11 +124,57 @@ func (m *mockAchMongoRepo) GetManyByIDs(
 	}, nil
 }
 
+func (m *mockAchMongoRepo) GetManyDeletedByIDs(
+	ctx context.Context,
+	ids []string,
+) (map[string]models.Achievement, error) {
+
+	return map[string]models.Achievement{}, nil
+}
+
 func (m *mockAchMongoRepo) UpdateStatus(ctx context.Context, id string, status string) error {
 	m.item.Status = status
 	return nil
 }
 
+func (m *mockAchMongoRepo) Delete(ctx context.Context, id string) error {
+	m.item = nil
+	return nil
+}
+
+func (m *mockAchMongoRepo) ListStates(ctx context.Context) ([]models.AchievementDocState, error) {
+	return nil, nil
+}
+
+func (m *mockAchMongoRepo) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockAchMongoRepo) ListVersions(ctx context.Context, id string) ([]models.AchievementVersion, error) {
+	return m.versions, nil
+}
+
+func (m *mockAchMongoRepo) GetVersion(ctx context.Context, id string, version int) (*models.AchievementVersion, error) {
+	for i := range m.versions {
+		if m.versions[i].Version == version {
+			return &m.versions[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockAchMongoRepo) RestoreVersion(ctx context.Context, id string, version int) (*models.Achievement, error) {
+	old, _ := m.GetVersion(ctx, id, version)
+	snap := old.Snapshot
+
+	m.archive(models.VersionReplacedByRestore)
+	m.item.Title = snap.Title
+	m.item.Description = snap.Description
+	m.item.Attachments = snap.Attachments
+	m.item.Points = snap.Points
+	return m.item, nil
+}
+
 //
 // =======================================================
 // MOCK AchievementReferenceRepository (MINIMAL)
@@ -116,42 +183,62 @@ func (m *mockAchMongoRepo) UpdateStatus(ctx context.Context, id string, status s
 
 type mockAchRefRepo struct{}
 
-func (m *mockAchRefRepo) GetAll() ([]models.AchievementReference, error) {
+func (m *mockAchRefRepo) GetAll(ctx context.Context) ([]models.AchievementReference, error) {
 	return nil, nil
 }
 
-func (m *mockAchRefRepo) GetAllWithPagination(limit, offset int) ([]models.AchievementReference, int64, error) {
+func (m *mockAchRefRepo) GetAllWithPagination(ctx context.Context, limit, offset int) ([]models.AchievementReference, int64, error) {
 	return nil, 0, nil
 }
 
-func (m *mockAchRefRepo) GetByID(id string) (*models.AchievementReference, error) {
+func (m *mockAchRefRepo) GetByID(ctx context.Context, id string) (*models.AchievementReference, error) {
+	return nil, nil
+}
+
+func (m *mockAchRefRepo) GetByStudentID(ctx context.Context, studentID string) ([]models.AchievementReference, error) {
 	return nil, nil
 }
 
-func (m *mockAchRefRepo) GetByStudentID(studentID string) ([]models.AchievementReference, error) {
+func (m *mockAchRefRepo) GetByMongoAchievementID(ctx context.Context, id string) (*models.AchievementReference, error) {
 	return nil, nil
 }
 
-func (m *mockAchRefRepo) GetByMongoAchievementID(id string) (*models.AchievementReference, error) {
+func (m *mockAchRefRepo) LockByMongoAchievementID(ctx context.Context, id string) (*models.AchievementReference, error) {
 	return nil, nil
 }
 
-func (m *mockAchRefRepo) GetByAdviseesWithPagination(ids []string, limit, offset int) ([]models.AchievementReference, int64, error) {
+func (m *mockAchRefRepo) GetByAdviseesWithPagination(ctx context.Context, ids []string, limit, offset int) ([]models.AchievementReference, int64, error) {
 	return nil, 0, nil
 }
 
-func (m *mockAchRefRepo) Create(studentID, mongoID string) (*models.AchievementReference, error) {
+func (m *mockAchRefRepo) Create(ctx context.Context, studentID, mongoID, actorID string) (*models.AchievementReference, error) {
 	return nil, nil
 }
 
-func (m *mockAchRefRepo) Submit(id string) error { return nil }
-func (m *mockAchRefRepo) Verify(id, vid string) error {
+func (m *mockAchRefRepo) Submit(ctx context.Context, id, actorID string) error { return nil }
+func (m *mockAchRefRepo) Decide(ctx context.Context, id string, d *models.StageDecision) error {
 	return nil
 }
-func (m *mockAchRefRepo) Reject(id, vid, note string) error {
+func (m *mockAchRefRepo) ListDecisions(ctx context.Context, referenceID string) ([]models.StageDecision, error) {
+	return nil, nil
+}
+func (m *mockAchRefRepo) SoftDelete(ctx context.Context, id, actorID string) error { return nil }
+func (m *mockAchRefRepo) Revise(ctx context.Context, id, actorID string) error     { return nil }
+func (m *mockAchRefRepo) Withdraw(ctx context.Context, id, actorID string) error   { return nil }
+func (m *mockAchRefRepo) DeleteOrphan(ctx context.Context, id, from, note string) error {
 	return nil
 }
-func (m *mockAchRefRepo) SoftDelete(id string) error { return nil }
+func (m *mockAchRefRepo) ListHistory(ctx context.Context, referenceID string) ([]models.AchievementStatusHistory, error) {
+	return nil, nil
+}
+func (m *mockAchRefRepo) ListDeleted(ctx context.Context, studentIDs []string, limit, offset int) ([]models.AchievementReference, int64, error) {
+	return nil, 0, nil
+}
+func (m *mockAchRefRepo) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]models.AchievementReference, error) {
+	return nil, nil
+}
+func (m *mockAchRefRepo) Restore(ctx context.Context, id, actorID string) error { return nil }
+func (m *mockAchRefRepo) Purge(ctx context.Context, id string) error            { return nil }
 
 //
 // =======================================================
@@ -161,29 +248,29 @@ func (m *mockAchRefRepo) SoftDelete(id string) error { return nil }
 
 type mockAchStudentRepo struct{}
 
-func (m *mockAchStudentRepo) GetAll() ([]models.Student, error) { return nil, nil }
-func (m *mockAchStudentRepo) GetByID(id string) (*models.Student, error) {
+func (m *mockAchStudentRepo) GetAll(ctx context.Context) ([]models.Student, error) { return nil, nil }
+func (m *mockAchStudentRepo) GetByID(ctx context.Context, id string) (*models.Student, error) {
 	return &models.Student{
 		ID:        id,
 		AdvisorID: ptTr("lecturer-1"),
 	}, nil
 }
-func (m *mockAchStudentRepo) GetByStudentID(studentID string) (*models.Student, error) {
+func (m *mockAchStudentRepo) GetByStudentID(ctx context.Context, studentID string) (*models.Student, error) {
 	return nil, nil
 }
-func (m *mockAchStudentRepo) GetByUserID(userID string) (*models.Student, error) {
+func (m *mockAchStudentRepo) GetByUserID(ctx context.Context, userID string) (*models.Student, error) {
 	return &models.Student{ID: "student-1"}, nil
 }
-func (m *mockAchStudentRepo) GetByAdvisorID(advisorID string) ([]models.Student, error) {
+func (m *mockAchStudentRepo) GetByAdvisorID(ctx context.Context, advisorID string) ([]models.Student, error) {
 	return []models.Student{{ID: "student-1"}}, nil
 }
-func (m *mockAchStudentRepo) Create(req models.CreateStudentRequest) (*models.Student, error) {
+func (m *mockAchStudentRepo) Create(ctx context.Context, req models.CreateStudentRequest) (*models.Student, error) {
 	return nil, nil
 }
-func (m *mockAchStudentRepo) Update(id string, req models.UpdateStudentRequest) (*models.Student, error) {
+func (m *mockAchStudentRepo) Update(ctx context.Context, id string, req models.UpdateStudentRequest) (*models.Student, error) {
 	return nil, nil
 }
-func (m *mockAchStudentRepo) UpdateAdvisor(id string, advisorID string) error {
+func (m *mockAchStudentRepo) UpdateAdvisor(ctx context.Context, id string, advisorID string) error {
 	return nil
 }
 
@@ -195,20 +282,20 @@ func (m *mockAchStudentRepo) UpdateAdvisor(id string, advisorID string) error {
 
 type mockAchLecturerRepo struct{}
 
-func (m *mockAchLecturerRepo) GetAll() ([]models.Lecturer, error) { return nil, nil }
-func (m *mockAchLecturerRepo) GetByID(id string) (*models.Lecturer, error) {
+func (m *mockAchLecturerRepo) GetAll(ctx context.Context) ([]models.Lecturer, error) { return nil, nil }
+func (m *mockAchLecturerRepo) GetByID(ctx context.Context, id string) (*models.Lecturer, error) {
 	return nil, nil
 }
-func (m *mockAchLecturerRepo) GetByUserID(userID string) (*models.Lecturer, error) {
+func (m *mockAchLecturerRepo) GetByUserID(ctx context.Context, userID string) (*models.Lecturer, error) {
 	return &models.Lecturer{ID: "lecturer-1"}, nil
 }
-func (m *mockAchLecturerRepo) GetByLecturerID(id string) (*models.Lecturer, error) {
+func (m *mockAchLecturerRepo) GetByLecturerID(ctx context.Context, id string) (*models.Lecturer, error) {
 	return nil, nil
 }
-func (m *mockAchLecturerRepo) Create(req models.CreateLecturerRequest) (*models.Lecturer, error) {
+func (m *mockAchLecturerRepo) Create(ctx context.Context, req models.CreateLecturerRequest) (*models.Lecturer, error) {
 	return nil, nil
 }
-func (m *mockAchLecturerRepo) Update(id string, req models.UpdateLecturerRequest) (*models.Lecturer, error) {
+func (m *mockAchLecturerRepo) Update(ctx context.Context, id string, req models.UpdateLecturerRequest) (*models.Lecturer, error) {
 	return nil, nil
 }
 
@@ -236,6 +323,7 @@ func TestAchievementMongo_CreateDraft(t *testing.T) {
 		&mockAchRefRepo{},
 		&mockAchStudentRepo{},
 		&mockAchLecturerRepo{},
+		repository.NewMemoryUnitOfWork(),
 	)
 
 	app.Post("/api/v1/achievements", func(c *fiber.Ctx) error {
@@ -260,6 +348,47 @@ func TestAchievementMongo_CreateDraft(t *testing.T) {
 	assert.Equal(t, "Lomba Nasional", mongoRepo.item.Title)
 }
 
+// failingAchRefRepo gagal membuat reference, untuk menguji kompensasi saga.
+type failingAchRefRepo struct{ mockAchRefRepo }
+
+func (m *failingAchRefRepo) Create(ctx context.Context, studentID, mongoID, actorID string) (*models.AchievementReference, error) {
+	return nil, errors.New("postgres down")
+}
+
+func TestAchievementMongo_CreateDraft_CompensatesWhenReferenceFails(t *testing.T) {
+	app := fiber.New()
+
+	mongoRepo := &mockAchMongoRepo{}
+
+	service := NewAchievementMongoService(
+		mongoRepo,
+		&failingAchRefRepo{},
+		&mockAchStudentRepo{},
+		&mockAchLecturerRepo{},
+		repository.NewMemoryUnitOfWork(),
+	)
+
+	app.Post("/api/v1/achievements", func(c *fiber.Ctx) error {
+		c.Locals("role_name", "Mahasiswa")
+		c.Locals("user_id", "user-1")
+		return service.CreateDraft(c)
+	})
+
+	b, _ := json.Marshal(models.CreateAchievementRequest{
+		AchievementType: "competition",
+		Title:           "Lomba Nasional",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/achievements", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	// dokumen Mongo dibatalkan
+	assert.Nil(t, mongoRepo.item)
+}
+
 //
 // =======================================================
 // TEST: UPDATE DRAFT
@@ -283,6 +412,7 @@ func TestAchievementMongo_UpdateDraft(t *testing.T) {
 		&mockAchRefRepo{},
 		&mockAchStudentRepo{},
 		&mockAchLecturerRepo{},
+		repository.NewMemoryUnitOfWork(),
 	)
 
 	app.Put("/api/v1/achievements/:id", func(c *fiber.Ctx) error {
@@ -307,6 +437,64 @@ func TestAchievementMongo_UpdateDraft(t *testing.T) {
 	assert.Equal(t, "Updated Title", mongoRepo.item.Title)
 }
 
+// Status Mongo masih draft karena sinkronisasi outbox tertinggal, tetapi
+// reference di Postgres sudah diajukan: isi dan lampiran tidak boleh berubah.
+func TestAchievementMongo_EditGatedOnReferenceStatus(t *testing.T) {
+	app := fiber.New()
+
+	mongoID := primitive.NewObjectID()
+	mongoRepo := &mockAchMongoRepo{
+		item: &models.Achievement{
+			ID:        mongoID,
+			StudentID: "student-1",
+			Title:     "Lomba",
+			Status:    models.StatusDraft,
+		},
+	}
+	refRepo := &mockAchievementRefRepo{ref: &models.AchievementReference{
+		ID:                 "ref-1",
+		StudentID:          "student-1",
+		MongoAchievementID: mongoID.Hex(),
+		Status:             models.StatusSubmitted,
+	}}
+
+	service := NewAchievementMongoService(
+		mongoRepo,
+		refRepo,
+		&mockAchStudentRepo{},
+		&mockAchLecturerRepo{},
+		repository.NewMemoryUnitOfWork(),
+	)
+
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role_name", "Mahasiswa")
+		c.Locals("user_id", "user-1")
+		return c.Next()
+	})
+	app.Put("/api/v1/achievements/:id", service.UpdateDraft)
+	app.Post("/api/v1/achievements/:id/attachments", service.UpdateAttachments)
+
+	resp := doRequest(t, app, http.MethodPut, "/api/v1/achievements/"+mongoID.Hex(), models.UpdateAchievementRequest{Title: "Diubah"}, nil)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodPost, "/api/v1/achievements/"+mongoID.Hex()+"/attachments", models.UpdateAchievementAttachmentsRequest{
+		Attachments: []models.Attachment{{FileName: "lain.pdf", FileURL: "/uploads/lain.pdf"}},
+	}, nil)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	assert.Equal(t, "Lomba", mongoRepo.item.Title)
+	assert.Empty(t, mongoRepo.item.Attachments)
+
+	// setelah ditarik kembali ke draft di Postgres, boleh diubah walau
+	// status Mongo belum tersinkron
+	refRepo.ref.Status = models.StatusDraft
+	mongoRepo.item.Status = models.StatusSubmitted
+
+	resp = doRequest(t, app, http.MethodPut, "/api/v1/achievements/"+mongoID.Hex(), models.UpdateAchievementRequest{Title: "Diubah"}, nil)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "Diubah", mongoRepo.item.Title)
+}
+
 //
 // =======================================================
 // TEST: SOFT DELETE
@@ -331,6 +519,7 @@ func TestAchievementMongo_SoftDelete(t *testing.T) {
 		&mockAchRefRepo{},
 		&mockAchStudentRepo{},
 		&mockAchLecturerRepo{},
+		repository.NewMemoryUnitOfWork(),
 	)
 
 	app.Delete("/api/v1/achievements/:id", func(c *fiber.Ctx) error {
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+)
+
+// OutboxRelayConfig mengatur pemrosesan outbox status achievement.
+type OutboxRelayConfig struct {
+	// BatchSize adalah jumlah event yang diambil per putaran.
+	BatchSize int
+	// MaxAttempts adalah batas percobaan; event yang melewatinya dibiarkan
+	// dan akan muncul sebagai temuan rekonsiliasi.
+	MaxAttempts int
+	// RetryBase adalah jeda percobaan ulang pertama, berlipat dua setiap gagal
+	// sampai RetryMax.
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// ClaimLease adalah lama event yang diambil satu relay disembunyikan dari
+	// relay lain; harus lebih lama dari waktu memproses satu batch.
+	ClaimLease time.Duration
+	// Retention adalah lama event yang sudah diproses disimpan.
+	Retention time.Duration
+}
+
+func DefaultOutboxRelayConfig() OutboxRelayConfig {
+	return OutboxRelayConfig{
+		BatchSize:   100,
+		MaxAttempts: 10,
+		RetryBase:   30 * time.Second,
+		RetryMax:    time.Hour,
+		ClaimLease:  5 * time.Minute,
+		Retention:   7 * 24 * time.Hour,
+	}
+}
+
+// AchievementOutboxRelay menyalin status achievement_references ke dokumen
+// Mongo berdasarkan event outbox yang ditulis bersama perubahan statusnya.
+// Status diambil dari reference saat event diproses, sehingga event yang
+// diproses dua kali atau tidak berurutan tetap menghasilkan status terkini.
+type AchievementOutboxRelay struct {
+	outbox    repository.AchievementOutboxStore
+	refRepo   repository.AchievementReferenceRepository
+	mongoRepo repository.MongoAchievementRepository
+	cfg       OutboxRelayConfig
+	now       func() time.Time
+}
+
+func NewAchievementOutboxRelay(
+	outbox repository.AchievementOutboxStore,
+	refRepo repository.AchievementReferenceRepository,
+	mongoRepo repository.MongoAchievementRepository,
+	cfg OutboxRelayConfig,
+) *AchievementOutboxRelay {
+	return &AchievementOutboxRelay{
+		outbox:    outbox,
+		refRepo:   refRepo,
+		mongoRepo: mongoRepo,
+		cfg:       cfg,
+		now:       time.Now,
+	}
+}
+
+// apply menyamakan status dokumen Mongo dengan status reference saat ini.
+func (r *AchievementOutboxRelay) apply(ctx context.Context, ev *models.AchievementOutboxEvent) error {
+	ref, err := r.refRepo.GetByID(ctx, ev.ReferenceID)
+	if err != nil {
+		return err
+	}
+	if ref == nil {
+		return nil
+	}
+
+	err = r.mongoRepo.UpdateStatus(ctx, ref.MongoAchievementID, ref.Status)
+	if errors.Is(err, repository.ErrMongoAchievementNotFound) && ref.Status == models.StatusDeleted {
+		// dokumen sudah dibuang, tidak ada yang perlu disinkronkan
+		return nil
+	}
+	return err
+}
+
+func (r *AchievementOutboxRelay) backoff(attempts int) time.Duration {
+	d := r.cfg.RetryBase
+	for i := 0; i < attempts && d < r.cfg.RetryMax; i++ {
+		d *= 2
+	}
+	return min(d, r.cfg.RetryMax)
+}
+
+// Drain memproses event yang jatuh tempo sampai habis. Event diklaim per
+// batch sehingga beberapa relay (replika aplikasi dan cmd/reconcile) bisa
+// berjalan bersamaan tanpa memproses event yang sama. Mengembalikan jumlah
+// event yang berhasil dan yang gagal (dijadwalkan ulang).
+func (r *AchievementOutboxRelay) Drain(ctx context.Context) (int, int, error) {
+	done, failed := 0, 0
+	// event yang sudah diklaim atau gagal di putaran ini tidak diambil lagi karena available_at-nya mundur
+	for {
+		now := r.now()
+		events, err := r.outbox.Claim(ctx, now, now.Add(r.cfg.ClaimLease), r.cfg.MaxAttempts, r.cfg.BatchSize)
+		if err != nil {
+			return done, failed, err
+		}
+		if len(events) == 0 {
+			return done, failed, nil
+		}
+
+		for i := range events {
+			ev := &events[i]
+			if err := r.apply(ctx, ev); err != nil {
+				log.Printf("[Outbox] sync %s to %s failed (attempt %d): %v", ev.MongoAchievementID, ev.ToStatus, ev.Attempts+1, err)
+				if err := r.outbox.MarkFailed(ctx, ev.ID, err.Error(), r.now().Add(r.backoff(ev.Attempts))); err != nil {
+					return done, failed, err
+				}
+				failed++
+				continue
+			}
+
+			if err := r.outbox.MarkProcessed(ctx, ev.ID, r.now()); err != nil {
+				return done, failed, err
+			}
+			done++
+		}
+	}
+}
+
+// syncMongoStatus langsung menyalin status baru ke Mongo setelah transaksi
+// Postgres berhasil, agar response sudah mencerminkan status akhir. Jika
+// gagal, event outbox yang ditulis bersama perubahan status akan mengulanginya.
+func syncMongoStatus(ctx context.Context, mongoRepo repository.MongoAchievementRepository, mongoID, status string) {
+	if err := mongoRepo.UpdateStatus(ctx, mongoID, status); err != nil {
+		log.Printf("[Outbox] immediate sync %s to %s failed, relay will retry: %v", mongoID, status, err)
+	}
+}
+
+// StartAchievementOutboxRelay menjalankan goroutine yang secara berkala
+// memproses outbox dan menghapus event lama yang sudah diproses.
+// Panggil fungsi yang dikembalikan untuk menghentikan relay.
+func StartAchievementOutboxRelay(relay *AchievementOutboxRelay, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				n, failed, err := relay.Drain(context.Background())
+				if err != nil {
+					log.Printf("[Outbox] drain error: %v", err)
+					continue
+				}
+				if n > 0 || failed > 0 {
+					log.Printf("[Outbox] synced %d events, %d failed", n, failed)
+				}
+
+				if _, err := relay.outbox.DeleteProcessed(context.Background(), relay.now().Add(-relay.cfg.Retention)); err != nil {
+					log.Printf("[Outbox] cleanup error: %v", err)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
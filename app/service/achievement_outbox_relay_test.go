@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// =======================================================
+// FAKE STORE (Postgres reference + outbox, dokumen Mongo)
+// =======================================================
+//
+
+// syncRefRepo menyimpan reference per ID. Seperti repository Postgres,
+// setiap perubahan status ikut menulis event outbox.
+type syncRefRepo struct {
+	mockAchievementRefRepo
+	refs   map[string]*models.AchievementReference
+	outbox repository.AchievementOutboxStore
+}
+
+func (m *syncRefRepo) GetAll(ctx context.Context) ([]models.AchievementReference, error) {
+	list := []models.AchievementReference{}
+	for _, r := range m.refs {
+		list = append(list, *r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+func (m *syncRefRepo) GetByID(ctx context.Context, id string) (*models.AchievementReference, error) {
+	return m.refs[id], nil
+}
+
+func (m *syncRefRepo) Create(ctx context.Context, studentID, mongoID, actorID string) (*models.AchievementReference, error) {
+	ref := &models.AchievementReference{
+		ID:                 "ref-" + mongoID,
+		StudentID:          studentID,
+		MongoAchievementID: mongoID,
+		Status:             models.StatusDraft,
+	}
+	m.refs[ref.ID] = ref
+	return ref, m.outbox.Enqueue(ctx, ref.ID, mongoID, ref.Status)
+}
+
+func (m *syncRefRepo) DeleteOrphan(ctx context.Context, id, from, note string) error {
+	ref := m.refs[id]
+	if ref == nil || ref.Status != from {
+		return sql.ErrNoRows
+	}
+	ref.Status = models.StatusDeleted
+	return m.outbox.Enqueue(ctx, id, ref.MongoAchievementID, ref.Status)
+}
+
+// syncMongoRepo menyimpan ringkasan dokumen per ID; err membuat semua
+// UpdateStatus gagal.
+type syncMongoRepo struct {
+	mockMongoAchievementRepo
+	docs map[string]*models.AchievementDocState
+	err  error
+}
+
+func (m *syncMongoRepo) UpdateStatus(ctx context.Context, id string, status string) error {
+	if m.err != nil {
+		return m.err
+	}
+	doc := m.docs[id]
+	if doc == nil {
+		return repository.ErrMongoAchievementNotFound
+	}
+	doc.Status = status
+	doc.IsDeleted = status == models.StatusDeleted
+	return nil
+}
+
+func (m *syncMongoRepo) ListStates(ctx context.Context) ([]models.AchievementDocState, error) {
+	list := []models.AchievementDocState{}
+	for id, d := range m.docs {
+		cp := *d
+		cp.ID = id
+		list = append(list, cp)
+	}
+	return list, nil
+}
+
+func newSyncStores() (*syncRefRepo, *syncMongoRepo, repository.AchievementOutboxStore) {
+	outbox := repository.NewMemoryAchievementOutboxStore()
+	refs := &syncRefRepo{refs: map[string]*models.AchievementReference{}, outbox: outbox}
+	docs := &syncMongoRepo{docs: map[string]*models.AchievementDocState{}}
+	return refs, docs, outbox
+}
+
+//
+// =======================================================
+// RELAY
+// =======================================================
+//
+
+func TestOutboxRelay_RetriesUntilMongoAvailable(t *testing.T) {
+	refs, docs, outbox := newSyncStores()
+	refs.refs["r1"] = &models.AchievementReference{ID: "r1", MongoAchievementID: "m1", Status: models.StatusVerified}
+	docs.docs["m1"] = &models.AchievementDocState{Status: models.StatusSubmitted}
+	assert.NoError(t, outbox.Enqueue(context.Background(), "r1", "m1", models.StatusVerified))
+
+	relay := NewAchievementOutboxRelay(outbox, refs, docs, DefaultOutboxRelayConfig())
+
+	docs.err = errors.New("mongo down")
+	done, failed, err := relay.Drain(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, done)
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, models.StatusSubmitted, docs.docs["m1"].Status)
+
+	// belum jatuh tempo
+	due, _ := outbox.Claim(context.Background(), time.Now(), time.Now(), 10, 10)
+	assert.Empty(t, due)
+
+	// lease sampai "later" sehingga relay di bawah masih bisa mengambilnya
+	later := time.Now().Add(time.Hour)
+	due, _ = outbox.Claim(context.Background(), later, later, 10, 10)
+	if assert.Len(t, due, 1) {
+		assert.Equal(t, 1, due[0].Attempts)
+		assert.Equal(t, "mongo down", *due[0].LastError)
+	}
+
+	docs.err = nil
+	relay.now = func() time.Time { return later }
+	done, failed, err = relay.Drain(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, done)
+	assert.Equal(t, 0, failed)
+	assert.Equal(t, models.StatusVerified, docs.docs["m1"].Status)
+
+	due, _ = outbox.Claim(context.Background(), later, later, 10, 10)
+	assert.Empty(t, due)
+}
+
+// Dua relay (replika atau sweeper) yang berjalan bersamaan tidak mengambil
+// event yang sama; event yang diklaim relay yang mati muncul lagi setelah
+// lease habis.
+func TestOutboxRelay_ClaimIsExclusiveUntilLeaseExpires(t *testing.T) {
+	_, _, outbox := newSyncStores()
+	for _, id := range []string{"r1", "r2", "r3"} {
+		assert.NoError(t, outbox.Enqueue(context.Background(), id, "m"+id, models.StatusVerified))
+	}
+
+	now := time.Now()
+	lease := now.Add(5 * time.Minute)
+
+	first, err := outbox.Claim(context.Background(), now, lease, 10, 2)
+	assert.NoError(t, err)
+	second, err := outbox.Claim(context.Background(), now, lease, 10, 2)
+	assert.NoError(t, err)
+
+	if assert.Len(t, first, 2) && assert.Len(t, second, 1) {
+		assert.Equal(t, "r1", first[0].ReferenceID)
+		assert.Equal(t, "r2", first[1].ReferenceID)
+		assert.Equal(t, "r3", second[0].ReferenceID)
+	}
+
+	none, _ := outbox.Claim(context.Background(), now.Add(time.Minute), lease, 10, 10)
+	assert.Empty(t, none)
+
+	// relay pertama mati tanpa MarkProcessed
+	assert.NoError(t, outbox.MarkProcessed(context.Background(), second[0].ID, now))
+	again, _ := outbox.Claim(context.Background(), lease, lease.Add(5*time.Minute), 10, 10)
+	assert.Len(t, again, 2)
+}
+
+func TestOutboxRelay_UsesCurrentReferenceStatus(t *testing.T) {
+	refs, docs, outbox := newSyncStores()
+	refs.refs["r1"] = &models.AchievementReference{ID: "r1", MongoAchievementID: "m1", Status: models.StatusDraft}
+	refs.refs["r2"] = &models.AchievementReference{ID: "r2", MongoAchievementID: "m2", Status: models.StatusDeleted}
+	docs.docs["m1"] = &models.AchievementDocState{Status: models.StatusSubmitted}
+
+	// event lama (submitted) diproses setelah reference ditarik kembali ke draft
+	assert.NoError(t, outbox.Enqueue(context.Background(), "r1", "m1", models.StatusSubmitted))
+	// dokumen yang sudah dibuang tidak dianggap gagal
+	assert.NoError(t, outbox.Enqueue(context.Background(), "r2", "m2", models.StatusDeleted))
+
+	relay := NewAchievementOutboxRelay(outbox, refs, docs, DefaultOutboxRelayConfig())
+	done, failed, err := relay.Drain(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, done)
+	assert.Equal(t, 0, failed)
+	assert.Equal(t, models.StatusDraft, docs.docs["m1"].Status)
+}
+
+func TestOutboxRelay_Backoff(t *testing.T) {
+	relay := NewAchievementOutboxRelay(nil, nil, nil, OutboxRelayConfig{RetryBase: time.Second, RetryMax: 5 * time.Second})
+
+	assert.Equal(t, time.Second, relay.backoff(0))
+	assert.Equal(t, 4*time.Second, relay.backoff(2))
+	assert.Equal(t, 5*time.Second, relay.backoff(10))
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+)
+
+// reconcileMissingNote dicatat di history saat reference ditandai deleted
+// karena dokumennya hilang.
+const reconcileMissingNote = "reconcile: dokumen Mongo tidak ditemukan"
+
+// AchievementReconciler mencari dan memperbaiki ketidaksesuaian antara
+// achievement_references (Postgres) dan dokumen prestasi (Mongo). Postgres
+// adalah sumber status, Mongo sumber isi prestasi:
+//   - reference tanpa dokumen ditandai deleted,
+//   - dokumen tanpa reference dibuatkan reference draft,
+//   - status dokumen yang berbeda dijadwalkan ulang lewat outbox.
+type AchievementReconciler struct {
+	refRepo   repository.AchievementReferenceRepository
+	mongoRepo repository.MongoAchievementRepository
+	outbox    repository.AchievementOutboxStore
+	relay     *AchievementOutboxRelay
+	// grace melindungi dokumen yang baru dibuat dan reference-nya masih
+	// dalam proses CreateDraft.
+	grace time.Duration
+	now   func() time.Time
+}
+
+func NewAchievementReconciler(
+	refRepo repository.AchievementReferenceRepository,
+	mongoRepo repository.MongoAchievementRepository,
+	outbox repository.AchievementOutboxStore,
+	relay *AchievementOutboxRelay,
+	grace time.Duration,
+) *AchievementReconciler {
+	return &AchievementReconciler{
+		refRepo:   refRepo,
+		mongoRepo: mongoRepo,
+		outbox:    outbox,
+		relay:     relay,
+		grace:     grace,
+		now:       time.Now,
+	}
+}
+
+// Run membandingkan kedua store. Tanpa repair hanya melaporkan temuan.
+func (r *AchievementReconciler) Run(ctx context.Context, repair bool) (*models.ReconcileReport, error) {
+	refs, err := r.refRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := r.mongoRepo.ListStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ReconcileReport{
+		CheckedReferences: len(refs),
+		CheckedDocuments:  len(docs),
+		Repair:            repair,
+		Findings:          []models.ReconcileFinding{},
+	}
+
+	docByID := make(map[string]models.AchievementDocState, len(docs))
+	for _, d := range docs {
+		docByID[d.ID] = d
+	}
+	referenced := make(map[string]bool, len(refs))
+
+	for _, ref := range refs {
+		referenced[ref.MongoAchievementID] = true
+		doc, ok := docByID[ref.MongoAchievementID]
+
+		switch {
+		case !ok && ref.Status == models.StatusDeleted:
+			// dokumen sudah dibuang setelah dihapus
+			continue
+		case !ok:
+			f := models.ReconcileFinding{
+				Kind:               models.ReconcileMissingDocument,
+				ReferenceID:        ref.ID,
+				MongoAchievementID: ref.MongoAchievementID,
+				StudentID:          ref.StudentID,
+				ReferenceStatus:    ref.Status,
+			}
+			if repair {
+				r.fix(&f, r.refRepo.DeleteOrphan(ctx, ref.ID, ref.Status, reconcileMissingNote))
+			}
+			report.Findings = append(report.Findings, f)
+		case doc.Status != ref.Status || doc.IsDeleted != (ref.Status == models.StatusDeleted):
+			f := models.ReconcileFinding{
+				Kind:               models.ReconcileStatusMismatch,
+				ReferenceID:        ref.ID,
+				MongoAchievementID: ref.MongoAchievementID,
+				StudentID:          ref.StudentID,
+				ReferenceStatus:    ref.Status,
+				DocumentStatus:     doc.Status,
+			}
+			if repair {
+				r.fix(&f, r.outbox.Enqueue(ctx, ref.ID, ref.MongoAchievementID, ref.Status))
+			}
+			report.Findings = append(report.Findings, f)
+		}
+	}
+
+	cutoff := r.now().Add(-r.grace)
+	for _, doc := range docs {
+		if referenced[doc.ID] || doc.IsDeleted || doc.CreatedAt.After(cutoff) {
+			continue
+		}
+
+		f := models.ReconcileFinding{
+			Kind:               models.ReconcileOrphanDocument,
+			MongoAchievementID: doc.ID,
+			StudentID:          doc.StudentID,
+			DocumentStatus:     doc.Status,
+		}
+		if repair {
+			// reference baru selalu draft; outbox-nya menyamakan status dokumen
+			ref, err := r.refRepo.Create(ctx, doc.StudentID, doc.ID, "")
+			if ref != nil {
+				f.ReferenceID = ref.ID
+			}
+			r.fix(&f, err)
+		}
+		report.Findings = append(report.Findings, f)
+	}
+
+	if repair && r.relay != nil {
+		if _, _, err := r.relay.Drain(ctx); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+func (r *AchievementReconciler) fix(f *models.ReconcileFinding, err error) {
+	if err != nil {
+		log.Printf("[Reconcile] repair %s %s error: %v", f.Kind, f.MongoAchievementID, err)
+		f.Error = err.Error()
+		return
+	}
+	f.Repaired = true
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	models "achievement_backend/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupReconciler() (*AchievementReconciler, *syncRefRepo, *syncMongoRepo) {
+	refs, docs, outbox := newSyncStores()
+	old := time.Now().Add(-time.Hour)
+
+	// status berbeda
+	refs.refs["r1"] = &models.AchievementReference{ID: "r1", StudentID: "s1", MongoAchievementID: "m1", Status: models.StatusVerified}
+	docs.docs["m1"] = &models.AchievementDocState{StudentID: "s1", Status: models.StatusSubmitted, CreatedAt: old}
+	// reference tanpa dokumen
+	refs.refs["r2"] = &models.AchievementReference{ID: "r2", StudentID: "s1", MongoAchievementID: "m2", Status: models.StatusSubmitted}
+	// sudah dihapus dan dokumennya dibuang: bukan temuan
+	refs.refs["r3"] = &models.AchievementReference{ID: "r3", StudentID: "s1", MongoAchievementID: "m3", Status: models.StatusDeleted}
+	// konsisten
+	refs.refs["r4"] = &models.AchievementReference{ID: "r4", StudentID: "s1", MongoAchievementID: "m4", Status: models.StatusDraft}
+	docs.docs["m4"] = &models.AchievementDocState{StudentID: "s1", Status: models.StatusDraft, CreatedAt: old}
+	// dokumen tanpa reference
+	docs.docs["m5"] = &models.AchievementDocState{StudentID: "s2", Status: models.StatusDraft, CreatedAt: old}
+	// masih dalam grace period (CreateDraft belum selesai)
+	docs.docs["m6"] = &models.AchievementDocState{StudentID: "s2", Status: models.StatusDraft, CreatedAt: time.Now()}
+	// dokumen terhapus tanpa reference tidak dihidupkan lagi
+	docs.docs["m7"] = &models.AchievementDocState{StudentID: "s2", Status: models.StatusDeleted, IsDeleted: true, CreatedAt: old}
+
+	relay := NewAchievementOutboxRelay(outbox, refs, docs, DefaultOutboxRelayConfig())
+	return NewAchievementReconciler(refs, docs, outbox, relay, 10*time.Minute), refs, docs
+}
+
+func findingKinds(report *models.ReconcileReport) map[string]models.ReconcileFinding {
+	out := map[string]models.ReconcileFinding{}
+	for _, f := range report.Findings {
+		out[f.Kind] = f
+	}
+	return out
+}
+
+func TestReconciler_ReportOnly(t *testing.T) {
+	r, refs, docs := setupReconciler()
+
+	report, err := r.Run(context.Background(), false)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, report.CheckedReferences)
+	assert.Equal(t, 5, report.CheckedDocuments)
+	assert.Len(t, report.Findings, 3)
+
+	kinds := findingKinds(report)
+	assert.Equal(t, "m1", kinds[models.ReconcileStatusMismatch].MongoAchievementID)
+	assert.Equal(t, models.StatusSubmitted, kinds[models.ReconcileStatusMismatch].DocumentStatus)
+	assert.Equal(t, "r2", kinds[models.ReconcileMissingDocument].ReferenceID)
+	assert.Equal(t, "m5", kinds[models.ReconcileOrphanDocument].MongoAchievementID)
+	for _, f := range report.Findings {
+		assert.False(t, f.Repaired)
+	}
+
+	// tanpa -repair tidak ada yang berubah
+	assert.Equal(t, models.StatusSubmitted, docs.docs["m1"].Status)
+	assert.Equal(t, models.StatusSubmitted, refs.refs["r2"].Status)
+	assert.Len(t, refs.refs, 4)
+}
+
+func TestReconciler_Repair(t *testing.T) {
+	r, refs, docs := setupReconciler()
+
+	report, err := r.Run(context.Background(), true)
+	assert.NoError(t, err)
+	assert.Len(t, report.Findings, 3)
+	for _, f := range report.Findings {
+		assert.True(t, f.Repaired, f.Kind)
+	}
+
+	assert.Equal(t, models.StatusVerified, docs.docs["m1"].Status)
+	assert.Equal(t, models.StatusDeleted, refs.refs["r2"].Status)
+	if assert.NotNil(t, refs.refs["ref-m5"]) {
+		assert.Equal(t, "s2", refs.refs["ref-m5"].StudentID)
+		assert.Equal(t, models.StatusDraft, refs.refs["ref-m5"].Status)
+	}
+
+	// putaran berikutnya bersih
+	report, err = r.Run(context.Background(), false)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Findings)
+}
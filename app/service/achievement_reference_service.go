@@ -1,6 +1,13 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/policy"
 	"achievement_backend/app/repository"
 
 	"github.com/gofiber/fiber/v2"
@@ -11,6 +18,10 @@ type AchievementReferenceService struct {
 	mongoRepo     repository.MongoAchievementRepository
 	studentRepo   repository.StudentRepository
 	lecturerRepo  repository.LecturerRepository
+	revisions     repository.AchievementRevisionStore
+	workflows     repository.VerificationWorkflowStore
+	uow           repository.UnitOfWork
+	authz         *policy.Policy
 }
 
 
@@ -19,12 +30,19 @@ func NewAchievementReferenceService(
 	m repository.MongoAchievementRepository,
 	s repository.StudentRepository,
 	l repository.LecturerRepository,
+	revisions repository.AchievementRevisionStore,
+	workflows repository.VerificationWorkflowStore,
+	uow repository.UnitOfWork,
 ) *AchievementReferenceService {
 	return &AchievementReferenceService{
 		repo:         r,
 		mongoRepo:    m,
 		studentRepo:  s,
 		lecturerRepo: l,
+		revisions:    revisions,
+		workflows:    workflows,
+		uow:          uow,
+		authz:        policy.New(s, l),
 	}
 }
 
@@ -47,7 +65,7 @@ func (s *AchievementReferenceService) GetAll(c *fiber.Ctx) error {
 	offset := (page - 1) * limit
 
 	// Step 1 — Ambil metadata dari Postgres
-	refs, total, err := s.repo.GetAllWithPagination(limit, offset)
+	refs, total, err := s.repo.GetAllWithPagination(c.UserContext(), limit, offset)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch references"})
 	}
@@ -59,7 +77,7 @@ func (s *AchievementReferenceService) GetAll(c *fiber.Ctx) error {
 	}
 
 	// Step 3 — Fetch details dari MongoDB
-	ctx := c.Context()
+	ctx := c.UserContext()
 	mDetails, err := s.mongoRepo.GetManyByIDs(ctx, mongoIDs)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch mongo data"})
@@ -75,6 +93,7 @@ func (s *AchievementReferenceService) GetAll(c *fiber.Ctx) error {
 			"mongo_id":     ref.MongoAchievementID,
 			"submitted_at": ref.SubmittedAt,
 			"verified_at":  ref.VerifiedAt,
+			"revision":     ref.Revision,
 			"achievement":  mDetails[ref.MongoAchievementID],
 		})
 	}
@@ -104,7 +123,7 @@ func (s *AchievementReferenceService) GetAll(c *fiber.Ctx) error {
 func (s *AchievementReferenceService) GetByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	ref, err := s.repo.GetByID(id)
+	ref, err := s.repo.GetByID(c.UserContext(), id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch reference"})
 	}
@@ -112,7 +131,7 @@ func (s *AchievementReferenceService) GetByID(c *fiber.Ctx) error {
 		return c.Status(404).JSON(fiber.Map{"error": "reference not found"})
 	}
 
-	mDetail, _ := s.mongoRepo.GetByID(c.Context(), ref.MongoAchievementID)
+	mDetail, _ := s.mongoRepo.GetByID(c.UserContext(), ref.MongoAchievementID)
 
 	return c.JSON(fiber.Map{
 		"reference": ref,
@@ -123,7 +142,7 @@ func (s *AchievementReferenceService) GetByID(c *fiber.Ctx) error {
 func (s *AchievementReferenceService) GetByStudent(c *fiber.Ctx) error {
 	studentID := c.Params("student_id")
 
-	data, err := s.repo.GetByStudentID(studentID)
+	data, err := s.repo.GetByStudentID(c.UserContext(), studentID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch student references"})
 	}
@@ -142,52 +161,35 @@ func (s *AchievementReferenceService) GetByStudent(c *fiber.Ctx) error {
 // @Failure 403 {object} map[string]interface{} "Forbidden"
 // @Failure 404 {object} map[string]interface{} "Reference tidak ditemukan"
 // @Failure 400 {object} map[string]interface{} "Status tidak valid"
-// @Failure 500 {object} map[string]interface{} "Gagal sinkronisasi MongoDB"
+// @Failure 500 {object} map[string]interface{} "Server error"
 // @Security Bearer
 // @Router /api/v1/achievements/{id}/submit [post]
 func (s *AchievementReferenceService) Submit(c *fiber.Ctx) error {
-	role := c.Locals("role_name").(string)
 	mongoID := c.Params("id")
 
-	ref, err := s.repo.GetByMongoAchievementID(mongoID)
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
+	}
+
+	ref, err := s.repo.GetByMongoAchievementID(c.UserContext(), mongoID)
 	if err != nil || ref == nil {
 		return c.Status(404).JSON(fiber.Map{"error": "reference not found"})
 	}
 
 	// ================= RBAC =================
-	switch role {
-
-	case "Admin":
-		// full access
-
-	case "Mahasiswa":
-		userID := c.Locals("user_id").(string)
-		student, err := s.studentRepo.GetByUserID(userID)
-		if err != nil || student == nil {
-			return c.Status(403).JSON(fiber.Map{"error": "student not found"})
-		}
-		if ref.StudentID != student.ID {
-			return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
-		}
-
-	default:
-		return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
+	if err := s.authz.Authorize(c.UserContext(), sub, policy.ActionSubmit, ref.StudentID); err != nil {
+		return policyError(c, err)
 	}
 
 	// ================= UPDATE STATUS (ONCE) =================
-	if err := s.repo.Submit(ref.ID); err != nil {
+	if err := s.repo.Submit(c.UserContext(), ref.ID, sub.UserID); err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error": "only draft achievements can be submitted",
 		})
 	}
 
-	if err := s.mongoRepo.UpdateStatus(
-		c.Context(), mongoID, "submitted",
-	); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "failed to sync mongo status",
-		})
-	}
+	syncMongoStatus(c.UserContext(), s.mongoRepo, mongoID, models.StatusSubmitted)
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -199,87 +201,132 @@ func (s *AchievementReferenceService) Submit(c *fiber.Ctx) error {
 	})
 }
 
+// review menjalankan satu keputusan verifikasi: cek RBAC, status, dan role
+// tahap workflow yang sedang menunggu, lalu mencatat keputusan dan
+// menyinkronkan status Mongo jika status achievement berubah (outbox
+// mengulanginya jika sinkronisasi langsung gagal).
+func (s *AchievementReferenceService) review(
+	ctx context.Context,
+	sub *policy.Subject,
+	mongoID string,
+	decision string,
+	note *string,
+) (*models.AchievementReference, *models.StageDecision, error) {
+	ref, err := s.repo.GetByMongoAchievementID(ctx, mongoID)
+	if err != nil || ref == nil {
+		return nil, nil, errReferenceNotFound
+	}
+
+	if err := s.authz.Authorize(ctx, sub, policy.ActionVerify, ref.StudentID); err != nil {
+		return nil, nil, err
+	}
+
+	if ref.Status != models.StatusSubmitted {
+		return nil, nil, errNotSubmitted
+	}
+
+	item, err := s.mongoRepo.GetByID(ctx, mongoID)
+	if err != nil || item == nil {
+		return nil, nil, errReferenceNotFound
+	}
+
+	stages, err := stagesFor(ctx, s.workflows, item)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// workflow bisa diperpendek admin saat achievement sedang berjalan
+	last := len(stages) - 1
+	stage := stages[min(ref.CurrentStage, last)]
+
+	if err := s.authz.AuthorizeStage(sub, stage.RoleName); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s (%s)", errWrongStage, stage.Name, stage.RoleName)
+	}
+
+	d := &models.StageDecision{
+		Revision:  ref.Revision,
+		Stage:     ref.CurrentStage,
+		StageName: stage.Name,
+		RoleName:  sub.Role,
+		Decision:  decision,
+		ActorID:   sub.UserID,
+		Note:      note,
+		Final:     ref.CurrentStage >= last,
+	}
+	if err := s.repo.Decide(ctx, ref.ID, d); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, errStageDecided
+		}
+		return nil, nil, err
+	}
+
+	status := ""
+	switch {
+	case decision == models.DecisionRejected:
+		status = models.StatusRejected
+	case d.Final:
+		status = models.StatusVerified
+	}
+	if status != "" {
+		syncMongoStatus(ctx, s.mongoRepo, mongoID, status)
+	}
+
+	updated, err := s.repo.GetByID(ctx, ref.ID)
+	if err != nil || updated == nil {
+		return nil, nil, errReload
+	}
+
+	return updated, d, nil
+}
+
 // Verify godoc
 // @Summary Verify achievement
-// @Description Memverifikasi achievement yang sudah disubmit (Admin atau Dosen Wali)
+// @Description Menyetujui tahap verifikasi yang sedang berjalan. Tahap dan role penyetuju mengikuti workflow per jenis prestasi dan tingkat kompetisi; achievement baru menjadi verified setelah tahap terakhir. Tanpa workflow, satu tahap oleh Admin atau Dosen Wali.
 // @Tags Achievement Reference
 // @Accept json
 // @Produce json
 // @Param id path string true "Mongo Achievement ID"
-// @Success 200 {object} map[string]interface{} "Achievement berhasil diverifikasi"
-// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Success 200 {object} map[string]interface{} "Tahap disetujui atau achievement berhasil diverifikasi"
+// @Failure 403 {object} map[string]interface{} "Forbidden atau bukan giliran role ini"
 // @Failure 404 {object} map[string]interface{} "Reference tidak ditemukan"
 // @Failure 400 {object} map[string]interface{} "Status tidak valid"
-// @Failure 500 {object} map[string]interface{} "Gagal sinkronisasi MongoDB"
+// @Failure 409 {object} map[string]interface{} "Tahap sudah diputuskan"
+// @Failure 500 {object} map[string]interface{} "Server error"
 // @Security Bearer
 // @Router /api/v1/achievements/{id}/verify [post]
 func (s *AchievementReferenceService) Verify(c *fiber.Ctx) error {
-	role := c.Locals("role_name").(string)
-	mongoID := c.Params("id")
-	verifierID := c.Locals("user_id").(string)
-	ctx := c.Context()
-
-	ref, err := s.repo.GetByMongoAchievementID(mongoID)
-	if err != nil || ref == nil {
-		return c.Status(404).JSON(fiber.Map{"error": "reference not found"})
-	}
-
-	// RBAC
-	switch role {
-	case "Admin":
-	case "Dosen Wali":
-		lecturer, err := s.lecturerRepo.GetByUserID(verifierID)
-		if err != nil || lecturer == nil {
-			return c.Status(403).JSON(fiber.Map{"error": "lecturer not found"})
-		}
-		student, err := s.studentRepo.GetByID(ref.StudentID)
-		if err != nil || student == nil {
-			return c.Status(404).JSON(fiber.Map{"error": "student not found"})
-		}
-		if student.AdvisorID == nil || *student.AdvisorID != lecturer.ID {
-			return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
-		}
-	default:
-		return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
-	}
-
-	// UPDATE POSTGRES
-	if err := s.repo.Verify(ref.ID, verifierID); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "only submitted achievements can be verified",
-		})
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
 	}
 
-	// SYNC MONGO
-	if err := s.mongoRepo.UpdateStatus(ctx, mongoID, "verified"); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "failed to sync mongo status",
-		})
+	updatedRef, d, err := s.review(c.UserContext(), sub, c.Params("id"), models.DecisionApproved, nil)
+	if err != nil {
+		return reviewError(c, err)
 	}
 
-	// 🔥 RELOAD DATA (INI KUNCINYA)
-	updatedRef, err := s.repo.GetByID(ref.ID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "failed to reload reference",
-		})
+	message := "achievement verified"
+	if !d.Final {
+		message = "verification stage approved"
 	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
-		"message": "achievement verified",
+		"message": message,
 		"data": fiber.Map{
-			"id":          updatedRef.ID,
-			"status":      updatedRef.Status,
-			"verified_at": updatedRef.VerifiedAt,
-			"verified_by": updatedRef.VerifiedBy,
+			"id":            updatedRef.ID,
+			"status":        updatedRef.Status,
+			"current_stage": updatedRef.CurrentStage,
+			"decision":      d,
+			"verified_at":   updatedRef.VerifiedAt,
+			"verified_by":   updatedRef.VerifiedBy,
 		},
 	})
 }
 
 // Reject godoc
 // @Summary Reject achievement
-// @Description Menolak achievement dengan catatan penolakan (Admin atau Dosen Wali)
+// @Description Menolak achievement dengan catatan penolakan pada tahap verifikasi yang sedang berjalan (role tahap tersebut atau Admin)
 // @Tags Achievement Reference
 // @Accept json
 // @Produce json
@@ -287,16 +334,17 @@ func (s *AchievementReferenceService) Verify(c *fiber.Ctx) error {
 // @Param body body object true "Rejection note" example({"rejection_note":"Data tidak valid"})
 // @Success 200 {object} map[string]interface{} "Achievement berhasil ditolak"
 // @Failure 400 {object} map[string]interface{} "Rejection note wajib diisi"
-// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 403 {object} map[string]interface{} "Forbidden atau bukan giliran role ini"
 // @Failure 404 {object} map[string]interface{} "Reference tidak ditemukan"
-// @Failure 500 {object} map[string]interface{} "Gagal sinkronisasi MongoDB"
+// @Failure 409 {object} map[string]interface{} "Tahap sudah diputuskan"
+// @Failure 500 {object} map[string]interface{} "Server error"
 // @Security Bearer
 // @Router /api/v1/achievements/{id}/reject [post]
 func (s *AchievementReferenceService) Reject(c *fiber.Ctx) error {
-	role := c.Locals("role_name").(string)
-	mongoID := c.Params("id")
-	verifierID := c.Locals("user_id").(string)
-	ctx := c.Context()
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
+	}
 
 	var req struct {
 		RejectionNote string `json:"rejection_note"`
@@ -305,57 +353,303 @@ func (s *AchievementReferenceService) Reject(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "rejection_note required"})
 	}
 
-	ref, err := s.repo.GetByMongoAchievementID(mongoID)
+	updatedRef, d, err := s.review(c.UserContext(), sub, c.Params("id"), models.DecisionRejected, &req.RejectionNote)
+	if err != nil {
+		return reviewError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "achievement rejected",
+		"data": fiber.Map{
+			"id":             updatedRef.ID,
+			"status":         updatedRef.Status,
+			"rejection_note": updatedRef.RejectionNote,
+			"decision":       d,
+		},
+	})
+}
+
+// Revise godoc
+// @Summary Revisi achievement yang ditolak
+// @Description Membuka lagi achievement berstatus rejected menjadi draft agar bisa diedit dan disubmit ulang (Mahasiswa pemilik atau Admin). Nomor revisi bertambah, catatan penolakan tetap terlihat, dan isi yang ditolak disimpan untuk perbandingan.
+// @Tags Achievement Reference
+// @Produce json
+// @Param id path string true "Mongo Achievement ID"
+// @Success 200 {object} map[string]interface{} "Achievement kembali menjadi draft"
+// @Failure 400 {object} map[string]interface{} "Status tidak valid"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Reference tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "Server error"
+// @Security Bearer
+// @Router /api/v1/achievements/{id}/revise [post]
+func (s *AchievementReferenceService) Revise(c *fiber.Ctx) error {
+	mongoID := c.Params("id")
+	ctx := c.UserContext()
+
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
+	}
+
+	ref, err := s.repo.GetByMongoAchievementID(ctx, mongoID)
 	if err != nil || ref == nil {
 		return c.Status(404).JSON(fiber.Map{"error": "reference not found"})
 	}
 
-	// RBAC
-	switch role {
-	case "Admin":
-	case "Dosen Wali":
-		lecturer, err := s.lecturerRepo.GetByUserID(verifierID)
-		if err != nil || lecturer == nil {
-			return c.Status(403).JSON(fiber.Map{"error": "lecturer not found"})
-		}
-		student, err := s.studentRepo.GetByID(ref.StudentID)
-		if err != nil || student == nil {
-			return c.Status(404).JSON(fiber.Map{"error": "student not found"})
-		}
-		if student.AdvisorID == nil || *student.AdvisorID != lecturer.ID {
-			return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
-		}
-	default:
-		return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
+	// RBAC — yang boleh submit boleh merevisi
+	if err := s.authz.Authorize(ctx, sub, policy.ActionSubmit, ref.StudentID); err != nil {
+		return policyError(c, err)
 	}
 
-	// UPDATE POSTGRES
-	if err := s.repo.Reject(ref.ID, verifierID, req.RejectionNote); err != nil {
+	if ref.Status != models.StatusRejected {
 		return c.Status(400).JSON(fiber.Map{
-			"error": "only submitted achievements can be rejected",
+			"error": "only rejected achievements can be revised",
 		})
 	}
 
-	if err := s.mongoRepo.UpdateStatus(ctx, mongoID, "rejected"); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "failed to sync mongo status",
+	item, err := s.mongoRepo.GetByID(ctx, mongoID)
+	if err != nil || item == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
+	}
+
+	// isi yang ditolak disimpan bersama kenaikan revisi dalam satu transaksi;
+	// baris revisi yatim akan membuat percobaan ulang bentrok di
+	// UNIQUE(reference_id, revision)
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.revisions.Save(ctx, &models.AchievementRevision{
+			ReferenceID:   ref.ID,
+			Revision:      ref.Revision,
+			Snapshot:      *item,
+			RejectionNote: ref.RejectionNote,
+			ReviewedBy:    ref.VerifiedBy,
+			ReviewedAt:    ref.VerifiedAt,
+			CreatedBy:     sub.UserID,
+		}); err != nil {
+			return fmt.Errorf("save revision: %w", err)
+		}
+		return s.repo.Revise(ctx, ref.ID, sub.UserID)
+	})
+	if err == sql.ErrNoRows {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "only rejected achievements can be revised",
 		})
 	}
+	if err != nil {
+		log.Printf("[Revise] revise %s error: %v", ref.ID, err)
+		return c.Status(500).JSON(fiber.Map{"error": "failed to revise achievement"})
+	}
 
-	updatedRef, err := s.repo.GetByID(ref.ID)
+	syncMongoStatus(ctx, s.mongoRepo, mongoID, models.StatusDraft)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "achievement reopened for revision",
+		"data": fiber.Map{
+			"id":             ref.ID,
+			"status":         models.StatusDraft,
+			"revision":       ref.Revision + 1,
+			"rejection_note": ref.RejectionNote,
+		},
+	})
+}
+
+// Changes godoc
+// @Summary Perubahan sejak review terakhir
+// @Description Menampilkan nomor revisi, catatan penolakan terakhir dan field yang berubah dibanding isi yang ditolak. changes kosong jika achievement belum pernah direvisi.
+// @Tags Achievement Reference
+// @Produce json
+// @Param id path string true "Mongo Achievement ID"
+// @Success 200 {object} models.AchievementChanges "Perubahan sejak review terakhir"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Reference tidak ditemukan"
+// @Security Bearer
+// @Router /api/v1/achievements/{id}/changes [get]
+func (s *AchievementReferenceService) Changes(c *fiber.Ctx) error {
+	mongoID := c.Params("id")
+
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
+	}
+
+	ref, err := s.repo.GetByMongoAchievementID(c.UserContext(), mongoID)
+	if err != nil || ref == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "reference not found"})
+	}
+
+	if err := s.authz.Authorize(c.UserContext(), sub, policy.ActionRead, ref.StudentID); err != nil {
+		return policyError(c, err)
+	}
+
+	out := models.AchievementChanges{
+		Revision: ref.Revision,
+		Changes:  []models.AchievementFieldChange{},
+	}
+
+	rev, err := s.revisions.Latest(c.UserContext(), ref.ID)
+	if err == sql.ErrNoRows {
+		return c.JSON(fiber.Map{"success": true, "data": out})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch revision"})
+	}
+
+	item, err := s.mongoRepo.GetByID(c.UserContext(), mongoID)
+	if err != nil || item == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
+	}
+
+	changes, err := diffAchievements(&rev.Snapshot, item)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to compare revisions"})
+	}
+
+	out.ReviewedRevision = rev.Revision
+	out.RejectionNote = rev.RejectionNote
+	out.ReviewedBy = rev.ReviewedBy
+	out.ReviewedAt = rev.ReviewedAt
+	out.Changes = changes
+
+	return c.JSON(fiber.Map{"success": true, "data": out})
+}
+
+// Withdraw godoc
+// @Summary Tarik kembali achievement yang sudah disubmit
+// @Description Mengembalikan achievement berstatus submitted menjadi draft selama belum diverifikasi atau ditolak (Mahasiswa pemilik atau Admin)
+// @Tags Achievement Reference
+// @Produce json
+// @Param id path string true "Mongo Achievement ID"
+// @Success 200 {object} map[string]interface{} "Achievement kembali menjadi draft"
+// @Failure 400 {object} map[string]interface{} "Status tidak valid"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Reference tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "Server error"
+// @Security Bearer
+// @Router /api/v1/achievements/{id}/withdraw [post]
+func (s *AchievementReferenceService) Withdraw(c *fiber.Ctx) error {
+	mongoID := c.Params("id")
+
+	sub, err := subjectFromCtx(c, s.authz)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "failed to reload reference",
+		return policyError(c, err)
+	}
+
+	ref, err := s.repo.GetByMongoAchievementID(c.UserContext(), mongoID)
+	if err != nil || ref == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "reference not found"})
+	}
+
+	// RBAC — hanya pemilik (atau admin) yang bisa menarik submit-nya
+	if err := s.authz.Authorize(c.UserContext(), sub, policy.ActionSubmit, ref.StudentID); err != nil {
+		return policyError(c, err)
+	}
+
+	// UPDATE hanya berhasil selama status masih submitted
+	if err := s.repo.Withdraw(c.UserContext(), ref.ID, sub.UserID); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "only submitted achievements that are not yet reviewed can be withdrawn",
 		})
 	}
 
+	syncMongoStatus(c.UserContext(), s.mongoRepo, mongoID, models.StatusDraft)
+
 	return c.JSON(fiber.Map{
 		"success": true,
-		"message": "achievement rejected",
+		"message": "achievement withdrawn",
 		"data": fiber.Map{
-			"id":             updatedRef.ID,
-			"status":         updatedRef.Status,
-			"rejection_note": updatedRef.RejectionNote,
+			"id":     ref.ID,
+			"status": models.StatusDraft,
+		},
+	})
+}
+
+// bulkReviewMax membatasi jumlah item per request bulk verify/reject.
+const bulkReviewMax = 100
+
+// bulkReview menjalankan review untuk setiap ID secara terpisah: setiap item
+// punya transaksinya sendiri sehingga item yang gagal tidak membatalkan atau
+// menahan item lain.
+func (s *AchievementReferenceService) bulkReview(c *fiber.Ctx, decision string) error {
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
+	}
+
+	var req models.BulkReviewRequest
+	if err := c.BodyParser(&req); err != nil || len(req.IDs) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ids required"})
+	}
+	if len(req.IDs) > bulkReviewMax {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("at most %d ids per request", bulkReviewMax)})
+	}
+
+	var note *string
+	if decision == models.DecisionRejected {
+		if req.RejectionNote == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "rejection_note required"})
+		}
+		note = &req.RejectionNote
+	}
+
+	results := []models.BulkReviewResult{}
+	seen := map[string]bool{}
+	succeeded := 0
+
+	for _, id := range req.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		res := models.BulkReviewResult{ID: id}
+		ref, _, err := s.review(c.UserContext(), sub, id, decision, note)
+		if err != nil {
+			res.Code, res.Error = reviewStatus(err)
+		} else {
+			res.Success, res.Code = true, 200
+			res.Status, res.CurrentStage = ref.Status, ref.CurrentStage
+			succeeded++
+		}
+		results = append(results, res)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"succeeded": succeeded,
+			"failed":    len(results) - succeeded,
+			"results":   results,
 		},
 	})
 }
+
+// BulkVerify godoc
+// @Summary Bulk verify achievements
+// @Description Menyetujui tahap verifikasi yang sedang berjalan untuk banyak achievement sekaligus (maksimal 100 ID). Setiap item dicek dan diproses sendiri seperti /verify; hasil sukses atau gagal dilaporkan per ID.
+// @Tags Achievement Reference
+// @Accept json
+// @Produce json
+// @Param body body models.BulkReviewRequest true "Daftar Mongo Achievement ID"
+// @Success 200 {object} map[string]interface{} "Hasil per ID"
+// @Failure 400 {object} map[string]interface{} "Input tidak valid"
+// @Security Bearer
+// @Router /api/v1/achievements/bulk-verify [post]
+func (s *AchievementReferenceService) BulkVerify(c *fiber.Ctx) error {
+	return s.bulkReview(c, models.DecisionApproved)
+}
+
+// BulkReject godoc
+// @Summary Bulk reject achievements
+// @Description Menolak banyak achievement sekaligus dengan satu catatan penolakan (maksimal 100 ID). Setiap item dicek dan diproses sendiri seperti /reject; hasil sukses atau gagal dilaporkan per ID.
+// @Tags Achievement Reference
+// @Accept json
+// @Produce json
+// @Param body body models.BulkReviewRequest true "Daftar Mongo Achievement ID dan catatan penolakan"
+// @Success 200 {object} map[string]interface{} "Hasil per ID"
+// @Failure 400 {object} map[string]interface{} "Input tidak valid"
+// @Security Bearer
+// @Router /api/v1/achievements/bulk-reject [post]
+func (s *AchievementReferenceService) BulkReject(c *fiber.Ctx) error {
+	return s.bulkReview(c, models.DecisionRejected)
+}
@@ -3,14 +3,18 @@ package service
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 )
@@ -22,59 +26,129 @@ import (
 //
 
 type mockAchievementRefRepo struct {
-	ref *models.AchievementReference
+	ref       *models.AchievementReference
+	decisions []models.StageDecision
 }
 
-func (m *mockAchievementRefRepo) GetAll() ([]models.AchievementReference, error) {
+func (m *mockAchievementRefRepo) GetAll(ctx context.Context) ([]models.AchievementReference, error) {
 	return nil, nil
 }
 
-func (m *mockAchievementRefRepo) GetAllWithPagination(limit, offset int) ([]models.AchievementReference, int64, error) {
+func (m *mockAchievementRefRepo) GetAllWithPagination(ctx context.Context, limit, offset int) ([]models.AchievementReference, int64, error) {
 	return []models.AchievementReference{*m.ref}, 1, nil
 }
 
-func (m *mockAchievementRefRepo) GetByID(id string) (*models.AchievementReference, error) {
+func (m *mockAchievementRefRepo) GetByID(ctx context.Context, id string) (*models.AchievementReference, error) {
 	return m.ref, nil
 }
 
-func (m *mockAchievementRefRepo) GetByStudentID(studentID string) ([]models.AchievementReference, error) {
+func (m *mockAchievementRefRepo) GetByStudentID(ctx context.Context, studentID string) ([]models.AchievementReference, error) {
 	return []models.AchievementReference{*m.ref}, nil
 }
 
-func (m *mockAchievementRefRepo) GetByMongoAchievementID(mongoID string) (*models.AchievementReference, error) {
+func (m *mockAchievementRefRepo) GetByMongoAchievementID(ctx context.Context, mongoID string) (*models.AchievementReference, error) {
 	return m.ref, nil
 }
 
-func (m *mockAchievementRefRepo) GetByAdviseesWithPagination(ids []string, limit, offset int) ([]models.AchievementReference, int64, error) {
+func (m *mockAchievementRefRepo) LockByMongoAchievementID(ctx context.Context, mongoID string) (*models.AchievementReference, error) {
+	return m.ref, nil
+}
+
+func (m *mockAchievementRefRepo) GetByAdviseesWithPagination(ctx context.Context, ids []string, limit, offset int) ([]models.AchievementReference, int64, error) {
 	return nil, 0, nil
 }
 
-func (m *mockAchievementRefRepo) Create(studentID, mongoID string) (*models.AchievementReference, error) {
+func (m *mockAchievementRefRepo) Create(ctx context.Context, studentID, mongoID, actorID string) (*models.AchievementReference, error) {
 	return m.ref, nil
 }
 
-func (m *mockAchievementRefRepo) Submit(id string) error {
+func (m *mockAchievementRefRepo) Submit(ctx context.Context, id, actorID string) error {
 	now := time.Now()
 	m.ref.Status = models.StatusSubmitted
 	m.ref.SubmittedAt = &now
+	m.ref.CurrentStage = 0
 	return nil
 }
 
-func (m *mockAchievementRefRepo) Verify(id, verifierID string) error {
+func (m *mockAchievementRefRepo) Decide(ctx context.Context, id string, d *models.StageDecision) error {
+	if m.ref.Status != models.StatusSubmitted || m.ref.CurrentStage != d.Stage {
+		return sql.ErrNoRows
+	}
+
 	now := time.Now()
-	m.ref.Status = models.StatusVerified
-	m.ref.VerifiedAt = &now
-	m.ref.VerifiedBy = &verifierID
+	switch {
+	case d.Decision == models.DecisionRejected:
+		m.ref.Status = models.StatusRejected
+		m.ref.RejectionNote = d.Note
+		m.ref.VerifiedAt = &now
+		m.ref.VerifiedBy = &d.ActorID
+	case d.Final:
+		m.ref.Status = models.StatusVerified
+		m.ref.VerifiedAt = &now
+		m.ref.VerifiedBy = &d.ActorID
+	default:
+		m.ref.CurrentStage++
+	}
+
+	m.decisions = append(m.decisions, *d)
+	return nil
+}
+
+func (m *mockAchievementRefRepo) ListDecisions(ctx context.Context, referenceID string) ([]models.StageDecision, error) {
+	return m.decisions, nil
+}
+
+func (m *mockAchievementRefRepo) SoftDelete(ctx context.Context, id, actorID string) error {
+	return nil
+}
+
+func (m *mockAchievementRefRepo) Revise(ctx context.Context, id, actorID string) error {
+	if m.ref.Status != models.StatusRejected {
+		return sql.ErrNoRows
+	}
+	m.ref.Status = models.StatusDraft
+	m.ref.Revision++
 	return nil
 }
 
-func (m *mockAchievementRefRepo) Reject(id, verifierID, note string) error {
-	m.ref.Status = models.StatusRejected
-	m.ref.RejectionNote = &note
+func (m *mockAchievementRefRepo) Withdraw(ctx context.Context, id, actorID string) error {
+	if m.ref.Status != models.StatusSubmitted || m.ref.CurrentStage != 0 {
+		return sql.ErrNoRows
+	}
+	m.ref.Status = models.StatusDraft
+	m.ref.SubmittedAt = nil
 	return nil
 }
 
-func (m *mockAchievementRefRepo) SoftDelete(id string) error {
+func (m *mockAchievementRefRepo) DeleteOrphan(ctx context.Context, id, from, note string) error {
+	if m.ref.Status != from {
+		return sql.ErrNoRows
+	}
+	m.ref.Status = models.StatusDeleted
+	return nil
+}
+
+func (m *mockAchievementRefRepo) ListHistory(ctx context.Context, referenceID string) ([]models.AchievementStatusHistory, error) {
+	return nil, nil
+}
+
+func (m *mockAchievementRefRepo) ListDeleted(ctx context.Context, studentIDs []string, limit, offset int) ([]models.AchievementReference, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *mockAchievementRefRepo) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]models.AchievementReference, error) {
+	return nil, nil
+}
+
+func (m *mockAchievementRefRepo) Restore(ctx context.Context, id, actorID string) error {
+	if m.ref.Status != models.StatusDeleted {
+		return sql.ErrNoRows
+	}
+	m.ref.Status = models.StatusDraft
+	return nil
+}
+
+func (m *mockAchievementRefRepo) Purge(ctx context.Context, id string) error {
 	return nil
 }
 
@@ -84,7 +158,9 @@ func (m *mockAchievementRefRepo) SoftDelete(id string) error {
 // =======================================================
 //
 
-type mockMongoAchievementRepo struct{}
+type mockMongoAchievementRepo struct {
+	doc *models.Achievement
+}
 
 func (m *mockMongoAchievementRepo) GetAll(ctx context.Context) ([]models.Achievement, error) {
 	return nil, nil
@@ -99,6 +175,10 @@ func (m *mockMongoAchievementRepo) CreateDraft(ctx context.Context, studentID st
 }
 
 func (m *mockMongoAchievementRepo) GetByID(ctx context.Context, id string) (*models.Achievement, error) {
+	if m.doc != nil {
+		cp := *m.doc
+		return &cp, nil
+	}
 	return &models.Achievement{Title: "Mock Achievement"}, nil
 }
 
@@ -126,10 +206,38 @@ func (m *mockMongoAchievementRepo) GetManyByIDs(ctx context.Context, ids []strin
 	return result, nil
 }
 
+func (m *mockMongoAchievementRepo) GetManyDeletedByIDs(ctx context.Context, ids []string) (map[string]models.Achievement, error) {
+	return map[string]models.Achievement{}, nil
+}
+
 func (m *mockMongoAchievementRepo) UpdateStatus(ctx context.Context, id string, status string) error {
 	return nil
 }
 
+func (m *mockMongoAchievementRepo) Delete(ctx context.Context, id string) error {
+	return nil
+}
+
+func (m *mockMongoAchievementRepo) ListStates(ctx context.Context) ([]models.AchievementDocState, error) {
+	return nil, nil
+}
+
+func (m *mockMongoAchievementRepo) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockMongoAchievementRepo) ListVersions(ctx context.Context, id string) ([]models.AchievementVersion, error) {
+	return nil, nil
+}
+
+func (m *mockMongoAchievementRepo) GetVersion(ctx context.Context, id string, version int) (*models.AchievementVersion, error) {
+	return nil, nil
+}
+
+func (m *mockMongoAchievementRepo) RestoreVersion(ctx context.Context, id string, version int) (*models.Achievement, error) {
+	return nil, nil
+}
+
 //
 // =======================================================
 // MOCK StudentRepository (NAMA UNIK)
@@ -138,26 +246,31 @@ func (m *mockMongoAchievementRepo) UpdateStatus(ctx context.Context, id string,
 
 type mockAchievementStudentRepo struct{}
 
-func (m *mockAchievementStudentRepo) GetAll() ([]models.Student, error) { return nil, nil }
-func (m *mockAchievementStudentRepo) GetByID(id string) (*models.Student, error) {
+func (m *mockAchievementStudentRepo) GetAll(ctx context.Context) ([]models.Student, error) {
+	return nil, nil
+}
+func (m *mockAchievementStudentRepo) GetByID(ctx context.Context, id string) (*models.Student, error) {
 	return &models.Student{ID: id}, nil
 }
-func (m *mockAchievementStudentRepo) GetByStudentID(studentID string) (*models.Student, error) {
+func (m *mockAchievementStudentRepo) GetByStudentID(ctx context.Context, studentID string) (*models.Student, error) {
 	return nil, nil
 }
-func (m *mockAchievementStudentRepo) GetByUserID(userID string) (*models.Student, error) {
+func (m *mockAchievementStudentRepo) GetByUserID(ctx context.Context, userID string) (*models.Student, error) {
 	return &models.Student{ID: "student-1"}, nil
 }
-func (m *mockAchievementStudentRepo) GetByAdvisorID(advisorID string) ([]models.Student, error) {
+func (m *mockAchievementStudentRepo) GetByAdvisorID(ctx context.Context, advisorID string) ([]models.Student, error) {
+	if advisorID == "lecturer-1" {
+		return []models.Student{{ID: "student-1"}}, nil
+	}
 	return nil, nil
 }
-func (m *mockAchievementStudentRepo) Create(req models.CreateStudentRequest) (*models.Student, error) {
+func (m *mockAchievementStudentRepo) Create(ctx context.Context, req models.CreateStudentRequest) (*models.Student, error) {
 	return nil, nil
 }
-func (m *mockAchievementStudentRepo) Update(id string, req models.UpdateStudentRequest) (*models.Student, error) {
+func (m *mockAchievementStudentRepo) Update(ctx context.Context, id string, req models.UpdateStudentRequest) (*models.Student, error) {
 	return nil, nil
 }
-func (m *mockAchievementStudentRepo) UpdateAdvisor(id string, advisorID string) error {
+func (m *mockAchievementStudentRepo) UpdateAdvisor(ctx context.Context, id string, advisorID string) error {
 	return nil
 }
 
@@ -169,20 +282,22 @@ func (m *mockAchievementStudentRepo) UpdateAdvisor(id string, advisorID string)
 
 type mockAchievementLecturerRepo struct{}
 
-func (m *mockAchievementLecturerRepo) GetAll() ([]models.Lecturer, error) { return nil, nil }
-func (m *mockAchievementLecturerRepo) GetByID(id string) (*models.Lecturer, error) {
+func (m *mockAchievementLecturerRepo) GetAll(ctx context.Context) ([]models.Lecturer, error) {
+	return nil, nil
+}
+func (m *mockAchievementLecturerRepo) GetByID(ctx context.Context, id string) (*models.Lecturer, error) {
 	return nil, nil
 }
-func (m *mockAchievementLecturerRepo) GetByUserID(userID string) (*models.Lecturer, error) {
+func (m *mockAchievementLecturerRepo) GetByUserID(ctx context.Context, userID string) (*models.Lecturer, error) {
 	return &models.Lecturer{ID: "lecturer-1"}, nil
 }
-func (m *mockAchievementLecturerRepo) GetByLecturerID(id string) (*models.Lecturer, error) {
+func (m *mockAchievementLecturerRepo) GetByLecturerID(ctx context.Context, id string) (*models.Lecturer, error) {
 	return nil, nil
 }
-func (m *mockAchievementLecturerRepo) Create(req models.CreateLecturerRequest) (*models.Lecturer, error) {
+func (m *mockAchievementLecturerRepo) Create(ctx context.Context, req models.CreateLecturerRequest) (*models.Lecturer, error) {
 	return nil, nil
 }
-func (m *mockAchievementLecturerRepo) Update(id string, req models.UpdateLecturerRequest) (*models.Lecturer, error) {
+func (m *mockAchievementLecturerRepo) Update(ctx context.Context, id string, req models.UpdateLecturerRequest) (*models.Lecturer, error) {
 	return nil, nil
 }
 
@@ -193,6 +308,18 @@ func (m *mockAchievementLecturerRepo) Update(id string, req models.UpdateLecture
 //
 
 func setupAchievementService() (*fiber.App, *mockAchievementRefRepo) {
+	app, refRepo, _ := setupAchievementServiceWithMongo()
+	return app, refRepo
+}
+
+func setupAchievementServiceWithMongo() (*fiber.App, *mockAchievementRefRepo, *mockMongoAchievementRepo) {
+	app, refRepo, mongoRepo, _ := setupAchievementWorkflow()
+	return app, refRepo, mongoRepo
+}
+
+// setupAchievementWorkflow juga memasang route /as/:role/... yang memakai
+// role dari path, untuk menguji tahap workflow per role.
+func setupAchievementWorkflow() (*fiber.App, *mockAchievementRefRepo, *mockMongoAchievementRepo, repository.VerificationWorkflowStore) {
 	app := fiber.New()
 
 	ref := &models.AchievementReference{
@@ -203,12 +330,17 @@ func setupAchievementService() (*fiber.App, *mockAchievementRefRepo) {
 	}
 
 	refRepo := &mockAchievementRefRepo{ref: ref}
+	mongoRepo := &mockMongoAchievementRepo{doc: &models.Achievement{Title: "Mock Achievement"}}
+	workflows := repository.NewMemoryVerificationWorkflowStore()
 
 	service := NewAchievementReferenceService(
 		refRepo,
-		&mockMongoAchievementRepo{},
+		mongoRepo,
 		&mockAchievementStudentRepo{},
 		&mockAchievementLecturerRepo{},
+		repository.NewMemoryAchievementRevisionStore(),
+		workflows,
+		repository.NewMemoryUnitOfWork(),
 	)
 
 	app.Get("/achievements", service.GetAll)
@@ -228,8 +360,34 @@ func setupAchievementService() (*fiber.App, *mockAchievementRefRepo) {
 		c.Locals("user_id", "lecturer-1")
 		return service.Reject(c)
 	})
+	app.Post("/achievements/:id/revise", func(c *fiber.Ctx) error {
+		c.Locals("role_name", "Mahasiswa")
+		c.Locals("user_id", "user-1")
+		return service.Revise(c)
+	})
+	app.Post("/achievements/:id/withdraw", func(c *fiber.Ctx) error {
+		c.Locals("role_name", "Mahasiswa")
+		c.Locals("user_id", "user-1")
+		return service.Withdraw(c)
+	})
+	app.Get("/achievements/:id/changes", func(c *fiber.Ctx) error {
+		c.Locals("role_name", "Admin")
+		c.Locals("user_id", "lecturer-1")
+		return service.Changes(c)
+	})
 
-	return app, refRepo
+	asRole := func(h fiber.Handler) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			role := strings.ReplaceAll(c.Params("role"), "_", " ")
+			c.Locals("role_name", role)
+			c.Locals("user_id", "user-"+c.Params("role"))
+			return h(c)
+		}
+	}
+	app.Post("/as/:role/achievements/:id/verify", asRole(service.Verify))
+	app.Post("/as/:role/achievements/:id/reject", asRole(service.Reject))
+
+	return app, refRepo, mongoRepo, workflows
 }
 
 //
@@ -258,6 +416,7 @@ func TestSubmit(t *testing.T) {
 
 func TestVerify(t *testing.T) {
 	app, repo := setupAchievementService()
+	repo.ref.Status = models.StatusSubmitted
 	req := httptest.NewRequest(http.MethodPost, "/achievements/mongo-1/verify", nil)
 	resp, _ := app.Test(req)
 
@@ -267,6 +426,7 @@ func TestVerify(t *testing.T) {
 
 func TestReject(t *testing.T) {
 	app, repo := setupAchievementService()
+	repo.ref.Status = models.StatusSubmitted
 
 	body, _ := json.Marshal(map[string]string{
 		"rejection_note": "invalid data",
@@ -280,3 +440,348 @@ func TestReject(t *testing.T) {
 	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
 	assert.Equal(t, models.StatusRejected, repo.ref.Status)
 }
+
+func TestRevise_RejectedBackToDraftWithChanges(t *testing.T) {
+	app, repo, mongoRepo := setupAchievementServiceWithMongo()
+	repo.ref.Status = models.StatusRejected
+	repo.ref.RejectionNote = ptr("sertifikat buram")
+
+	resp, _ := app.Test(httptest.NewRequest(http.MethodPost, "/achievements/mongo-1/revise", nil))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, models.StatusDraft, repo.ref.Status)
+	assert.Equal(t, 1, repo.ref.Revision)
+	assert.Equal(t, "sertifikat buram", *repo.ref.RejectionNote)
+
+	// mahasiswa mengedit judul
+	mongoRepo.doc.Title = "Juara 1 Lomba"
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodGet, "/achievements/mongo-1/changes", nil))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data models.AchievementChanges `json:"data"`
+	}
+	decodeBody(t, resp, &body)
+	assert.Equal(t, 1, body.Data.Revision)
+	assert.Equal(t, 0, body.Data.ReviewedRevision)
+	assert.Equal(t, "sertifikat buram", *body.Data.RejectionNote)
+	assert.Equal(t, []models.AchievementFieldChange{
+		{Field: "title", Old: "Mock Achievement", New: "Juara 1 Lomba"},
+	}, body.Data.Changes)
+}
+
+func TestRevise_OnlyRejected(t *testing.T) {
+	app, repo := setupAchievementService()
+
+	resp, _ := app.Test(httptest.NewRequest(http.MethodPost, "/achievements/mongo-1/revise", nil))
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, models.StatusDraft, repo.ref.Status)
+	assert.Equal(t, 0, repo.ref.Revision)
+}
+
+// failingReviseRepo gagal saat menaikkan revisi, setelah baris revisi ditulis.
+type failingReviseRepo struct {
+	mockAchievementRefRepo
+}
+
+func (m *failingReviseRepo) Revise(ctx context.Context, id, actorID string) error {
+	return sql.ErrConnDone
+}
+
+func TestRevise_RollsBackRevisionOnFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := &failingReviseRepo{mockAchievementRefRepo{ref: &models.AchievementReference{
+		ID:                 "ref-1",
+		StudentID:          "student-1",
+		MongoAchievementID: "mongo-1",
+		Status:             models.StatusRejected,
+	}}}
+
+	service := NewAchievementReferenceService(
+		repo,
+		&mockMongoAchievementRepo{doc: &models.Achievement{Title: "Mock Achievement"}},
+		&mockAchievementStudentRepo{},
+		&mockAchievementLecturerRepo{},
+		repository.NewAchievementRevisionRepository(db),
+		repository.NewMemoryVerificationWorkflowStore(),
+		repository.NewUnitOfWork(db),
+	)
+
+	app := fiber.New()
+	app.Post("/achievements/:id/revise", func(c *fiber.Ctx) error {
+		c.Locals("role_name", "Mahasiswa")
+		c.Locals("user_id", "user-1")
+		return service.Revise(c)
+	})
+
+	// baris revisi tidak boleh tertinggal jika kenaikan revisi gagal
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO achievement_revisions`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	resp, _ := app.Test(httptest.NewRequest(http.MethodPost, "/achievements/mongo-1/revise", nil))
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, models.StatusRejected, repo.ref.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithdraw_SubmittedBackToDraft(t *testing.T) {
+	app, repo := setupAchievementService()
+
+	resp, _ := app.Test(httptest.NewRequest(http.MethodPost, "/achievements/mongo-1/submit", nil))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodPost, "/achievements/mongo-1/withdraw", nil))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, models.StatusDraft, repo.ref.Status)
+	assert.Nil(t, repo.ref.SubmittedAt)
+}
+
+func TestWithdraw_NotAfterReview(t *testing.T) {
+	app, repo := setupAchievementService()
+	repo.ref.Status = models.StatusVerified
+
+	resp, _ := app.Test(httptest.NewRequest(http.MethodPost, "/achievements/mongo-1/withdraw", nil))
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, models.StatusVerified, repo.ref.Status)
+}
+
+// Setelah dosen wali menyetujui tahap pertama, keputusannya tidak boleh
+// hilang karena mahasiswa menarik submit.
+func TestWithdraw_NotAfterStageApproved(t *testing.T) {
+	app, repo, mongoRepo, workflows := setupAchievementWorkflow()
+
+	assert.NoError(t, workflows.Create(context.Background(), &models.VerificationWorkflow{
+		AchievementType:  "competition",
+		CompetitionLevel: "national",
+		Name:             "Kompetisi nasional",
+		Stages: []models.VerificationStage{
+			{Name: "Pemeriksaan dosen wali", RoleName: "Dosen Wali"},
+			{Name: "Persetujuan kemahasiswaan", RoleName: "Kemahasiswaan"},
+		},
+	}))
+	mongoRepo.doc.AchievementType = "competition"
+	mongoRepo.doc.Details.CompetitionLevel = ptr("national")
+	repo.ref.Status = models.StatusSubmitted
+
+	resp, _ := app.Test(httptest.NewRequest(http.MethodPost, "/achievements/mongo-1/verify", nil))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, repo.ref.CurrentStage)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodPost, "/achievements/mongo-1/withdraw", nil))
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, models.StatusSubmitted, repo.ref.Status)
+	assert.Len(t, repo.decisions, 1)
+}
+
+func TestWithdraw_OtherStudentForbidden(t *testing.T) {
+	app, repo := setupAchievementService()
+	repo.ref.Status = models.StatusSubmitted
+	repo.ref.StudentID = "student-2"
+
+	resp, _ := app.Test(httptest.NewRequest(http.MethodPost, "/achievements/mongo-1/withdraw", nil))
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, models.StatusSubmitted, repo.ref.Status)
+}
+
+func TestVerify_DraftRejected(t *testing.T) {
+	app, repo := setupAchievementService()
+
+	resp, _ := app.Test(httptest.NewRequest(http.MethodPost, "/achievements/mongo-1/verify", nil))
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, models.StatusDraft, repo.ref.Status)
+}
+
+func TestVerify_MultiStageWorkflow(t *testing.T) {
+	app, repo, mongoRepo, workflows := setupAchievementWorkflow()
+
+	assert.NoError(t, workflows.Create(context.Background(), &models.VerificationWorkflow{
+		AchievementType:  "competition",
+		CompetitionLevel: "national",
+		Name:             "Kompetisi nasional",
+		Stages: []models.VerificationStage{
+			{Name: "Pemeriksaan dosen wali", RoleName: "Dosen Wali"},
+			{Name: "Persetujuan kemahasiswaan", RoleName: "Kemahasiswaan"},
+		},
+	}))
+	mongoRepo.doc.AchievementType = "competition"
+	mongoRepo.doc.Details.CompetitionLevel = ptr("national")
+	repo.ref.Status = models.StatusSubmitted
+
+	verify := func(role string) int {
+		resp, _ := app.Test(httptest.NewRequest(http.MethodPost, "/as/"+role+"/achievements/mongo-1/verify", nil))
+		return resp.StatusCode
+	}
+
+	// kemahasiswaan belum gilirannya
+	assert.Equal(t, fiber.StatusForbidden, verify("Kemahasiswaan"))
+
+	assert.Equal(t, fiber.StatusOK, verify("Dosen_Wali"))
+	assert.Equal(t, models.StatusSubmitted, repo.ref.Status)
+	assert.Equal(t, 1, repo.ref.CurrentStage)
+
+	// dosen wali tidak bisa menyetujui tahap kedua
+	assert.Equal(t, fiber.StatusForbidden, verify("Dosen_Wali"))
+
+	assert.Equal(t, fiber.StatusOK, verify("Kemahasiswaan"))
+	assert.Equal(t, models.StatusVerified, repo.ref.Status)
+
+	assert.Len(t, repo.decisions, 2)
+	assert.Equal(t, "Dosen Wali", repo.decisions[0].RoleName)
+	assert.False(t, repo.decisions[0].Final)
+	assert.Equal(t, "Kemahasiswaan", repo.decisions[1].RoleName)
+	assert.True(t, repo.decisions[1].Final)
+}
+
+// Tanpa workflow, kemahasiswaan tidak memegang tahap apa pun.
+func TestVerify_KemahasiswaanOnlyOwnStage(t *testing.T) {
+	app, repo, _, _ := setupAchievementWorkflow()
+	repo.ref.Status = models.StatusSubmitted
+
+	resp, _ := app.Test(httptest.NewRequest(http.MethodPost, "/as/Kemahasiswaan/achievements/mongo-1/verify", nil))
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, models.StatusSubmitted, repo.ref.Status)
+	assert.Empty(t, repo.decisions)
+}
+
+func TestReject_AtSecondStage(t *testing.T) {
+	app, repo, mongoRepo, workflows := setupAchievementWorkflow()
+
+	assert.NoError(t, workflows.Create(context.Background(), &models.VerificationWorkflow{
+		AchievementType: "competition",
+		Name:            "Kompetisi",
+		Stages: []models.VerificationStage{
+			{Name: "Pemeriksaan dosen wali", RoleName: "Dosen Wali"},
+			{Name: "Persetujuan kemahasiswaan", RoleName: "Kemahasiswaan"},
+		},
+	}))
+	// workflow tanpa tingkat berlaku untuk semua tingkat
+	mongoRepo.doc.AchievementType = "competition"
+	mongoRepo.doc.Details.CompetitionLevel = ptr("international")
+	repo.ref.Status = models.StatusSubmitted
+	repo.ref.CurrentStage = 1
+
+	req := httptest.NewRequest(http.MethodPost, "/as/Kemahasiswaan/achievements/mongo-1/reject",
+		jsonBody(map[string]string{"rejection_note": "surat tugas tidak ada"}))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, _ := app.Test(req)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, models.StatusRejected, repo.ref.Status)
+	assert.Equal(t, "surat tugas tidak ada", *repo.decisions[0].Note)
+	assert.Equal(t, 1, repo.decisions[0].Stage)
+}
+
+//
+// =======================================================
+// BULK VERIFY / REJECT
+// =======================================================
+//
+
+// bulkRefRepo menyimpan beberapa reference (key: Mongo ID); perilaku
+// Decide sama dengan mockAchievementRefRepo.
+type bulkRefRepo struct {
+	mockAchievementRefRepo
+	refs map[string]*models.AchievementReference
+}
+
+func (m *bulkRefRepo) GetByMongoAchievementID(ctx context.Context, mongoID string) (*models.AchievementReference, error) {
+	return m.refs[mongoID], nil
+}
+
+func (m *bulkRefRepo) GetByID(ctx context.Context, id string) (*models.AchievementReference, error) {
+	for _, r := range m.refs {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *bulkRefRepo) Decide(ctx context.Context, id string, d *models.StageDecision) error {
+	m.ref, _ = m.GetByID(ctx, id)
+	return m.mockAchievementRefRepo.Decide(ctx, id, d)
+}
+
+func setupBulkReview() (*fiber.App, *bulkRefRepo) {
+	repo := &bulkRefRepo{refs: map[string]*models.AchievementReference{
+		"m1": {ID: "r1", StudentID: "student-1", MongoAchievementID: "m1", Status: models.StatusSubmitted},
+		"m2": {ID: "r2", StudentID: "student-1", MongoAchievementID: "m2", Status: models.StatusDraft},
+		"m3": {ID: "r3", StudentID: "student-2", MongoAchievementID: "m3", Status: models.StatusSubmitted},
+		"m5": {ID: "r5", StudentID: "student-1", MongoAchievementID: "m5", Status: models.StatusSubmitted},
+	}}
+
+	service := NewAchievementReferenceService(
+		repo,
+		&mockMongoAchievementRepo{},
+		&mockAchievementStudentRepo{},
+		&mockAchievementLecturerRepo{},
+		repository.NewMemoryAchievementRevisionStore(),
+		repository.NewMemoryVerificationWorkflowStore(),
+		repository.NewMemoryUnitOfWork(),
+	)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role_name", "Dosen Wali")
+		c.Locals("user_id", "user-lecturer")
+		return c.Next()
+	})
+	app.Post("/bulk-verify", service.BulkVerify)
+	app.Post("/bulk-reject", service.BulkReject)
+
+	return app, repo
+}
+
+type bulkReviewBody struct {
+	Data struct {
+		Succeeded int                       `json:"succeeded"`
+		Failed    int                       `json:"failed"`
+		Results   []models.BulkReviewResult `json:"results"`
+	} `json:"data"`
+}
+
+func TestBulkVerify_ReportsPerItem(t *testing.T) {
+	app, repo := setupBulkReview()
+
+	resp := postJSON(t, app, "/bulk-verify", models.BulkReviewRequest{
+		IDs: []string{"m1", "m2", "m3", "m4", "m1", "m5"},
+	})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body bulkReviewBody
+	decodeBody(t, resp, &body)
+
+	assert.Equal(t, 2, body.Data.Succeeded)
+	assert.Equal(t, 3, body.Data.Failed)
+
+	codes := map[string]int{}
+	for _, r := range body.Data.Results {
+		codes[r.ID] = r.Code
+	}
+	assert.Equal(t, map[string]int{"m1": 200, "m2": 400, "m3": 403, "m4": 404, "m5": 200}, codes)
+
+	// item yang gagal di tengah tidak menahan item sesudahnya
+	assert.Equal(t, models.StatusVerified, repo.refs["m1"].Status)
+	assert.Equal(t, models.StatusVerified, repo.refs["m5"].Status)
+	assert.Equal(t, models.StatusSubmitted, repo.refs["m3"].Status)
+}
+
+func TestBulkReject_RequiresNote(t *testing.T) {
+	app, repo := setupBulkReview()
+
+	resp := postJSON(t, app, "/bulk-reject", models.BulkReviewRequest{IDs: []string{"m1"}})
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp = postJSON(t, app, "/bulk-reject", models.BulkReviewRequest{
+		IDs:           []string{"m1", "m5"},
+		RejectionNote: "lampiran tidak terbaca",
+	})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, models.StatusRejected, repo.refs["m1"].Status)
+	assert.Equal(t, "lampiran tidak terbaca", *repo.refs["m5"].RejectionNote)
+}
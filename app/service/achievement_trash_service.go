@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/policy"
+	"achievement_backend/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// trashPurgeBatch adalah jumlah prestasi yang di-purge per pemanggilan Purge.
+const trashPurgeBatch = 200
+
+// minPurgeAgeDays mencegah purge manual mengosongkan prestasi yang baru
+// saja dihapus sebelum pemiliknya sempat me-restore.
+const minPurgeAgeDays = 1
+
+// AchievementTrashService mengelola recycle bin prestasi: prestasi yang
+// dihapus (status deleted) masih bisa dilihat dan dikembalikan menjadi draft
+// oleh pemiliknya atau admin, lalu dihapus permanen setelah retention habis.
+type AchievementTrashService struct {
+	refRepo   repository.AchievementReferenceRepository
+	mongoRepo repository.MongoAchievementRepository
+	uow       repository.UnitOfWork
+	authz     *policy.Policy
+	uploadDir string
+	retention time.Duration
+}
+
+func NewAchievementTrashService(
+	refRepo repository.AchievementReferenceRepository,
+	mongoRepo repository.MongoAchievementRepository,
+	studentRepo repository.StudentRepository,
+	lecturerRepo repository.LecturerRepository,
+	uow repository.UnitOfWork,
+	uploadDir string,
+	retention time.Duration,
+) *AchievementTrashService {
+	return &AchievementTrashService{
+		refRepo:   refRepo,
+		mongoRepo: mongoRepo,
+		uow:       uow,
+		authz:     policy.New(studentRepo, lecturerRepo),
+		uploadDir: uploadDir,
+		retention: retention,
+	}
+}
+
+// ListTrash godoc
+// @Summary Daftar prestasi di recycle bin
+// @Description Admin melihat semua prestasi yang dihapus, Mahasiswa hanya miliknya sendiri. purge_at adalah waktu prestasi akan dihapus permanen.
+// @Tags Achievement Trash
+// @Produce json
+// @Param page query int false "Nomor halaman (default: 1)"
+// @Param limit query int false "Jumlah data per halaman (default: 10)"
+// @Success 200 {object} map[string]interface{} "Daftar prestasi yang dihapus"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Server error"
+// @Security Bearer
+// @Router /api/v1/achievements/trash [get]
+func (s *AchievementTrashService) List(c *fiber.Ctx) error {
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
+	}
+
+	all, studentIDs, err := s.authz.StudentScope(c.UserContext(), sub, policy.ActionDelete)
+	if err != nil {
+		return policyError(c, err)
+	}
+
+	ctx := c.UserContext()
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+	offset := (page - 1) * limit
+
+	out := []fiber.Map{}
+	var total int64
+
+	if all || len(studentIDs) > 0 {
+		if all {
+			studentIDs = nil
+		}
+
+		var refs []models.AchievementReference
+		refs, total, err = s.refRepo.ListDeleted(ctx, studentIDs, limit, offset)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "failed to fetch deleted achievements"})
+		}
+
+		mongoIDs := []string{}
+		for _, r := range refs {
+			mongoIDs = append(mongoIDs, r.MongoAchievementID)
+		}
+
+		mDetails, _ := s.mongoRepo.GetManyDeletedByIDs(ctx, mongoIDs)
+
+		for _, r := range refs {
+			out = append(out, fiber.Map{
+				"reference":  r,
+				"detail":     mDetails[r.MongoAchievementID],
+				"deleted_at": r.UpdatedAt,
+				"purge_at":   r.UpdatedAt.Add(s.retention),
+			})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    out,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// RestoreAchievement godoc
+// @Summary Mengembalikan prestasi dari recycle bin
+// @Description Prestasi yang dihapus dikembalikan menjadi draft. Akses sama dengan menghapus prestasi.
+// @Tags Achievement Trash
+// @Produce json
+// @Param id path string true "Mongo Achievement ID"
+// @Success 200 {object} map[string]interface{} "Prestasi dikembalikan menjadi draft"
+// @Failure 400 {object} map[string]interface{} "Prestasi tidak sedang dihapus"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Not found"
+// @Failure 500 {object} map[string]interface{} "Server error"
+// @Security Bearer
+// @Router /api/v1/achievements/{id}/restore [post]
+func (s *AchievementTrashService) Restore(c *fiber.Ctx) error {
+	id := c.Params("id")
+	ctx := c.UserContext()
+
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
+	}
+
+	ref, err := s.refRepo.GetByMongoAchievementID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to restore achievement"})
+	}
+	if ref == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
+	}
+
+	if err := s.authz.Authorize(ctx, sub, policy.ActionDelete, ref.StudentID); err != nil {
+		return policyError(c, err)
+	}
+
+	// dokumen Mongo diubah di dalam transaksi agar reference yang dokumennya
+	// sudah hilang tidak kembali menjadi draft kosong
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.refRepo.Restore(ctx, ref.ID, sub.UserID); err != nil {
+			return err
+		}
+		return s.mongoRepo.UpdateStatus(ctx, id, models.StatusDraft)
+	})
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return c.Status(400).JSON(fiber.Map{"error": "achievement is not deleted"})
+	case errors.Is(err, repository.ErrMongoAchievementNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
+	case err != nil:
+		log.Printf("[Trash] restore %s error: %v", id, err)
+		return c.Status(500).JSON(fiber.Map{"error": "failed to restore achievement"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "achievement restored as draft",
+	})
+}
+
+// PurgeTrash godoc
+// @Summary Menghapus permanen isi recycle bin
+// @Description Menghapus dokumen Mongo, reference, dan file lampiran prestasi yang dihapus lebih dari older_than_days hari yang lalu (minimal 1). Butuh permission achievement:purge.
+// @Tags Achievement Trash
+// @Produce json
+// @Param older_than_days query int false "Umur minimal di recycle bin dalam hari (default: retention)"
+// @Success 200 {object} map[string]interface{} "Jumlah prestasi yang dihapus permanen"
+// @Failure 400 {object} map[string]interface{} "Input tidak valid"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Server error"
+// @Security Bearer
+// @Router /api/v1/achievements/trash [delete]
+func (s *AchievementTrashService) PurgeTrash(c *fiber.Ctx) error {
+	days := c.QueryInt("older_than_days", int(s.retention/(24*time.Hour)))
+	if days < minPurgeAgeDays {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("older_than_days must be at least %d", minPurgeAgeDays)})
+	}
+
+	purged, failed, err := s.Purge(c.UserContext(), time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to purge achievements"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"purged": purged,
+			"failed": failed,
+		},
+	})
+}
+
+// Purge menghapus permanen paling banyak trashPurgeBatch prestasi yang
+// dihapus sebelum before. Prestasi yang gagal dilewati dan dicoba lagi pada
+// pemanggilan berikutnya, termasuk dokumen Mongo yang tertinggal setelah
+// reference-nya terhapus (lihat purgeOrphanDocuments).
+func (s *AchievementTrashService) Purge(ctx context.Context, before time.Time) (purged, failed int, err error) {
+	refs, err := s.refRepo.ListDeletedBefore(ctx, before, trashPurgeBatch)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	attempted := make(map[string]bool, len(refs))
+	for i := range refs {
+		attempted[refs[i].MongoAchievementID] = true
+		err := s.purge(ctx, &refs[i])
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			// sudah di-restore atau di-purge oleh proses lain
+		case err != nil:
+			log.Printf("[Trash] purge %s error: %v", refs[i].MongoAchievementID, err)
+			failed++
+		default:
+			purged++
+		}
+	}
+
+	n, f, err := s.purgeOrphanDocuments(ctx, before, attempted)
+	if err != nil {
+		return purged, failed, err
+	}
+
+	return purged + n, failed + f, nil
+}
+
+// purgeOrphanDocuments menghapus dokumen Mongo yang sudah dihapus sebelum
+// before tetapi tidak lagi punya reference: sisa purge yang reference-nya
+// sudah ter-commit tetapi penghapusan dokumennya gagal, atau dokumen lama
+// tanpa reference. Reconciler melewati dokumen yang sudah dihapus, jadi
+// tanpa ini dokumen dan lampirannya tidak pernah hilang. Dokumen di skip
+// sudah dicoba pada pemanggilan ini.
+func (s *AchievementTrashService) purgeOrphanDocuments(ctx context.Context, before time.Time, skip map[string]bool) (purged, failed int, err error) {
+	ids, err := s.mongoRepo.ListDeletedBefore(ctx, before, trashPurgeBatch)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, id := range ids {
+		if skip[id] {
+			continue
+		}
+
+		ref, err := s.refRepo.GetByMongoAchievementID(ctx, id)
+		if err == nil && ref != nil {
+			// masih di recycle bin, dihapus lewat reference-nya
+			continue
+		}
+		if err == nil {
+			var files []string
+			files, err = s.uploadedFiles(ctx, id)
+			if err == nil {
+				err = s.deleteDocument(ctx, id, files)
+			}
+		}
+		if err != nil {
+			log.Printf("[Trash] purge orphan document %s error: %v", id, err)
+			failed++
+			continue
+		}
+		purged++
+	}
+
+	return purged, failed, nil
+}
+
+// PurgeExpired menghapus permanen prestasi yang sudah melewati retention.
+func (s *AchievementTrashService) PurgeExpired(ctx context.Context) (purged, failed int, err error) {
+	return s.Purge(ctx, time.Now().Add(-s.retention))
+}
+
+// purge menghapus reference lebih dulu sehingga barisnya terkunci sampai
+// transaksi selesai dan restore yang bersamaan tidak bisa lolos. Dokumen
+// Mongo dan file lampiran tidak bisa di-rollback, jadi baru dihapus setelah
+// transaksi berhasil di-commit.
+func (s *AchievementTrashService) purge(ctx context.Context, ref *models.AchievementReference) error {
+	var files []string
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.refRepo.Purge(ctx, ref.ID); err != nil {
+			return err
+		}
+
+		var err error
+		files, err = s.uploadedFiles(ctx, ref.MongoAchievementID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.deleteDocument(ctx, ref.MongoAchievementID, files); err != nil {
+		// reference sudah terhapus; dokumen tertinggal berstatus deleted dan
+		// dibersihkan purgeOrphanDocuments
+		return fmt.Errorf("delete document after purging reference: %w", err)
+	}
+	return nil
+}
+
+// deleteDocument menghapus dokumen Mongo beserta versinya, lalu file
+// lampirannya. File dibiarkan jika dokumen gagal dihapus agar bisa dicoba
+// lagi bersama dokumennya.
+func (s *AchievementTrashService) deleteDocument(ctx context.Context, mongoID string, files []string) error {
+	if err := s.mongoRepo.Delete(ctx, mongoID); err != nil {
+		return err
+	}
+
+	for _, name := range files {
+		err := os.Remove(filepath.Join(s.uploadDir, name))
+		if err != nil && !os.IsNotExist(err) {
+			log.Printf("[Trash] remove %s error: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// uploadedFiles mengumpulkan nama file di uploadDir yang dirujuk lampiran
+// dokumen maupun versi lamanya. Lampiran dengan URL luar diabaikan.
+func (s *AchievementTrashService) uploadedFiles(ctx context.Context, mongoID string) ([]string, error) {
+	docs, err := s.mongoRepo.GetManyDeletedByIDs(ctx, []string{mongoID})
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.mongoRepo.ListVersions(ctx, mongoID)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := docs[mongoID].Attachments
+	for _, v := range versions {
+		attachments = append(attachments, v.Snapshot.Attachments...)
+	}
+
+	seen := map[string]bool{}
+	files := []string{}
+	for _, a := range attachments {
+		name, ok := strings.CutPrefix(a.FileURL, "/uploads/")
+		if !ok || name == "" || name != filepath.Base(name) || seen[name] {
+			continue
+		}
+		seen[name] = true
+		files = append(files, name)
+	}
+
+	return files, nil
+}
+
+// StartAchievementTrashPurger menjalankan goroutine yang secara berkala
+// menghapus permanen prestasi yang sudah melewati retention.
+// Panggil fungsi yang dikembalikan untuk menghentikan purger.
+func StartAchievementTrashPurger(svc *AchievementTrashService, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				n, failed, err := svc.PurgeExpired(context.Background())
+				if err != nil {
+					log.Printf("[Trash] purge error: %v", err)
+					continue
+				}
+				if n > 0 || failed > 0 {
+					log.Printf("[Trash] purged %d achievements, %d failed", n, failed)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
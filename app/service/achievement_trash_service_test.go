@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// =======================================================
+// FAKE STORE (recycle bin)
+// =======================================================
+//
+
+// trashRefRepo menyimpan reference per ID.
+type trashRefRepo struct {
+	mockAchievementRefRepo
+	refs map[string]*models.AchievementReference
+}
+
+func (m *trashRefRepo) GetByMongoAchievementID(ctx context.Context, mongoID string) (*models.AchievementReference, error) {
+	for _, r := range m.refs {
+		if r.MongoAchievementID == mongoID {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *trashRefRepo) ListDeleted(ctx context.Context, studentIDs []string, limit, offset int) ([]models.AchievementReference, int64, error) {
+	list := []models.AchievementReference{}
+	for _, r := range m.refs {
+		if r.Status != models.StatusDeleted {
+			continue
+		}
+		if studentIDs != nil && !containsString(studentIDs, r.StudentID) {
+			continue
+		}
+		list = append(list, *r)
+	}
+	return list, int64(len(list)), nil
+}
+
+func (m *trashRefRepo) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]models.AchievementReference, error) {
+	list := []models.AchievementReference{}
+	for _, r := range m.refs {
+		if r.Status == models.StatusDeleted && r.UpdatedAt.Before(before) {
+			list = append(list, *r)
+		}
+	}
+	return list, nil
+}
+
+func (m *trashRefRepo) Restore(ctx context.Context, id, actorID string) error {
+	r := m.refs[id]
+	if r == nil || r.Status != models.StatusDeleted {
+		return sql.ErrNoRows
+	}
+	r.Status = models.StatusDraft
+	return nil
+}
+
+func (m *trashRefRepo) Purge(ctx context.Context, id string) error {
+	r := m.refs[id]
+	if r == nil || r.Status != models.StatusDeleted {
+		return sql.ErrNoRows
+	}
+	delete(m.refs, id)
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// trashMongoRepo menyimpan dokumen dan versi lamanya per ID.
+type trashMongoRepo struct {
+	mockMongoAchievementRepo
+	docs      map[string]*models.Achievement
+	versions  map[string][]models.AchievementVersion
+	deleteErr error
+}
+
+func (m *trashMongoRepo) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	ids := []string{}
+	for id, d := range m.docs {
+		if d.IsDeleted && d.UpdatedAt.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *trashMongoRepo) GetManyDeletedByIDs(ctx context.Context, ids []string) (map[string]models.Achievement, error) {
+	result := map[string]models.Achievement{}
+	for _, id := range ids {
+		if d := m.docs[id]; d != nil && d.IsDeleted {
+			result[id] = *d
+		}
+	}
+	return result, nil
+}
+
+func (m *trashMongoRepo) ListVersions(ctx context.Context, id string) ([]models.AchievementVersion, error) {
+	return m.versions[id], nil
+}
+
+func (m *trashMongoRepo) UpdateStatus(ctx context.Context, id string, status string) error {
+	d := m.docs[id]
+	if d == nil {
+		return repository.ErrMongoAchievementNotFound
+	}
+	d.Status = status
+	d.IsDeleted = status == models.StatusDeleted
+	return nil
+}
+
+func (m *trashMongoRepo) Delete(ctx context.Context, id string) error {
+	if m.deleteErr != nil {
+		return m.deleteErr
+	}
+	delete(m.docs, id)
+	delete(m.versions, id)
+	return nil
+}
+
+// setupTrash mengisi recycle bin dengan m1 (milik student-1, dihapus 40 hari
+// lalu, dengan lampiran) dan m2 (milik student-2, baru dihapus), serta m3
+// yang masih draft. String terakhir adalah folder upload sementara.
+func setupTrash(t *testing.T) (*fiber.App, *AchievementTrashService, *trashRefRepo, *trashMongoRepo, string) {
+	old := time.Now().AddDate(0, 0, -40)
+
+	refs := &trashRefRepo{refs: map[string]*models.AchievementReference{
+		"r1": {ID: "r1", StudentID: "student-1", MongoAchievementID: "m1", Status: models.StatusDeleted, UpdatedAt: old},
+		"r2": {ID: "r2", StudentID: "student-2", MongoAchievementID: "m2", Status: models.StatusDeleted, UpdatedAt: time.Now()},
+		"r3": {ID: "r3", StudentID: "student-1", MongoAchievementID: "m3", Status: models.StatusDraft, UpdatedAt: old},
+	}}
+	docs := &trashMongoRepo{
+		docs: map[string]*models.Achievement{
+			"m1": {Title: "Lomba Web", Status: models.StatusDeleted, IsDeleted: true, Attachments: []models.Attachment{
+				{FileName: "baru.pdf", FileURL: "/uploads/1_baru.pdf"},
+				{FileName: "luar.pdf", FileURL: "https://example.com/luar.pdf"},
+			}},
+			"m2": {Title: "Lomba Data", Status: models.StatusDeleted, IsDeleted: true},
+			"m3": {Title: "Lomba UI", Status: models.StatusDraft},
+		},
+		versions: map[string][]models.AchievementVersion{
+			"m1": {{AchievementID: "m1", Version: 0, Snapshot: models.Achievement{Attachments: []models.Attachment{
+				{FileName: "lama.pdf", FileURL: "/uploads/0_lama.pdf"},
+				{FileName: "baru.pdf", FileURL: "/uploads/1_baru.pdf"},
+			}}}},
+		},
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"0_lama.pdf", "1_baru.pdf", "2_lain.pdf"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("pdf"), 0o644))
+	}
+
+	svc := NewAchievementTrashService(
+		refs, docs,
+		&mockAchievementStudentRepo{}, &mockAchievementLecturerRepo{},
+		repository.NewMemoryUnitOfWork(),
+		dir, 30*24*time.Hour,
+	)
+
+	app := fiber.New()
+	app.Use(headerUser)
+	app.Get("/achievements/trash", svc.List)
+	app.Delete("/achievements/trash", svc.PurgeTrash)
+	app.Post("/achievements/:id/restore", svc.Restore)
+
+	return app, svc, refs, docs, dir
+}
+
+// trashRequest mengirim request sebagai user "user-<role>" dan mengembalikan body JSON-nya.
+func trashRequest(t *testing.T, app *fiber.App, method, path, role string) (*http.Response, map[string]interface{}) {
+	resp := doRequest(t, app, method, path, nil, as(role, "user-"+role))
+
+	var body map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	return resp, body
+}
+
+func uploadedNames(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+//
+// =======================================================
+// LIST
+// =======================================================
+//
+
+func TestTrash_List_ScopedByOwner(t *testing.T) {
+	app, _, _, _, _ := setupTrash(t)
+
+	resp, body := trashRequest(t, app, http.MethodGet, "/achievements/trash", "Admin")
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Len(t, body["data"], 2)
+
+	resp, body = trashRequest(t, app, http.MethodGet, "/achievements/trash", "Mahasiswa")
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	data := body["data"].([]interface{})
+	if assert.Len(t, data, 1) {
+		item := data[0].(map[string]interface{})
+		assert.Equal(t, "m1", item["reference"].(map[string]interface{})["mongo_achievement_id"])
+		assert.Equal(t, "Lomba Web", item["detail"].(map[string]interface{})["title"])
+		assert.NotEmpty(t, item["purge_at"])
+	}
+
+	resp, _ = trashRequest(t, app, http.MethodGet, "/achievements/trash", "Dosen Wali")
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+//
+// =======================================================
+// RESTORE
+// =======================================================
+//
+
+func TestTrash_Restore(t *testing.T) {
+	app, _, refs, docs, _ := setupTrash(t)
+
+	// bukan milik mahasiswa ini
+	resp, _ := trashRequest(t, app, http.MethodPost, "/achievements/m2/restore", "Mahasiswa")
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	resp, _ = trashRequest(t, app, http.MethodPost, "/achievements/m1/restore", "Mahasiswa")
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, models.StatusDraft, refs.refs["r1"].Status)
+	assert.Equal(t, models.StatusDraft, docs.docs["m1"].Status)
+	assert.False(t, docs.docs["m1"].IsDeleted)
+
+	// sudah draft
+	resp, body := trashRequest(t, app, http.MethodPost, "/achievements/m1/restore", "Mahasiswa")
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "achievement is not deleted", body["error"])
+}
+
+// Dokumen yang sudah hilang membuat restore gagal; dengan unit of work
+// Postgres, perubahan reference ikut dibatalkan.
+func TestTrash_Restore_MissingDocument(t *testing.T) {
+	app, _, _, docs, _ := setupTrash(t)
+	delete(docs.docs, "m2")
+
+	resp, _ := trashRequest(t, app, http.MethodPost, "/achievements/m2/restore", "Admin")
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+//
+// =======================================================
+// PURGE
+// =======================================================
+//
+
+func TestTrash_Purge_RemovesExpired(t *testing.T) {
+	_, svc, refs, docs, dir := setupTrash(t)
+
+	purged, failed, err := svc.PurgeExpired(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Equal(t, 0, failed)
+
+	// m1 hilang seluruhnya, m2 masih dalam retention, draft tidak tersentuh
+	assert.NotContains(t, refs.refs, "r1")
+	assert.NotContains(t, docs.docs, "m1")
+	assert.NotContains(t, docs.versions, "m1")
+	assert.Contains(t, refs.refs, "r2")
+	assert.Contains(t, refs.refs, "r3")
+	assert.Equal(t, []string{"2_lain.pdf"}, uploadedNames(t, dir))
+}
+
+func TestTrash_PurgeEndpoint(t *testing.T) {
+	app, _, refs, _, _ := setupTrash(t)
+
+	resp, _ := trashRequest(t, app, http.MethodDelete, "/achievements/trash?older_than_days=-1", "Admin")
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	// recycle bin tidak bisa dikosongkan seketika
+	resp, _ = trashRequest(t, app, http.MethodDelete, "/achievements/trash?older_than_days=0", "Admin")
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp, body := trashRequest(t, app, http.MethodDelete, "/achievements/trash?older_than_days=1", "Admin")
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(1), body["data"].(map[string]interface{})["purged"])
+	assert.NotContains(t, refs.refs, "r1")
+	assert.Contains(t, refs.refs, "r2")
+}
+
+// commitFailingUoW menjalankan fn lalu gagal seolah-olah commit ditolak.
+type commitFailingUoW struct{}
+
+func (commitFailingUoW) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	return sql.ErrTxDone
+}
+
+// Dokumen Mongo dan file lampiran baru dihapus setelah reference
+// benar-benar terhapus.
+func TestTrash_Purge_KeepsDocumentWhenCommitFails(t *testing.T) {
+	_, svc, _, docs, dir := setupTrash(t)
+	svc.uow = commitFailingUoW{}
+
+	purged, failed, err := svc.PurgeExpired(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+	assert.Equal(t, 1, failed)
+
+	assert.Contains(t, docs.docs, "m1")
+	assert.Contains(t, docs.versions, "m1")
+	assert.Equal(t, []string{"0_lama.pdf", "1_baru.pdf", "2_lain.pdf"}, uploadedNames(t, dir))
+}
+
+// Reference sudah terhapus tetapi dokumen Mongo gagal dihapus: dokumen dan
+// lampirannya dibersihkan pada purge berikutnya walau tidak lagi punya
+// reference.
+func TestTrash_Purge_RetriesDocumentWhenMongoDeleteFails(t *testing.T) {
+	_, svc, refs, docs, dir := setupTrash(t)
+	docs.deleteErr = errors.New("mongo down")
+
+	purged, failed, err := svc.PurgeExpired(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+	assert.Equal(t, 1, failed)
+	assert.NotContains(t, refs.refs, "r1")
+	assert.Contains(t, docs.docs, "m1")
+	assert.Equal(t, []string{"0_lama.pdf", "1_baru.pdf", "2_lain.pdf"}, uploadedNames(t, dir))
+
+	docs.deleteErr = nil
+	purged, failed, err = svc.PurgeExpired(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Equal(t, 0, failed)
+	assert.NotContains(t, docs.docs, "m1")
+	assert.NotContains(t, docs.versions, "m1")
+	assert.Equal(t, []string{"2_lain.pdf"}, uploadedNames(t, dir))
+
+	// m2 masih punya reference di recycle bin, tidak ikut terhapus
+	assert.Contains(t, docs.docs, "m2")
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/policy"
+	"achievement_backend/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var errVersionNotFound = errors.New("version not found")
+
+// AchievementVersionService menampilkan riwayat isi draft prestasi. Setiap
+// UpdateDraft dan UpdateAttachments menyimpan isi sebelumnya sebagai versi
+// di Mongo (koleksi achievement_versions), sehingga reviewer bisa melihat
+// apa yang diubah dan mahasiswa bisa membatalkan perubahan.
+type AchievementVersionService struct {
+	refRepo   repository.AchievementReferenceRepository
+	mongoRepo repository.MongoAchievementRepository
+	authz     *policy.Policy
+	uow       repository.UnitOfWork
+}
+
+func NewAchievementVersionService(
+	refRepo repository.AchievementReferenceRepository,
+	mongoRepo repository.MongoAchievementRepository,
+	studentRepo repository.StudentRepository,
+	lecturerRepo repository.LecturerRepository,
+	uow repository.UnitOfWork,
+) *AchievementVersionService {
+	return &AchievementVersionService{
+		refRepo:   refRepo,
+		mongoRepo: mongoRepo,
+		authz:     policy.New(studentRepo, lecturerRepo),
+		uow:       uow,
+	}
+}
+
+// versionStatus memetakan error akses versi ke status HTTP dan pesan error.
+func versionStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, errReferenceNotFound):
+		return 404, "achievement not found"
+	case errors.Is(err, errVersionNotFound):
+		return 404, err.Error()
+	case errors.Is(err, errUnauthenticated), errors.Is(err, policy.ErrForbidden), errors.Is(err, policy.ErrProfileNotFound):
+		return policyStatus(err)
+	}
+
+	return 500, "failed to fetch achievement"
+}
+
+func versionError(c *fiber.Ctx, err error) error {
+	code, msg := versionStatus(err)
+	return c.Status(code).JSON(fiber.Map{"error": msg})
+}
+
+// load memuat dokumen prestasi yang berlaku dan memeriksa hak akses action
+// terhadap mahasiswa pemiliknya.
+func (s *AchievementVersionService) load(c *fiber.Ctx, action policy.Action) (*models.Achievement, error) {
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return nil, err
+	}
+
+	mongoID := c.Params("id")
+	ref, err := s.refRepo.GetByMongoAchievementID(c.UserContext(), mongoID)
+	if err != nil || ref == nil {
+		return nil, errReferenceNotFound
+	}
+
+	if err := s.authz.Authorize(c.UserContext(), sub, action, ref.StudentID); err != nil {
+		return nil, err
+	}
+
+	item, err := s.mongoRepo.GetByID(c.UserContext(), mongoID)
+	if err != nil {
+		log.Printf("[Versions] mongoRepo.GetByID error: %v", err)
+		return nil, err
+	}
+	if item == nil {
+		return nil, errReferenceNotFound
+	}
+
+	return item, nil
+}
+
+// version mengembalikan isi prestasi pada nomor versi v; versi yang sedang
+// berlaku diambil dari dokumen itu sendiri.
+func (s *AchievementVersionService) version(c *fiber.Ctx, item *models.Achievement, v int) (*models.Achievement, error) {
+	if v == item.Version {
+		return item, nil
+	}
+
+	old, err := s.mongoRepo.GetVersion(c.UserContext(), c.Params("id"), v)
+	if err != nil {
+		return nil, err
+	}
+	if old == nil {
+		return nil, errVersionNotFound
+	}
+	return &old.Snapshot, nil
+}
+
+func parseVersion(raw string) (int, error) {
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 0 {
+		return 0, errVersionNotFound
+	}
+	return v, nil
+}
+
+// ListVersions godoc
+// @Summary Daftar versi isi prestasi
+// @Description Versi lama (disimpan setiap draft atau lampiran diubah) ditambah versi yang sedang berlaku, urut dari yang paling lama. Akses sama dengan detail prestasi.
+// @Tags Achievement Versions
+// @Produce json
+// @Param id path string true "ID Prestasi di MongoDB"
+// @Success 200 {object} map[string]interface{} "Daftar versi"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Achievement not found"
+// @Security Bearer
+// @Router /api/v1/achievements/{id}/versions [get]
+func (s *AchievementVersionService) List(c *fiber.Ctx) error {
+	item, err := s.load(c, policy.ActionRead)
+	if err != nil {
+		return versionError(c, err)
+	}
+
+	versions, err := s.mongoRepo.ListVersions(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch versions"})
+	}
+
+	out := []models.AchievementVersionSummary{}
+	for i := range versions {
+		v := &versions[i]
+		out = append(out, models.AchievementVersionSummary{
+			Version:    v.Version,
+			Title:      v.Snapshot.Title,
+			UpdatedAt:  v.Snapshot.UpdatedAt,
+			ReplacedBy: v.ReplacedBy,
+			ArchivedAt: &v.ArchivedAt,
+		})
+	}
+	out = append(out, models.AchievementVersionSummary{
+		Version:   item.Version,
+		Title:     item.Title,
+		UpdatedAt: item.UpdatedAt,
+		Current:   true,
+	})
+
+	return c.JSON(fiber.Map{"success": true, "data": out})
+}
+
+// GetVersion godoc
+// @Summary Isi prestasi pada versi tertentu
+// @Tags Achievement Versions
+// @Produce json
+// @Param id path string true "ID Prestasi di MongoDB"
+// @Param version path int true "Nomor versi"
+// @Success 200 {object} map[string]interface{} "Isi prestasi pada versi tersebut"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Achievement atau versi tidak ditemukan"
+// @Security Bearer
+// @Router /api/v1/achievements/{id}/versions/{version} [get]
+func (s *AchievementVersionService) Get(c *fiber.Ctx) error {
+	item, err := s.load(c, policy.ActionRead)
+	if err != nil {
+		return versionError(c, err)
+	}
+
+	v, err := parseVersion(c.Params("version"))
+	if err != nil {
+		return versionError(c, err)
+	}
+
+	doc, err := s.version(c, item, v)
+	if err != nil {
+		return versionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"version": v,
+		"current": v == item.Version,
+		"data":    doc,
+	})
+}
+
+// DiffVersions godoc
+// @Summary Perbedaan isi antara dua versi prestasi
+// @Description Membandingkan field per field (nama JSON bertitik, mis. "details.rank"). Parameter to boleh dikosongkan untuk membandingkan dengan versi yang sedang berlaku.
+// @Tags Achievement Versions
+// @Produce json
+// @Param id path string true "ID Prestasi di MongoDB"
+// @Param from query int true "Versi awal"
+// @Param to query int false "Versi akhir (default: versi berlaku)"
+// @Success 200 {object} models.AchievementVersionDiff "Perbedaan field"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Achievement atau versi tidak ditemukan"
+// @Security Bearer
+// @Router /api/v1/achievements/{id}/versions/diff [get]
+func (s *AchievementVersionService) Diff(c *fiber.Ctx) error {
+	item, err := s.load(c, policy.ActionRead)
+	if err != nil {
+		return versionError(c, err)
+	}
+
+	from, err := parseVersion(c.Query("from"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "from must be a version number"})
+	}
+	to := item.Version
+	if raw := c.Query("to"); raw != "" {
+		if to, err = parseVersion(raw); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "to must be a version number"})
+		}
+	}
+
+	prev, err := s.version(c, item, from)
+	if err != nil {
+		return versionError(c, err)
+	}
+	cur, err := s.version(c, item, to)
+	if err != nil {
+		return versionError(c, err)
+	}
+
+	changes, err := diffAchievements(prev, cur)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to compare versions"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": models.AchievementVersionDiff{
+			From:    from,
+			To:      to,
+			Changes: changes,
+		},
+	})
+}
+
+// RestoreVersion godoc
+// @Summary Mengembalikan draft ke versi lama
+// @Description Isi draft diganti dengan isi versi tersebut sebagai versi baru; isi sebelum restore tetap tersimpan sehingga bisa dipulihkan lagi. Hanya untuk prestasi berstatus draft.
+// @Tags Achievement Versions
+// @Produce json
+// @Param id path string true "ID Prestasi di MongoDB"
+// @Param version path int true "Nomor versi"
+// @Success 200 {object} map[string]interface{} "Draft dipulihkan"
+// @Failure 400 {object} map[string]interface{} "Bukan draft atau versi sudah berlaku"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Achievement atau versi tidak ditemukan"
+// @Security Bearer
+// @Router /api/v1/achievements/{id}/versions/{version}/restore [post]
+func (s *AchievementVersionService) Restore(c *fiber.Ctx) error {
+	item, err := s.load(c, policy.ActionUpdate)
+	if err != nil {
+		return versionError(c, err)
+	}
+
+	v, err := parseVersion(c.Params("version"))
+	if err != nil {
+		return versionError(c, err)
+	}
+	if v == item.Version {
+		return c.Status(400).JSON(fiber.Map{"error": "version is already current"})
+	}
+	if _, err := s.version(c, item, v); err != nil {
+		return versionError(c, err)
+	}
+
+	var restored *models.Achievement
+	var restoreErr error
+	err = editDraft(c.UserContext(), s.uow, s.refRepo, item, func(ctx context.Context) error {
+		restored, restoreErr = s.mongoRepo.RestoreVersion(ctx, c.Params("id"), v)
+		return restoreErr
+	})
+	switch {
+	case errors.Is(err, errNotDraft):
+		return c.Status(400).JSON(fiber.Map{"error": "only draft can be restored"})
+	case restoreErr != nil:
+		return c.Status(400).JSON(fiber.Map{"error": restoreErr.Error()})
+	case err != nil:
+		log.Printf("[Versions] restore %s error: %v", c.Params("id"), err)
+		return c.Status(500).JSON(fiber.Map{"error": "failed to restore version"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":       true,
+		"restored_from": v,
+		"data":          restored,
+	})
+}
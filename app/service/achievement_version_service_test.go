@@ -0,0 +1,196 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// versionsAchievementID adalah ID Mongo prestasi yang dipakai setupVersions.
+const versionsAchievementID = "650000000000000000000001"
+
+// setupVersions memakai header X-Role dan X-User sebagai user yang login.
+func setupVersions() (*fiber.App, *mockAchievementRefRepo, *mockAchMongoRepo) {
+	mongoID, _ := primitive.ObjectIDFromHex(versionsAchievementID)
+	p := 10.0
+	mongo := &mockAchMongoRepo{item: &models.Achievement{
+		ID:          mongoID,
+		StudentID:   "student-1",
+		Title:       "Lomba Web",
+		Description: "Juara 3",
+		Points:      &p,
+		Status:      models.StatusDraft,
+		Version:     1,
+		UpdatedAt:   time.Now(),
+	}}
+	ref := &mockAchievementRefRepo{ref: &models.AchievementReference{
+		ID:                 "ref-1",
+		StudentID:          "student-1",
+		MongoAchievementID: mongoID.Hex(),
+		Status:             models.StatusDraft,
+	}}
+
+	drafts := NewAchievementMongoService(mongo, ref, &mockAchievementStudentRepo{}, &mockAchievementLecturerRepo{}, repository.NewMemoryUnitOfWork())
+	versions := NewAchievementVersionService(ref, mongo, &mockAchievementStudentRepo{}, &mockAchievementLecturerRepo{}, repository.NewMemoryUnitOfWork())
+
+	app := fiber.New()
+	app.Use(headerUser)
+	app.Put("/achievements/:id", drafts.UpdateDraft)
+	app.Get("/achievements/:id/versions", versions.List)
+	app.Get("/achievements/:id/versions/diff", versions.Diff)
+	app.Get("/achievements/:id/versions/:version", versions.Get)
+	app.Post("/achievements/:id/versions/:version/restore", versions.Restore)
+
+	return app, ref, mongo
+}
+
+// versionRequest mengirim request ke path di bawah prestasi setupVersions
+// sebagai user "user-<role>".
+func versionRequest(t *testing.T, app *fiber.App, method, path, role string, body interface{}) *http.Response {
+	return doRequest(t, app, method, "/achievements/"+versionsAchievementID+path, body, as(role, "user-"+role))
+}
+
+func putDraft(t *testing.T, app *fiber.App, title, description string) {
+	resp := versionRequest(t, app, http.MethodPut, "", "Mahasiswa", models.UpdateAchievementRequest{
+		AchievementType: "competition",
+		Title:           title,
+		Description:     description,
+	})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+//
+// =======================================================
+// DAFTAR & ISI VERSI
+// =======================================================
+//
+
+func TestVersions_UpdateDraftArchivesPrevious(t *testing.T) {
+	app, _, _ := setupVersions()
+
+	putDraft(t, app, "Lomba Web Nasional", "Juara 3")
+	putDraft(t, app, "Lomba Web Nasional", "Juara 2")
+
+	resp := versionRequest(t, app, http.MethodGet, "/versions", "Dosen Wali", nil)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var list struct {
+		Data []models.AchievementVersionSummary `json:"data"`
+	}
+	decodeBody(t, resp, &list)
+	assert.Len(t, list.Data, 3)
+	assert.Equal(t, "Lomba Web", list.Data[0].Title)
+	assert.Equal(t, models.VersionReplacedByUpdate, list.Data[0].ReplacedBy)
+	assert.True(t, list.Data[2].Current)
+	assert.Equal(t, 3, list.Data[2].Version)
+
+	resp = versionRequest(t, app, http.MethodGet, "/versions/1", "Mahasiswa", nil)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var one struct {
+		Current bool               `json:"current"`
+		Data    models.Achievement `json:"data"`
+	}
+	decodeBody(t, resp, &one)
+	assert.False(t, one.Current)
+	assert.Equal(t, "Lomba Web", one.Data.Title)
+
+	resp = versionRequest(t, app, http.MethodGet, "/versions/9", "Mahasiswa", nil)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestVersions_SameAccessAsDetail(t *testing.T) {
+	app, repo, _ := setupVersions()
+	repo.ref.StudentID = "student-2"
+
+	resp := versionRequest(t, app, http.MethodGet, "/versions", "Mahasiswa", nil)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+//
+// =======================================================
+// DIFF
+// =======================================================
+//
+
+func TestVersions_DiffBetweenAnyTwo(t *testing.T) {
+	app, _, _ := setupVersions()
+
+	putDraft(t, app, "Lomba Web Nasional", "Juara 3")
+	putDraft(t, app, "Lomba Web Nasional", "Juara 2")
+
+	// tanpa "to" dibandingkan dengan versi berlaku
+	resp := versionRequest(t, app, http.MethodGet, "/versions/diff?from=1", "Dosen Wali", nil)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data models.AchievementVersionDiff `json:"data"`
+	}
+	decodeBody(t, resp, &body)
+	assert.Equal(t, 3, body.Data.To)
+
+	fields := map[string]bool{}
+	for _, ch := range body.Data.Changes {
+		fields[ch.Field] = true
+	}
+	assert.True(t, fields["title"])
+	assert.True(t, fields["description"])
+	assert.False(t, fields["version"])
+
+	resp = versionRequest(t, app, http.MethodGet, "/versions/diff?from=2&to=3", "Dosen Wali", nil)
+	decodeBody(t, resp, &body)
+	assert.Len(t, body.Data.Changes, 1)
+	assert.Equal(t, "description", body.Data.Changes[0].Field)
+	assert.Equal(t, "Juara 3", body.Data.Changes[0].Old)
+
+	resp = versionRequest(t, app, http.MethodGet, "/versions/diff?from=x", "Dosen Wali", nil)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+//
+// =======================================================
+// RESTORE
+// =======================================================
+//
+
+func TestVersions_RestoreIsUndoable(t *testing.T) {
+	app, _, mongoRepo := setupVersions()
+
+	putDraft(t, app, "Salah ketik", "Juara 3")
+
+	resp := versionRequest(t, app, http.MethodPost, "/versions/1/restore", "Mahasiswa", nil)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "Lomba Web", mongoRepo.item.Title)
+	assert.Equal(t, 3, mongoRepo.item.Version)
+
+	// isi sebelum restore tetap tersimpan sebagai versi 2
+	last := mongoRepo.versions[len(mongoRepo.versions)-1]
+	assert.Equal(t, 2, last.Version)
+	assert.Equal(t, "Salah ketik", last.Snapshot.Title)
+	assert.Equal(t, models.VersionReplacedByRestore, last.ReplacedBy)
+
+	resp = versionRequest(t, app, http.MethodPost, "/versions/3/restore", "Mahasiswa", nil)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestVersions_RestoreOnlyDraftAndOwner(t *testing.T) {
+	app, refRepo, mongoRepo := setupVersions()
+	putDraft(t, app, "Lomba Web Nasional", "Juara 3")
+
+	// dosen wali hanya membaca
+	resp := versionRequest(t, app, http.MethodPost, "/versions/1/restore", "Dosen Wali", nil)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	// status Postgres yang menentukan, walau Mongo belum tersinkron
+	refRepo.ref.Status = models.StatusSubmitted
+	resp = versionRequest(t, app, http.MethodPost, "/versions/1/restore", "Mahasiswa", nil)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "Lomba Web Nasional", mongoRepo.item.Title)
+}
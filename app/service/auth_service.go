@@ -1,8 +1,12 @@
 package service
 
 import (
+	"context"
 	"database/sql"
-	"sync"
+	"log"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	models "achievement_backend/app/model"
@@ -10,6 +14,8 @@ import (
 	"achievement_backend/utils"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -19,19 +25,19 @@ type AuthService struct {
 	rolePermRepo repository.RolePermissionRepository
 	studentRepo  repository.StudentRepository
 	lecturerRepo repository.LecturerRepository
+	refreshStore repository.RefreshTokenStore
+	revocations  repository.TokenRevocationStore
+	authState    repository.AuthStateRepository
+	authRepo     repository.AuthRepository
+	loginGuard   *LoginGuard
+	passwords    *PasswordManager
+	mfa          *MFAService
+	sessions     *SessionService
 }
 
-type refreshEntry struct {
-	ExpiresAt time.Time
-	UserID    string
-}
+var refreshTokenTTL = time.Hour * 24 * 7 // 7 hari
 
-var (
-	// refreshTokens maps refresh token -> metadata (expires, user)
-	refreshTokens   = make(map[string]refreshEntry)
-	refreshMutex    sync.RWMutex
-	refreshTokenTTL = time.Hour * 24 * 7 // 7 hari
-)
+var mfaChallengeTTL = 5 * time.Minute
 
 func NewAuthService(
 	userRepo repository.UserRepository,
@@ -39,6 +45,14 @@ func NewAuthService(
 	rolePermRepo repository.RolePermissionRepository,
 	studentRepo repository.StudentRepository,
 	lecturerRepo repository.LecturerRepository,
+	refreshStore repository.RefreshTokenStore,
+	revocations repository.TokenRevocationStore,
+	authState repository.AuthStateRepository,
+	authRepo repository.AuthRepository,
+	loginGuard *LoginGuard,
+	passwords *PasswordManager,
+	mfa *MFAService,
+	sessions *SessionService,
 ) *AuthService {
 	return &AuthService{
 		userRepo:     userRepo,
@@ -46,20 +60,99 @@ func NewAuthService(
 		rolePermRepo: rolePermRepo,
 		studentRepo:  studentRepo,
 		lecturerRepo: lecturerRepo,
+		refreshStore: refreshStore,
+		revocations:  revocations,
+		authState:    authState,
+		authRepo:     authRepo,
+		loginGuard:   loginGuard,
+		passwords:    passwords,
+		mfa:          mfa,
+		sessions:     sessions,
 	}
 }
 
+// authVersion mengambil versi user dan role terkini untuk ditanam ke JWT.
+func (s *AuthService) authVersion(ctx context.Context, userID string) (models.AuthVersion, error) {
+	st, err := s.authState.GetAuthState(ctx, userID)
+	if err != nil {
+		return models.AuthVersion{}, err
+	}
+	return models.AuthVersion{User: st.UserVersion, Role: st.RoleVersion}, nil
+}
+
+// issueRefreshToken membuat refresh token baru dalam family yang diberikan
+// dan menyimpan hash-nya ke store.
+func (s *AuthService) issueRefreshToken(ctx context.Context, userID, familyID string) (string, error) {
+	raw := utils.GenerateRefreshToken()
+
+	err := s.refreshStore.Create(ctx, &models.RefreshToken{
+		TokenHash: utils.HashToken(raw),
+		UserID:    userID,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return raw, nil
+}
+
+// recordLoginFailure mencatat login gagal untuk IP dan (jika diketahui) akunnya.
+// Error store hanya di-log agar login tetap menjawab 401 yang sama.
+func (s *AuthService) recordLoginFailure(ctx context.Context, ip string, userID *string) {
+	if err := s.loginGuard.Fail(ctx, models.LoginAttemptIP, ip, nil); err != nil {
+		log.Printf("[Login] record ip failure error: %v", err)
+	}
+	if userID != nil {
+		if err := s.loginGuard.Fail(ctx, models.LoginAttemptAccount, *userID, userID); err != nil {
+			log.Printf("[Login] record account failure error: %v", err)
+		}
+	}
+}
+
+// loginBlocked menjawab 429 beserta Retry-After jika akun/IP dikunci atau dijeda.
+func loginBlocked(c *fiber.Ctx, err error) error {
+	blocked, ok := err.(*LoginBlockedError)
+	if !ok {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	retry := int(math.Ceil(time.Until(blocked.Until).Seconds()))
+	if retry < 1 {
+		retry = 1
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retry))
+
+	msg := "too many login attempts, please wait before retrying"
+	if blocked.Locked {
+		msg = "account temporarily locked due to too many failed login attempts"
+		if blocked.Kind == models.LoginAttemptIP {
+			msg = "too many failed login attempts from this address"
+		}
+	}
+
+	return c.Status(429).JSON(fiber.Map{
+		"error":       msg,
+		"locked":      blocked.Locked,
+		"retry_after": retry,
+		"until":       blocked.Until,
+	})
+}
+
 // Login godoc
 // @Summary Login pengguna
-// @Description Autentikasi pengguna menggunakan username dan password, mengembalikan access token dan refresh token
+// @Description Autentikasi pengguna menggunakan username, email, NIM atau NIP beserta password, mengembalikan access token dan refresh token.
+// @Description Jika MFA aktif atau diwajibkan role, response berstatus "mfa_required" berisi token tantangan untuk /auth/mfa/verify.
 // @Tags Auth
 // @Accept json
 // @Produce json
-// @Param body body models.LoginRequest true "Username dan Password"
+// @Param body body models.LoginRequest true "Username/email/NIM/NIP dan Password"
 // @Success 200 {object} models.LoginResponse "Login berhasil"
 // @Failure 400 {object} map[string]interface{} "Request tidak valid"
 // @Failure 401 {object} map[string]interface{} "Username atau password salah"
 // @Failure 403 {object} map[string]interface{} "User tidak aktif"
+// @Failure 429 {object} map[string]interface{} "Terlalu banyak percobaan gagal, akun/IP dikunci sementara"
 // @Failure 500 {object} map[string]interface{} "Kesalahan server"
 // @Router /api/v1/auth/login [post]
 func (s *AuthService) Login(c *fiber.Ctx) error {
@@ -68,15 +161,28 @@ func (s *AuthService) Login(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
 	}
 
-	// Ambil user
-	user, err := s.userRepo.GetByUsername(req.Username)
+	ip := c.IP()
+
+	// IP yang sedang dikunci / dijeda tidak boleh mencoba sama sekali
+	if err := s.loginGuard.Check(c.UserContext(), models.LoginAttemptIP, ip); err != nil {
+		return loginBlocked(c, err)
+	}
+
+	// Ambil user (username, email, NIM atau NIP)
+	user, err := s.authRepo.GetForLogin(c.UserContext(), strings.TrimSpace(req.Username))
 	if err != nil {
-		if err == sql.ErrNoRows {
+		// identifier ambigu diperlakukan sama dengan user tidak ditemukan
+		if err == sql.ErrNoRows || err == repository.ErrAmbiguousLogin {
+			s.recordLoginFailure(c.UserContext(), ip, nil)
 			return c.Status(401).JSON(fiber.Map{"error": "wrong username or password"})
 		}
 		return c.Status(500).JSON(fiber.Map{"error": "database error"})
 	}
 
+	if err := s.loginGuard.Check(c.UserContext(), models.LoginAttemptAccount, user.ID); err != nil {
+		return loginBlocked(c, err)
+	}
+
 	// User nonaktif → tidak boleh login
 	if !user.IsActive {
 		return c.Status(403).JSON(fiber.Map{"error": "user inactive"})
@@ -84,13 +190,57 @@ func (s *AuthService) Login(c *fiber.Ctx) error {
 
 	// Cek password
 	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		s.recordLoginFailure(c.UserContext(), ip, &user.ID)
 		return c.Status(401).JSON(fiber.Map{"error": "wrong username or password"})
 	}
 
+	return s.authenticated(c, user)
+}
+
+// authenticated melanjutkan login user yang identitasnya sudah terbukti
+// (lewat password atau SSO): tantangan MFA jika perlu, lalu token.
+func (s *AuthService) authenticated(c *fiber.Ctx, user *models.User) error {
+	// MFA aktif atau diwajibkan role → password saja belum cukup.
+	// Hitungan gagal belum di-reset agar kode TOTP tidak bisa di-brute force.
+	cfg, required, err := s.mfa.state(c.UserContext(), user)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+	if required || mfaEnabled(cfg) {
+		return s.mfaChallenge(c, user, mfaEnabled(cfg))
+	}
+
+	if err := s.loginGuard.Succeed(c.UserContext(), user.ID); err != nil {
+		log.Printf("[Login] reset failed attempts error: %v", err)
+	}
+
+	return s.completeLogin(c, user, nil)
+}
+
+// mfaChallenge menjawab login dengan token tantangan MFA, bukan JWT.
+func (s *AuthService) mfaChallenge(c *fiber.Ctx, user *models.User, enrolled bool) error {
+	token, exp, err := utils.GenerateMFAChallengeToken(user.ID, mfaChallengeTTL)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to generate token"})
+	}
+
+	return c.JSON(models.MFAChallengeResponse{
+		Status: "mfa_required",
+		Data: models.MFAChallengeData{
+			MFAToken:  token,
+			Enrolled:  enrolled,
+			ExpiresAt: exp,
+		},
+	})
+}
+
+// completeLogin menerbitkan access token dan refresh token untuk user yang
+// sudah lolos semua pemeriksaan login.
+func (s *AuthService) completeLogin(c *fiber.Ctx, user *models.User, recoveryCodes []string) error {
 	// ===============================================================
 	// GET ROLE NAME
 	// ===============================================================
-	role, err := s.roleRepo.GetByID(*user.RoleID)
+	role, err := s.roleRepo.GetByID(c.UserContext(), *user.RoleID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "role not found"})
 	}
@@ -98,7 +248,7 @@ func (s *AuthService) Login(c *fiber.Ctx) error {
 	// ===============================================================
 	// GET PERMISSIONS
 	// ===============================================================
-	perms, _ := s.rolePermRepo.GetPermissionsByRole(*user.RoleID)
+	perms, _ := s.rolePermRepo.GetPermissionsByRole(c.UserContext(), *user.RoleID)
 
 	var permList []string
 	for _, p := range perms {
@@ -108,7 +258,18 @@ func (s *AuthService) Login(c *fiber.Ctx) error {
 	// ===============================================================
 	// GENERATE ACCESS TOKEN
 	// ===============================================================
-	accessToken, err := utils.GenerateToken(*user, role.Name, permList)
+	version, err := s.authVersion(c.UserContext(), user.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	// setiap login adalah sesi baru; ID sesi = family refresh token
+	sessionID := uuid.New().String()
+	if err := s.sessions.start(c, user.ID, sessionID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to create session"})
+	}
+
+	accessToken, err := utils.GenerateToken(*user, role.Name, permList, version, sessionID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "failed to generate token"})
 	}
@@ -116,11 +277,10 @@ func (s *AuthService) Login(c *fiber.Ctx) error {
 	// ===============================================================
 	// GENERATE REFRESH TOKEN
 	// ===============================================================
-	refreshToken := utils.GenerateRefreshToken()
-
-	refreshMutex.Lock()
-	refreshTokens[refreshToken] = refreshEntry{ExpiresAt: time.Now().Add(refreshTokenTTL), UserID: user.ID}
-	refreshMutex.Unlock()
+	refreshToken, err := s.issueRefreshToken(c.UserContext(), user.ID, sessionID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to generate refresh token"})
+	}
 
 	// ===============================================================
 	// SUCCESS RESPONSE (SESUIAI SRS)
@@ -137,15 +297,148 @@ func (s *AuthService) Login(c *fiber.Ctx) error {
 				Role:        role.Name,
 				Permissions: permList,
 			},
+			RecoveryCodes: recoveryCodes,
 		},
 	}
 
 	return c.JSON(resp)
 }
 
+// mfaChallengeUser memvalidasi token tantangan MFA dan mengembalikan user-nya.
+func (s *AuthService) mfaChallengeUser(ctx context.Context, token string) (*models.User, *jwt.RegisteredClaims, error) {
+	claims, err := utils.ValidateMFAChallengeToken(token)
+	if err != nil {
+		return nil, nil, errUnauthenticated
+	}
+
+	revoked, err := s.revocations.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if revoked {
+		return nil, nil, errUnauthenticated
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.Subject)
+	if err != nil || user == nil {
+		return nil, nil, errUnauthenticated
+	}
+
+	return user, claims, nil
+}
+
+// SetupMFA godoc
+// @Summary Enrollment TOTP saat login
+// @Description Untuk user yang role-nya mewajibkan MFA tetapi belum enroll: menukar token tantangan MFA dengan secret TOTP dan URI otpauth:// (payload QR code). Kode pertama dikirim ke /auth/mfa/verify.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body models.MFASetupRequest true "Token tantangan MFA dari login"
+// @Success 200 {object} models.MFAEnrollment "Secret dan URI provisioning"
+// @Failure 401 {object} map[string]interface{} "Token tantangan tidak valid atau kadaluarsa"
+// @Failure 409 {object} map[string]interface{} "MFA sudah aktif"
+// @Failure 500 {object} map[string]interface{} "Kesalahan server"
+// @Router /api/v1/auth/mfa/setup [post]
+func (s *AuthService) SetupMFA(c *fiber.Ctx) error {
+	var req models.MFASetupRequest
+	if err := c.BodyParser(&req); err != nil || req.MFAToken == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "mfa_token required"})
+	}
+
+	user, _, err := s.mfaChallengeUser(c.UserContext(), req.MFAToken)
+	if err != nil {
+		if err == errUnauthenticated {
+			return c.Status(401).JSON(fiber.Map{"error": "invalid or expired mfa token"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	enrollment, err := s.mfa.beginEnrollment(c.UserContext(), user)
+	if err != nil {
+		return mfaError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"success": true, "data": enrollment})
+}
+
+// VerifyMFA godoc
+// @Summary Langkah kedua login (TOTP)
+// @Description Menukar token tantangan MFA dan kode TOTP (atau kode pemulihan) dengan access token dan refresh token. Jika MFA belum aktif, kode pertama sekaligus mengaktifkan MFA dan kode pemulihan dikembalikan sekali.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body models.MFAVerifyRequest true "Token tantangan MFA dan kode"
+// @Success 200 {object} models.LoginResponse "Login berhasil"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid atau enrollment belum dimulai"
+// @Failure 401 {object} map[string]interface{} "Token tantangan atau kode salah"
+// @Failure 403 {object} map[string]interface{} "User tidak aktif"
+// @Failure 429 {object} map[string]interface{} "Terlalu banyak percobaan gagal"
+// @Failure 500 {object} map[string]interface{} "Kesalahan server"
+// @Router /api/v1/auth/mfa/verify [post]
+func (s *AuthService) VerifyMFA(c *fiber.Ctx) error {
+	var req models.MFAVerifyRequest
+	if err := c.BodyParser(&req); err != nil || req.MFAToken == "" || req.Code == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "mfa_token and code required"})
+	}
+
+	ip := c.IP()
+	if err := s.loginGuard.Check(c.UserContext(), models.LoginAttemptIP, ip); err != nil {
+		return loginBlocked(c, err)
+	}
+
+	user, claims, err := s.mfaChallengeUser(c.UserContext(), req.MFAToken)
+	if err != nil {
+		if err == errUnauthenticated {
+			return c.Status(401).JSON(fiber.Map{"error": "invalid or expired mfa token"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	if err := s.loginGuard.Check(c.UserContext(), models.LoginAttemptAccount, user.ID); err != nil {
+		return loginBlocked(c, err)
+	}
+
+	if !user.IsActive {
+		return c.Status(403).JSON(fiber.Map{"error": "user inactive"})
+	}
+
+	cfg, _, err := s.mfa.state(c.UserContext(), user)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	var recoveryCodes []string
+	if mfaEnabled(cfg) {
+		err = s.mfa.verify(c.UserContext(), user.ID, req.Code)
+	} else {
+		recoveryCodes, err = s.mfa.activate(c.UserContext(), user.ID, req.Code)
+	}
+	if err != nil {
+		if err == errMFAInvalidCode {
+			// kode salah dihitung sama dengan password salah
+			s.recordLoginFailure(c.UserContext(), ip, &user.ID)
+			return c.Status(401).JSON(fiber.Map{"error": "invalid mfa code"})
+		}
+		return mfaError(c, err)
+	}
+
+	// token tantangan hanya bisa dipakai sekali
+	if err := s.revocations.Revoke(c.UserContext(), claims.ID, claims.ExpiresAt.Time); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to revoke token"})
+	}
+
+	if err := s.loginGuard.Succeed(c.UserContext(), user.ID); err != nil {
+		log.Printf("[Login] reset failed attempts error: %v", err)
+	}
+
+	return s.completeLogin(c, user, recoveryCodes)
+}
+
 // RefreshToken godoc
 // @Summary Refresh access token
-// @Description Menghasilkan access token baru menggunakan refresh token yang masih valid
+// @Description Menghasilkan access token baru menggunakan refresh token yang masih valid.
+// @Description Refresh token lama langsung hangus dan diganti refresh token baru (rotasi).
+// @Description Memakai ulang refresh token yang sudah dirotasi akan mencabut seluruh family token tersebut.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -164,23 +457,58 @@ func (s *AuthService) RefreshToken(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "refresh_token required"})
 	}
 
+	tokenHash := utils.HashToken(body.Refresh)
+
 	// cek refresh token valid atau expired
-	refreshMutex.RLock()
-	entry, exists := refreshTokens[body.Refresh]
-	refreshMutex.RUnlock()
+	entry, err := s.refreshStore.GetByHash(c.UserContext(), tokenHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(401).JSON(fiber.Map{"error": "invalid or expired refresh token"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
 
-	if !exists || time.Now().After(entry.ExpiresAt) {
+	if entry.RevokedAt != nil || time.Now().After(entry.ExpiresAt) {
 		return c.Status(401).JSON(fiber.Map{"error": "invalid or expired refresh token"})
 	}
 
+	// token yang sudah dirotasi dipakai lagi → anggap bocor, cabut seluruh family
+	if entry.ConsumedAt != nil {
+		_ = s.refreshStore.RevokeFamily(c.UserContext(), entry.FamilyID)
+		return c.Status(401).JSON(fiber.Map{"error": "refresh token reuse detected"})
+	}
+
+	if err := s.refreshStore.Consume(c.UserContext(), tokenHash); err != nil {
+		if err == sql.ErrNoRows {
+			// kalah balapan dengan request lain yang memakai token yang sama
+			_ = s.refreshStore.RevokeFamily(c.UserContext(), entry.FamilyID)
+			return c.Status(401).JSON(fiber.Map{"error": "refresh token reuse detected"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	active, err := s.sessions.touch(c, entry.FamilyID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+	if !active {
+		_ = s.refreshStore.RevokeFamily(c.UserContext(), entry.FamilyID)
+		return c.Status(401).JSON(fiber.Map{"error": "session revoked"})
+	}
+
 	// get user id from stored refresh token entry
-	user, err := s.userRepo.GetByID(entry.UserID)
+	user, err := s.userRepo.GetByID(c.UserContext(), entry.UserID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "user not found"})
 	}
 
+	if !user.IsActive {
+		_ = s.refreshStore.RevokeFamily(c.UserContext(), entry.FamilyID)
+		return c.Status(403).JSON(fiber.Map{"error": "user inactive"})
+	}
+
 	// permissions
-	perms, _ := s.rolePermRepo.GetPermissionsByRole(*user.RoleID)
+	perms, _ := s.rolePermRepo.GetPermissionsByRole(c.UserContext(), *user.RoleID)
 
 	var permList []string
 	for _, p := range perms {
@@ -188,23 +516,34 @@ func (s *AuthService) RefreshToken(c *fiber.Ctx) error {
 	}
 
 	// ambil role
-	role, _ := s.roleRepo.GetByID(*user.RoleID)
+	role, _ := s.roleRepo.GetByID(c.UserContext(), *user.RoleID)
+
+	version, err := s.authVersion(c.UserContext(), user.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
 
 	// token baru
-	newToken, err := utils.GenerateToken(*user, role.Name, permList)
+	newToken, err := utils.GenerateToken(*user, role.Name, permList, version, entry.FamilyID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "failed to generate token"})
 	}
 
+	newRefresh, err := s.issueRefreshToken(c.UserContext(), user.ID, entry.FamilyID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to generate refresh token"})
+	}
+
 	return c.JSON(fiber.Map{
-		"success": true,
-		"token":   newToken,
+		"success":       true,
+		"token":         newToken,
+		"refresh_token": newRefresh,
 	})
 }
 
 // Logout godoc
 // @Summary Logout pengguna
-// @Description Logout pengguna dan mem-blacklist access token serta menghapus refresh token
+// @Description Logout pengguna, mencabut access token (berdasarkan jti) sampai waktu kadaluarsanya, serta mencabut sesi dan refresh token perangkat ini saja
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -213,30 +552,31 @@ func (s *AuthService) RefreshToken(c *fiber.Ctx) error {
 // @Security Bearer
 // @Router /api/v1/auth/logout [post]
 func (s *AuthService) Logout(c *fiber.Ctx) error {
-	raw := c.Locals("raw_token")
-	if raw == nil {
+	jti, ok := c.Locals("jti").(string)
+	if !ok || jti == "" {
 		return c.Status(400).JSON(fiber.Map{"error": "token not found in context"})
 	}
 
-	token := raw.(string)
-	exp := time.Now().Add(time.Hour * 1)
+	exp, ok := c.Locals("token_exp").(time.Time)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "token not found in context"})
+	}
 
-	// add token to global utils blacklist so middleware recognizes it
-	utils.BlacklistMutex.Lock()
-	utils.TokenBlacklist[token] = exp
-	utils.BlacklistMutex.Unlock()
+	// token tetap dicabut sampai exp aslinya
+	if err := s.revocations.Revoke(c.UserContext(), jti, exp); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to revoke token"})
+	}
 
-	// Revoke refresh tokens belonging to this user (if available in context)
-	uid := c.Locals("user_id")
-	if uid != nil {
-		userID := uid.(string)
-		refreshMutex.Lock()
-		for t, e := range refreshTokens {
-			if e.UserID == userID {
-				delete(refreshTokens, t)
-			}
+	// hanya sesi ini yang diakhiri; sesi di perangkat lain tetap berjalan
+	if sid, _ := c.Locals("session_id").(string); sid != "" {
+		if err := s.sessions.revoke(c.UserContext(), sid); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "failed to revoke session"})
+		}
+	} else if uid, ok := c.Locals("user_id").(string); ok {
+		// token lama tanpa sid: tidak tahu sesinya, cabut semua refresh token user
+		if err := s.refreshStore.RevokeByUser(c.UserContext(), uid); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "failed to revoke refresh tokens"})
 		}
-		refreshMutex.Unlock()
 	}
 
 	return c.JSON(fiber.Map{
@@ -245,6 +585,75 @@ func (s *AuthService) Logout(c *fiber.Ctx) error {
 	})
 }
 
+// ChangePassword godoc
+// @Summary Mengganti password sendiri
+// @Description Mengganti password user yang sedang login. Password lama wajib diisi dan password baru harus memenuhi policy (panjang minimal, jenis karakter, bukan username/NIM/NIP, dan belum dipakai baru-baru ini).
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body models.ChangePasswordRequest true "Password lama dan password baru"
+// @Success 200 {object} map[string]interface{} "Password berhasil diganti"
+// @Failure 400 {object} map[string]interface{} "Password lama salah atau password baru tidak memenuhi policy"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Kesalahan server"
+// @Security Bearer
+// @Router /api/v1/auth/password [put]
+func (s *AuthService) ChangePassword(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	if userID == "" {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req models.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "current_password and new_password required"})
+	}
+
+	user, err := s.userRepo.GetByID(c.UserContext(), userID)
+	if err != nil || user == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)) != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "current password is incorrect"})
+	}
+
+	if err := s.passwords.Validate(c.UserContext(), user, req.NewPassword); err != nil {
+		return passwordError(c, err)
+	}
+
+	if err := s.passwords.Set(c.UserContext(), userID, req.NewPassword); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to update password"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "password changed",
+	})
+}
+
+// JWKS godoc
+// @Summary Public key untuk verifikasi JWT
+// @Description Mengembalikan JSON Web Key Set berisi public key (RS256/EdDSA) yang masih aktif, agar layanan lain dapat memverifikasi token tanpa berbagi secret. Kunci HS256 tidak dipublikasikan.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} utils.JWKS "JSON Web Key Set"
+// @Failure 503 {object} map[string]interface{} "Kunci belum dikonfigurasi"
+// @Router /.well-known/jwks.json [get]
+func (s *AuthService) JWKS(c *fiber.Ctx) error {
+	ks := utils.CurrentKeySet()
+	if ks == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "signing keys not configured"})
+	}
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(ks.JWKS())
+}
+
 // GetProfile godoc
 // @Summary Mendapatkan profil pengguna
 // @Description Mengambil data profil pengguna berdasarkan token (Mahasiswa, Dosen Wali, atau Admin)
@@ -260,7 +669,7 @@ func (s *AuthService) GetProfile(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(string)
 	role := c.Locals("role_name").(string)
 
-	user, err := s.userRepo.GetByID(userID)
+	user, err := s.userRepo.GetByID(c.UserContext(), userID)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"success": false,
@@ -279,11 +688,11 @@ func (s *AuthService) GetProfile(c *fiber.Ctx) error {
 
 	switch role {
 	case "Mahasiswa":
-		student, _ := s.studentRepo.GetByUserID(userID)
+		student, _ := s.studentRepo.GetByUserID(c.UserContext(), userID)
 		data["profile"] = student
 
 	case "Dosen Wali":
-		lecturer, _ := s.lecturerRepo.GetByUserID(userID)
+		lecturer, _ := s.lecturerRepo.GetByUserID(c.UserContext(), userID)
 		data["profile"] = lecturer
 
 	case "Admin":
@@ -1,17 +1,22 @@
 package service
 
 import (
+	"context"
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	models "achievement_backend/app/model"
-	// "achievement_backend/app/repository"
+	"achievement_backend/app/repository"
+	"achievement_backend/utils"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -24,16 +29,17 @@ import (
 
 // ---------- USER ----------
 type mockAuthUserRepo struct {
-	users map[string]*models.User
+	users           map[string]*models.User
+	revokedSessions []string
 }
 
 func newMockAuthUserRepo() *mockAuthUserRepo {
 	return &mockAuthUserRepo{users: map[string]*models.User{}}
 }
 
-func (m *mockAuthUserRepo) GetAll() ([]models.User, error) { return nil, nil }
+func (m *mockAuthUserRepo) GetAll(ctx context.Context) ([]models.User, error) { return nil, nil }
 
-func (m *mockAuthUserRepo) GetByID(id string) (*models.User, error) {
+func (m *mockAuthUserRepo) GetByID(ctx context.Context, id string) (*models.User, error) {
 	u, ok := m.users[id]
 	if !ok {
 		return nil, fiber.ErrNotFound
@@ -41,9 +47,9 @@ func (m *mockAuthUserRepo) GetByID(id string) (*models.User, error) {
 	return u, nil
 }
 
-func (m *mockAuthUserRepo) GetByEmail(email string) (*models.User, error) { return nil, nil }
+func (m *mockAuthUserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) { return nil, nil }
 
-func (m *mockAuthUserRepo) GetByUsername(username string) (*models.User, error) {
+func (m *mockAuthUserRepo) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	for _, u := range m.users {
 		if u.Username == username {
 			return u, nil
@@ -52,58 +58,78 @@ func (m *mockAuthUserRepo) GetByUsername(username string) (*models.User, error)
 	return nil, fiber.ErrNotFound
 }
 
-func (m *mockAuthUserRepo) Create(req models.CreateUserRequest) (*models.User, error) {
+// GetForLogin membuat mock ini sekaligus memenuhi repository.AuthRepository.
+func (m *mockAuthUserRepo) GetForLogin(ctx context.Context, identifier string) (*models.User, error) {
+	for _, u := range m.users {
+		if u.Username == identifier || (u.Email != "" && strings.EqualFold(u.Email, identifier)) {
+			return u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockAuthUserRepo) Create(ctx context.Context, req models.CreateUserRequest) (*models.User, error) {
 	return nil, nil
 }
 
-func (m *mockAuthUserRepo) UpdatePartial(u *models.User) (*models.User, error) {
+func (m *mockAuthUserRepo) UpdatePartial(ctx context.Context, u *models.User) (*models.User, error) {
 	return u, nil
 }
 
-func (m *mockAuthUserRepo) UpdatePassword(id string, hash string) error {
+func (m *mockAuthUserRepo) UpdatePassword(ctx context.Context, id string, hash string) error {
+	if u, ok := m.users[id]; ok {
+		u.PasswordHash = hash
+	}
 	return nil
 }
 
-func (m *mockAuthUserRepo) Delete(id string) error { return nil }
+func (m *mockAuthUserRepo) RevokeSessions(ctx context.Context, id string) error {
+	m.revokedSessions = append(m.revokedSessions, id)
+	return nil
+}
+
+func (m *mockAuthUserRepo) Delete(ctx context.Context, id string) error { return nil }
 
 // ---------- ROLE ----------
 type mockAuthRoleRepo struct{}
 
-func (m *mockAuthRoleRepo) GetAll() ([]models.Role, error) { return nil, nil }
+func (m *mockAuthRoleRepo) GetAll(ctx context.Context) ([]models.Role, error) { return nil, nil }
 
-func (m *mockAuthRoleRepo) GetByID(id string) (*models.Role, error) {
+func (m *mockAuthRoleRepo) GetByID(ctx context.Context, id string) (*models.Role, error) {
 	return &models.Role{ID: id, Name: "student"}, nil
 }
 
-func (m *mockAuthRoleRepo) Create(req models.CreateRoleRequest) (*models.Role, error) {
+func (m *mockAuthRoleRepo) Create(ctx context.Context, req models.CreateRoleRequest) (*models.Role, error) {
 	return nil, nil
 }
 
-func (m *mockAuthRoleRepo) Update(id string, req models.UpdateRoleRequest) (*models.Role, error) {
+func (m *mockAuthRoleRepo) Update(ctx context.Context, id string, req models.UpdateRoleRequest) (*models.Role, error) {
 	return nil, nil
 }
 
-func (m *mockAuthRoleRepo) Delete(id string) error { return nil }
+func (m *mockAuthRoleRepo) Delete(ctx context.Context, id string) error { return nil }
+
+func (m *mockAuthRoleRepo) CountUsers(ctx context.Context, id string) (int, error) { return 0, nil }
 
 // ---------- ROLE PERMISSION ----------
 type mockRolePermRepo struct{}
 
-func (m *mockRolePermRepo) AssignPermission(roleID string, permissionID string) error {
+func (m *mockRolePermRepo) AssignPermission(ctx context.Context, roleID string, permissionID string) error {
 	return nil
 }
 
-func (m *mockRolePermRepo) RemovePermission(roleID string, permissionID string) error {
+func (m *mockRolePermRepo) RemovePermission(ctx context.Context, roleID string, permissionID string) error {
 	return nil
 }
 
-func (m *mockRolePermRepo) GetPermissionsByRole(roleID string) ([]models.Permission, error) {
+func (m *mockRolePermRepo) GetPermissionsByRole(ctx context.Context, roleID string) ([]models.Permission, error) {
 	return []models.Permission{
 		{Name: "read"},
 		{Name: "write"},
 	}, nil
 }
 
-func (m *mockRolePermRepo) GetRolesByPermission(permissionID string) ([]models.Role, error) {
+func (m *mockRolePermRepo) GetRolesByPermission(ctx context.Context, permissionID string) ([]models.Role, error) {
 	return []models.Role{
 		{ID: "1", Name: "student"},
 	}, nil
@@ -113,67 +139,80 @@ func (m *mockRolePermRepo) GetRolesByPermission(permissionID string) ([]models.R
 // ---------- STUDENT ----------
 type mockAuthStudentRepo struct{}
 
-func (m *mockAuthStudentRepo) GetAll() ([]models.Student, error) { return nil, nil }
-func (m *mockAuthStudentRepo) GetByID(id string) (*models.Student, error) {
+func (m *mockAuthStudentRepo) GetAll(ctx context.Context) ([]models.Student, error) { return nil, nil }
+func (m *mockAuthStudentRepo) GetByID(ctx context.Context, id string) (*models.Student, error) {
 	return nil, nil
 }
-func (m *mockAuthStudentRepo) GetByStudentID(studentID string) (*models.Student, error) {
+func (m *mockAuthStudentRepo) GetByStudentID(ctx context.Context, studentID string) (*models.Student, error) {
 	return nil, nil
 }
-func (m *mockAuthStudentRepo) GetByUserID(userID string) (*models.Student, error) {
+func (m *mockAuthStudentRepo) GetByUserID(ctx context.Context, userID string) (*models.Student, error) {
 	return &models.Student{UserID: userID}, nil
 }
-func (m *mockAuthStudentRepo) GetByAdvisorID(advisorID string) ([]models.Student, error) {
+func (m *mockAuthStudentRepo) GetByAdvisorID(ctx context.Context, advisorID string) ([]models.Student, error) {
 	return nil, nil
 }
-func (m *mockAuthStudentRepo) Create(req models.CreateStudentRequest) (*models.Student, error) {
+func (m *mockAuthStudentRepo) Create(ctx context.Context, req models.CreateStudentRequest) (*models.Student, error) {
 	return nil, nil
 }
-func (m *mockAuthStudentRepo) Update(id string, req models.UpdateStudentRequest) (*models.Student, error) {
+func (m *mockAuthStudentRepo) Update(ctx context.Context, id string, req models.UpdateStudentRequest) (*models.Student, error) {
 	return nil, nil
 }
-func (m *mockAuthStudentRepo) UpdateAdvisor(id string, advisorID string) error {
+func (m *mockAuthStudentRepo) UpdateAdvisor(ctx context.Context, id string, advisorID string) error {
 	return nil
 }
 
 // ---------- LECTURER ----------
 type mockAuthLecturerRepo struct{}
 
-func (m *mockAuthLecturerRepo) GetAll() ([]models.Lecturer, error) { return nil, nil }
-func (m *mockAuthLecturerRepo) GetByID(id string) (*models.Lecturer, error) {
+func (m *mockAuthLecturerRepo) GetAll(ctx context.Context) ([]models.Lecturer, error) { return nil, nil }
+func (m *mockAuthLecturerRepo) GetByID(ctx context.Context, id string) (*models.Lecturer, error) {
 	return nil, nil
 }
-func (m *mockAuthLecturerRepo) GetByUserID(userID string) (*models.Lecturer, error) {
+func (m *mockAuthLecturerRepo) GetByUserID(ctx context.Context, userID string) (*models.Lecturer, error) {
 	return &models.Lecturer{UserID: userID}, nil
 }
-func (m *mockAuthLecturerRepo) GetByLecturerID(lecturerID string) (*models.Lecturer, error) {
+func (m *mockAuthLecturerRepo) GetByLecturerID(ctx context.Context, lecturerID string) (*models.Lecturer, error) {
 	return nil, nil
 }
-func (m *mockAuthLecturerRepo) Create(req models.CreateLecturerRequest) (*models.Lecturer, error) {
+func (m *mockAuthLecturerRepo) Create(ctx context.Context, req models.CreateLecturerRequest) (*models.Lecturer, error) {
 	return nil, nil
 }
-func (m *mockAuthLecturerRepo) Update(id string, req models.UpdateLecturerRequest) (*models.Lecturer, error) {
+func (m *mockAuthLecturerRepo) Update(ctx context.Context, id string, req models.UpdateLecturerRequest) (*models.Lecturer, error) {
 	return nil, nil
 }
 
+// ---------- AUTH STATE ----------
+type mockAuthStateRepo struct{}
+
+func (m *mockAuthStateRepo) GetAuthState(ctx context.Context, userID string) (*models.AuthState, error) {
+	return &models.AuthState{UserID: userID, IsActive: true, UserVersion: 1, RoleVersion: 1}, nil
+}
+
 //
 // =======================================================
 // SETUP
 // =======================================================
 //
 
-func setupAuthService() (*fiber.App, *mockAuthUserRepo) {
+func setupTestKeys() {
+	secret := []byte("test-secret")
+	ks, _ := utils.NewKeySet("test", &utils.SigningKey{
+		KID:     "test",
+		Method:  jwt.SigningMethodHS256,
+		Private: secret,
+		Public:  secret,
+	})
+	utils.SetKeySet(ks)
+}
+
+func setupAuthService() (*fiber.App, *mockAuthUserRepo, repository.RefreshTokenStore) {
+	setupTestKeys()
 	app := fiber.New()
 
 	userRepo := newMockAuthUserRepo()
-
-	service := NewAuthService(
-		userRepo,
-		&mockAuthRoleRepo{},
-		&mockRolePermRepo{},
-		&mockAuthStudentRepo{},
-		&mockAuthLecturerRepo{},
-	)
+	deps := &testAuthDeps{users: userRepo}
+	service := newTestAuthService(deps)
 
 	app.Post("/login", service.Login)
 	app.Post("/refresh", service.RefreshToken)
@@ -183,8 +222,12 @@ func setupAuthService() (*fiber.App, *mockAuthUserRepo) {
 		c.Locals("role_name", "student")
 		return service.GetProfile(c)
 	})
+	app.Put("/password", func(c *fiber.Ctx) error {
+		c.Locals("user_id", "1")
+		return service.ChangePassword(c)
+	})
 
-	return app, userRepo
+	return app, userRepo, deps.refresh
 }
 
 //
@@ -194,7 +237,7 @@ func setupAuthService() (*fiber.App, *mockAuthUserRepo) {
 //
 
 func TestAuthService_Login_Success(t *testing.T) {
-	app, repo := setupAuthService()
+	app, repo, _ := setupAuthService()
 
 	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.DefaultCost)
 
@@ -222,6 +265,43 @@ func TestAuthService_Login_Success(t *testing.T) {
 	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
 }
 
+func TestAuthService_Login_ByEmail(t *testing.T) {
+	app, repo, _ := setupAuthService()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.DefaultCost)
+
+	repo.users["1"] = &models.User{
+		ID:           "1",
+		Username:     "john",
+		Email:        "john@mail.com",
+		PasswordHash: string(hash),
+		IsActive:     true,
+		RoleID:       ptr("role-1"),
+	}
+
+	b, _ := json.Marshal(models.LoginRequest{Username: "John@Mail.com", Password: "secret"})
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestAuthService_Login_UnknownIdentifier(t *testing.T) {
+	app, _, _ := setupAuthService()
+
+	b, _ := json.Marshal(models.LoginRequest{Username: "2021999", Password: "secret"})
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
 //
 // =======================================================
 // TEST REFRESH TOKEN
@@ -229,7 +309,7 @@ func TestAuthService_Login_Success(t *testing.T) {
 //
 
 func TestAuthService_RefreshToken(t *testing.T) {
-	app, repo := setupAuthService()
+	app, repo, store := setupAuthService()
 
 	repo.users["1"] = &models.User{
 		ID:       "1",
@@ -237,23 +317,104 @@ func TestAuthService_RefreshToken(t *testing.T) {
 		IsActive: true,
 	}
 
-	refreshMutex.Lock()
-	refreshTokens["valid"] = refreshEntry{
+	_ = store.Create(context.Background(), &models.RefreshToken{
+		TokenHash: utils.HashToken("valid"),
 		UserID:    "1",
+		FamilyID:  "family-1",
 		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	resp := doRefresh(t, app, "valid")
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var out map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	assert.NotEmpty(t, out["refresh_token"])
+	assert.NotEqual(t, "valid", out["refresh_token"])
+}
+
+//
+// =======================================================
+// TEST REFRESH TOKEN REUSE → REVOKE FAMILY
+// =======================================================
+//
+
+func TestAuthService_RefreshToken_ReuseRevokesFamily(t *testing.T) {
+	app, repo, store := setupAuthService()
+
+	repo.users["1"] = &models.User{
+		ID:       "1",
+		RoleID:   ptr("role-1"),
+		IsActive: true,
 	}
-	refreshMutex.Unlock()
 
-	body := map[string]string{"refresh_token": "valid"}
-	b, _ := json.Marshal(body)
+	_ = store.Create(context.Background(), &models.RefreshToken{
+		TokenHash: utils.HashToken("first"),
+		UserID:    "1",
+		FamilyID:  "family-1",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	// rotasi pertama → dapat token baru
+	resp := doRefresh(t, app, "first")
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var out map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	rotated := out["refresh_token"].(string)
+
+	// token lama dipakai lagi → ditolak
+	resp = doRefresh(t, app, "first")
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	// token hasil rotasi ikut dicabut
+	resp = doRefresh(t, app, rotated)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func doRefresh(t *testing.T, app *fiber.App, token string) *http.Response {
+	b, _ := json.Marshal(map[string]string{"refresh_token": token})
 
 	req := httptest.NewRequest(http.MethodPost, "/refresh", bytes.NewReader(b))
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	return resp
+}
+
+//
+// =======================================================
+// TEST LOGOUT → REVOKE JTI SAMPAI EXP
+// =======================================================
+//
+
+func TestAuthService_Logout_RevokesJTIUntilExpiry(t *testing.T) {
+	app := fiber.New()
+	revocations := repository.NewMemoryTokenRevocationStore()
+	service := newTestAuthService(&testAuthDeps{revocations: revocations})
+
+	exp := time.Now().Add(24 * time.Hour)
+	app.Post("/logout", func(c *fiber.Ctx) error {
+		c.Locals("jti", "jti-1")
+		c.Locals("token_exp", exp)
+		c.Locals("user_id", "1")
+		return service.Logout(c)
+	})
 
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/logout", nil))
 	assert.NoError(t, err)
 	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	revoked, _ := revocations.IsRevoked(context.Background(), "jti-1")
+	assert.True(t, revoked)
+
+	// sweeper tidak menghapus entri sebelum token kadaluarsa
+	n, _ := revocations.DeleteExpired(context.Background(), time.Now().Add(2 * time.Hour))
+	assert.Equal(t, int64(0), n)
+
+	n, _ = revocations.DeleteExpired(context.Background(), exp.Add(time.Second))
+	assert.Equal(t, int64(1), n)
 }
 
 //
@@ -263,7 +424,7 @@ func TestAuthService_RefreshToken(t *testing.T) {
 //
 
 func TestAuthService_GetProfile(t *testing.T) {
-	app, repo := setupAuthService()
+	app, repo, _ := setupAuthService()
 
 	repo.users["1"] = &models.User{
 		ID:       "1",
@@ -278,13 +439,3 @@ func TestAuthService_GetProfile(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
 }
-
-//
-// =======================================================
-// HELPER
-// =======================================================
-//
-
-func ptr[T any](v T) *T {
-	return &v
-}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"errors"
+
+	"achievement_backend/app/policy"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var errUnauthenticated = errors.New("unauthenticated")
+
+// subjectFromCtx me-resolve subject policy dari user yang sedang login.
+func subjectFromCtx(c *fiber.Ctx, authz *policy.Policy) (*policy.Subject, error) {
+	uid, _ := c.Locals("user_id").(string)
+	role, _ := c.Locals("role_name").(string)
+	if uid == "" || role == "" {
+		return nil, errUnauthenticated
+	}
+
+	return authz.Subject(c.UserContext(), uid, role)
+}
+
+// policyStatus memetakan error dari policy ke status HTTP dan pesan error.
+func policyStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, errUnauthenticated):
+		return 401, "unauthorized"
+	case errors.Is(err, policy.ErrProfileNotFound):
+		return 403, "profile not found"
+	case errors.Is(err, policy.ErrForbidden):
+		return 403, "forbidden"
+	}
+
+	return 500, "failed to check access"
+}
+
+// policyError menerjemahkan error dari policy menjadi response HTTP.
+func policyError(c *fiber.Ctx, err error) error {
+	code, msg := policyStatus(err)
+	return c.Status(code).JSON(fiber.Map{"error": msg})
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"achievement_backend/app/repository"
+	"achievement_backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// =======================================================
+// AUTH SERVICE UNTUK TEST
+// =======================================================
+//
+
+// authTestUsers dipakai AuthService sekaligus sebagai UserRepository dan
+// AuthRepository, seperti mockAuthUserRepo.
+type authTestUsers interface {
+	repository.UserRepository
+	repository.AuthRepository
+}
+
+// testAuthDeps berisi dependency AuthService yang ingin diamati test.
+// Field yang nil diisi default oleh newTestAuthService.
+type testAuthDeps struct {
+	users       authTestUsers
+	students    repository.StudentRepository
+	refresh     repository.RefreshTokenStore
+	revocations repository.TokenRevocationStore
+	mfa         *MFAService
+	sessions    *SessionService
+}
+
+// newTestAuthService membuat AuthService dengan store in-memory dan mock.
+// deps diisi di tempat sehingga test bisa memakai store default-nya.
+func newTestAuthService(deps *testAuthDeps) *AuthService {
+	if deps.users == nil {
+		deps.users = newMockAuthUserRepo()
+	}
+	if deps.students == nil {
+		deps.students = &mockAuthStudentRepo{}
+	}
+	if deps.refresh == nil {
+		deps.refresh = repository.NewMemoryRefreshTokenStore()
+	}
+	if deps.revocations == nil {
+		deps.revocations = repository.NewMemoryTokenRevocationStore()
+	}
+	if deps.mfa == nil {
+		deps.mfa = NewMFAService(repository.NewMemoryMFAStore(), deps.users, &mockAuthRoleRepo{}, "Test")
+	}
+	if deps.sessions == nil {
+		deps.sessions = NewSessionService(repository.NewMemorySessionStore(), deps.refresh, deps.revocations, deps.users)
+	}
+
+	return NewAuthService(
+		deps.users,
+		&mockAuthRoleRepo{},
+		&mockRolePermRepo{},
+		deps.students,
+		&mockAuthLecturerRepo{},
+		deps.refresh,
+		deps.revocations,
+		&mockAuthStateRepo{},
+		deps.users,
+		NewLoginGuard(repository.NewMemoryLoginAttemptStore(), DefaultLoginThrottleConfig()),
+		NewPasswordManager(
+			utils.DefaultPasswordPolicy(),
+			repository.NewMemoryPasswordHistoryStore(),
+			deps.users,
+			deps.students,
+			&mockAuthLecturerRepo{},
+		),
+		deps.mfa,
+		deps.sessions,
+	)
+}
+
+//
+// =======================================================
+// REQUEST & RESPONSE
+// =======================================================
+//
+
+// doRequest mengirim request ke app. body non-nil dikirim sebagai JSON.
+func doRequest(t *testing.T, app *fiber.App, method, path string, body interface{}, headers map[string]string) *http.Response {
+	req := httptest.NewRequest(method, path, nil)
+	if body != nil {
+		req = httptest.NewRequest(method, path, jsonBody(body))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	return resp
+}
+
+func postJSON(t *testing.T, app *fiber.App, path string, body interface{}) *http.Response {
+	return doRequest(t, app, http.MethodPost, path, body, nil)
+}
+
+// bearer membuat header Authorization untuk doRequest.
+func bearer(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+// headerUser memasang role dan user dari header X-Role dan X-User, sebagai
+// pengganti AuthRequired pada test handler.
+func headerUser(c *fiber.Ctx) error {
+	c.Locals("role_name", c.Get("X-Role"))
+	c.Locals("user_id", c.Get("X-User"))
+	return c.Next()
+}
+
+// as membuat header X-Role dan X-User untuk app yang memakai headerUser.
+func as(role, user string) map[string]string {
+	return map[string]string{"X-Role": role, "X-User": user}
+}
+
+func jsonBody(v interface{}) io.Reader {
+	b, _ := json.Marshal(v)
+	return bytes.NewReader(b)
+}
+
+func decodeBody(t *testing.T, resp *http.Response, v interface{}) {
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+	"achievement_backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ImpersonationService memungkinkan admin "login sebagai" user lain untuk
+// melihat apa yang dilihat user tersebut. Token yang diterbitkan berumur
+// pendek, membawa identitas admin di klaim act, hanya boleh membaca (lihat
+// middleware.AuthRequired), dan setiap impersonation beserta request-nya
+// dicatat.
+type ImpersonationService struct {
+	store       repository.ImpersonationStore
+	userRepo    repository.UserRepository
+	authState   repository.AuthStateRepository
+	revocations repository.TokenRevocationStore
+	ttl         time.Duration
+}
+
+func NewImpersonationService(
+	store repository.ImpersonationStore,
+	userRepo repository.UserRepository,
+	authState repository.AuthStateRepository,
+	revocations repository.TokenRevocationStore,
+	ttl time.Duration,
+) *ImpersonationService {
+	return &ImpersonationService{
+		store:       store,
+		userRepo:    userRepo,
+		authState:   authState,
+		revocations: revocations,
+		ttl:         ttl,
+	}
+}
+
+// end menutup catatan impersonation dan mencabut token-nya (sid = ID catatan).
+func (s *ImpersonationService) end(c *fiber.Ctx, id, endedBy string) error {
+	rec, err := s.store.Get(c.UserContext(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "impersonation not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	if err := s.store.End(c.UserContext(), id, endedBy, time.Now()); err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(409).JSON(fiber.Map{"error": "impersonation already ended"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	if err := s.revocations.Revoke(c.UserContext(), id, rec.ExpiresAt); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to revoke token"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "impersonation ended"})
+}
+
+// Start godoc
+// @Summary Login sebagai user lain (admin)
+// @Description Menerbitkan token impersonation berumur pendek atas nama user target. Token hanya bisa dipakai untuk membaca, membawa header response X-Impersonated-By, dan setiap request dicatat. Alasan wajib diisi. Admin lain tidak bisa di-impersonate.
+// @Tags Impersonation
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID target"
+// @Param body body models.StartImpersonationRequest true "Alasan impersonation"
+// @Success 200 {object} map[string]interface{} "Token impersonation"
+// @Failure 400 {object} map[string]interface{} "Alasan kosong, target diri sendiri, atau target tidak aktif"
+// @Failure 403 {object} map[string]interface{} "Target tidak boleh di-impersonate"
+// @Failure 404 {object} map[string]interface{} "User tidak ditemukan"
+// @Security Bearer
+// @Router /api/v1/users/{id}/impersonate [post]
+func (s *ImpersonationService) Start(c *fiber.Ctx) error {
+	adminID, _ := c.Locals("user_id").(string)
+	if adminID == "" {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	if by, _ := c.Locals("impersonator_id").(string); by != "" {
+		return c.Status(403).JSON(fiber.Map{"error": "cannot impersonate while impersonating"})
+	}
+	if viaAPIKey(c) {
+		return c.Status(403).JSON(fiber.Map{"error": "not allowed for service accounts"})
+	}
+
+	var req models.StartImpersonationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "reason is required"})
+	}
+
+	targetID := c.Params("id")
+	if targetID == adminID {
+		return c.Status(400).JSON(fiber.Map{"error": "cannot impersonate yourself"})
+	}
+
+	target, err := s.userRepo.GetByID(c.UserContext(), targetID)
+	if err != nil || target == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "user not found"})
+	}
+
+	state, err := s.authState.GetAuthState(c.UserContext(), target.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to load user state"})
+	}
+	if !state.IsActive {
+		return c.Status(400).JSON(fiber.Map{"error": "user inactive"})
+	}
+
+	// impersonate sesama pengelola user sama saja dengan naik hak akses
+	for _, p := range state.Permissions {
+		if p == "user:manage" {
+			return c.Status(403).JSON(fiber.Map{"error": "cannot impersonate an administrator"})
+		}
+	}
+
+	// nilai dari fiber.Ctx dipakai ulang setelah request selesai, jadi disalin
+	rec := &models.Impersonation{
+		AdminID:      adminID,
+		TargetUserID: target.ID,
+		Reason:       req.Reason,
+		IP:           strings.Clone(c.IP()),
+		UserAgent:    strings.Clone(c.Get(fiber.HeaderUserAgent)),
+		ExpiresAt:    time.Now().Add(s.ttl),
+	}
+	if err := s.store.Create(c.UserContext(), rec); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to record impersonation"})
+	}
+
+	username, _ := c.Locals("username").(string)
+	token, exp, err := utils.GenerateImpersonationToken(
+		*target,
+		state.RoleName,
+		state.Permissions,
+		models.AuthVersion{User: state.UserVersion, Role: state.RoleVersion},
+		models.Actor{UserID: adminID, Username: username},
+		rec.ID,
+		s.ttl,
+	)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to generate token"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": models.ImpersonationToken{
+			Token:           token,
+			ExpiresAt:       exp,
+			ImpersonationID: rec.ID,
+			User: models.LoginUser{
+				ID:          target.ID,
+				Username:    target.Username,
+				FullName:    target.FullName,
+				Role:        state.RoleName,
+				Permissions: state.Permissions,
+			},
+		},
+	})
+}
+
+// EndCurrent godoc
+// @Summary Mengakhiri impersonation yang sedang berjalan
+// @Description Dipanggil dengan token impersonation. Token langsung dicabut.
+// @Tags Impersonation
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Impersonation diakhiri"
+// @Failure 400 {object} map[string]interface{} "Token bukan token impersonation"
+// @Security Bearer
+// @Router /api/v1/auth/impersonation/end [post]
+func (s *ImpersonationService) EndCurrent(c *fiber.Ctx) error {
+	id, _ := c.Locals("impersonation_id").(string)
+	by, _ := c.Locals("impersonator_id").(string)
+	if id == "" || by == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "not impersonating"})
+	}
+
+	return s.end(c, id, by)
+}
+
+// End godoc
+// @Summary Mengakhiri impersonation (admin)
+// @Tags Impersonation
+// @Produce json
+// @Param id path string true "Impersonation ID"
+// @Success 200 {object} map[string]interface{} "Impersonation diakhiri"
+// @Failure 404 {object} map[string]interface{} "Impersonation tidak ditemukan"
+// @Failure 409 {object} map[string]interface{} "Impersonation sudah berakhir"
+// @Security Bearer
+// @Router /api/v1/impersonations/{id} [delete]
+func (s *ImpersonationService) End(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "impersonation not found"})
+	}
+
+	adminID, _ := c.Locals("user_id").(string)
+	return s.end(c, id, adminID)
+}
+
+// List godoc
+// @Summary Audit log impersonation
+// @Description Daftar impersonation terbaru, bisa difilter per admin atau per user target
+// @Tags Impersonation
+// @Produce json
+// @Param admin_id query string false "Filter berdasarkan admin"
+// @Param user_id query string false "Filter berdasarkan user target"
+// @Param limit query int false "Jumlah data (default: 50)"
+// @Success 200 {object} map[string]interface{} "Daftar impersonation"
+// @Failure 500 {object} map[string]interface{} "Gagal mengambil data"
+// @Security Bearer
+// @Router /api/v1/impersonations [get]
+func (s *ImpersonationService) List(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	list, err := s.store.List(c.UserContext(), c.Query("admin_id"), c.Query("user_id"), limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch impersonations"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "data": list})
+}
+
+// ListActions godoc
+// @Summary Request yang dibuat selama impersonation
+// @Tags Impersonation
+// @Produce json
+// @Param id path string true "Impersonation ID"
+// @Param limit query int false "Jumlah data (default: 100)"
+// @Success 200 {object} map[string]interface{} "Daftar request"
+// @Failure 404 {object} map[string]interface{} "Impersonation tidak ditemukan"
+// @Security Bearer
+// @Router /api/v1/impersonations/{id}/actions [get]
+func (s *ImpersonationService) ListActions(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "impersonation not found"})
+	}
+	if _, err := s.store.Get(c.UserContext(), id); err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "impersonation not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	limit := c.QueryInt("limit", 100)
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	actions, err := s.store.ListActions(c.UserContext(), id, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch impersonation actions"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "data": actions})
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+	"achievement_backend/middleware"
+	"achievement_backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockImpersonationStateRepo mengembalikan auth state per user agar role dan
+// permission admin dan mahasiswa bisa dibedakan.
+type mockImpersonationStateRepo struct {
+	states map[string]*models.AuthState
+}
+
+func (m *mockImpersonationStateRepo) GetAuthState(ctx context.Context, userID string) (*models.AuthState, error) {
+	st, ok := m.states[userID]
+	if !ok {
+		return nil, fiber.ErrNotFound
+	}
+	cp := *st
+	return &cp, nil
+}
+
+// setupImpersonation mengembalikan access token admin untuk memulai impersonation.
+func setupImpersonation(t *testing.T) (*fiber.App, repository.ImpersonationStore, string) {
+	setupTestKeys()
+	app := fiber.New()
+
+	users := newMockAuthUserRepo()
+	users.users["admin"] = &models.User{ID: "admin", Username: "admin", IsActive: true, RoleID: ptr("role-admin")}
+	users.users["admin2"] = &models.User{ID: "admin2", Username: "admin2", IsActive: true, RoleID: ptr("role-admin")}
+	users.users["mhs"] = &models.User{ID: "mhs", Username: "mahasiswa", FullName: "Mahasiswa", IsActive: true, RoleID: ptr("role-mhs")}
+
+	adminPerms := []string{"user:manage", "achievement:read"}
+	states := &mockImpersonationStateRepo{states: map[string]*models.AuthState{
+		"admin":  {UserID: "admin", IsActive: true, RoleID: "role-admin", RoleName: "Admin", Permissions: adminPerms, UserVersion: 1, RoleVersion: 1},
+		"admin2": {UserID: "admin2", IsActive: true, RoleID: "role-admin", RoleName: "Admin", Permissions: adminPerms, UserVersion: 1, RoleVersion: 1},
+		"mhs":    {UserID: "mhs", IsActive: true, RoleID: "role-mhs", RoleName: "Mahasiswa", Permissions: []string{"achievement:read"}, UserVersion: 1, RoleVersion: 1},
+	}}
+
+	store := repository.NewMemoryImpersonationStore()
+	revocations := repository.NewMemoryTokenRevocationStore()
+	svc := NewImpersonationService(store, users, states, revocations, 15*time.Minute)
+
+	authRequired := middleware.AuthRequired(revocations, states, store, repository.NewMemoryAPIKeyStore())
+
+	app.Post("/api/v1/auth/impersonation/end", authRequired, svc.EndCurrent)
+	app.Post("/users/:id/impersonate", authRequired, middleware.PermissionRequired("user:manage"), svc.Start)
+	app.Get("/impersonations", authRequired, middleware.PermissionRequired("user:manage"), svc.List)
+	app.Get("/impersonations/:id/actions", authRequired, middleware.PermissionRequired("user:manage"), svc.ListActions)
+	app.Delete("/impersonations/:id", authRequired, middleware.PermissionRequired("user:manage"), svc.End)
+
+	whoami := func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"user_id":         c.Locals("user_id"),
+			"role_name":       c.Locals("role_name"),
+			"impersonator_id": c.Locals("impersonator_id"),
+		})
+	}
+	app.Get("/achievements", authRequired, whoami)
+	app.Post("/achievements", authRequired, whoami)
+
+	token, err := utils.GenerateToken(*users.users["admin"], "Admin", adminPerms, models.AuthVersion{User: 1, Role: 1}, "")
+	assert.NoError(t, err)
+
+	return app, store, token
+}
+
+func startImpersonation(t *testing.T, app *fiber.App, adminToken, target string) models.ImpersonationToken {
+	resp := doRequest(t, app, http.MethodPost, "/users/"+target+"/impersonate",
+		models.StartImpersonationRequest{Reason: "laporan daftar prestasi kosong"}, bearer(adminToken))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data models.ImpersonationToken `json:"data"`
+	}
+	decodeBody(t, resp, &body)
+	return body.Data
+}
+
+//
+// =======================================================
+// MULAI IMPERSONATION
+// =======================================================
+//
+
+func TestImpersonation_TokenCarriesBothIdentities(t *testing.T) {
+	app, _, adminToken := setupImpersonation(t)
+	imp := startImpersonation(t, app, adminToken, "mhs")
+
+	claims, err := utils.ValidateToken(imp.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "mhs", claims.UserID)
+	assert.Equal(t, "Mahasiswa", claims.RoleName)
+	assert.Equal(t, &models.Actor{UserID: "admin", Username: "admin"}, claims.Actor)
+	assert.Equal(t, imp.ImpersonationID, claims.SessionID)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), imp.ExpiresAt, time.Minute)
+
+	resp := doRequest(t, app, http.MethodGet, "/achievements", nil, bearer(imp.Token))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "admin", resp.Header.Get("X-Impersonated-By"))
+
+	var who map[string]interface{}
+	decodeBody(t, resp, &who)
+	assert.Equal(t, "mhs", who["user_id"])
+	assert.Equal(t, "Mahasiswa", who["role_name"])
+	assert.Equal(t, "admin", who["impersonator_id"])
+}
+
+func TestImpersonation_Rejected(t *testing.T) {
+	app, store, adminToken := setupImpersonation(t)
+
+	resp := doRequest(t, app, http.MethodPost, "/users/mhs/impersonate", models.StartImpersonationRequest{Reason: "  "}, bearer(adminToken))
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodPost, "/users/admin/impersonate", models.StartImpersonationRequest{Reason: "x"}, bearer(adminToken))
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodPost, "/users/admin2/impersonate", models.StartImpersonationRequest{Reason: "x"}, bearer(adminToken))
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodPost, "/users/nobody/impersonate", models.StartImpersonationRequest{Reason: "x"}, bearer(adminToken))
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	list, _ := store.List(context.Background(), "", "", 10)
+	assert.Empty(t, list)
+}
+
+//
+// =======================================================
+// HANYA BACA & AUDIT
+// =======================================================
+//
+
+func TestImpersonation_WritesBlockedAndAudited(t *testing.T) {
+	app, store, adminToken := setupImpersonation(t)
+	imp := startImpersonation(t, app, adminToken, "mhs")
+
+	resp := doRequest(t, app, http.MethodGet, "/achievements", nil, bearer(imp.Token))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodPost, "/achievements", fiber.Map{"title": "x"}, bearer(imp.Token))
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodGet, "/impersonations/"+imp.ImpersonationID+"/actions", nil, bearer(adminToken))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data []models.ImpersonationAction `json:"data"`
+	}
+	decodeBody(t, resp, &body)
+	assert.Len(t, body.Data, 2)
+	assert.Equal(t, http.MethodPost, body.Data[0].Method)
+	assert.Equal(t, fiber.StatusForbidden, body.Data[0].Status)
+	assert.Equal(t, "/achievements", body.Data[1].Path)
+	assert.Equal(t, fiber.StatusOK, body.Data[1].Status)
+
+	rec, err := store.Get(context.Background(), imp.ImpersonationID)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", rec.AdminID)
+	assert.Equal(t, "mhs", rec.TargetUserID)
+	assert.Equal(t, "laporan daftar prestasi kosong", rec.Reason)
+}
+
+//
+// =======================================================
+// MENGAKHIRI IMPERSONATION
+// =======================================================
+//
+
+func TestImpersonation_EndRevokesToken(t *testing.T) {
+	app, store, adminToken := setupImpersonation(t)
+	imp := startImpersonation(t, app, adminToken, "mhs")
+
+	resp := doRequest(t, app, http.MethodPost, "/api/v1/auth/impersonation/end", nil, bearer(imp.Token))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodGet, "/achievements", nil, bearer(imp.Token))
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	rec, _ := store.Get(context.Background(), imp.ImpersonationID)
+	assert.NotNil(t, rec.EndedAt)
+	assert.Equal(t, "admin", *rec.EndedBy)
+
+	resp = doRequest(t, app, http.MethodDelete, "/impersonations/"+imp.ImpersonationID, nil, bearer(adminToken))
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+}
+
+func TestImpersonation_AdminEnd(t *testing.T) {
+	app, _, adminToken := setupImpersonation(t)
+	imp := startImpersonation(t, app, adminToken, "mhs")
+
+	resp := doRequest(t, app, http.MethodDelete, "/impersonations/"+imp.ImpersonationID, nil, bearer(adminToken))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodGet, "/achievements", nil, bearer(imp.Token))
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodDelete, "/impersonations/not-a-uuid", nil, bearer(adminToken))
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
@@ -63,7 +63,7 @@ func (s *LecturerService) GetAll(c *fiber.Ctx) error {
 		})
 	}
 
-	data, err := s.repo.GetAll()
+	data, err := s.repo.GetAll(c.UserContext())
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "failed to get lecturers"})
 	}
@@ -103,7 +103,7 @@ func (s *LecturerService) GetAdvisees(c *fiber.Ctx) error {
 
 	// ================= ADMIN =================
 	if r == "Admin" {
-		students, err := s.studentRepo.GetByAdvisorID(lecturerID)
+		students, err := s.studentRepo.GetByAdvisorID(c.UserContext(), lecturerID)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{
 				"error": "failed to get advisees",
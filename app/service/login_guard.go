@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+)
+
+// LoginThrottleConfig mengatur batas percobaan login gagal.
+// Nilai 0 pada Max*Failures mematikan penguncian untuk jenis tersebut.
+type LoginThrottleConfig struct {
+	MaxAccountFailures int
+	MaxIPFailures      int
+	BaseDelay          time.Duration // jeda setelah kegagalan kedua, berlipat dua tiap kegagalan berikutnya
+	MaxDelay           time.Duration
+	LockDuration       time.Duration
+	Window             time.Duration // hitungan gagal dimulai ulang setelah window ini
+}
+
+func DefaultLoginThrottleConfig() LoginThrottleConfig {
+	return LoginThrottleConfig{
+		MaxAccountFailures: 5,
+		MaxIPFailures:      20,
+		BaseDelay:          time.Second,
+		MaxDelay:           30 * time.Second,
+		LockDuration:       15 * time.Minute,
+		Window:             15 * time.Minute,
+	}
+}
+
+// LoginBlockedError dikembalikan Check jika akun/IP sedang dikunci
+// atau belum melewati jeda progresif.
+type LoginBlockedError struct {
+	Kind   string
+	Locked bool
+	Until  time.Time
+}
+
+func (e *LoginBlockedError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("login %s locked until %s", e.Kind, e.Until.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("login %s throttled until %s", e.Kind, e.Until.Format(time.RFC3339))
+}
+
+// LoginGuard melacak login gagal per akun dan per IP.
+type LoginGuard struct {
+	store repository.LoginAttemptStore
+	cfg   LoginThrottleConfig
+	now   func() time.Time
+}
+
+func NewLoginGuard(store repository.LoginAttemptStore, cfg LoginThrottleConfig) *LoginGuard {
+	return &LoginGuard{store: store, cfg: cfg, now: time.Now}
+}
+
+// Check mengembalikan *LoginBlockedError jika key tersebut belum boleh mencoba login.
+func (g *LoginGuard) Check(ctx context.Context, kind, key string) error {
+	a, err := g.store.Get(ctx, kind, key)
+	if err != nil || a == nil {
+		return err
+	}
+
+	now := g.now()
+	if a.LockedUntil != nil && now.Before(*a.LockedUntil) {
+		return &LoginBlockedError{Kind: kind, Locked: true, Until: *a.LockedUntil}
+	}
+	if a.NextAttemptAt != nil && now.Before(*a.NextAttemptAt) {
+		return &LoginBlockedError{Kind: kind, Until: *a.NextAttemptAt}
+	}
+
+	return nil
+}
+
+// Fail mencatat satu login gagal lalu memasang jeda atau kunci.
+// userID diisi untuk kunci akun agar event-nya bisa ditampilkan ke pemilik akun.
+func (g *LoginGuard) Fail(ctx context.Context, kind, key string, userID *string) error {
+	now := g.now()
+
+	a, err := g.store.RegisterFailure(ctx, kind, key, now, now.Add(-g.cfg.Window))
+	if err != nil {
+		return err
+	}
+
+	if max := g.maxFailures(kind); max > 0 && a.Failures >= max {
+		until := now.Add(g.cfg.LockDuration)
+		if err := g.store.SetBlock(ctx, kind, key, nil, &until); err != nil {
+			return err
+		}
+		return g.store.RecordLockEvent(ctx, &models.LoginLockEvent{
+			Kind:        kind,
+			Key:         key,
+			UserID:      userID,
+			Failures:    a.Failures,
+			LockedUntil: until,
+		})
+	}
+
+	if delay := g.delay(a.Failures); delay > 0 {
+		next := now.Add(delay)
+		return g.store.SetBlock(ctx, kind, key, &next, nil)
+	}
+
+	return nil
+}
+
+// Succeed menghapus hitungan gagal akun setelah login berhasil.
+// Hitungan per IP sengaja tidak di-reset agar satu akun valid tidak bisa
+// dipakai untuk "membersihkan" IP penyerang.
+func (g *LoginGuard) Succeed(ctx context.Context, userID string) error {
+	if err := g.store.Clear(ctx, models.LoginAttemptAccount, userID); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	return nil
+}
+
+func (g *LoginGuard) maxFailures(kind string) int {
+	if kind == models.LoginAttemptIP {
+		return g.cfg.MaxIPFailures
+	}
+	return g.cfg.MaxAccountFailures
+}
+
+// delay: kegagalan pertama tanpa jeda, lalu BaseDelay, 2×BaseDelay, ... sampai MaxDelay.
+func (g *LoginGuard) delay(failures int) time.Duration {
+	if failures < 2 || g.cfg.BaseDelay <= 0 {
+		return 0
+	}
+
+	d := g.cfg.BaseDelay
+	for i := 2; i < failures; i++ {
+		d *= 2
+		if g.cfg.MaxDelay > 0 && d >= g.cfg.MaxDelay {
+			return g.cfg.MaxDelay
+		}
+	}
+	if g.cfg.MaxDelay > 0 && d > g.cfg.MaxDelay {
+		return g.cfg.MaxDelay
+	}
+	return d
+}
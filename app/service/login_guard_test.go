@@ -0,0 +1,129 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestLoginGuard(cfg LoginThrottleConfig) (*LoginGuard, repository.LoginAttemptStore, *time.Time) {
+	store := repository.NewMemoryLoginAttemptStore()
+	now := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+
+	g := NewLoginGuard(store, cfg)
+	g.now = func() time.Time { return now }
+
+	return g, store, &now
+}
+
+//
+// =======================================================
+// JEDA PROGRESIF
+// =======================================================
+//
+
+func TestLoginGuard_ProgressiveDelay(t *testing.T) {
+	cfg := DefaultLoginThrottleConfig()
+	g, _, _ := newTestLoginGuard(cfg)
+
+	assert.Equal(t, time.Duration(0), g.delay(1))
+	assert.Equal(t, 1*time.Second, g.delay(2))
+	assert.Equal(t, 2*time.Second, g.delay(3))
+	assert.Equal(t, 4*time.Second, g.delay(4))
+	assert.Equal(t, 30*time.Second, g.delay(10))
+}
+
+//
+// =======================================================
+// KUNCI AKUN SETELAH N KEGAGALAN
+// =======================================================
+//
+
+func TestLoginGuard_LocksAccountAndRecordsEvent(t *testing.T) {
+	cfg := DefaultLoginThrottleConfig()
+	cfg.BaseDelay = 0
+	cfg.MaxAccountFailures = 3
+	g, store, now := newTestLoginGuard(cfg)
+
+	userID := "user-1"
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, g.Check(context.Background(), models.LoginAttemptAccount, userID))
+		assert.NoError(t, g.Fail(context.Background(), models.LoginAttemptAccount, userID, &userID))
+	}
+
+	err := g.Check(context.Background(), models.LoginAttemptAccount, userID)
+	blocked, ok := err.(*LoginBlockedError)
+	assert.True(t, ok)
+	assert.True(t, blocked.Locked)
+	assert.Equal(t, now.Add(cfg.LockDuration), blocked.Until)
+
+	events, _ := store.ListLockEvents(context.Background(), userID, 10)
+	assert.Len(t, events, 1)
+	assert.Equal(t, 3, events[0].Failures)
+
+	// setelah masa kunci habis boleh mencoba lagi
+	*now = now.Add(cfg.LockDuration + time.Second)
+	assert.NoError(t, g.Check(context.Background(), models.LoginAttemptAccount, userID))
+}
+
+func TestLoginGuard_SucceedClearsAccount(t *testing.T) {
+	cfg := DefaultLoginThrottleConfig()
+	g, store, _ := newTestLoginGuard(cfg)
+
+	userID := "user-1"
+	assert.NoError(t, g.Fail(context.Background(), models.LoginAttemptAccount, userID, &userID))
+	assert.NoError(t, g.Succeed(context.Background(), userID))
+
+	a, _ := store.Get(context.Background(), models.LoginAttemptAccount, userID)
+	assert.Nil(t, a)
+
+	// tanpa percobaan gagal sebelumnya juga tidak error
+	assert.NoError(t, g.Succeed(context.Background(), "user-2"))
+}
+
+//
+// =======================================================
+// LOGIN → 429 SAAT DIJEDA
+// =======================================================
+//
+
+func TestAuthService_Login_ThrottledAfterFailures(t *testing.T) {
+	app, repo, _ := setupAuthService()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	repo.users["1"] = &models.User{
+		ID:           "1",
+		Username:     "john",
+		PasswordHash: string(hash),
+		IsActive:     true,
+		RoleID:       ptr("role-1"),
+	}
+
+	login := func(password string) *http.Response {
+		b, _ := json.Marshal(models.LoginRequest{Username: "john", Password: password})
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	assert.Equal(t, fiber.StatusUnauthorized, login("wrong").StatusCode)
+	assert.Equal(t, fiber.StatusUnauthorized, login("wrong").StatusCode)
+
+	// kegagalan kedua memasang jeda, password benar pun harus menunggu
+	resp := login("secret")
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get(fiber.HeaderRetryAfter))
+}
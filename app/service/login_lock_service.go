@@ -0,0 +1,132 @@
+package service
+
+import (
+	"database/sql"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type LoginLockService struct {
+	store repository.LoginAttemptStore
+}
+
+func NewLoginLockService(store repository.LoginAttemptStore) *LoginLockService {
+	return &LoginLockService{store: store}
+}
+
+// ListLoginLocks godoc
+// @Summary Mendapatkan daftar akun/IP yang terkunci
+// @Description Mendapatkan daftar akun dan alamat IP yang sedang dikunci karena terlalu banyak login gagal
+// @Tags Login Lock
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Daftar kunci aktif"
+// @Failure 500 {object} map[string]interface{} "Gagal mengambil data"
+// @Security Bearer
+// @Router /api/v1/login-locks [get]
+func (s *LoginLockService) ListLocks(c *fiber.Ctx) error {
+	locks, err := s.store.ListLocked(c.UserContext(), time.Now())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch locks"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    locks,
+	})
+}
+
+// ClearLoginLock godoc
+// @Summary Membuka kunci login
+// @Description Menghapus hitungan login gagal dan kunci untuk satu akun (kind=account, key=user ID) atau IP (kind=ip)
+// @Tags Login Lock
+// @Accept json
+// @Produce json
+// @Param kind path string true "account atau ip"
+// @Param key path string true "User ID atau alamat IP"
+// @Success 200 {object} map[string]interface{} "Kunci berhasil dibuka"
+// @Failure 400 {object} map[string]interface{} "Kind tidak valid"
+// @Failure 404 {object} map[string]interface{} "Tidak ada kunci"
+// @Failure 500 {object} map[string]interface{} "Gagal membuka kunci"
+// @Security Bearer
+// @Router /api/v1/login-locks/{kind}/{key} [delete]
+func (s *LoginLockService) ClearLock(c *fiber.Ctx) error {
+	kind := c.Params("kind")
+	key := c.Params("key")
+
+	if kind != models.LoginAttemptAccount && kind != models.LoginAttemptIP {
+		return c.Status(400).JSON(fiber.Map{"error": "kind must be account or ip"})
+	}
+
+	if err := s.store.Clear(c.UserContext(), kind, key); err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "lock not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed to clear lock"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "lock cleared",
+	})
+}
+
+// ListLoginLockEvents godoc
+// @Summary Mendapatkan riwayat penguncian login
+// @Description Mendapatkan riwayat event penguncian login, bisa difilter per user
+// @Tags Login Lock
+// @Accept json
+// @Produce json
+// @Param user_id query string false "Filter berdasarkan user ID"
+// @Param limit query int false "Jumlah data (default: 50)"
+// @Success 200 {object} map[string]interface{} "Riwayat penguncian"
+// @Failure 500 {object} map[string]interface{} "Gagal mengambil data"
+// @Security Bearer
+// @Router /api/v1/login-locks/events [get]
+func (s *LoginLockService) ListEvents(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	events, err := s.store.ListLockEvents(c.UserContext(), c.Query("user_id"), limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch lock events"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    events,
+	})
+}
+
+// MyLockEvents godoc
+// @Summary Mendapatkan riwayat penguncian akun sendiri
+// @Description Menampilkan kapan dan kenapa akun user yang login pernah dikunci
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Riwayat penguncian"
+// @Failure 500 {object} map[string]interface{} "Gagal mengambil data"
+// @Security Bearer
+// @Router /api/v1/auth/lock-events [get]
+func (s *LoginLockService) MyEvents(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	if userID == "" {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	events, err := s.store.ListLockEvents(c.UserContext(), userID, 20)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch lock events"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    events,
+	})
+}
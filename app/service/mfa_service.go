@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+	"achievement_backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	errMFAInvalidCode    = errors.New("invalid mfa code")
+	errMFANotEnrolled    = errors.New("mfa enrollment not started")
+	errMFAAlreadyEnabled = errors.New("mfa already enabled")
+)
+
+const recoveryCodeCount = 10
+
+// MFAService mengelola enrollment TOTP, kode pemulihan, dan kewajiban MFA per role.
+type MFAService struct {
+	store    repository.MFAStore
+	userRepo repository.UserRepository
+	roleRepo repository.RoleRepository
+	issuer   string
+	now      func() time.Time
+}
+
+// NewMFAService: issuer adalah nama yang tampil di aplikasi authenticator.
+func NewMFAService(
+	store repository.MFAStore,
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	issuer string,
+) *MFAService {
+	return &MFAService{
+		store:    store,
+		userRepo: userRepo,
+		roleRepo: roleRepo,
+		issuer:   issuer,
+		now:      time.Now,
+	}
+}
+
+// state mengembalikan konfigurasi MFA user (nil jika belum enroll) dan
+// apakah role user mewajibkan MFA.
+func (s *MFAService) state(ctx context.Context, user *models.User) (*models.UserMFA, bool, error) {
+	cfg, err := s.store.Get(ctx, user.ID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if user.RoleID == nil {
+		return cfg, false, nil
+	}
+
+	required, err := s.store.RoleRequiresMFA(ctx, *user.RoleID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	return cfg, required, nil
+}
+
+func mfaEnabled(cfg *models.UserMFA) bool {
+	return cfg != nil && cfg.EnabledAt != nil
+}
+
+// beginEnrollment membuat secret baru yang belum aktif sampai dikonfirmasi
+// dengan activate. Secret lama yang belum aktif ditimpa.
+func (s *MFAService) beginEnrollment(ctx context.Context, user *models.User) (*models.MFAEnrollment, error) {
+	cfg, err := s.store.Get(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfaEnabled(cfg) {
+		return nil, errMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveSecret(ctx, user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.Username
+	}
+	uri := utils.TOTPProvisioningURI(s.issuer, account, secret)
+
+	return &models.MFAEnrollment{
+		Secret:      secret,
+		OtpauthURI:  uri,
+		QRPayload:   uri,
+		Issuer:      s.issuer,
+		AccountName: account,
+	}, nil
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashToken(utils.NormalizeRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// activate mengonfirmasi enrollment dengan kode pertama dan mengembalikan
+// kode pemulihan dalam bentuk asli (hanya ditampilkan sekali).
+func (s *MFAService) activate(ctx context.Context, userID, code string) ([]string, error) {
+	cfg, err := s.store.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return nil, errMFANotEnrolled
+	}
+	if mfaEnabled(cfg) {
+		return nil, errMFAAlreadyEnabled
+	}
+
+	step, ok := utils.ValidateTOTP(cfg.Secret, code, s.now())
+	if !ok {
+		return nil, errMFAInvalidCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Enable(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// verify memeriksa kode TOTP atau kode pemulihan untuk MFA yang sudah aktif.
+// Kode TOTP yang sama tidak bisa dipakai dua kali.
+func (s *MFAService) verify(ctx context.Context, userID, code string) error {
+	cfg, err := s.store.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !mfaEnabled(cfg) {
+		return errMFANotEnrolled
+	}
+
+	if step, ok := utils.ValidateTOTP(cfg.Secret, code, s.now()); ok {
+		fresh, err := s.store.UseStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return errMFAInvalidCode
+		}
+		return nil
+	}
+
+	used, err := s.store.UseRecoveryCode(ctx, userID, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errMFAInvalidCode
+	}
+
+	return nil
+}
+
+// mfaError menerjemahkan error MFA menjadi response HTTP.
+func mfaError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errMFAInvalidCode):
+		return c.Status(400).JSON(fiber.Map{"error": "invalid mfa code"})
+	case errors.Is(err, errMFANotEnrolled):
+		return c.Status(400).JSON(fiber.Map{"error": "mfa enrollment not started"})
+	case errors.Is(err, errMFAAlreadyEnabled):
+		return c.Status(409).JSON(fiber.Map{"error": "mfa already enabled"})
+	}
+
+	return c.Status(500).JSON(fiber.Map{"error": "database error"})
+}
+
+// currentUser mengambil user yang sedang login dari context.
+func (s *MFAService) currentUser(c *fiber.Ctx) (*models.User, error) {
+	uid, _ := c.Locals("user_id").(string)
+	if uid == "" {
+		return nil, errUnauthenticated
+	}
+
+	user, err := s.userRepo.GetByID(c.UserContext(), uid)
+	if err != nil || user == nil {
+		return nil, errUnauthenticated
+	}
+
+	return user, nil
+}
+
+// GetStatus godoc
+// @Summary Status MFA user
+// @Description Menampilkan apakah MFA aktif, apakah diwajibkan oleh role, dan sisa kode pemulihan
+// @Tags MFA
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Status MFA"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Kesalahan server"
+// @Security Bearer
+// @Router /api/v1/auth/mfa [get]
+func (s *MFAService) GetStatus(c *fiber.Ctx) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	cfg, required, err := s.state(c.UserContext(), user)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	remaining := 0
+	if mfaEnabled(cfg) {
+		if remaining, err = s.store.CountRecoveryCodes(c.UserContext(), user.ID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "database error"})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"enabled":                  mfaEnabled(cfg),
+			"required":                 required,
+			"recovery_codes_remaining": remaining,
+		},
+	})
+}
+
+// Enroll godoc
+// @Summary Memulai enrollment TOTP
+// @Description Membuat secret TOTP baru beserta URI otpauth:// (payload QR code). MFA baru aktif setelah dikonfirmasi lewat /auth/mfa/enable.
+// @Tags MFA
+// @Produce json
+// @Success 200 {object} models.MFAEnrollment "Secret dan URI provisioning"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 409 {object} map[string]interface{} "MFA sudah aktif"
+// @Failure 500 {object} map[string]interface{} "Kesalahan server"
+// @Security Bearer
+// @Router /api/v1/auth/mfa/enroll [post]
+func (s *MFAService) Enroll(c *fiber.Ctx) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	enrollment, err := s.beginEnrollment(c.UserContext(), user)
+	if err != nil {
+		return mfaError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"success": true, "data": enrollment})
+}
+
+// Enable godoc
+// @Summary Mengaktifkan MFA
+// @Description Mengonfirmasi enrollment dengan kode TOTP pertama. Kode pemulihan dikembalikan sekali saja.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param body body models.MFACodeRequest true "Kode TOTP"
+// @Success 200 {object} map[string]interface{} "MFA aktif beserta kode pemulihan"
+// @Failure 400 {object} map[string]interface{} "Kode salah atau enrollment belum dimulai"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 409 {object} map[string]interface{} "MFA sudah aktif"
+// @Security Bearer
+// @Router /api/v1/auth/mfa/enable [post]
+func (s *MFAService) Enable(c *fiber.Ctx) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req models.MFACodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "code required"})
+	}
+
+	codes, err := s.activate(c.UserContext(), user.ID, req.Code)
+	if err != nil {
+		return mfaError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    fiber.Map{"recovery_codes": codes},
+	})
+}
+
+// Disable godoc
+// @Summary Menonaktifkan MFA
+// @Description Menonaktifkan MFA dengan kode TOTP atau kode pemulihan. Ditolak jika role user mewajibkan MFA.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param body body models.MFACodeRequest true "Kode TOTP atau kode pemulihan"
+// @Success 200 {object} map[string]interface{} "MFA dinonaktifkan"
+// @Failure 400 {object} map[string]interface{} "Kode salah"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "MFA diwajibkan untuk role user"
+// @Security Bearer
+// @Router /api/v1/auth/mfa/disable [post]
+func (s *MFAService) Disable(c *fiber.Ctx) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req models.MFACodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "code required"})
+	}
+
+	_, required, err := s.state(c.UserContext(), user)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+	if required {
+		return c.Status(403).JSON(fiber.Map{"error": "mfa is required for your role"})
+	}
+
+	if err := s.verify(c.UserContext(), user.ID, req.Code); err != nil {
+		return mfaError(c, err)
+	}
+
+	if err := s.store.Disable(c.UserContext(), user.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "mfa disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Membuat ulang kode pemulihan
+// @Description Mengganti semua kode pemulihan lama dengan yang baru. Membutuhkan kode TOTP.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param body body models.MFACodeRequest true "Kode TOTP"
+// @Success 200 {object} map[string]interface{} "Kode pemulihan baru"
+// @Failure 400 {object} map[string]interface{} "Kode salah atau MFA belum aktif"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security Bearer
+// @Router /api/v1/auth/mfa/recovery-codes [post]
+func (s *MFAService) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req models.MFACodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "code required"})
+	}
+
+	if err := s.verify(c.UserContext(), user.ID, req.Code); err != nil {
+		return mfaError(c, err)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to generate recovery codes"})
+	}
+	if err := s.store.ReplaceRecoveryCodes(c.UserContext(), user.ID, hashes); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    fiber.Map{"recovery_codes": codes},
+	})
+}
+
+// SetRoleRequirement godoc
+// @Summary Mewajibkan MFA untuk role
+// @Description Jika diwajibkan, user dengan role ini harus enroll/memasukkan kode TOTP pada login berikutnya
+// @Tags Role
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID"
+// @Param body body models.SetRoleMFARequest true "Wajib MFA atau tidak"
+// @Success 200 {object} map[string]interface{} "Pengaturan tersimpan"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 404 {object} map[string]interface{} "Role tidak ditemukan"
+// @Security Bearer
+// @Router /api/v1/roles/{id}/mfa [put]
+func (s *MFAService) SetRoleRequirement(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req models.SetRoleMFARequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	if role, err := s.roleRepo.GetByID(c.UserContext(), id); err != nil || role == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "role not found"})
+	}
+
+	if err := s.store.SetRoleRequiresMFA(c.UserContext(), id, req.Required); err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "role not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    fiber.Map{"role_id": id, "mfa_required": req.Required},
+	})
+}
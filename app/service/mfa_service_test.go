@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+	"achievement_backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// setupMFA mengembalikan clock yang dipakai MFAService; test memajukannya
+// untuk berpindah periode TOTP.
+func setupMFA() (*fiber.App, repository.MFAStore, *time.Time) {
+	setupTestKeys()
+	app := fiber.New()
+
+	users := newMockAuthUserRepo()
+	store := repository.NewMemoryMFAStore()
+	mfa := NewMFAService(store, users, &mockAuthRoleRepo{}, "Test")
+
+	clock := time.Now()
+	mfa.now = func() time.Time { return clock }
+
+	auth := newTestAuthService(&testAuthDeps{users: users, mfa: mfa})
+
+	app.Post("/login", auth.Login)
+	app.Post("/mfa/setup", auth.SetupMFA)
+	app.Post("/mfa/verify", auth.VerifyMFA)
+
+	asUser := func(h fiber.Handler) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			c.Locals("user_id", "1")
+			return h(c)
+		}
+	}
+	app.Get("/mfa", asUser(mfa.GetStatus))
+	app.Post("/mfa/enroll", asUser(mfa.Enroll))
+	app.Post("/mfa/enable", asUser(mfa.Enable))
+	app.Post("/mfa/disable", asUser(mfa.Disable))
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	users.users["1"] = &models.User{
+		ID:           "1",
+		Username:     "dosen",
+		Email:        "dosen@mail.com",
+		PasswordHash: string(hash),
+		IsActive:     true,
+		RoleID:       ptr("role-dosen"),
+	}
+
+	return app, store, &clock
+}
+
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	code, err := utils.TOTPCode(secret, at)
+	assert.NoError(t, err)
+	return code
+}
+
+func loginMFA(t *testing.T, app *fiber.App) models.MFAChallengeResponse {
+	resp := postJSON(t, app, "/login", models.LoginRequest{Username: "dosen", Password: "secret"})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var challenge models.MFAChallengeResponse
+	decodeBody(t, resp, &challenge)
+	assert.Equal(t, "mfa_required", challenge.Status)
+	return challenge
+}
+
+//
+// =======================================================
+// ROLE WAJIB MFA: ENROLL SAAT LOGIN
+// =======================================================
+//
+
+func TestMFA_RequiredRole_EnrollDuringLogin(t *testing.T) {
+	app, store, clock := setupMFA()
+	_ = store.SetRoleRequiresMFA(context.Background(), "role-dosen", true)
+
+	challenge := loginMFA(t, app)
+	assert.False(t, challenge.Data.Enrolled)
+
+	resp := postJSON(t, app, "/mfa/setup", models.MFASetupRequest{MFAToken: challenge.Data.MFAToken})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var setup struct {
+		Data models.MFAEnrollment `json:"data"`
+	}
+	decodeBody(t, resp, &setup)
+	assert.Contains(t, setup.Data.QRPayload, "otpauth://totp/")
+
+	resp = postJSON(t, app, "/mfa/verify", models.MFAVerifyRequest{
+		MFAToken: challenge.Data.MFAToken,
+		Code:     totpCode(t, setup.Data.Secret, *clock),
+	})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var login models.LoginResponse
+	decodeBody(t, resp, &login)
+	assert.NotEmpty(t, login.Data.Token)
+	assert.Len(t, login.Data.RecoveryCodes, recoveryCodeCount)
+
+	// token tantangan hanya sekali pakai
+	*clock = clock.Add(time.Minute)
+	resp = postJSON(t, app, "/mfa/verify", models.MFAVerifyRequest{
+		MFAToken: challenge.Data.MFAToken,
+		Code:     totpCode(t, setup.Data.Secret, *clock),
+	})
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestMFA_NotRequired_LoginReturnsToken(t *testing.T) {
+	app, _, _ := setupMFA()
+
+	resp := postJSON(t, app, "/login", models.LoginRequest{Username: "dosen", Password: "secret"})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var login models.LoginResponse
+	decodeBody(t, resp, &login)
+	assert.Equal(t, "success", login.Status)
+	assert.NotEmpty(t, login.Data.Token)
+}
+
+//
+// =======================================================
+// MFA AKTIF: KODE, REPLAY, KODE PEMULIHAN
+// =======================================================
+//
+
+func enableMFA(t *testing.T, app *fiber.App, clock *time.Time) (string, []string) {
+	resp := postJSON(t, app, "/mfa/enroll", nil)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var enroll struct {
+		Data models.MFAEnrollment `json:"data"`
+	}
+	decodeBody(t, resp, &enroll)
+
+	resp = postJSON(t, app, "/mfa/enable", models.MFACodeRequest{Code: totpCode(t, enroll.Data.Secret, *clock)})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var enabled struct {
+		Data struct {
+			RecoveryCodes []string `json:"recovery_codes"`
+		} `json:"data"`
+	}
+	decodeBody(t, resp, &enabled)
+
+	return enroll.Data.Secret, enabled.Data.RecoveryCodes
+}
+
+func TestMFA_Enabled_RejectsReplayedCode(t *testing.T) {
+	app, _, clock := setupMFA()
+	secret, _ := enableMFA(t, app, clock)
+
+	// kode yang sama dengan saat enable tidak boleh dipakai lagi
+	challenge := loginMFA(t, app)
+	assert.True(t, challenge.Data.Enrolled)
+
+	resp := postJSON(t, app, "/mfa/verify", models.MFAVerifyRequest{
+		MFAToken: challenge.Data.MFAToken,
+		Code:     totpCode(t, secret, *clock),
+	})
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	*clock = clock.Add(time.Duration(utils.TOTPPeriod) * time.Second)
+	resp = postJSON(t, app, "/mfa/verify", models.MFAVerifyRequest{
+		MFAToken: challenge.Data.MFAToken,
+		Code:     totpCode(t, secret, *clock),
+	})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestMFA_RecoveryCodeSingleUse(t *testing.T) {
+	app, store, clock := setupMFA()
+	_, codes := enableMFA(t, app, clock)
+
+	challenge := loginMFA(t, app)
+	resp := postJSON(t, app, "/mfa/verify", models.MFAVerifyRequest{MFAToken: challenge.Data.MFAToken, Code: codes[0]})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	challenge = loginMFA(t, app)
+	resp = postJSON(t, app, "/mfa/verify", models.MFAVerifyRequest{MFAToken: challenge.Data.MFAToken, Code: codes[0]})
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	remaining, _ := store.CountRecoveryCodes(context.Background(), "1")
+	assert.Equal(t, recoveryCodeCount-1, remaining)
+}
+
+func TestMFA_ChallengeTokenIsNotAccessToken(t *testing.T) {
+	app, _, clock := setupMFA()
+	enableMFA(t, app, clock)
+
+	challenge := loginMFA(t, app)
+
+	_, err := utils.ValidateToken(challenge.Data.MFAToken)
+	assert.Error(t, err)
+}
+
+func TestMFA_DisableBlockedWhenRoleRequires(t *testing.T) {
+	app, store, clock := setupMFA()
+	secret, _ := enableMFA(t, app, clock)
+	_ = store.SetRoleRequiresMFA(context.Background(), "role-dosen", true)
+
+	*clock = clock.Add(time.Duration(utils.TOTPPeriod) * time.Second)
+	resp := postJSON(t, app, "/mfa/disable", models.MFACodeRequest{Code: totpCode(t, secret, *clock)})
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	_ = store.SetRoleRequiresMFA(context.Background(), "role-dosen", false)
+	resp = postJSON(t, app, "/mfa/disable", models.MFACodeRequest{Code: totpCode(t, secret, *clock)})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	req := httptest.NewRequest(http.MethodGet, "/mfa", nil)
+	resp, _ = app.Test(req)
+	var status struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	decodeBody(t, resp, &status)
+	assert.Equal(t, false, status.Data["enabled"])
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+	"achievement_backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrPasswordReused = errors.New("password was used recently")
+
+// PasswordManager menerapkan satu PasswordPolicy yang sama untuk semua jalur
+// yang mengatur password: buat user, reset admin, reset lewat email, dan
+// ganti password sendiri.
+type PasswordManager struct {
+	policy       utils.PasswordPolicy
+	history      repository.PasswordHistoryStore
+	userRepo     repository.UserRepository
+	studentRepo  repository.StudentRepository
+	lecturerRepo repository.LecturerRepository
+}
+
+func NewPasswordManager(
+	policy utils.PasswordPolicy,
+	history repository.PasswordHistoryStore,
+	userRepo repository.UserRepository,
+	studentRepo repository.StudentRepository,
+	lecturerRepo repository.LecturerRepository,
+) *PasswordManager {
+	return &PasswordManager{
+		policy:       policy,
+		history:      history,
+		userRepo:     userRepo,
+		studentRepo:  studentRepo,
+		lecturerRepo: lecturerRepo,
+	}
+}
+
+// Validate memeriksa password baru untuk user. User yang belum tersimpan
+// (ID kosong) hanya dicek terhadap username dan email-nya.
+func (m *PasswordManager) Validate(ctx context.Context, user *models.User, password string) error {
+	forbidden := []string{user.Username, user.Email}
+	if local, _, ok := strings.Cut(user.Email, "@"); ok {
+		forbidden = append(forbidden, local)
+	}
+
+	if user.ID != "" {
+		if s, err := m.studentRepo.GetByUserID(ctx, user.ID); err == nil && s != nil {
+			forbidden = append(forbidden, s.StudentID)
+		}
+		if l, err := m.lecturerRepo.GetByUserID(ctx, user.ID); err == nil && l != nil {
+			forbidden = append(forbidden, l.LecturerID)
+		}
+	}
+
+	if err := m.policy.Validate(password, forbidden...); err != nil {
+		return err
+	}
+
+	if m.policy.HistorySize <= 0 || user.ID == "" {
+		return nil
+	}
+
+	recent, err := m.history.Recent(ctx, user.ID, m.policy.HistorySize)
+	if err != nil {
+		return err
+	}
+	// user lama mungkin belum punya riwayat, jadi hash aktif ikut dicek
+	if user.PasswordHash != "" {
+		recent = append(recent, user.PasswordHash)
+	}
+
+	for _, h := range recent {
+		if bcrypt.CompareHashAndPassword([]byte(h), []byte(password)) == nil {
+			return ErrPasswordReused
+		}
+	}
+
+	return nil
+}
+
+// Set meng-hash dan menyimpan password baru tanpa validasi; panggil
+// Validate lebih dulu.
+func (m *PasswordManager) Set(ctx context.Context, userID, password string) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	if err := m.userRepo.UpdatePassword(ctx, userID, string(hashed)); err != nil {
+		return err
+	}
+
+	m.Remember(ctx, userID, string(hashed))
+	return nil
+}
+
+// Remember mencatat hash ke riwayat. Gagal mencatat tidak membatalkan
+// perubahan password yang sudah tersimpan.
+func (m *PasswordManager) Remember(ctx context.Context, userID, passwordHash string) {
+	if m.policy.HistorySize <= 0 {
+		return
+	}
+	if err := m.history.Add(ctx, userID, passwordHash); err != nil {
+		log.Printf("[PasswordManager] add history for user %s error: %v", userID, err)
+	}
+}
+
+// passwordError menerjemahkan error validasi password menjadi response HTTP.
+func passwordError(c *fiber.Ctx, err error) error {
+	var pe *utils.PasswordPolicyError
+	switch {
+	case errors.As(err, &pe):
+		return c.Status(400).JSON(fiber.Map{
+			"error":      "password does not meet policy",
+			"violations": pe.Violations,
+		})
+	case errors.Is(err, ErrPasswordReused):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(500).JSON(fiber.Map{"error": "failed to validate password"})
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+	"achievement_backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type pwStudentRepo struct{ mockStudentRepo }
+
+func (m *pwStudentRepo) GetByUserID(ctx context.Context, userID string) (*models.Student, error) {
+	return &models.Student{UserID: userID, StudentID: "M2024001x"}, nil
+}
+
+func changePassword(t *testing.T, app *fiber.App, current, next string) *http.Response {
+	b, _ := json.Marshal(models.ChangePasswordRequest{CurrentPassword: current, NewPassword: next})
+	req := httptest.NewRequest(http.MethodPut, "/password", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	return resp
+}
+
+func seedPasswordUser(repo *mockAuthUserRepo, password string) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	repo.users["1"] = &models.User{
+		ID:           "1",
+		Username:     "john",
+		Email:        "john@mail.com",
+		PasswordHash: string(hash),
+		IsActive:     true,
+	}
+}
+
+//
+// =======================================================
+// GANTI PASSWORD SENDIRI
+// =======================================================
+//
+
+func TestAuthService_ChangePassword_Success(t *testing.T) {
+	app, repo, _ := setupAuthService()
+	seedPasswordUser(repo, "Old-Pass1")
+
+	resp := changePassword(t, app, "Old-Pass1", "N3w-Password")
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	hash := repo.users["1"].PasswordHash
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("N3w-Password")))
+}
+
+func TestAuthService_ChangePassword_WrongCurrent(t *testing.T) {
+	app, repo, _ := setupAuthService()
+	seedPasswordUser(repo, "Old-Pass1")
+
+	resp := changePassword(t, app, "not-it", "N3w-Password")
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	hash := repo.users["1"].PasswordHash
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("Old-Pass1")))
+}
+
+func TestAuthService_ChangePassword_PolicyViolation(t *testing.T) {
+	app, repo, _ := setupAuthService()
+	seedPasswordUser(repo, "Old-Pass1")
+
+	resp := changePassword(t, app, "Old-Pass1", "weak")
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	var body map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	assert.NotEmpty(t, body["violations"])
+}
+
+func TestAuthService_ChangePassword_RejectsReuse(t *testing.T) {
+	app, repo, _ := setupAuthService()
+	seedPasswordUser(repo, "Old-Pass1")
+
+	// password aktif tidak boleh dipakai lagi
+	resp := changePassword(t, app, "Old-Pass1", "Old-Pass1")
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp = changePassword(t, app, "Old-Pass1", "N3w-Password")
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	resp = changePassword(t, app, "N3w-Password", "Th1rd-Password")
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	// masih dalam riwayat
+	resp = changePassword(t, app, "Th1rd-Password", "N3w-Password")
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+//
+// =======================================================
+// PASSWORD MANAGER
+// =======================================================
+//
+
+func TestPasswordManager_RejectsStudentNumber(t *testing.T) {
+	users := newMockAuthUserRepo()
+	m := NewPasswordManager(
+		utils.DefaultPasswordPolicy(),
+		repository.NewMemoryPasswordHistoryStore(),
+		users,
+		&pwStudentRepo{},
+		&mockLecturerRepo{},
+	)
+
+	err := m.Validate(context.Background(), &models.User{ID: "1", Username: "john"}, "M2024001X")
+	assert.IsType(t, &utils.PasswordPolicyError{}, err)
+}
+
+func TestPasswordManager_HistoryLimit(t *testing.T) {
+	users := newMockAuthUserRepo()
+	users.users["1"] = &models.User{ID: "1", Username: "john"}
+
+	policy := utils.DefaultPasswordPolicy()
+	policy.HistorySize = 2
+	m := NewPasswordManager(policy, repository.NewMemoryPasswordHistoryStore(), users, &mockStudentRepo{}, &mockLecturerRepo{})
+
+	for _, pw := range []string{"First-Pass1", "Second-Pass1", "Third-Pass1"} {
+		assert.NoError(t, m.Set(context.Background(), "1", pw))
+	}
+
+	user := users.users["1"]
+	assert.Equal(t, ErrPasswordReused, m.Validate(context.Background(), user, "Second-Pass1"))
+	// sudah keluar dari riwayat 2 password terakhir
+	assert.NoError(t, m.Validate(context.Background(), user, "First-Pass1"))
+}
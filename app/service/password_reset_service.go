@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+	"achievement_backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PasswordResetService struct {
+	authRepo   repository.AuthRepository
+	userRepo   repository.UserRepository
+	resetStore repository.PasswordResetStore
+	sessions   *SessionService
+	mailer     utils.Mailer
+	resetURL   string
+	ttl        time.Duration
+	passwords  *PasswordManager
+	uow        repository.UnitOfWork
+}
+
+// NewPasswordResetService: resetURL adalah halaman frontend yang menerima
+// query ?token=..., ttl adalah masa berlaku token reset.
+func NewPasswordResetService(
+	authRepo repository.AuthRepository,
+	userRepo repository.UserRepository,
+	resetStore repository.PasswordResetStore,
+	sessions *SessionService,
+	mailer utils.Mailer,
+	resetURL string,
+	ttl time.Duration,
+	passwords *PasswordManager,
+	uow repository.UnitOfWork,
+) *PasswordResetService {
+	return &PasswordResetService{
+		authRepo:   authRepo,
+		userRepo:   userRepo,
+		resetStore: resetStore,
+		sessions:   sessions,
+		mailer:     mailer,
+		resetURL:   resetURL,
+		ttl:        ttl,
+		passwords:  passwords,
+		uow:        uow,
+	}
+}
+
+// ForgotPassword godoc
+// @Summary Meminta reset password
+// @Description Mengirim tautan reset password sekali pakai ke email user. Response selalu sama agar keberadaan akun tidak bocor.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body models.ForgotPasswordRequest true "Username, email, NIM atau NIP"
+// @Success 200 {object} map[string]interface{} "Permintaan diterima"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 500 {object} map[string]interface{} "Kesalahan server"
+// @Router /api/v1/auth/password/forgot [post]
+func (s *PasswordResetService) RequestReset(c *fiber.Ctx) error {
+	var req models.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	identifier := strings.TrimSpace(req.Identifier)
+	if identifier == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "identifier required"})
+	}
+
+	accepted := fiber.Map{
+		"success": true,
+		"message": "if the account exists, a reset link has been sent to its email",
+	}
+
+	user, err := s.authRepo.GetForLogin(c.UserContext(), identifier)
+	if err != nil {
+		if err == sql.ErrNoRows || err == repository.ErrAmbiguousLogin {
+			return c.JSON(accepted)
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	if !user.IsActive || user.Email == "" {
+		return c.JSON(accepted)
+	}
+
+	// hanya tautan terbaru yang berlaku
+	if err := s.resetStore.InvalidateByUser(c.UserContext(), user.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	raw, err := utils.GenerateSecureToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to generate token"})
+	}
+
+	err = s.resetStore.Create(c.UserContext(), &models.PasswordResetToken{
+		TokenHash: utils.HashToken(raw),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(s.ttl),
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	err = s.mailer.Send(utils.MailMessage{
+		To:      user.Email,
+		Subject: "Reset password",
+		Body: fmt.Sprintf(
+			"Halo %s,\n\nKami menerima permintaan reset password untuk akun Anda.\n"+
+				"Buka tautan berikut untuk membuat password baru (berlaku %s):\n\n%s?token=%s\n\n"+
+				"Abaikan email ini jika Anda tidak meminta reset password.\n",
+			user.FullName, s.ttl, s.resetURL, raw,
+		),
+	})
+	if err != nil {
+		// mailer di produksi mengantre (utils.AsyncMailer) sehingga waktu
+		// respons tidak bergantung pada SMTP; kegagalan pun tidak dibocorkan
+		log.Printf("[PasswordReset] send mail to user %s error: %v", user.ID, err)
+	}
+
+	return c.JSON(accepted)
+}
+
+// ResetPassword godoc
+// @Summary Mengatur password baru dengan token reset
+// @Description Token hanya bisa dipakai sekali. Setelah berhasil, semua sesi user dicabut.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body models.ResetPasswordRequest true "Token dan password baru"
+// @Success 200 {object} map[string]interface{} "Password berhasil direset"
+// @Failure 400 {object} map[string]interface{} "Token tidak valid, kadaluarsa, atau password tidak memenuhi policy"
+// @Failure 500 {object} map[string]interface{} "Kesalahan server"
+// @Router /api/v1/auth/password/reset [post]
+func (s *PasswordResetService) Reset(c *fiber.Ctx) error {
+	var req models.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	if req.Token == "" || req.NewPassword == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "token and new_password required"})
+	}
+
+	tokenHash := utils.HashToken(req.Token)
+
+	// validasi password sebelum token dipakai, agar token tidak hangus
+	// hanya karena password ditolak policy
+	pending, err := s.resetStore.GetValid(c.UserContext(), tokenHash, time.Now())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid or expired token"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	user, err := s.userRepo.GetByID(c.UserContext(), pending.UserID)
+	if err != nil || user == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid or expired token"})
+	}
+
+	if err := s.passwords.Validate(c.UserContext(), user, req.NewPassword); err != nil {
+		return passwordError(c, err)
+	}
+
+	// token, password dan pencabutan sesi ditulis atomik: jika pencabutan
+	// gagal, token belum terpakai dan password lama tetap berlaku
+	err = s.uow.Do(c.UserContext(), func(ctx context.Context) error {
+		token, err := s.resetStore.Consume(ctx, tokenHash, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if err := s.passwords.Set(ctx, token.UserID, req.NewPassword); err != nil {
+			return err
+		}
+
+		// cabut semua sesi: access token lama ditolak middleware, sesi dan refresh token dicabut
+		if err := s.userRepo.RevokeSessions(ctx, token.UserID); err != nil {
+			return err
+		}
+		if _, err := s.sessions.revokeAll(ctx, token.UserID, ""); err != nil {
+			return err
+		}
+		return s.resetStore.InvalidateByUser(ctx, token.UserID)
+	})
+	if err == sql.ErrNoRows {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid or expired token"})
+	}
+	if err != nil {
+		log.Printf("[PasswordReset] reset password error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "failed to reset password"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "password has been reset, please login again",
+	})
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+	"achievement_backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func setupPasswordResetService() (*fiber.App, *mockAuthUserRepo, repository.RefreshTokenStore, *utils.MemoryMailer) {
+	mailer := &utils.MemoryMailer{}
+	app, users, refreshStore := setupPasswordResetServiceWithMailer(mailer)
+	return app, users, refreshStore, mailer
+}
+
+func setupPasswordResetServiceWithMailer(mailer utils.Mailer) (*fiber.App, *mockAuthUserRepo, repository.RefreshTokenStore) {
+	app := fiber.New()
+
+	users := newMockAuthUserRepo()
+	refreshStore := repository.NewMemoryRefreshTokenStore()
+
+	svc := NewPasswordResetService(
+		users,
+		users,
+		repository.NewMemoryPasswordResetStore(),
+		NewSessionService(
+			repository.NewMemorySessionStore(),
+			refreshStore,
+			repository.NewMemoryTokenRevocationStore(),
+			users,
+		),
+		mailer,
+		"http://frontend/reset",
+		30*time.Minute,
+		NewPasswordManager(
+			utils.DefaultPasswordPolicy(),
+			repository.NewMemoryPasswordHistoryStore(),
+			users,
+			&mockAuthStudentRepo{},
+			&mockAuthLecturerRepo{},
+		),
+		repository.NewMemoryUnitOfWork(),
+	)
+
+	app.Post("/forgot", svc.RequestReset)
+	app.Post("/reset", svc.Reset)
+
+	return app, users, refreshStore
+}
+
+var resetTokenPattern = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+//
+// =======================================================
+// ALUR LENGKAP: MINTA RESET → RESET → TOKEN HANGUS
+// =======================================================
+//
+
+func TestPasswordReset_FullFlow(t *testing.T) {
+	app, users, refreshStore, mailer := setupPasswordResetService()
+
+	users.users["1"] = &models.User{
+		ID:       "1",
+		Username: "john",
+		Email:    "john@mail.com",
+		IsActive: true,
+	}
+	_ = refreshStore.Create(context.Background(), &models.RefreshToken{
+		TokenHash: "old-refresh",
+		UserID:    "1",
+		FamilyID:  "fam-1",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	resp := postJSON(t, app, "/forgot", models.ForgotPasswordRequest{Identifier: "john@mail.com"})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	sent := mailer.Sent()
+	assert.Len(t, sent, 1)
+	assert.Equal(t, "john@mail.com", sent[0].To)
+
+	m := resetTokenPattern.FindStringSubmatch(sent[0].Body)
+	assert.Len(t, m, 2)
+	token := m[1]
+
+	resp = postJSON(t, app, "/reset", models.ResetPasswordRequest{Token: token, NewPassword: "N3w-Secret"})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	hash := users.users["1"].PasswordHash
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("N3w-Secret")))
+
+	// semua sesi dicabut
+	assert.Equal(t, []string{"1"}, users.revokedSessions)
+	old, _ := refreshStore.GetByHash(context.Background(), "old-refresh")
+	assert.NotNil(t, old.RevokedAt)
+
+	// token sekali pakai
+	resp = postJSON(t, app, "/reset", models.ResetPasswordRequest{Token: token, NewPassword: "Again-123"})
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestPasswordReset_UnknownAccount_SameResponse(t *testing.T) {
+	app, _, _, mailer := setupPasswordResetService()
+
+	resp := postJSON(t, app, "/forgot", models.ForgotPasswordRequest{Identifier: "nobody"})
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Empty(t, mailer.Sent())
+}
+
+func TestPasswordReset_NewRequestInvalidatesOldToken(t *testing.T) {
+	app, users, _, mailer := setupPasswordResetService()
+
+	users.users["1"] = &models.User{ID: "1", Username: "john", Email: "john@mail.com", IsActive: true}
+
+	postJSON(t, app, "/forgot", models.ForgotPasswordRequest{Identifier: "john"})
+	postJSON(t, app, "/forgot", models.ForgotPasswordRequest{Identifier: "john"})
+
+	sent := mailer.Sent()
+	assert.Len(t, sent, 2)
+	first := resetTokenPattern.FindStringSubmatch(sent[0].Body)[1]
+
+	resp := postJSON(t, app, "/reset", models.ResetPasswordRequest{Token: first, NewPassword: "N3w-Secret"})
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestPasswordReset_PolicyViolationKeepsToken(t *testing.T) {
+	app, users, _, mailer := setupPasswordResetService()
+
+	users.users["1"] = &models.User{ID: "1", Username: "john", Email: "john@mail.com", IsActive: true}
+
+	postJSON(t, app, "/forgot", models.ForgotPasswordRequest{Identifier: "john"})
+	token := resetTokenPattern.FindStringSubmatch(mailer.Sent()[0].Body)[1]
+
+	resp := postJSON(t, app, "/reset", models.ResetPasswordRequest{Token: token, NewPassword: "weak"})
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	// token belum hangus, bisa dipakai dengan password yang memenuhi policy
+	resp = postJSON(t, app, "/reset", models.ResetPasswordRequest{Token: token, NewPassword: "N3w-Secret"})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+// blockingMailer menahan Send sampai release ditutup.
+type blockingMailer struct {
+	release chan struct{}
+	inner   utils.MemoryMailer
+}
+
+func (m *blockingMailer) Send(msg utils.MailMessage) error {
+	<-m.release
+	return m.inner.Send(msg)
+}
+
+func TestPasswordReset_AsyncMailerDoesNotDelayResponse(t *testing.T) {
+	slow := &blockingMailer{release: make(chan struct{})}
+	mailer := utils.NewAsyncMailer(slow, 10)
+	app, users, _ := setupPasswordResetServiceWithMailer(mailer)
+
+	users.users["1"] = &models.User{ID: "1", Username: "john", Email: "john@mail.com", IsActive: true}
+
+	// server SMTP belum menjawab, respons tetap langsung kembali
+	resp := postJSON(t, app, "/forgot", models.ForgotPasswordRequest{Identifier: "john"})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Empty(t, slow.inner.Sent())
+
+	close(slow.release)
+	mailer.Close()
+	assert.Len(t, slow.inner.Sent(), 1)
+}
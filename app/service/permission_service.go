@@ -0,0 +1,87 @@
+package service
+
+import (
+	"database/sql"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PermissionService struct {
+	permRepo     repository.PermissionRepository
+	rolePermRepo repository.RolePermissionRepository
+}
+
+func NewPermissionService(
+	permRepo repository.PermissionRepository,
+	rolePermRepo repository.RolePermissionRepository,
+) *PermissionService {
+	return &PermissionService{
+		permRepo:     permRepo,
+		rolePermRepo: rolePermRepo,
+	}
+}
+
+// GetAllPermissions godoc
+// @Summary Mendapatkan daftar permission
+// @Description Mendapatkan daftar semua permission yang tersedia
+// @Tags Permission
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Daftar permission"
+// @Failure 500 {object} map[string]interface{} "Gagal mengambil data"
+// @Security Bearer
+// @Router /api/v1/permissions [get]
+func (s *PermissionService) GetAll(c *fiber.Ctx) error {
+	perms, err := s.permRepo.GetAll(c.UserContext())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch permissions"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    perms,
+	})
+}
+
+// GetPermissionByID godoc
+// @Summary Mendapatkan detail permission
+// @Description Mendapatkan detail permission beserta role yang memilikinya
+// @Tags Permission
+// @Accept json
+// @Produce json
+// @Param id path string true "Permission ID"
+// @Success 200 {object} map[string]interface{} "Detail permission"
+// @Failure 404 {object} map[string]interface{} "Permission not found"
+// @Failure 500 {object} map[string]interface{} "Gagal mengambil data"
+// @Security Bearer
+// @Router /api/v1/permissions/{id} [get]
+func (s *PermissionService) GetByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	perm, err := s.permRepo.GetByID(c.UserContext(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "permission not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch permission"})
+	}
+
+	roles, err := s.rolePermRepo.GetRolesByPermission(c.UserContext(), id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch roles"})
+	}
+	if roles == nil {
+		roles = []models.Role{}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"permission": perm,
+			"roles":      roles,
+		},
+	})
+}
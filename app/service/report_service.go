@@ -4,6 +4,7 @@ import (
     "sort"
 
     model "achievement_backend/app/model"
+    "achievement_backend/app/policy"
     "achievement_backend/app/repository"
 
     "github.com/gofiber/fiber/v2"
@@ -15,6 +16,7 @@ type ReportService struct {
     lecturerRepo repository.LecturerRepository
     mongoRepo    repository.MongoAchievementRepository
     userRepo     repository.UserRepository
+    authz        *policy.Policy
 }
 
 func NewReportService(
@@ -30,6 +32,7 @@ func NewReportService(
         lecturerRepo: lecturerRepo,
         mongoRepo:    mongoRepo,
         userRepo:     userRepo,
+        authz:        policy.New(studentRepo, lecturerRepo),
     }
 }
 
@@ -64,55 +67,29 @@ type TopStudent struct {
 // @Security Bearer
 // @Router /api/v1/reports/statistics [get]
 func (s *ReportService) GetStatistics(c *fiber.Ctx) error {
-	role := c.Locals("role_name").(string)
-	userID := c.Locals("user_id").(string)
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
+	}
+
+	all, studentIDs, err := s.authz.StudentScope(c.UserContext(), sub, policy.ActionRead)
+	if err != nil {
+		return policyError(c, err)
+	}
 
 	var refs []model.AchievementReference
 	var total int64
-	var err error
 
 	limit := 1_000_000
 	offset := 0
 
-	// ADMIN — full access
-	if role == "Admin" {
-		refs, total, err = s.refRepo.GetAllWithPagination(limit, offset)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "failed to fetch data"})
-		}
-	}
-
-	// DOSEN WALI — only advisees
-	if role == "Dosen Wali" {
-		lect, _ := s.lecturerRepo.GetByUserID(userID)
-		if lect == nil {
-			return c.Status(403).JSON(fiber.Map{"error": "lecturer not found"})
-		}
-
-		advisees, _ := s.studentRepo.GetByAdvisorID(lect.ID)
-		ids := []string{}
-		for _, s := range advisees {
-			ids = append(ids, s.ID)
-		}
-
-		refs, total, err = s.refRepo.GetByAdviseesWithPagination(ids, limit, offset)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "failed to fetch data"})
-		}
+	if all {
+		refs, total, err = s.refRepo.GetAllWithPagination(c.UserContext(), limit, offset)
+	} else if len(studentIDs) > 0 {
+		refs, total, err = s.refRepo.GetByAdviseesWithPagination(c.UserContext(), studentIDs, limit, offset)
 	}
-
-	// MAHASISWA — only own achievement
-	if role == "Mahasiswa" {
-		stu, _ := s.studentRepo.GetByUserID(userID)
-		if stu == nil {
-			return c.Status(403).JSON(fiber.Map{"error": "student not found"})
-		}
-
-		refs, err = s.refRepo.GetByStudentID(stu.ID)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "failed to fetch data"})
-		}
-		total = int64(len(refs))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch data"})
 	}
 
 	// ——————————————————
@@ -129,7 +106,7 @@ func (s *ReportService) GetStatistics(c *fiber.Ctx) error {
 	pointsMap := map[string]int64{}
 
 	for _, ref := range refs {
-		mg, err := s.mongoRepo.GetByID(c.Context(), ref.MongoAchievementID)
+		mg, err := s.mongoRepo.GetByID(c.UserContext(), ref.MongoAchievementID)
 		if err != nil || mg == nil {
 			continue
 		}
@@ -170,8 +147,8 @@ func (s *ReportService) GetStatistics(c *fiber.Ctx) error {
 			break
 		}
 
-		stuObj, _ := s.studentRepo.GetByID(st.ID)
-		user, _ := s.userRepo.GetByID(stuObj.UserID)
+		stuObj, _ := s.studentRepo.GetByID(c.UserContext(), st.ID)
+		user, _ := s.userRepo.GetByID(c.UserContext(), stuObj.UserID)
 
 		name := "Unknown"
 		if user != nil {
@@ -210,14 +187,17 @@ func (s *ReportService) GetStatistics(c *fiber.Ctx) error {
 // @Security Bearer
 // @Router /api/v1/reports/student/{id} [get]
 func (s *ReportService) GetStudentReport(c *fiber.Ctx) error {
-	role := c.Locals("role_name").(string)
-	loggedUID := c.Locals("user_id").(string)
 	studentID := c.Params("id")
 
+	sub, err := subjectFromCtx(c, s.authz)
+	if err != nil {
+		return policyError(c, err)
+	}
+
 	// ========================
 	// 1. STUDENT
 	// ========================
-	student, err := s.studentRepo.GetByID(studentID)
+	student, err := s.studentRepo.GetByID(c.UserContext(), studentID)
 	if err != nil || student == nil {
 		return c.Status(404).JSON(fiber.Map{"error": "student not found"})
 	}
@@ -225,34 +205,14 @@ func (s *ReportService) GetStudentReport(c *fiber.Ctx) error {
 	// ========================
 	// 2. RBAC
 	// ========================
-	switch role {
-
-	case "Mahasiswa":
-		if student.UserID != loggedUID {
-			return c.Status(403).JSON(fiber.Map{
-				"error": "forbidden: access own report only",
-			})
-		}
-
-	case "Dosen Wali":
-		lect, err := s.lecturerRepo.GetByUserID(loggedUID)
-		if err != nil || lect == nil || student.AdvisorID == nil || *student.AdvisorID != lect.ID {
-			return c.Status(403).JSON(fiber.Map{
-				"error": "forbidden: not your advisee",
-			})
-		}
-
-	case "Admin":
-		// full access
-
-	default:
-		return c.Status(403).JSON(fiber.Map{"error": "invalid role"})
+	if err := s.authz.Authorize(c.UserContext(), sub, policy.ActionRead, student.ID); err != nil {
+		return policyError(c, err)
 	}
 
 	// ========================
 	// 3. ALL ACHIEVEMENTS (NO FILTER)
 	// ========================
-	refs, err := s.refRepo.GetByStudentID(studentID)
+	refs, err := s.refRepo.GetByStudentID(c.UserContext(), studentID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed to fetch achievements",
@@ -269,7 +229,7 @@ func (s *ReportService) GetStudentReport(c *fiber.Ctx) error {
 	)
 
 	for _, ref := range refs {
-		mg, err := s.mongoRepo.GetByID(c.Context(), ref.MongoAchievementID)
+		mg, err := s.mongoRepo.GetByID(c.UserContext(), ref.MongoAchievementID)
 		if err != nil || mg == nil {
 			continue // skip broken data
 		}
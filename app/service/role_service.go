@@ -0,0 +1,316 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/policy"
+	"achievement_backend/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type RoleService struct {
+	roleRepo     repository.RoleRepository
+	rolePermRepo repository.RolePermissionRepository
+	permRepo     repository.PermissionRepository
+}
+
+func NewRoleService(
+	roleRepo repository.RoleRepository,
+	rolePermRepo repository.RolePermissionRepository,
+	permRepo repository.PermissionRepository,
+) *RoleService {
+	return &RoleService{
+		roleRepo:     roleRepo,
+		rolePermRepo: rolePermRepo,
+		permRepo:     permRepo,
+	}
+}
+
+// role mengambil role dari parameter :id. ID yang bukan UUID dianggap tidak
+// ada.
+func (s *RoleService) role(c *fiber.Ctx) (*models.Role, error) {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, sql.ErrNoRows
+	}
+	return s.roleRepo.GetByID(c.UserContext(), id)
+}
+
+func roleError(c *fiber.Ctx, err error) error {
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "role not found"})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": "failed to fetch role"})
+}
+
+// GetAllRoles godoc
+// @Summary Mendapatkan daftar role
+// @Description Mendapatkan daftar semua role
+// @Tags Role
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Daftar role"
+// @Failure 500 {object} map[string]interface{} "Gagal mengambil data"
+// @Security Bearer
+// @Router /api/v1/roles [get]
+func (s *RoleService) GetAll(c *fiber.Ctx) error {
+	roles, err := s.roleRepo.GetAll(c.UserContext())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch roles"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    roles,
+	})
+}
+
+// GetRoleByID godoc
+// @Summary Mendapatkan detail role
+// @Description Mendapatkan detail role beserta daftar permission-nya
+// @Tags Role
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID"
+// @Success 200 {object} map[string]interface{} "Detail role"
+// @Failure 404 {object} map[string]interface{} "Role not found"
+// @Failure 500 {object} map[string]interface{} "Gagal mengambil data"
+// @Security Bearer
+// @Router /api/v1/roles/{id} [get]
+func (s *RoleService) GetByID(c *fiber.Ctx) error {
+	role, err := s.role(c)
+	if err != nil {
+		return roleError(c, err)
+	}
+
+	perms, err := s.rolePermRepo.GetPermissionsByRole(c.UserContext(), role.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch permissions"})
+	}
+	if perms == nil {
+		perms = []models.Permission{}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"role":        role,
+			"permissions": perms,
+		},
+	})
+}
+
+// CreateRole godoc
+// @Summary Membuat role baru
+// @Description Membuat role baru (tanpa permission)
+// @Tags Role
+// @Accept json
+// @Produce json
+// @Param body body models.CreateRoleRequest true "Data role"
+// @Success 201 {object} map[string]interface{} "Role berhasil dibuat"
+// @Failure 400 {object} map[string]interface{} "Input tidak valid"
+// @Failure 500 {object} map[string]interface{} "Gagal membuat role"
+// @Security Bearer
+// @Router /api/v1/roles [post]
+func (s *RoleService) Create(c *fiber.Ctx) error {
+	var req models.CreateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "name required"})
+	}
+
+	role, err := s.roleRepo.Create(c.UserContext(), req)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to create role"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data":    role,
+	})
+}
+
+// UpdateRole godoc
+// @Summary Memperbarui role
+// @Description Memperbarui nama dan deskripsi role. Role bawaan (Admin, Mahasiswa, Dosen Wali, Kemahasiswaan) hanya bisa diubah deskripsinya.
+// @Tags Role
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID"
+// @Param body body models.UpdateRoleRequest true "Data role"
+// @Success 200 {object} map[string]interface{} "Role berhasil diperbarui"
+// @Failure 400 {object} map[string]interface{} "Input tidak valid"
+// @Failure 404 {object} map[string]interface{} "Role not found"
+// @Failure 409 {object} map[string]interface{} "Role bawaan tidak bisa diganti namanya"
+// @Failure 500 {object} map[string]interface{} "Gagal memperbarui role"
+// @Security Bearer
+// @Router /api/v1/roles/{id} [put]
+func (s *RoleService) Update(c *fiber.Ctx) error {
+	var req models.UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "name required"})
+	}
+
+	current, err := s.role(c)
+	if err != nil {
+		return roleError(c, err)
+	}
+	if policy.BuiltinRole(current.Name) && req.Name != current.Name {
+		return c.Status(409).JSON(fiber.Map{"error": "built-in role cannot be renamed"})
+	}
+
+	role, err := s.roleRepo.Update(c.UserContext(), current.ID, req)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "role not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed to update role"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    role,
+	})
+}
+
+// DeleteRole godoc
+// @Summary Menghapus role
+// @Description Menghapus role. Role bawaan dan role yang masih dipakai user tidak dapat dihapus.
+// @Tags Role
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID"
+// @Success 200 {object} map[string]interface{} "Role berhasil dihapus"
+// @Failure 404 {object} map[string]interface{} "Role not found"
+// @Failure 409 {object} map[string]interface{} "Role bawaan atau masih dipakai user"
+// @Failure 500 {object} map[string]interface{} "Gagal menghapus role"
+// @Security Bearer
+// @Router /api/v1/roles/{id} [delete]
+func (s *RoleService) Delete(c *fiber.Ctx) error {
+	role, err := s.role(c)
+	if err != nil {
+		return roleError(c, err)
+	}
+	if policy.BuiltinRole(role.Name) {
+		return c.Status(409).JSON(fiber.Map{"error": "built-in role cannot be deleted"})
+	}
+
+	total, err := s.roleRepo.CountUsers(c.UserContext(), role.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to count role users"})
+	}
+	if total > 0 {
+		return c.Status(409).JSON(fiber.Map{
+			"error": "role still assigned to users",
+			"users": total,
+		})
+	}
+
+	// Delete memeriksa ulang pemakaian secara atomik
+	if err := s.roleRepo.Delete(c.UserContext(), role.ID); err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			return c.Status(404).JSON(fiber.Map{"error": "role not found"})
+		case errors.Is(err, repository.ErrRoleInUse):
+			return c.Status(409).JSON(fiber.Map{"error": "role still assigned to users"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed to delete role"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "role deleted",
+	})
+}
+
+// AssignPermission godoc
+// @Summary Menambahkan permission ke role
+// @Description Menambahkan permission ke role. Token user dengan role ini akan dievaluasi ulang.
+// @Tags Role
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID"
+// @Param body body object true "Permission ID" example({"permission_id":"string"})
+// @Success 200 {object} map[string]interface{} "Permission berhasil ditambahkan"
+// @Failure 400 {object} map[string]interface{} "permission_id wajib diisi"
+// @Failure 404 {object} map[string]interface{} "Role atau permission tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "Gagal menambahkan permission"
+// @Security Bearer
+// @Router /api/v1/roles/{id}/permissions [post]
+func (s *RoleService) AssignPermission(c *fiber.Ctx) error {
+	roleID := c.Params("id")
+
+	var body struct {
+		PermissionID string `json:"permission_id"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.PermissionID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "permission_id required"})
+	}
+
+	if _, err := s.role(c); err != nil {
+		return roleError(c, err)
+	}
+
+	if _, err := s.permRepo.GetByID(c.UserContext(), body.PermissionID); err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "permission not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch permission"})
+	}
+
+	if err := s.rolePermRepo.AssignPermission(c.UserContext(), roleID, body.PermissionID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to assign permission"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "permission assigned",
+	})
+}
+
+// RemovePermission godoc
+// @Summary Melepas permission dari role
+// @Description Melepas permission dari role. Token user dengan role ini akan dievaluasi ulang.
+// @Tags Role
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID"
+// @Param permissionId path string true "Permission ID"
+// @Success 200 {object} map[string]interface{} "Permission berhasil dilepas"
+// @Failure 404 {object} map[string]interface{} "Permission tidak terpasang pada role"
+// @Failure 500 {object} map[string]interface{} "Gagal melepas permission"
+// @Security Bearer
+// @Router /api/v1/roles/{id}/permissions/{permissionId} [delete]
+func (s *RoleService) RemovePermission(c *fiber.Ctx) error {
+	roleID := c.Params("id")
+	permissionID := c.Params("permissionId")
+	if _, err := uuid.Parse(roleID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "role not found"})
+	}
+
+	if err := s.rolePermRepo.RemovePermission(c.UserContext(), roleID, permissionID); err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "permission not assigned to role"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed to remove permission"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "permission removed",
+	})
+}
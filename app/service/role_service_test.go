@@ -0,0 +1,213 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// =======================================================
+// MOCK REPOSITORIES
+// =======================================================
+//
+
+type mockRoleSvcRoleRepo struct {
+	roles     map[string]*models.Role
+	userCount int
+	deleted   []string
+	deleteErr error
+}
+
+func (m *mockRoleSvcRoleRepo) GetAll(ctx context.Context) ([]models.Role, error) { return nil, nil }
+
+func (m *mockRoleSvcRoleRepo) GetByID(ctx context.Context, id string) (*models.Role, error) {
+	r, ok := m.roles[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return r, nil
+}
+
+func (m *mockRoleSvcRoleRepo) Create(ctx context.Context, req models.CreateRoleRequest) (*models.Role, error) {
+	return &models.Role{ID: "new", Name: req.Name}, nil
+}
+
+func (m *mockRoleSvcRoleRepo) Update(ctx context.Context, id string, req models.UpdateRoleRequest) (*models.Role, error) {
+	return &models.Role{ID: id, Name: req.Name}, nil
+}
+
+func (m *mockRoleSvcRoleRepo) Delete(ctx context.Context, id string) error {
+	if m.deleteErr != nil {
+		return m.deleteErr
+	}
+	m.deleted = append(m.deleted, id)
+	return nil
+}
+
+func (m *mockRoleSvcRoleRepo) CountUsers(ctx context.Context, id string) (int, error) {
+	return m.userCount, nil
+}
+
+type mockPermissionRepo struct{}
+
+func (m *mockPermissionRepo) GetAll(ctx context.Context) ([]models.Permission, error) {
+	return nil, nil
+}
+
+func (m *mockPermissionRepo) GetByID(ctx context.Context, id string) (*models.Permission, error) {
+	if id != "perm-1" {
+		return nil, sql.ErrNoRows
+	}
+	return &models.Permission{ID: id, Name: "achievement:read"}, nil
+}
+
+// ID role untuk test; handler menolak ID yang bukan UUID.
+const (
+	testRoleID        = "00000000-0000-0000-0000-0000000000a1"
+	testBuiltinRoleID = "00000000-0000-0000-0000-0000000000a2"
+)
+
+func setupRoleService(roleRepo *mockRoleSvcRoleRepo) *fiber.App {
+	app := fiber.New()
+	svc := NewRoleService(roleRepo, &mockRolePermRepo{}, &mockPermissionRepo{})
+
+	app.Post("/roles", svc.Create)
+	app.Put("/roles/:id", svc.Update)
+	app.Delete("/roles/:id", svc.Delete)
+	app.Post("/roles/:id/permissions", svc.AssignPermission)
+
+	return app
+}
+
+//
+// =======================================================
+// DELETE ROLE YANG MASIH DIPAKAI → 409
+// =======================================================
+//
+
+func TestRoleService_Delete_RoleInUse(t *testing.T) {
+	repo := &mockRoleSvcRoleRepo{
+		roles:     map[string]*models.Role{testRoleID: {ID: testRoleID, Name: "Kaprodi"}},
+		userCount: 3,
+	}
+	app := setupRoleService(repo)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/roles/"+testRoleID, nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	assert.Empty(t, repo.deleted)
+}
+
+func TestRoleService_Delete_Success(t *testing.T) {
+	repo := &mockRoleSvcRoleRepo{
+		roles: map[string]*models.Role{testRoleID: {ID: testRoleID, Name: "Kaprodi"}},
+	}
+	app := setupRoleService(repo)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/roles/"+testRoleID, nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{testRoleID}, repo.deleted)
+}
+
+// Role dipakai user di antara CountUsers dan Delete.
+func TestRoleService_Delete_AssignedConcurrently(t *testing.T) {
+	repo := &mockRoleSvcRoleRepo{
+		roles:     map[string]*models.Role{testRoleID: {ID: testRoleID, Name: "Kaprodi"}},
+		deleteErr: repository.ErrRoleInUse,
+	}
+	app := setupRoleService(repo)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/roles/"+testRoleID, nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+}
+
+func TestRoleService_Delete_InvalidID(t *testing.T) {
+	app := setupRoleService(&mockRoleSvcRoleRepo{})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/roles/not-a-uuid", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+//
+// =======================================================
+// ROLE BAWAAN
+// =======================================================
+//
+
+func TestRoleService_BuiltinRoleProtected(t *testing.T) {
+	repo := &mockRoleSvcRoleRepo{
+		roles: map[string]*models.Role{testBuiltinRoleID: {ID: testBuiltinRoleID, Name: "Dosen Wali"}},
+	}
+	app := setupRoleService(repo)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/roles/"+testBuiltinRoleID, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	assert.Empty(t, repo.deleted)
+
+	put := func(req models.UpdateRoleRequest) int {
+		b, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPut, "/roles/"+testBuiltinRoleID, bytes.NewReader(b))
+		r.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(r)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusConflict, put(models.UpdateRoleRequest{Name: "Pembimbing"}))
+	// deskripsi tetap bisa diubah
+	assert.Equal(t, fiber.StatusOK, put(models.UpdateRoleRequest{Name: "Dosen Wali", Description: "Dosen pembimbing akademik"}))
+}
+
+//
+// =======================================================
+// ASSIGN PERMISSION
+// =======================================================
+//
+
+func TestRoleService_AssignPermission_UnknownPermission(t *testing.T) {
+	repo := &mockRoleSvcRoleRepo{
+		roles: map[string]*models.Role{testRoleID: {ID: testRoleID, Name: "Kaprodi"}},
+	}
+	app := setupRoleService(repo)
+
+	b, _ := json.Marshal(map[string]string{"permission_id": "missing"})
+	req := httptest.NewRequest(http.MethodPost, "/roles/"+testRoleID+"/permissions", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestRoleService_Create_NameRequired(t *testing.T) {
+	app := setupRoleService(&mockRoleSvcRoleRepo{})
+
+	b, _ := json.Marshal(models.CreateRoleRequest{Name: "  "})
+	req := httptest.NewRequest(http.MethodPost, "/roles", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+	"achievement_backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Batas masa berlaku API key. Key tanpa expires_in_days berlaku apiKeyDefaultDays.
+const (
+	apiKeyDefaultDays = 90
+	apiKeyMaxDays     = 365
+)
+
+// ServiceAccountService mengelola service account dan API key-nya untuk
+// integrasi mesin (mis. SIAKAD menarik prestasi terverifikasi setiap malam).
+type ServiceAccountService struct {
+	store repository.APIKeyStore
+}
+
+func NewServiceAccountService(store repository.APIKeyStore) *ServiceAccountService {
+	return &ServiceAccountService{store: store}
+}
+
+// account mengambil service account dari parameter :id. ID yang bukan UUID
+// dianggap tidak ada.
+func (s *ServiceAccountService) account(c *fiber.Ctx) (*models.ServiceAccount, error) {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, sql.ErrNoRows
+	}
+	return s.store.GetServiceAccount(c.UserContext(), id)
+}
+
+func serviceAccountError(c *fiber.Ctx, err error) error {
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "service account not found"})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": "database error"})
+}
+
+// viaAPIKey melaporkan apakah request diautentikasi dengan API key. Service
+// account tidak boleh membuat kredensial lain atau meng-impersonate user.
+func viaAPIKey(c *fiber.Ctx) bool {
+	id, _ := c.Locals("service_account_id").(string)
+	return id != ""
+}
+
+// notForAPIKeys mengembalikan permission pertama yang tidak termasuk
+// models.APIKeyPermissions.
+func notForAPIKeys(requested []string) string {
+	for _, p := range requested {
+		p = strings.TrimSpace(p)
+		if p != "" && !models.APIKeyPermissionAllowed(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// grantable memastikan permission key tidak kosong dan tidak melebihi
+// permission admin yang membuatnya.
+func grantable(requested, own []string) ([]string, string) {
+	has := make(map[string]bool, len(own))
+	for _, p := range own {
+		has[p] = true
+	}
+
+	seen := map[string]bool{}
+	var perms []string
+	for _, p := range requested {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		if !has[p] {
+			return nil, p
+		}
+		seen[p] = true
+		perms = append(perms, p)
+	}
+
+	return perms, ""
+}
+
+// CreateServiceAccount godoc
+// @Summary Membuat service account (admin)
+// @Tags Service Account
+// @Accept json
+// @Produce json
+// @Param body body models.CreateServiceAccountRequest true "Nama dan deskripsi"
+// @Success 201 {object} map[string]interface{} "Service account dibuat"
+// @Failure 400 {object} map[string]interface{} "Nama kosong"
+// @Security Bearer
+// @Router /api/v1/service-accounts [post]
+func (s *ServiceAccountService) CreateServiceAccount(c *fiber.Ctx) error {
+	if viaAPIKey(c) {
+		return c.Status(403).JSON(fiber.Map{"error": "not allowed for service accounts"})
+	}
+
+	var req models.CreateServiceAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "name is required"})
+	}
+
+	sa := &models.ServiceAccount{
+		Name:        req.Name,
+		Description: req.Description,
+		IsActive:    true,
+	}
+	if uid, _ := c.Locals("user_id").(string); uid != "" {
+		sa.CreatedBy = &uid
+	}
+
+	if err := s.store.CreateServiceAccount(c.UserContext(), sa); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to create service account"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{"success": true, "data": sa})
+}
+
+// ListServiceAccounts godoc
+// @Summary Daftar service account (admin)
+// @Tags Service Account
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Daftar service account"
+// @Security Bearer
+// @Router /api/v1/service-accounts [get]
+func (s *ServiceAccountService) ListServiceAccounts(c *fiber.Ctx) error {
+	list, err := s.store.ListServiceAccounts(c.UserContext())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch service accounts"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "data": list})
+}
+
+// IssueKey godoc
+// @Summary Menerbitkan API key untuk service account (admin)
+// @Description Key hanya ditampilkan sekali di response ini; yang disimpan hanya hash-nya. Permission key terbatas pada permission baca untuk integrasi (achievement:read) dan tidak boleh melebihi permission admin yang menerbitkan. Masa berlaku default 90 hari, maksimal 365 hari. Key dipakai sebagai header Authorization: Bearer <key>.
+// @Tags Service Account
+// @Accept json
+// @Produce json
+// @Param id path string true "Service account ID"
+// @Param body body models.CreateAPIKeyRequest true "Nama, permission, dan masa berlaku key"
+// @Success 201 {object} map[string]interface{} "Key dibuat"
+// @Failure 400 {object} map[string]interface{} "Permission tidak boleh untuk API key atau masa berlaku tidak valid"
+// @Failure 403 {object} map[string]interface{} "Permission melebihi milik admin"
+// @Failure 404 {object} map[string]interface{} "Service account tidak ditemukan"
+// @Security Bearer
+// @Router /api/v1/service-accounts/{id}/keys [post]
+func (s *ServiceAccountService) IssueKey(c *fiber.Ctx) error {
+	if viaAPIKey(c) {
+		return c.Status(403).JSON(fiber.Map{"error": "not allowed for service accounts"})
+	}
+
+	sa, err := s.account(c)
+	if err != nil {
+		return serviceAccountError(c, err)
+	}
+	if !sa.IsActive {
+		return c.Status(400).JSON(fiber.Map{"error": "service account inactive"})
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if p := notForAPIKeys(req.Permissions); p != "" {
+		return c.Status(400).JSON(fiber.Map{"error": "permission `" + p + "` cannot be granted to api keys"})
+	}
+
+	own, _ := c.Locals("permissions").([]string)
+	perms, denied := grantable(req.Permissions, own)
+	if denied != "" {
+		return c.Status(403).JSON(fiber.Map{"error": "cannot grant permission `" + denied + "`"})
+	}
+	if len(perms) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "at least one permission is required"})
+	}
+
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = apiKeyDefaultDays
+	}
+	if days < 0 || days > apiKeyMaxDays {
+		return c.Status(400).JSON(fiber.Map{"error": "expires_in_days must be between 1 and 365"})
+	}
+
+	raw, prefix, err := utils.GenerateAPIKey()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to generate api key"})
+	}
+
+	key := models.APIKey{
+		ServiceAccountID: sa.ID,
+		Name:             strings.TrimSpace(req.Name),
+		Prefix:           prefix,
+		KeyHash:          utils.HashToken(raw),
+		Permissions:      perms,
+		ExpiresAt:        time.Now().AddDate(0, 0, days),
+	}
+	if uid, _ := c.Locals("user_id").(string); uid != "" {
+		key.CreatedBy = &uid
+	}
+
+	if err := s.store.CreateKey(c.UserContext(), &key); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to create api key"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data":    models.IssuedAPIKey{Key: raw, APIKey: key},
+	})
+}
+
+// ListKeys godoc
+// @Summary Daftar API key service account (admin)
+// @Description Menampilkan prefix, permission, masa berlaku, waktu pemakaian terakhir, dan status pencabutan. Key asli tidak pernah ditampilkan lagi.
+// @Tags Service Account
+// @Produce json
+// @Param id path string true "Service account ID"
+// @Success 200 {object} map[string]interface{} "Daftar key"
+// @Failure 404 {object} map[string]interface{} "Service account tidak ditemukan"
+// @Security Bearer
+// @Router /api/v1/service-accounts/{id}/keys [get]
+func (s *ServiceAccountService) ListKeys(c *fiber.Ctx) error {
+	sa, err := s.account(c)
+	if err != nil {
+		return serviceAccountError(c, err)
+	}
+
+	keys, err := s.store.ListKeys(c.UserContext(), sa.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to fetch api keys"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "data": keys})
+}
+
+// RevokeKey godoc
+// @Summary Mencabut API key (admin)
+// @Tags Service Account
+// @Produce json
+// @Param id path string true "Service account ID"
+// @Param keyId path string true "API key ID"
+// @Success 200 {object} map[string]interface{} "Key dicabut"
+// @Failure 404 {object} map[string]interface{} "Key tidak ditemukan atau sudah dicabut"
+// @Security Bearer
+// @Router /api/v1/service-accounts/{id}/keys/{keyId} [delete]
+func (s *ServiceAccountService) RevokeKey(c *fiber.Ctx) error {
+	sa, err := s.account(c)
+	if err != nil {
+		return serviceAccountError(c, err)
+	}
+
+	keyID := c.Params("keyId")
+	if _, err := uuid.Parse(keyID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "api key not found"})
+	}
+
+	if err := s.store.RevokeKey(c.UserContext(), sa.ID, keyID); err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "api key not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed to revoke api key"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "api key revoked"})
+}
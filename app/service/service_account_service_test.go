@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+	"achievement_backend/middleware"
+	"achievement_backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupServiceAccounts mengembalikan access token admin yang mengelola service account.
+func setupServiceAccounts(t *testing.T) (*fiber.App, repository.APIKeyStore, string) {
+	setupTestKeys()
+	app := fiber.New()
+
+	adminPerms := []string{"user:manage", "achievement:read"}
+	states := &mockImpersonationStateRepo{states: map[string]*models.AuthState{
+		"admin": {UserID: "admin", IsActive: true, RoleID: "role-admin", RoleName: "Admin", Permissions: adminPerms, UserVersion: 1, RoleVersion: 1},
+	}}
+
+	store := repository.NewMemoryAPIKeyStore()
+	svc := NewServiceAccountService(store)
+
+	authRequired := middleware.AuthRequired(
+		repository.NewMemoryTokenRevocationStore(),
+		states,
+		repository.NewMemoryImpersonationStore(),
+		store,
+	)
+
+	admin := app.Group("/service-accounts", authRequired, middleware.PermissionRequired("user:manage"))
+	admin.Post("/", svc.CreateServiceAccount)
+	admin.Get("/:id/keys", svc.ListKeys)
+	admin.Post("/:id/keys", svc.IssueKey)
+	admin.Delete("/:id/keys/:keyId", svc.RevokeKey)
+
+	// sama dengan route.go: grup admin memakai NoAPIKey di depan permission
+	app.Put("/users/:id/password", authRequired, middleware.NoAPIKey(), middleware.PermissionRequired("user:manage"), func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"success": true})
+	})
+
+	app.Get("/achievements", authRequired, middleware.PermissionRequired("achievement:read"), func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"user_id": c.Locals("user_id"), "role_name": c.Locals("role_name")})
+	})
+
+	token, err := utils.GenerateToken(
+		models.User{ID: "admin", Username: "admin", RoleID: ptr("role-admin")},
+		"Admin", adminPerms, models.AuthVersion{User: 1, Role: 1}, "",
+	)
+	assert.NoError(t, err)
+
+	return app, store, token
+}
+
+func createServiceAccount(t *testing.T, app *fiber.App, adminToken string) string {
+	resp := doRequest(t, app, http.MethodPost, "/service-accounts", models.CreateServiceAccountRequest{Name: "siakad"}, bearer(adminToken))
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	var body struct {
+		Data models.ServiceAccount `json:"data"`
+	}
+	decodeBody(t, resp, &body)
+	return body.Data.ID
+}
+
+func issueAPIKey(t *testing.T, app *fiber.App, adminToken, accountID string, req models.CreateAPIKeyRequest) models.IssuedAPIKey {
+	resp := doRequest(t, app, http.MethodPost, "/service-accounts/"+accountID+"/keys", req, bearer(adminToken))
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	var body struct {
+		Data models.IssuedAPIKey `json:"data"`
+	}
+	decodeBody(t, resp, &body)
+	return body.Data
+}
+
+//
+// =======================================================
+// MENERBITKAN KEY
+// =======================================================
+//
+
+func TestServiceAccount_IssuedKeyIsHashedAndScoped(t *testing.T) {
+	app, store, adminToken := setupServiceAccounts(t)
+	id := createServiceAccount(t, app, adminToken)
+
+	issued := issueAPIKey(t, app, adminToken, id, models.CreateAPIKeyRequest{Name: "nightly", Permissions: []string{"achievement:read"}})
+	assert.True(t, utils.IsAPIKey(issued.Key))
+	assert.Contains(t, issued.Key, issued.Prefix)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, apiKeyDefaultDays), issued.ExpiresAt, time.Minute)
+
+	stored, err := store.GetKeyByPrefix(context.Background(), issued.Prefix)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.HashToken(issued.Key), stored.KeyHash)
+	assert.Nil(t, stored.LastUsedAt)
+
+	// key asli tidak ikut di daftar
+	resp := doRequest(t, app, http.MethodGet, "/service-accounts/"+id+"/keys", nil, bearer(adminToken))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var list map[string]interface{}
+	decodeBody(t, resp, &list)
+	keys := list["data"].([]interface{})
+	assert.Len(t, keys, 1)
+	assert.NotContains(t, keys[0], "key")
+	assert.NotContains(t, keys[0], "key_hash")
+}
+
+func TestServiceAccount_IssueValidation(t *testing.T) {
+	app, _, adminToken := setupServiceAccounts(t)
+	id := createServiceAccount(t, app, adminToken)
+	path := "/service-accounts/" + id + "/keys"
+
+	resp := doRequest(t, app, http.MethodPost, path, models.CreateAPIKeyRequest{}, bearer(adminToken))
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	// hanya permission integrasi, walau admin sendiri memilikinya
+	resp = doRequest(t, app, http.MethodPost, path, models.CreateAPIKeyRequest{Permissions: []string{"user:manage"}}, bearer(adminToken))
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	resp = doRequest(t, app, http.MethodPost, path, models.CreateAPIKeyRequest{Permissions: []string{"achievement:read", "achievement:verify"}}, bearer(adminToken))
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodPost, path, models.CreateAPIKeyRequest{Permissions: []string{"achievement:read"}, ExpiresInDays: apiKeyMaxDays + 1}, bearer(adminToken))
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodPost, "/service-accounts/not-a-uuid/keys", models.CreateAPIKeyRequest{Permissions: []string{"achievement:read"}}, bearer(adminToken))
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+//
+// =======================================================
+// AUTENTIKASI DENGAN KEY
+// =======================================================
+//
+
+func TestServiceAccount_KeyAuthenticates(t *testing.T) {
+	app, store, adminToken := setupServiceAccounts(t)
+	id := createServiceAccount(t, app, adminToken)
+	issued := issueAPIKey(t, app, adminToken, id, models.CreateAPIKeyRequest{Permissions: []string{"achievement:read"}})
+
+	resp := doRequest(t, app, http.MethodGet, "/achievements", nil, bearer(issued.Key))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var who map[string]interface{}
+	decodeBody(t, resp, &who)
+	assert.Equal(t, id, who["user_id"])
+	assert.Equal(t, models.ServiceAccountRole, who["role_name"])
+
+	stored, _ := store.GetKeyByPrefix(context.Background(), issued.Prefix)
+	assert.NotNil(t, stored.LastUsedAt)
+
+	// permission key membatasi akses
+	resp = doRequest(t, app, http.MethodGet, "/service-accounts/"+id+"/keys", nil, bearer(issued.Key))
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	// prefix benar tapi rahasia salah
+	resp = doRequest(t, app, http.MethodGet, "/achievements", nil, bearer(issued.Key[:len(issued.Key)-2]+"xx"))
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestServiceAccount_RevokedAndExpiredKeysRejected(t *testing.T) {
+	app, store, adminToken := setupServiceAccounts(t)
+	id := createServiceAccount(t, app, adminToken)
+	issued := issueAPIKey(t, app, adminToken, id, models.CreateAPIKeyRequest{Permissions: []string{"achievement:read"}})
+
+	resp := doRequest(t, app, http.MethodDelete, "/service-accounts/"+id+"/keys/"+issued.ID, nil, bearer(adminToken))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodGet, "/achievements", nil, bearer(issued.Key))
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodDelete, "/service-accounts/"+id+"/keys/"+issued.ID, nil, bearer(adminToken))
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	raw, prefix, _ := utils.GenerateAPIKey()
+	_ = store.CreateKey(context.Background(), &models.APIKey{
+		ServiceAccountID: id,
+		Prefix:           prefix,
+		KeyHash:          utils.HashToken(raw),
+		Permissions:      []string{"achievement:read"},
+		ExpiresAt:        time.Now().Add(-time.Minute),
+	})
+
+	resp = doRequest(t, app, http.MethodGet, "/achievements", nil, bearer(raw))
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestServiceAccount_KeyCannotResetUserPassword(t *testing.T) {
+	app, store, adminToken := setupServiceAccounts(t)
+	id := createServiceAccount(t, app, adminToken)
+
+	// key lama yang diterbitkan sebelum allowlist, dengan user:manage
+	raw, prefix, _ := utils.GenerateAPIKey()
+	_ = store.CreateKey(context.Background(), &models.APIKey{
+		ServiceAccountID: id,
+		Prefix:           prefix,
+		KeyHash:          utils.HashToken(raw),
+		Permissions:      []string{"achievement:read", "user:manage"},
+		ExpiresAt:        time.Now().Add(time.Hour),
+	})
+
+	resp := doRequest(t, app, http.MethodPut, "/users/u1/password", map[string]string{"password": "N3w-Secret"}, bearer(raw))
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	// permission di luar allowlist juga tidak berlaku di route lain
+	resp = doRequest(t, app, http.MethodGet, "/service-accounts/"+id+"/keys", nil, bearer(raw))
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodGet, "/achievements", nil, bearer(raw))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	// admin sendiri tetap bisa
+	resp = doRequest(t, app, http.MethodPut, "/users/u1/password", map[string]string{"password": "N3w-Secret"}, bearer(adminToken))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
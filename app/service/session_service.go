@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+	"achievement_backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// SessionService mencatat setiap login sebagai sesi dan mencabutnya per
+// perangkat. Mencabut sesi berarti: baris sesi ditandai, family refresh
+// token dicabut, dan sid dimasukkan ke revocation store agar access token
+// sesi itu langsung ditolak middleware.
+type SessionService struct {
+	sessions     repository.SessionStore
+	refreshStore repository.RefreshTokenStore
+	revocations  repository.TokenRevocationStore
+	userRepo     repository.UserRepository
+}
+
+func NewSessionService(
+	sessions repository.SessionStore,
+	refreshStore repository.RefreshTokenStore,
+	revocations repository.TokenRevocationStore,
+	userRepo repository.UserRepository,
+) *SessionService {
+	return &SessionService{
+		sessions:     sessions,
+		refreshStore: refreshStore,
+		revocations:  revocations,
+		userRepo:     userRepo,
+	}
+}
+
+// start mencatat sesi baru untuk login yang berhasil.
+func (s *SessionService) start(c *fiber.Ctx, userID, sessionID string) error {
+	// nilai dari fiber.Ctx dipakai ulang setelah request selesai, jadi disalin
+	return s.sessions.Create(c.UserContext(), &models.Session{
+		ID:        sessionID,
+		UserID:    userID,
+		UserAgent: strings.Clone(c.Get(fiber.HeaderUserAgent)),
+		IP:        strings.Clone(c.IP()),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	})
+}
+
+// touch dipanggil saat refresh token dirotasi. Mengembalikan false jika sesi
+// sudah dicabut. Refresh token lama (sebelum ada tabel sesi) tidak punya
+// baris sesi dan tetap dilayani.
+func (s *SessionService) touch(c *fiber.Ctx, sessionID string) (bool, error) {
+	sess, err := s.sessions.Get(c.UserContext(), sessionID)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if sess.RevokedAt != nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	return true, s.sessions.Touch(c.UserContext(), sessionID, strings.Clone(c.IP()), now, now.Add(refreshTokenTTL))
+}
+
+// revokeTokens mencabut refresh token dan access token milik satu sesi.
+func (s *SessionService) revokeTokens(ctx context.Context, sessionID string) error {
+	if err := s.refreshStore.RevokeFamily(ctx, sessionID); err != nil {
+		return err
+	}
+	return s.revocations.Revoke(ctx, sessionID, time.Now().Add(utils.AccessTokenTTL))
+}
+
+func (s *SessionService) revoke(ctx context.Context, sessionID string) error {
+	if err := s.sessions.Revoke(ctx, sessionID); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	return s.revokeTokens(ctx, sessionID)
+}
+
+// revokeAll mencabut semua sesi user kecuali exceptID. Tanpa pengecualian,
+// refresh token lama yang tidak punya baris sesi ikut dicabut.
+func (s *SessionService) revokeAll(ctx context.Context, userID, exceptID string) (int, error) {
+	ids, err := s.sessions.RevokeByUser(ctx, userID, exceptID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		if err := s.revokeTokens(ctx, id); err != nil {
+			return 0, err
+		}
+	}
+
+	if exceptID == "" {
+		if err := s.refreshStore.RevokeByUser(ctx, userID); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(ids), nil
+}
+
+func (s *SessionService) list(c *fiber.Ctx, userID string) error {
+	list, err := s.sessions.ListActive(c.UserContext(), userID, time.Now())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	current, _ := c.Locals("session_id").(string)
+	for i := range list {
+		list[i].Current = list[i].ID == current
+	}
+
+	return c.JSON(fiber.Map{"success": true, "data": list})
+}
+
+func (s *SessionService) revokeOne(c *fiber.Ctx, userID, sessionID string) error {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "session not found"})
+	}
+
+	sess, err := s.sessions.Get(c.UserContext(), sessionID)
+	if err != nil || sess.UserID != userID {
+		if err != nil && err != sql.ErrNoRows {
+			return c.Status(500).JSON(fiber.Map{"error": "database error"})
+		}
+		return c.Status(404).JSON(fiber.Map{"error": "session not found"})
+	}
+
+	if err := s.revoke(c.UserContext(), sessionID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to revoke session"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "session revoked"})
+}
+
+// ListMine godoc
+// @Summary Daftar sesi login saya
+// @Description Menampilkan sesi aktif (perangkat/user-agent, IP, waktu login dan pemakaian terakhir). Sesi yang sedang dipakai ditandai current.
+// @Tags Session
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Daftar sesi"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security Bearer
+// @Router /api/v1/auth/sessions [get]
+func (s *SessionService) ListMine(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	if uid == "" {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	return s.list(c, uid)
+}
+
+// RevokeMine godoc
+// @Summary Mencabut salah satu sesi saya
+// @Tags Session
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]interface{} "Sesi dicabut"
+// @Failure 404 {object} map[string]interface{} "Sesi tidak ditemukan"
+// @Security Bearer
+// @Router /api/v1/auth/sessions/{id} [delete]
+func (s *SessionService) RevokeMine(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	if uid == "" {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	return s.revokeOne(c, uid, c.Params("id"))
+}
+
+// RevokeOthers godoc
+// @Summary Mencabut semua sesi saya yang lain
+// @Description Semua sesi selain sesi yang sedang dipakai dicabut
+// @Tags Session
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Jumlah sesi yang dicabut"
+// @Security Bearer
+// @Router /api/v1/auth/sessions [delete]
+func (s *SessionService) RevokeOthers(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	current, _ := c.Locals("session_id").(string)
+	if uid == "" {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	if current == "" {
+		// token lama tanpa sid tidak bisa membedakan sesi sendiri
+		return c.Status(400).JSON(fiber.Map{"error": "current session unknown, please login again"})
+	}
+
+	n, err := s.revokeAll(c.UserContext(), uid, current)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to revoke sessions"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "data": fiber.Map{"revoked": n}})
+}
+
+// ListForUser godoc
+// @Summary Daftar sesi user (admin)
+// @Tags Session
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{} "Daftar sesi"
+// @Failure 404 {object} map[string]interface{} "User tidak ditemukan"
+// @Security Bearer
+// @Router /api/v1/users/{id}/sessions [get]
+func (s *SessionService) ListForUser(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if u, err := s.userRepo.GetByID(c.UserContext(), id); err != nil || u == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "user not found"})
+	}
+
+	return s.list(c, id)
+}
+
+// RevokeForUser godoc
+// @Summary Mencabut satu sesi user (admin)
+// @Tags Session
+// @Produce json
+// @Param id path string true "User ID"
+// @Param sessionId path string true "Session ID"
+// @Success 200 {object} map[string]interface{} "Sesi dicabut"
+// @Failure 404 {object} map[string]interface{} "Sesi tidak ditemukan"
+// @Security Bearer
+// @Router /api/v1/users/{id}/sessions/{sessionId} [delete]
+func (s *SessionService) RevokeForUser(c *fiber.Ctx) error {
+	return s.revokeOne(c, c.Params("id"), c.Params("sessionId"))
+}
+
+// RevokeAllForUser godoc
+// @Summary Mencabut semua sesi user (admin)
+// @Tags Session
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{} "Jumlah sesi yang dicabut"
+// @Failure 404 {object} map[string]interface{} "User tidak ditemukan"
+// @Security Bearer
+// @Router /api/v1/users/{id}/sessions [delete]
+func (s *SessionService) RevokeAllForUser(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if u, err := s.userRepo.GetByID(c.UserContext(), id); err != nil || u == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "user not found"})
+	}
+
+	n, err := s.revokeAll(c.UserContext(), id, "")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to revoke sessions"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "data": fiber.Map{"revoked": n}})
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+	"achievement_backend/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func setupSessions() *fiber.App {
+	setupTestKeys()
+	app := fiber.New()
+
+	users := newMockAuthUserRepo()
+	deps := &testAuthDeps{users: users}
+	auth := newTestAuthService(deps)
+	sessionService := deps.sessions
+
+	authRequired := middleware.AuthRequired(deps.revocations, &mockAuthStateRepo{}, repository.NewMemoryImpersonationStore(), repository.NewMemoryAPIKeyStore())
+
+	app.Post("/login", auth.Login)
+	app.Post("/refresh", auth.RefreshToken)
+	app.Post("/logout", authRequired, auth.Logout)
+	app.Get("/sessions", authRequired, sessionService.ListMine)
+	app.Delete("/sessions", authRequired, sessionService.RevokeOthers)
+	app.Delete("/sessions/:id", authRequired, sessionService.RevokeMine)
+	app.Delete("/users/:id/sessions", sessionService.RevokeAllForUser)
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	users.users["1"] = &models.User{
+		ID:           "1",
+		Username:     "john",
+		PasswordHash: string(hash),
+		IsActive:     true,
+		RoleID:       ptr("role-1"),
+	}
+
+	return app
+}
+
+func loginFrom(t *testing.T, app *fiber.App, userAgent string) models.LoginData {
+	resp := doRequest(t, app, http.MethodPost, "/login", models.LoginRequest{Username: "john", Password: "secret"},
+		map[string]string{"User-Agent": userAgent})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body models.LoginResponse
+	decodeBody(t, resp, &body)
+	return body.Data
+}
+
+func listSessions(t *testing.T, app *fiber.App, token string) []models.Session {
+	resp := doRequest(t, app, http.MethodGet, "/sessions", nil, bearer(token))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data []models.Session `json:"data"`
+	}
+	decodeBody(t, resp, &body)
+	return body.Data
+}
+
+//
+// =======================================================
+// DAFTAR SESI
+// =======================================================
+//
+
+func TestSessions_ListMarksCurrent(t *testing.T) {
+	app := setupSessions()
+
+	laptop := loginFrom(t, app, "Firefox/Laptop")
+	loginFrom(t, app, "Android/Phone")
+
+	list := listSessions(t, app, laptop.Token)
+	assert.Len(t, list, 2)
+
+	agents := map[string]bool{}
+	for _, s := range list {
+		agents[s.UserAgent] = s.Current
+	}
+	assert.Equal(t, map[string]bool{"Firefox/Laptop": true, "Android/Phone": false}, agents)
+}
+
+//
+// =======================================================
+// CABUT SESI
+// =======================================================
+//
+
+func TestSessions_RevokeOthersKeepsCurrent(t *testing.T) {
+	app := setupSessions()
+
+	laptop := loginFrom(t, app, "laptop")
+	phone := loginFrom(t, app, "phone")
+
+	resp := doRequest(t, app, http.MethodDelete, "/sessions", nil, bearer(laptop.Token))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	// access token dan refresh token perangkat lain langsung mati
+	resp = doRequest(t, app, http.MethodGet, "/sessions", nil, bearer(phone.Token))
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	resp = postJSON(t, app, "/refresh", fiber.Map{"refresh_token": phone.RefreshToken})
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	assert.Len(t, listSessions(t, app, laptop.Token), 1)
+}
+
+func TestSessions_RevokeOne(t *testing.T) {
+	app := setupSessions()
+
+	laptop := loginFrom(t, app, "laptop")
+	loginFrom(t, app, "phone")
+
+	var phoneID string
+	for _, s := range listSessions(t, app, laptop.Token) {
+		if !s.Current {
+			phoneID = s.ID
+		}
+	}
+
+	resp := doRequest(t, app, http.MethodDelete, "/sessions/"+phoneID, nil, bearer(laptop.Token))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodDelete, "/sessions/does-not-exist", nil, bearer(laptop.Token))
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	assert.Len(t, listSessions(t, app, laptop.Token), 1)
+}
+
+func TestSessions_LogoutEndsOnlyCurrentSession(t *testing.T) {
+	app := setupSessions()
+
+	laptop := loginFrom(t, app, "laptop")
+	phone := loginFrom(t, app, "phone")
+
+	resp := doRequest(t, app, http.MethodPost, "/logout", nil, bearer(laptop.Token))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp = postJSON(t, app, "/refresh", fiber.Map{"refresh_token": laptop.RefreshToken})
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	resp = postJSON(t, app, "/refresh", fiber.Map{"refresh_token": phone.RefreshToken})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestSessions_AdminRevokeAll(t *testing.T) {
+	app := setupSessions()
+
+	laptop := loginFrom(t, app, "laptop")
+	loginFrom(t, app, "phone")
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/users/1/sessions", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp = doRequest(t, app, http.MethodGet, "/sessions", nil, bearer(laptop.Token))
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+	"achievement_backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SSOConfig mengatur pemetaan identitas IdP ke user lokal.
+type SSOConfig struct {
+	// AutoProvision membuat user Mahasiswa beserta profil Student untuk
+	// identitas IdP yang belum punya akun.
+	AutoProvision bool
+	// ProvisionRole adalah nama role untuk user hasil auto-provision.
+	ProvisionRole string
+	// StudentIDClaim adalah klaim ID token yang berisi NIM.
+	StudentIDClaim string
+	// EmailLinkRoles adalah role user lokal yang boleh ditautkan otomatis
+	// lewat email terverifikasi. Role lain (misal Admin) harus ditautkan
+	// secara eksplisit agar akun IdP dengan email yang sama tidak bisa
+	// mengambil alih akun berhak tinggi.
+	EmailLinkRoles []string
+	// StateTTL adalah batas waktu dari authorize sampai callback.
+	StateTTL time.Duration
+}
+
+func DefaultSSOConfig() SSOConfig {
+	return SSOConfig{
+		ProvisionRole:  "Mahasiswa",
+		StudentIDClaim: "student_id",
+		EmailLinkRoles: []string{"Mahasiswa", "Dosen Wali"},
+		StateTTL:       10 * time.Minute,
+	}
+}
+
+var (
+	errSSONoAccount   = errors.New("no account linked to this identity")
+	errSSONoStudentID = errors.New("identity has no student id")
+	errSSOConflict    = errors.New("student id or username already registered")
+	errSSOLinkRefused = errors.New("account must be linked to this identity explicitly")
+)
+
+// SSOService menjalankan login OpenID Connect (authorization code + PKCE)
+// di samping login password. Setelah identitas IdP dipetakan ke user lokal,
+// login diteruskan ke AuthService sehingga MFA, sesi dan token sama persis
+// dengan login biasa.
+type SSOService struct {
+	provider    *utils.OIDCProvider
+	store       repository.OIDCStore
+	userRepo    repository.UserRepository
+	roleRepo    repository.RoleRepository
+	studentRepo repository.StudentRepository
+	auth        *AuthService
+	uow         repository.UnitOfWork
+	cfg         SSOConfig
+}
+
+// NewSSOService membuat service SSO. provider nil berarti SSO tidak
+// dikonfigurasi dan semua endpoint-nya menjawab 404.
+func NewSSOService(
+	provider *utils.OIDCProvider,
+	store repository.OIDCStore,
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	studentRepo repository.StudentRepository,
+	auth *AuthService,
+	uow repository.UnitOfWork,
+	cfg SSOConfig,
+) *SSOService {
+	return &SSOService{
+		provider:    provider,
+		store:       store,
+		userRepo:    userRepo,
+		roleRepo:    roleRepo,
+		studentRepo: studentRepo,
+		auth:        auth,
+		uow:         uow,
+		cfg:         cfg,
+	}
+}
+
+// resolve memetakan identitas IdP ke user lokal: lewat tautan issuer+sub,
+// lalu lewat email terverifikasi (hanya untuk role di EmailLinkRoles), lalu
+// auto-provision jika diizinkan.
+func (s *SSOService) resolve(ctx context.Context, id *utils.OIDCIdentity) (*models.User, error) {
+	userID, err := s.store.GetIdentity(ctx, id.Issuer, id.Subject)
+	if err == nil {
+		return s.userRepo.GetByID(ctx, userID)
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if id.Email != "" && id.EmailVerified {
+		user, err := s.userRepo.GetByEmail(ctx, id.Email)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if user != nil {
+			if !s.emailLinkAllowed(user) {
+				log.Printf("[SSO] refusing email link of %s to user %s (role %q)", id.Subject, user.ID, user.RoleName)
+				return nil, errSSOLinkRefused
+			}
+			if err := s.store.LinkIdentity(ctx, id.Issuer, id.Subject, user.ID); err != nil {
+				return nil, err
+			}
+			return user, nil
+		}
+	}
+
+	if !s.cfg.AutoProvision {
+		return nil, errSSONoAccount
+	}
+	return s.provision(ctx, id)
+}
+
+func (s *SSOService) emailLinkAllowed(user *models.User) bool {
+	for _, role := range s.cfg.EmailLinkRoles {
+		if user.RoleName == role {
+			return true
+		}
+	}
+	return false
+}
+
+// provision membuat user Mahasiswa dan profil Student dari klaim IdP.
+// Password diisi acak sehingga login lokal baru bisa lewat reset password.
+func (s *SSOService) provision(ctx context.Context, id *utils.OIDCIdentity) (*models.User, error) {
+	nim, _ := id.Claims[s.cfg.StudentIDClaim].(string)
+	nim = strings.TrimSpace(nim)
+	if nim == "" || id.Email == "" || !id.EmailVerified {
+		return nil, errSSONoStudentID
+	}
+
+	if st, err := s.studentRepo.GetByStudentID(ctx, nim); err != nil {
+		return nil, err
+	} else if st != nil {
+		return nil, errSSOConflict
+	}
+	if u, _ := s.userRepo.GetByUsername(ctx, nim); u != nil {
+		return nil, errSSOConflict
+	}
+
+	roles, err := s.roleRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var roleID string
+	for _, r := range roles {
+		if r.Name == s.cfg.ProvisionRole {
+			roleID = r.ID
+		}
+	}
+	if roleID == "" {
+		return nil, errors.New("provision role not found: " + s.cfg.ProvisionRole)
+	}
+
+	secret, err := utils.GenerateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	fullName := id.Name
+	if fullName == "" {
+		fullName = nim
+	}
+
+	programStudy, _ := id.Claims["program_study"].(string)
+	academicYear, _ := id.Claims["academic_year"].(string)
+
+	// user tanpa profil Student tidak bisa memakai fitur prestasi, jadi
+	// user, profil dan tautan identitas dibuat atomik
+	var user *models.User
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.userRepo.Create(ctx, models.CreateUserRequest{
+			Username:     nim,
+			Email:        id.Email,
+			PasswordHash: string(hash),
+			FullName:     fullName,
+		})
+		if err != nil {
+			return err
+		}
+
+		user.RoleID = &roleID
+		if user, err = s.userRepo.UpdatePartial(ctx, user); err != nil {
+			return err
+		}
+
+		_, err = s.studentRepo.Create(ctx, models.CreateStudentRequest{
+			UserID:       user.ID,
+			StudentID:    nim,
+			ProgramStudy: programStudy,
+			AcademicYear: academicYear,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.store.LinkIdentity(ctx, id.Issuer, id.Subject, user.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Authorize godoc
+// @Summary Memulai login SSO
+// @Description Membuat state, nonce dan PKCE verifier lalu mengembalikan URL login IdP. Frontend mengarahkan browser ke URL tersebut; IdP mengembalikan code dan state ke redirect URL frontend yang lalu memanggil /auth/sso/callback.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "URL login IdP"
+// @Failure 404 {object} map[string]interface{} "SSO tidak dikonfigurasi"
+// @Failure 502 {object} map[string]interface{} "IdP tidak bisa dihubungi"
+// @Router /api/v1/auth/sso/authorize [get]
+func (s *SSOService) Authorize(c *fiber.Ctx) error {
+	if s.provider == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "sso not configured"})
+	}
+
+	state, err := utils.GenerateSecureToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to start sso"})
+	}
+	nonce, err := utils.GenerateSecureToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to start sso"})
+	}
+	verifier, err := utils.GenerateSecureToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to start sso"})
+	}
+
+	authURL, err := s.provider.AuthCodeURL(c.UserContext(), state, nonce, utils.PKCEChallenge(verifier))
+	if err != nil {
+		log.Printf("[SSO] discovery error: %v", err)
+		return c.Status(502).JSON(fiber.Map{"error": "identity provider unavailable"})
+	}
+
+	exp := time.Now().Add(s.cfg.StateTTL)
+	if err := s.store.SaveState(c.UserContext(), &models.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    exp,
+	}); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to start sso"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": models.SSOAuthorizeData{
+			AuthorizationURL: authURL,
+			State:            state,
+			ExpiresAt:        exp,
+		},
+	})
+}
+
+// Callback godoc
+// @Summary Menyelesaikan login SSO
+// @Description Menukar authorization code dari IdP, memverifikasi ID token, lalu memetakan identitas ke user lokal (tautan akun, email terverifikasi, atau auto-provision Mahasiswa jika diaktifkan). Response sama dengan /auth/login, termasuk tantangan MFA bila diperlukan.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body models.SSOCallbackRequest true "Code dan state dari IdP"
+// @Success 200 {object} models.LoginResponse "Login berhasil"
+// @Failure 400 {object} map[string]interface{} "State tidak valid atau kedaluwarsa"
+// @Failure 401 {object} map[string]interface{} "Code atau ID token ditolak"
+// @Failure 403 {object} map[string]interface{} "Tidak ada akun untuk identitas ini atau user tidak aktif"
+// @Failure 404 {object} map[string]interface{} "SSO tidak dikonfigurasi"
+// @Router /api/v1/auth/sso/callback [post]
+func (s *SSOService) Callback(c *fiber.Ctx) error {
+	if s.provider == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "sso not configured"})
+	}
+
+	var req models.SSOCallbackRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" || req.State == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "code and state are required"})
+	}
+
+	state, err := s.store.ConsumeState(c.UserContext(), utils.HashToken(req.State), time.Now())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid or expired state"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	rawIDToken, err := s.provider.Exchange(c.UserContext(), req.Code, state.CodeVerifier)
+	if err != nil {
+		log.Printf("[SSO] code exchange error: %v", err)
+		return c.Status(401).JSON(fiber.Map{"error": "sso login failed"})
+	}
+
+	identity, err := s.provider.VerifyIDToken(c.UserContext(), rawIDToken, state.Nonce)
+	if err != nil {
+		log.Printf("[SSO] id token error: %v", err)
+		return c.Status(401).JSON(fiber.Map{"error": "sso login failed"})
+	}
+
+	user, err := s.resolve(c.UserContext(), identity)
+	if err != nil {
+		switch {
+		case errors.Is(err, errSSONoAccount), errors.Is(err, errSSONoStudentID), errors.Is(err, errSSOConflict),
+			errors.Is(err, errSSOLinkRefused):
+			return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Printf("[SSO] resolve user %s error: %v", identity.Subject, err)
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	if !user.IsActive {
+		return c.Status(403).JSON(fiber.Map{"error": "user inactive"})
+	}
+	if user.RoleID == nil {
+		return c.Status(403).JSON(fiber.Map{"error": "user has no role"})
+	}
+
+	return s.auth.authenticated(c, user)
+}
@@ -0,0 +1,405 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+	"achievement_backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// =======================================================
+// MOCK OIDC PROVIDER (IN-PROCESS)
+// =======================================================
+//
+
+type mockIdPGrant struct {
+	challenge   string
+	nonce       string
+	redirectURI string
+	claims      jwt.MapClaims
+}
+
+// mockOIDCProvider adalah IdP minimal: discovery, JWKS, dan token endpoint
+// yang memeriksa PKCE. Halaman login IdP diganti dengan approve().
+type mockOIDCProvider struct {
+	server *httptest.Server
+	key    *utils.SigningKey
+	keys   *utils.KeySet
+
+	mu     sync.Mutex
+	grants map[string]mockIdPGrant
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	idp := &mockOIDCProvider{
+		key:    &utils.SigningKey{KID: "idp-1", Method: jwt.SigningMethodRS256, Private: priv, Public: &priv.PublicKey},
+		grants: map[string]mockIdPGrant{},
+	}
+	idp.keys, err = utils.NewKeySet("idp-1", idp.key)
+	assert.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(idp.keys.JWKS())
+	})
+	mux.HandleFunc("/token", idp.token)
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// approve mensimulasikan user login di IdP: membaca URL authorize dan
+// mengembalikan code serta state seperti redirect IdP.
+func (idp *mockOIDCProvider) approve(t *testing.T, authURL string, claims jwt.MapClaims) (code, state string) {
+	u, err := url.Parse(authURL)
+	assert.NoError(t, err)
+	q := u.Query()
+
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	assert.Contains(t, q.Get("scope"), "openid")
+
+	code, err = utils.GenerateSecureToken()
+	assert.NoError(t, err)
+
+	idp.mu.Lock()
+	idp.grants[code] = mockIdPGrant{
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		redirectURI: q.Get("redirect_uri"),
+		claims:      claims,
+	}
+	idp.mu.Unlock()
+
+	return code, q.Get("state")
+}
+
+func (idp *mockOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	fail := func(e string) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": e})
+	}
+
+	if user, pass, ok := r.BasicAuth(); !ok || user != "backend" || pass != "s3cret" {
+		fail("invalid_client")
+		return
+	}
+	_ = r.ParseForm()
+
+	idp.mu.Lock()
+	grant, ok := idp.grants[r.PostForm.Get("code")]
+	delete(idp.grants, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("redirect_uri") != grant.redirectURI {
+		fail("invalid_grant")
+		return
+	}
+	if utils.PKCEChallenge(r.PostForm.Get("code_verifier")) != grant.challenge {
+		fail("invalid_grant")
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   idp.server.URL,
+		"aud":   "backend",
+		"nonce": grant.nonce,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+	}
+	for k, v := range grant.claims {
+		claims[k] = v
+	}
+
+	tok := jwt.NewWithClaims(idp.key.Method, claims)
+	tok.Header["kid"] = idp.key.KID
+	signed, _ := tok.SignedString(idp.key.Private)
+
+	_ = json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+}
+
+//
+// =======================================================
+// MOCK REPOSITORIES
+// =======================================================
+//
+
+type ssoUserRepo struct {
+	*mockAuthUserRepo
+}
+
+func (m *ssoUserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	for _, u := range m.users {
+		if strings.EqualFold(u.Email, email) {
+			return u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *ssoUserRepo) Create(ctx context.Context, req models.CreateUserRequest) (*models.User, error) {
+	u := &models.User{
+		ID:           "new-user",
+		Username:     req.Username,
+		Email:        req.Email,
+		PasswordHash: req.PasswordHash,
+		FullName:     req.FullName,
+		IsActive:     true,
+	}
+	m.users[u.ID] = u
+	return u, nil
+}
+
+type ssoRoleRepo struct {
+	mockAuthRoleRepo
+}
+
+func (m *ssoRoleRepo) GetAll(ctx context.Context) ([]models.Role, error) {
+	return []models.Role{{ID: "role-admin", Name: "Admin"}, {ID: "role-mhs", Name: "Mahasiswa"}}, nil
+}
+
+type ssoStudentRepo struct {
+	mockAuthStudentRepo
+	created []models.CreateStudentRequest
+}
+
+func (m *ssoStudentRepo) GetByStudentID(ctx context.Context, studentID string) (*models.Student, error) {
+	for _, st := range m.created {
+		if st.StudentID == studentID {
+			return &models.Student{UserID: st.UserID, StudentID: st.StudentID}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *ssoStudentRepo) Create(ctx context.Context, req models.CreateStudentRequest) (*models.Student, error) {
+	m.created = append(m.created, req)
+	return &models.Student{UserID: req.UserID, StudentID: req.StudentID}, nil
+}
+
+//
+// =======================================================
+// SETUP
+// =======================================================
+//
+
+func setupSSO(t *testing.T, autoProvision bool) (*fiber.App, *mockOIDCProvider, *ssoUserRepo, *ssoStudentRepo) {
+	setupTestKeys()
+	app := fiber.New()
+	idp := newMockOIDCProvider(t)
+
+	users := &ssoUserRepo{newMockAuthUserRepo()}
+	students := &ssoStudentRepo{}
+	auth := newTestAuthService(&testAuthDeps{users: users, students: students})
+
+	provider := utils.NewOIDCProvider(utils.OIDCConfig{
+		IssuerURL:    idp.server.URL,
+		ClientID:     "backend",
+		ClientSecret: "s3cret",
+		RedirectURL:  "http://frontend.test/sso/callback",
+	}, idp.server.Client())
+
+	cfg := DefaultSSOConfig()
+	cfg.AutoProvision = autoProvision
+	sso := NewSSOService(provider, repository.NewMemoryOIDCStore(), users, &ssoRoleRepo{}, students, auth, repository.NewMemoryUnitOfWork(), cfg)
+
+	app.Get("/sso/authorize", sso.Authorize)
+	app.Post("/sso/callback", sso.Callback)
+
+	users.users["1"] = &models.User{
+		ID:       "1",
+		Username: "dosen",
+		Email:    "dosen@kampus.ac.id",
+		IsActive: true,
+		RoleID:   ptr("role-dosen"),
+		RoleName: "Dosen Wali",
+	}
+	users.users["2"] = &models.User{
+		ID:       "2",
+		Username: "admin",
+		Email:    "admin@kampus.ac.id",
+		IsActive: true,
+		RoleID:   ptr("role-admin"),
+		RoleName: "Admin",
+	}
+
+	return app, idp, users, students
+}
+
+func ssoAuthorize(t *testing.T, app *fiber.App) models.SSOAuthorizeData {
+	resp := doRequest(t, app, http.MethodGet, "/sso/authorize", nil, nil)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data models.SSOAuthorizeData `json:"data"`
+	}
+	decodeBody(t, resp, &body)
+	return body.Data
+}
+
+// ssoLogin menjalankan alur lengkap authorize → IdP → callback.
+func ssoLogin(t *testing.T, app *fiber.App, idp *mockOIDCProvider, claims jwt.MapClaims) *http.Response {
+	start := ssoAuthorize(t, app)
+	code, state := idp.approve(t, start.AuthorizationURL, claims)
+	assert.Equal(t, start.State, state)
+
+	return postJSON(t, app, "/sso/callback", models.SSOCallbackRequest{Code: code, State: state})
+}
+
+//
+// =======================================================
+// PEMETAAN IDENTITAS
+// =======================================================
+//
+
+func TestSSO_VerifiedEmailMapsToExistingUser(t *testing.T) {
+	app, idp, _, _ := setupSSO(t, false)
+
+	resp := ssoLogin(t, app, idp, jwt.MapClaims{"sub": "idp-42", "email": "DOSEN@kampus.ac.id", "email_verified": true})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var login models.LoginResponse
+	decodeBody(t, resp, &login)
+	assert.Equal(t, "success", login.Status)
+	assert.NotEmpty(t, login.Data.Token)
+	assert.NotEmpty(t, login.Data.RefreshToken)
+	assert.Equal(t, "1", login.Data.User.ID)
+
+	claims, err := utils.ValidateToken(login.Data.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", claims.UserID)
+
+	// setelah tertaut, subject yang sama tetap dikenali meski email berubah di IdP
+	resp = ssoLogin(t, app, idp, jwt.MapClaims{"sub": "idp-42", "email": "lain@kampus.ac.id", "email_verified": true})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestSSO_UnverifiedEmailOrUnknownIdentityRejected(t *testing.T) {
+	app, idp, _, students := setupSSO(t, false)
+
+	resp := ssoLogin(t, app, idp, jwt.MapClaims{"sub": "idp-1", "email": "dosen@kampus.ac.id", "email_verified": false})
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	resp = ssoLogin(t, app, idp, jwt.MapClaims{"sub": "idp-2", "email": "baru@kampus.ac.id", "email_verified": true, "student_id": "2101"})
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	assert.Empty(t, students.created)
+}
+
+// Akun Admin tidak boleh diambil alih hanya karena IdP menyatakan email
+// yang sama terverifikasi.
+func TestSSO_PrivilegedRoleNotLinkedByEmail(t *testing.T) {
+	app, idp, _, students := setupSSO(t, true)
+
+	resp := ssoLogin(t, app, idp, jwt.MapClaims{"sub": "idp-7", "email": "admin@kampus.ac.id", "email_verified": true, "student_id": "2107"})
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	assert.Empty(t, students.created)
+
+	var body map[string]string
+	decodeBody(t, resp, &body)
+	assert.Equal(t, errSSOLinkRefused.Error(), body["error"])
+}
+
+func TestSSO_AutoProvisionStudent(t *testing.T) {
+	app, idp, users, students := setupSSO(t, true)
+
+	resp := ssoLogin(t, app, idp, jwt.MapClaims{
+		"sub":            "idp-mhs",
+		"email":          "mhs@student.kampus.ac.id",
+		"email_verified": true,
+		"name":           "Mahasiswa Baru",
+		"student_id":     "2101001",
+		"program_study":  "Informatika",
+	})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	user := users.users["new-user"]
+	if assert.NotNil(t, user) {
+		assert.Equal(t, "2101001", user.Username)
+		assert.Equal(t, "role-mhs", *user.RoleID)
+		assert.NotEmpty(t, user.PasswordHash)
+	}
+	assert.Equal(t, []models.CreateStudentRequest{{
+		UserID:       "new-user",
+		StudentID:    "2101001",
+		ProgramStudy: "Informatika",
+	}}, students.created)
+
+	// tanpa klaim NIM tidak ada yang dibuat
+	resp = ssoLogin(t, app, idp, jwt.MapClaims{"sub": "idp-x", "email": "x@kampus.ac.id", "email_verified": true})
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	assert.Len(t, students.created, 1)
+}
+
+//
+// =======================================================
+// STATE, NONCE & PKCE
+// =======================================================
+//
+
+func TestSSO_StateIsSingleUse(t *testing.T) {
+	app, idp, _, _ := setupSSO(t, false)
+
+	start := ssoAuthorize(t, app)
+	code, state := idp.approve(t, start.AuthorizationURL, jwt.MapClaims{"sub": "idp-42", "email": "dosen@kampus.ac.id", "email_verified": true})
+
+	resp := postJSON(t, app, "/sso/callback", models.SSOCallbackRequest{Code: code, State: "forged"})
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp = postJSON(t, app, "/sso/callback", models.SSOCallbackRequest{Code: code, State: state})
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp = postJSON(t, app, "/sso/callback", models.SSOCallbackRequest{Code: code, State: state})
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestSSO_CodeFromAnotherLoginRejected(t *testing.T) {
+	app, idp, _, _ := setupSSO(t, false)
+	claims := jwt.MapClaims{"sub": "idp-42", "email": "dosen@kampus.ac.id", "email_verified": true}
+
+	// code dicuri dari percobaan login lain: PKCE verifier tidak cocok
+	victim := ssoAuthorize(t, app)
+	stolenCode, _ := idp.approve(t, victim.AuthorizationURL, claims)
+
+	attacker := ssoAuthorize(t, app)
+	resp := postJSON(t, app, "/sso/callback", models.SSOCallbackRequest{Code: stolenCode, State: attacker.State})
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestSSO_NotConfigured(t *testing.T) {
+	app := fiber.New()
+	sso := NewSSOService(nil, repository.NewMemoryOIDCStore(), nil, nil, nil, nil, repository.NewMemoryUnitOfWork(), DefaultSSOConfig())
+	app.Get("/sso/authorize", sso.Authorize)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/sso/authorize", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
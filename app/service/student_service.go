@@ -54,7 +54,7 @@ func (s *StudentService) GetAll(c *fiber.Ctx) error {
 		})
 	}
 
-	students, err := s.repo.GetAll()
+	students, err := s.repo.GetAll(c.UserContext())
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed to get students",
@@ -99,7 +99,7 @@ func (s *StudentService) GetByID(c *fiber.Ctx) error {
 
 	id := c.Params("id")
 
-	student, err := s.repo.GetByID(id)
+	student, err := s.repo.GetByID(c.UserContext(), id)
 	if err != nil || student == nil {
 		return c.Status(404).JSON(fiber.Map{
 			"error": "student not found",
@@ -158,7 +158,7 @@ func (s *StudentService) UpdateAdvisor(c *fiber.Ctx) error {
 		})
 	}
 
-	student, err := s.repo.GetByID(id)
+	student, err := s.repo.GetByID(c.UserContext(), id)
 	if err != nil || student == nil {
 		return c.Status(404).JSON(fiber.Map{
 			"error": "student not found",
@@ -166,13 +166,13 @@ func (s *StudentService) UpdateAdvisor(c *fiber.Ctx) error {
 	}
 
 	// Update only advisor_id
-	if err := s.repo.UpdateAdvisor(id, req.AdvisorID); err != nil {
+	if err := s.repo.UpdateAdvisor(c.UserContext(), id, req.AdvisorID); err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed to update advisor",
 		})
 	}
 
-	updated, _ := s.repo.GetByID(id)
+	updated, _ := s.repo.GetByID(c.UserContext(), id)
 
 	return c.JSON(fiber.Map{
 		"success": true,
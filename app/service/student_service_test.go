@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"bytes"
 	// "encoding/json"
 	"net/http"
@@ -21,26 +22,26 @@ type MockStudentRepo struct {
 	UpdateAdvisorFn func(string, string) error
 }
 
-func (m *MockStudentRepo) GetAll() ([]models.Student, error) {
+func (m *MockStudentRepo) GetAll(ctx context.Context) ([]models.Student, error) {
 	return m.GetAllFn()
 }
-func (m *MockStudentRepo) GetByID(id string) (*models.Student, error) {
+func (m *MockStudentRepo) GetByID(ctx context.Context, id string) (*models.Student, error) {
 	return m.GetByIDFn(id)
 }
-func (m *MockStudentRepo) Create(req models.CreateStudentRequest) (*models.Student, error) {
+func (m *MockStudentRepo) Create(ctx context.Context, req models.CreateStudentRequest) (*models.Student, error) {
 	return m.CreateFn(req)
 }
-func (m *MockStudentRepo) Update(id string, req models.UpdateStudentRequest) (*models.Student, error) {
+func (m *MockStudentRepo) Update(ctx context.Context, id string, req models.UpdateStudentRequest) (*models.Student, error) {
 	return m.UpdateFn(id, req)
 }
-func (m *MockStudentRepo) UpdateAdvisor(id string, advisorID string) error {
+func (m *MockStudentRepo) UpdateAdvisor(ctx context.Context, id string, advisorID string) error {
 	return m.UpdateAdvisorFn(id, advisorID)
 }
 
 /* unused methods (biar satisfy interface) */
-func (m *MockStudentRepo) GetByStudentID(string) (*models.Student, error) { return nil, nil }
-func (m *MockStudentRepo) GetByUserID(string) (*models.Student, error)    { return nil, nil }
-func (m *MockStudentRepo) GetByAdvisorID(string) ([]models.Student, error) {
+func (m *MockStudentRepo) GetByStudentID(context.Context, string) (*models.Student, error) { return nil, nil }
+func (m *MockStudentRepo) GetByUserID(context.Context, string) (*models.Student, error)    { return nil, nil }
+func (m *MockStudentRepo) GetByAdvisorID(context.Context, string) ([]models.Student, error) {
 	return nil, nil
 }
 
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"achievement_backend/app/repository"
+)
+
+// StartTokenRevocationSweeper menjalankan goroutine yang secara berkala
+// menghapus entri revocation yang token-nya sudah kadaluarsa.
+// Panggil fungsi yang dikembalikan untuk menghentikan sweeper.
+func StartTokenRevocationSweeper(store repository.TokenRevocationStore, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				n, err := store.DeleteExpired(context.Background(), time.Now())
+				if err != nil {
+					log.Printf("[TokenSweeper] delete expired error: %v", err)
+					continue
+				}
+				if n > 0 {
+					log.Printf("[TokenSweeper] removed %d expired revocations", n)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+
 	"golang.org/x/crypto/bcrypt"
 
 	models "achievement_backend/app/model"
@@ -14,6 +16,8 @@ type UserService struct {
 	roleRepo     repository.RoleRepository
 	studentRepo  repository.StudentRepository
 	lecturerRepo repository.LecturerRepository
+	passwords    *PasswordManager
+	uow          repository.UnitOfWork
 }
 
 func NewUserService(
@@ -21,12 +25,16 @@ func NewUserService(
 	roleRepo repository.RoleRepository,
 	studentRepo repository.StudentRepository,
 	lecturerRepo repository.LecturerRepository,
+	passwords *PasswordManager,
+	uow repository.UnitOfWork,
 ) *UserService {
 	return &UserService{
 		userRepo:     userRepo,
 		roleRepo:     roleRepo,
 		studentRepo:  studentRepo,
 		lecturerRepo: lecturerRepo,
+		passwords:    passwords,
+		uow:          uow,
 	}
 }
 
@@ -41,7 +49,7 @@ func NewUserService(
 // @Security Bearer
 // @Router /api/v1/users [get]
 func (s *UserService) GetAll(c *fiber.Ctx) error {
-	users, err := s.userRepo.GetAll()
+	users, err := s.userRepo.GetAll(c.UserContext())
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch users")
 	}
@@ -66,7 +74,7 @@ func (s *UserService) GetAll(c *fiber.Ctx) error {
 func (s *UserService) GetByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	user, err := s.userRepo.GetByID(id)
+	user, err := s.userRepo.GetByID(c.UserContext(), id)
 	if err != nil {
 		return fiber.NewError(fiber.StatusNotFound, "User not found")
 	}
@@ -85,7 +93,7 @@ func (s *UserService) GetByID(c *fiber.Ctx) error {
 // @Produce json
 // @Param user body models.CreateUserRequest true "Data pengguna baru"
 // @Success 201 {object} map[string]interface{} "Pengguna berhasil dibuat"
-// @Failure 400 {object} map[string]interface{} "Invalid request body atau data sudah ada"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, data sudah ada, atau password tidak memenuhi policy"
 // @Failure 500 {object} map[string]interface{} "Gagal membuat pengguna"
 // @Security Bearer
 // @Router /api/v1/users [post]
@@ -96,15 +104,20 @@ func (s *UserService) Create(c *fiber.Ctx) error {
 	}
 
 	// validasi email
-	if u, _ := s.userRepo.GetByEmail(req.Email); u != nil {
+	if u, _ := s.userRepo.GetByEmail(c.UserContext(), req.Email); u != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "Email already registered")
 	}
 
 	// validasi username
-	if u, _ := s.userRepo.GetByUsername(req.Username); u != nil {
+	if u, _ := s.userRepo.GetByUsername(c.UserContext(), req.Username); u != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "Username already taken")
 	}
 
+	// validasi password
+	if err := s.passwords.Validate(c.UserContext(), &models.User{Username: req.Username, Email: req.Email}, req.PasswordHash); err != nil {
+		return passwordError(c, err)
+	}
+
 	// hash password
 	hashed, err := bcrypt.GenerateFromPassword([]byte(req.PasswordHash), bcrypt.DefaultCost)
 	if err != nil {
@@ -118,10 +131,11 @@ func (s *UserService) Create(c *fiber.Ctx) error {
 		})
 	}
 
-	user, err := s.userRepo.Create(req)
+	user, err := s.userRepo.Create(c.UserContext(), req)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create user")
 	}
+	s.passwords.Remember(c.UserContext(), user.ID, req.PasswordHash)
 
 	return c.Status(201).JSON(fiber.Map{
 		"success": true,
@@ -139,7 +153,7 @@ func (s *UserService) Create(c *fiber.Ctx) error {
 // @Param id path string true "User ID"
 // @Param user body models.UpdateUserRequest true "Data pengguna yang diperbarui"
 // @Success 200 {object} map[string]interface{} "Pengguna berhasil diperbarui"
-// @Failure 400 {object} map[string]interface{} "Invalid request body atau data sudah ada"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, data sudah ada, atau password tidak memenuhi policy"
 // @Failure 404 {object} map[string]interface{} "User not found"
 // @Failure 500 {object} map[string]interface{} "Gagal memperbarui pengguna"
 // @Security Bearer
@@ -148,7 +162,7 @@ func (s *UserService) Update(c *fiber.Ctx) error {
 	id := c.Params("id")
 
 	// cek user lama
-	existing, err := s.userRepo.GetByID(id)
+	existing, err := s.userRepo.GetByID(c.UserContext(), id)
 	if err != nil {
 		return fiber.NewError(fiber.StatusNotFound, "User not found")
 	}
@@ -159,14 +173,14 @@ func (s *UserService) Update(c *fiber.Ctx) error {
 	}
 
 	if req.Username != nil {
-		if u, _ := s.userRepo.GetByUsername(*req.Username); u != nil && u.ID != existing.ID {
+		if u, _ := s.userRepo.GetByUsername(c.UserContext(), *req.Username); u != nil && u.ID != existing.ID {
 			return fiber.NewError(fiber.StatusBadRequest, "Username already taken")
 		}
 		existing.Username = *req.Username
 	}
 
 	if req.Email != nil {
-		if u, _ := s.userRepo.GetByEmail(*req.Email); u != nil && u.ID != existing.ID {
+		if u, _ := s.userRepo.GetByEmail(c.UserContext(), *req.Email); u != nil && u.ID != existing.ID {
 			return fiber.NewError(fiber.StatusBadRequest, "Email already registered")
 		}
 		existing.Email = *req.Email
@@ -177,7 +191,7 @@ func (s *UserService) Update(c *fiber.Ctx) error {
 	}
 
 	if req.RoleID != nil {
-		if _, err := s.roleRepo.GetByID(*req.RoleID); err != nil {
+		if _, err := s.roleRepo.GetByID(c.UserContext(), *req.RoleID); err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, "Invalid role_id")
 		}
 		existing.RoleID = req.RoleID
@@ -188,24 +202,38 @@ func (s *UserService) Update(c *fiber.Ctx) error {
 	}
 
 	// ======================================
-	// UPDATE USER DATA
+	// UPDATE USER DATA + PROFIL (SATU TRANSAKSI)
 	// ======================================
-	updated, err := s.userRepo.UpdatePartial(existing)
+	// profil yang gagal disimpan ikut membatalkan perubahan user
+	var updated *models.User
+	var profileErr error
+	err = s.uow.Do(c.UserContext(), func(ctx context.Context) error {
+		var err error
+		if updated, err = s.userRepo.UpdatePartial(ctx, existing); err != nil {
+			return err
+		}
+
+		switch {
+		case req.Student != nil:
+			profileErr = s.setStudentProfileFromUserUpdate(ctx, id, req.Student)
+		case req.Lecturer != nil:
+			profileErr = s.setLecturerProfileFromUserUpdate(ctx, id, req.Lecturer)
+		}
+		return profileErr
+	})
+	if profileErr != nil {
+		return fiber.NewError(fiber.StatusBadRequest, profileErr.Error())
+	}
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to update user")
 	}
 
 	// ======================================
-	// UPDATE STUDENT PROFILE
+	// RESPONSE STUDENT PROFILE
 	// ======================================
 	if req.Student != nil {
-		err := s.setStudentProfileFromUserUpdate(id, req.Student)
-		if err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
-		}
-
 		// AMBIL DATA STUDENT TERBARU UNTUK RESPONSE
-		student, _ := s.studentRepo.GetByUserID(id)
+		student, _ := s.studentRepo.GetByUserID(c.UserContext(), id)
 
 		return c.JSON(fiber.Map{
 			"success": true,
@@ -215,15 +243,10 @@ func (s *UserService) Update(c *fiber.Ctx) error {
 	}
 
 	// ======================================
-	// UPDATE LECTURER PROFILE
+	// RESPONSE LECTURER PROFILE
 	// ======================================
 	if req.Lecturer != nil {
-		err := s.setLecturerProfileFromUserUpdate(id, req.Lecturer)
-		if err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
-		}
-
-		lecturer, _ := s.lecturerRepo.GetByUserID(id)
+		lecturer, _ := s.lecturerRepo.GetByUserID(c.UserContext(), id)
 
 		return c.JSON(fiber.Map{
 			"success": true,
@@ -251,7 +274,7 @@ func (s *UserService) Update(c *fiber.Ctx) error {
 // @Param id path string true "User ID"
 // @Param password body map[string]string true "Password baru"
 // @Success 200 {object} map[string]interface{} "Password berhasil diperbarui"
-// @Failure 400 {object} map[string]interface{} "Invalid request body"
+// @Failure 400 {object} map[string]interface{} "Invalid request body atau password tidak memenuhi policy"
 // @Failure 404 {object} map[string]interface{} "User not found"
 // @Failure 500 {object} map[string]interface{} "Gagal memperbarui password"
 // @Security Bearer
@@ -271,16 +294,16 @@ func (s *UserService) UpdatePassword(c *fiber.Ctx) error {
 	}
 
 	// check user exists
-	if _, err := s.userRepo.GetByID(id); err != nil {
+	user, err := s.userRepo.GetByID(c.UserContext(), id)
+	if err != nil || user == nil {
 		return fiber.NewError(fiber.StatusNotFound, "User not found")
 	}
 
-	hashed, err := bcrypt.GenerateFromPassword([]byte(body.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Failed to hash password")
+	if err := s.passwords.Validate(c.UserContext(), user, body.Password); err != nil {
+		return passwordError(c, err)
 	}
 
-	if err := s.userRepo.UpdatePassword(id, string(hashed)); err != nil {
+	if err := s.passwords.Set(c.UserContext(), id, body.Password); err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to update password")
 	}
 
@@ -305,11 +328,11 @@ func (s *UserService) UpdatePassword(c *fiber.Ctx) error {
 func (s *UserService) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	if _, err := s.userRepo.GetByID(id); err != nil {
+	if _, err := s.userRepo.GetByID(c.UserContext(), id); err != nil {
 		return fiber.NewError(fiber.StatusNotFound, "User not found")
 	}
 
-	if err := s.userRepo.Delete(id); err != nil {
+	if err := s.userRepo.Delete(c.UserContext(), id); err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete user")
 	}
 
@@ -333,12 +356,12 @@ func (s *UserService) Delete(c *fiber.Ctx) error {
 // @Failure 500 {object} map[string]interface{} "Gagal mengatur profil mahasiswa"
 // @Security Bearer
 // @Router /api/v1/users/{id} [put]
-func (s *UserService) setStudentProfileFromUserUpdate(userId string, data *models.SetStudentProfileRequest) error {
+func (s *UserService) setStudentProfileFromUserUpdate(ctx context.Context, userId string, data *models.SetStudentProfileRequest) error {
 	if data == nil {
 		return nil
 	}
 
-	user, err := s.userRepo.GetByID(userId)
+	user, err := s.userRepo.GetByID(ctx, userId)
 	if err != nil {
 		return err
 	}
@@ -348,13 +371,13 @@ func (s *UserService) setStudentProfileFromUserUpdate(userId string, data *model
 	}
 
 	// check existing
-	existing, err := s.studentRepo.GetByUserID(userId)
+	existing, err := s.studentRepo.GetByUserID(ctx, userId)
 	if err != nil {
 		return err
 	}
 
 	if existing != nil {
-		_, err := s.studentRepo.Update(existing.ID, models.UpdateStudentRequest{
+		_, err := s.studentRepo.Update(ctx, existing.ID, models.UpdateStudentRequest{
 			UserID:       userId,
 			StudentID:    data.StudentID,
 			ProgramStudy: data.ProgramStudy,
@@ -364,7 +387,7 @@ func (s *UserService) setStudentProfileFromUserUpdate(userId string, data *model
 		return err
 	}
 
-	_, err = s.studentRepo.Create(models.CreateStudentRequest{
+	_, err = s.studentRepo.Create(ctx, models.CreateStudentRequest{
 		UserID:       userId,
 		StudentID:    data.StudentID,
 		ProgramStudy: data.ProgramStudy,
@@ -389,12 +412,12 @@ func (s *UserService) setStudentProfileFromUserUpdate(userId string, data *model
 // @Failure 500 {object} map[string]interface{} "Gagal mengatur profil dosen"
 // @Security Bearer
 // @Router /api/v1/users/{id} [put]
-func (s *UserService) setLecturerProfileFromUserUpdate(userId string, data *models.SetLecturerProfileRequest) error {
+func (s *UserService) setLecturerProfileFromUserUpdate(ctx context.Context, userId string, data *models.SetLecturerProfileRequest) error {
 	if data == nil {
 		return nil
 	}
 
-	user, err := s.userRepo.GetByID(userId)
+	user, err := s.userRepo.GetByID(ctx, userId)
 	if err != nil {
 		return err
 	}
@@ -403,20 +426,20 @@ func (s *UserService) setLecturerProfileFromUserUpdate(userId string, data *mode
 		return fiber.NewError(fiber.StatusBadRequest, "User is not Dosen Wali")
 	}
 
-	existing, err := s.lecturerRepo.GetByUserID(userId)
+	existing, err := s.lecturerRepo.GetByUserID(ctx, userId)
 	if err != nil {
 		return err
 	}
 
 	if existing != nil {
-		_, err := s.lecturerRepo.Update(existing.ID, models.UpdateLecturerRequest{
+		_, err := s.lecturerRepo.Update(ctx, existing.ID, models.UpdateLecturerRequest{
 			LecturerID: data.LecturerID,
 			Department: data.Department,
 		})
 		return err
 	}
 
-	_, err = s.lecturerRepo.Create(models.CreateLecturerRequest{
+	_, err = s.lecturerRepo.Create(ctx, models.CreateLecturerRequest{
 		UserID:     userId,
 		LecturerID: data.LecturerID,
 		Department: data.Department,
@@ -2,13 +2,15 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	models "achievement_backend/app/model"
-	// "achievement_backend/app/repository"
+	"achievement_backend/app/repository"
+	"achievement_backend/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
@@ -28,7 +30,7 @@ func newMockUserRepo() *mockUserRepo {
 	return &mockUserRepo{data: map[string]*models.User{}}
 }
 
-func (m *mockUserRepo) GetAll() ([]models.User, error) {
+func (m *mockUserRepo) GetAll(ctx context.Context) ([]models.User, error) {
 	var res []models.User
 	for _, u := range m.data {
 		res = append(res, *u)
@@ -36,7 +38,7 @@ func (m *mockUserRepo) GetAll() ([]models.User, error) {
 	return res, nil
 }
 
-func (m *mockUserRepo) GetByID(id string) (*models.User, error) {
+func (m *mockUserRepo) GetByID(ctx context.Context, id string) (*models.User, error) {
 	u, ok := m.data[id]
 	if !ok {
 		return nil, fiber.ErrNotFound
@@ -44,7 +46,7 @@ func (m *mockUserRepo) GetByID(id string) (*models.User, error) {
 	return u, nil
 }
 
-func (m *mockUserRepo) GetByEmail(email string) (*models.User, error) {
+func (m *mockUserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	for _, u := range m.data {
 		if u.Email == email {
 			return u, nil
@@ -53,7 +55,7 @@ func (m *mockUserRepo) GetByEmail(email string) (*models.User, error) {
 	return nil, nil
 }
 
-func (m *mockUserRepo) GetByUsername(username string) (*models.User, error) {
+func (m *mockUserRepo) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	for _, u := range m.data {
 		if u.Username == username {
 			return u, nil
@@ -62,7 +64,7 @@ func (m *mockUserRepo) GetByUsername(username string) (*models.User, error) {
 	return nil, nil
 }
 
-func (m *mockUserRepo) Create(req models.CreateUserRequest) (*models.User, error) {
+func (m *mockUserRepo) Create(ctx context.Context, req models.CreateUserRequest) (*models.User, error) {
 	u := &models.User{
 		ID:       "1",
 		Username: req.Username,
@@ -74,12 +76,12 @@ func (m *mockUserRepo) Create(req models.CreateUserRequest) (*models.User, error
 	return u, nil
 }
 
-func (m *mockUserRepo) UpdatePartial(u *models.User) (*models.User, error) {
+func (m *mockUserRepo) UpdatePartial(ctx context.Context, u *models.User) (*models.User, error) {
 	m.data[u.ID] = u
 	return u, nil
 }
 
-func (m *mockUserRepo) UpdatePassword(id string, passwordHash string) error {
+func (m *mockUserRepo) UpdatePassword(ctx context.Context, id string, passwordHash string) error {
 	u, ok := m.data[id]
 	if !ok {
 		return fiber.ErrNotFound
@@ -88,7 +90,9 @@ func (m *mockUserRepo) UpdatePassword(id string, passwordHash string) error {
 	return nil
 }
 
-func (m *mockUserRepo) Delete(id string) error {
+func (m *mockUserRepo) RevokeSessions(ctx context.Context, id string) error { return nil }
+
+func (m *mockUserRepo) Delete(ctx context.Context, id string) error {
 	if _, ok := m.data[id]; !ok {
 		return fiber.ErrNotFound
 	}
@@ -104,26 +108,30 @@ func (m *mockUserRepo) Delete(id string) error {
 
 type mockRoleRepo struct{}
 
-func (m *mockRoleRepo) GetAll() ([]models.Role, error) {
+func (m *mockRoleRepo) GetAll(ctx context.Context) ([]models.Role, error) {
 	return []models.Role{}, nil
 }
 
-func (m *mockRoleRepo) GetByID(id string) (*models.Role, error) {
+func (m *mockRoleRepo) GetByID(ctx context.Context, id string) (*models.Role, error) {
 	return &models.Role{ID: id, Name: "Mahasiswa"}, nil
 }
 
-func (m *mockRoleRepo) Create(req models.CreateRoleRequest) (*models.Role, error) {
+func (m *mockRoleRepo) Create(ctx context.Context, req models.CreateRoleRequest) (*models.Role, error) {
 	return &models.Role{ID: "1", Name: req.Name}, nil
 }
 
-func (m *mockRoleRepo) Update(id string, req models.UpdateRoleRequest) (*models.Role, error) {
+func (m *mockRoleRepo) Update(ctx context.Context, id string, req models.UpdateRoleRequest) (*models.Role, error) {
 	return &models.Role{ID: id, Name: req.Name}, nil
 }
 
-func (m *mockRoleRepo) Delete(id string) error {
+func (m *mockRoleRepo) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+func (m *mockRoleRepo) CountUsers(ctx context.Context, id string) (int, error) {
+	return 0, nil
+}
+
 //
 // =======================================================
 // MOCK STUDENT REPOSITORY (FULL INTERFACE)
@@ -132,35 +140,35 @@ func (m *mockRoleRepo) Delete(id string) error {
 
 type mockStudentRepo struct{}
 
-func (m *mockStudentRepo) GetAll() ([]models.Student, error) {
+func (m *mockStudentRepo) GetAll(ctx context.Context) ([]models.Student, error) {
 	return []models.Student{}, nil
 }
 
-func (m *mockStudentRepo) GetByID(id string) (*models.Student, error) {
+func (m *mockStudentRepo) GetByID(ctx context.Context, id string) (*models.Student, error) {
 	return &models.Student{ID: id}, nil
 }
 
-func (m *mockStudentRepo) GetByStudentID(studentID string) (*models.Student, error) {
+func (m *mockStudentRepo) GetByStudentID(ctx context.Context, studentID string) (*models.Student, error) {
 	return nil, nil
 }
 
-func (m *mockStudentRepo) GetByUserID(userID string) (*models.Student, error) {
+func (m *mockStudentRepo) GetByUserID(ctx context.Context, userID string) (*models.Student, error) {
 	return nil, nil
 }
 
-func (m *mockStudentRepo) GetByAdvisorID(advisorID string) ([]models.Student, error) {
+func (m *mockStudentRepo) GetByAdvisorID(ctx context.Context, advisorID string) ([]models.Student, error) {
 	return []models.Student{}, nil
 }
 
-func (m *mockStudentRepo) Create(req models.CreateStudentRequest) (*models.Student, error) {
+func (m *mockStudentRepo) Create(ctx context.Context, req models.CreateStudentRequest) (*models.Student, error) {
 	return &models.Student{ID: "1", UserID: req.UserID}, nil
 }
 
-func (m *mockStudentRepo) Update(id string, req models.UpdateStudentRequest) (*models.Student, error) {
+func (m *mockStudentRepo) Update(ctx context.Context, id string, req models.UpdateStudentRequest) (*models.Student, error) {
 	return &models.Student{ID: id, UserID: req.UserID}, nil
 }
 
-func (m *mockStudentRepo) UpdateAdvisor(id string, advisorID string) error {
+func (m *mockStudentRepo) UpdateAdvisor(ctx context.Context, id string, advisorID string) error {
 	return nil
 }
 
@@ -172,27 +180,27 @@ func (m *mockStudentRepo) UpdateAdvisor(id string, advisorID string) error {
 
 type mockLecturerRepo struct{}
 
-func (m *mockLecturerRepo) GetAll() ([]models.Lecturer, error) {
+func (m *mockLecturerRepo) GetAll(ctx context.Context) ([]models.Lecturer, error) {
 	return []models.Lecturer{}, nil
 }
 
-func (m *mockLecturerRepo) GetByID(id string) (*models.Lecturer, error) {
+func (m *mockLecturerRepo) GetByID(ctx context.Context, id string) (*models.Lecturer, error) {
 	return &models.Lecturer{ID: id}, nil
 }
 
-func (m *mockLecturerRepo) GetByUserID(userID string) (*models.Lecturer, error) {
+func (m *mockLecturerRepo) GetByUserID(ctx context.Context, userID string) (*models.Lecturer, error) {
 	return nil, nil
 }
 
-func (m *mockLecturerRepo) GetByLecturerID(lecturerID string) (*models.Lecturer, error) {
+func (m *mockLecturerRepo) GetByLecturerID(ctx context.Context, lecturerID string) (*models.Lecturer, error) {
 	return nil, nil
 }
 
-func (m *mockLecturerRepo) Create(req models.CreateLecturerRequest) (*models.Lecturer, error) {
+func (m *mockLecturerRepo) Create(ctx context.Context, req models.CreateLecturerRequest) (*models.Lecturer, error) {
 	return &models.Lecturer{ID: "1", UserID: req.UserID}, nil
 }
 
-func (m *mockLecturerRepo) Update(id string, req models.UpdateLecturerRequest) (*models.Lecturer, error) {
+func (m *mockLecturerRepo) Update(ctx context.Context, id string, req models.UpdateLecturerRequest) (*models.Lecturer, error) {
 	return &models.Lecturer{ID: id}, nil
 }
 
@@ -212,6 +220,14 @@ func setupUserService() (*fiber.App, *mockUserRepo) {
 		&mockRoleRepo{},
 		&mockStudentRepo{},
 		&mockLecturerRepo{},
+		NewPasswordManager(
+			utils.DefaultPasswordPolicy(),
+			repository.NewMemoryPasswordHistoryStore(),
+			userRepo,
+			&mockStudentRepo{},
+			&mockLecturerRepo{},
+		),
+		repository.NewMemoryUnitOfWork(),
 	)
 
 	app.Get("/users", service.GetAll)
@@ -248,7 +264,7 @@ func TestUserService_Create(t *testing.T) {
 	body := models.CreateUserRequest{
 		Username:     "cindy",
 		Email:        "cindy@mail.com",
-		PasswordHash: "password",
+		PasswordHash: "Cindy-Pass1",
 		FullName:     "cindy Doe",
 	}
 
@@ -268,7 +284,7 @@ func TestUserService_UpdatePassword(t *testing.T) {
 
 	repo.data["1"] = &models.User{ID: "1"}
 
-	body := map[string]string{"password": "newpass"}
+	body := map[string]string{"password": "N3w-Password"}
 	b, _ := json.Marshal(body)
 
 	req := httptest.NewRequest(http.MethodPut, "/users/1/password", bytes.NewReader(b))
@@ -292,3 +308,98 @@ func TestUserService_Delete(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
 }
+
+func TestUserService_Create_WeakPassword(t *testing.T) {
+	app, repo := setupUserService()
+
+	body := models.CreateUserRequest{
+		Username:     "cindy",
+		Email:        "cindy@mail.com",
+		PasswordHash: "cindy",
+		FullName:     "cindy Doe",
+	}
+
+	b, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.Empty(t, repo.data)
+}
+
+// recordingUnitOfWork mencatat hasil fn terakhir: error berarti transaksi
+// di-rollback.
+type recordingUnitOfWork struct {
+	calls int
+	err   error
+}
+
+func (u *recordingUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	u.calls++
+	u.err = fn(ctx)
+	return u.err
+}
+
+func setupUserUpdate() (*fiber.App, *mockUserRepo, *recordingUnitOfWork) {
+	app := fiber.New()
+	userRepo := newMockUserRepo()
+	uow := &recordingUnitOfWork{}
+
+	service := NewUserService(
+		userRepo,
+		&mockRoleRepo{},
+		&mockStudentRepo{},
+		&mockLecturerRepo{},
+		NewPasswordManager(
+			utils.DefaultPasswordPolicy(),
+			repository.NewMemoryPasswordHistoryStore(),
+			userRepo,
+			&mockStudentRepo{},
+			&mockLecturerRepo{},
+		),
+		uow,
+	)
+	app.Put("/users/:id", service.Update)
+
+	return app, userRepo, uow
+}
+
+func TestUserService_Update_ProfileInSameTransaction(t *testing.T) {
+	app, repo, uow := setupUserUpdate()
+	repo.data["1"] = &models.User{ID: "1", Username: "cindy", RoleName: "Mahasiswa"}
+
+	req := httptest.NewRequest(http.MethodPut, "/users/1", jsonBody(fiber.Map{
+		"full_name": "Cindy",
+		"student":   fiber.Map{"student_id": "M001", "program_study": "TI"},
+	}))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, uow.calls)
+	assert.NoError(t, uow.err)
+}
+
+func TestUserService_Update_ProfileErrorRollsBack(t *testing.T) {
+	app, repo, uow := setupUserUpdate()
+	repo.data["1"] = &models.User{ID: "1", Username: "cindy", RoleName: "Dosen Wali"}
+
+	req := httptest.NewRequest(http.MethodPut, "/users/1", jsonBody(fiber.Map{
+		"full_name": "Cindy",
+		"student":   fiber.Map{"student_id": "M001"},
+	}))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	// error dikembalikan ke unit of work sehingga update user ikut dibatalkan
+	assert.Error(t, uow.err)
+}
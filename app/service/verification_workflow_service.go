@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/policy"
+	"achievement_backend/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+var (
+	errReferenceNotFound = errors.New("reference not found")
+	errNotSubmitted      = errors.New("only submitted achievements can be reviewed")
+	errWrongStage        = errors.New("current stage must be decided by another role")
+	errStageDecided      = errors.New("stage already decided, reload and try again")
+	errReload            = errors.New("failed to reload reference")
+)
+
+// reviewStatus memetakan error dari review ke status HTTP dan pesan error.
+func reviewStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, errReferenceNotFound):
+		return 404, err.Error()
+	case errors.Is(err, errNotSubmitted):
+		return 400, err.Error()
+	case errors.Is(err, errWrongStage):
+		return 403, err.Error()
+	case errors.Is(err, errStageDecided):
+		return 409, err.Error()
+	case errors.Is(err, errReload):
+		return 500, err.Error()
+	case errors.Is(err, errUnauthenticated), errors.Is(err, policy.ErrForbidden), errors.Is(err, policy.ErrProfileNotFound):
+		return policyStatus(err)
+	}
+
+	return 500, "failed to review achievement"
+}
+
+// reviewError menerjemahkan error dari review menjadi response HTTP.
+func reviewError(c *fiber.Ctx, err error) error {
+	code, msg := reviewStatus(err)
+	return c.Status(code).JSON(fiber.Map{"error": msg})
+}
+
+// stagesFor mencari workflow untuk jenis dan tingkat kompetisi achievement:
+// cocok persis, lalu workflow jenis itu untuk semua tingkat, lalu satu tahap
+// default.
+func stagesFor(ctx context.Context, store repository.VerificationWorkflowStore, item *models.Achievement) ([]models.VerificationStage, error) {
+	level := ""
+	if item.Details.CompetitionLevel != nil {
+		level = *item.Details.CompetitionLevel
+	}
+
+	keys := []string{level}
+	if level != "" {
+		keys = append(keys, "")
+	}
+
+	for _, lv := range keys {
+		w, err := store.GetByKey(ctx, item.AchievementType, lv)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(w.Stages) > 0 {
+			return w.Stages, nil
+		}
+	}
+
+	return models.DefaultVerificationStages, nil
+}
+
+// VerificationWorkflowService mengelola definisi workflow verifikasi (admin).
+type VerificationWorkflowService struct {
+	store repository.VerificationWorkflowStore
+	rules policy.Rules
+}
+
+func NewVerificationWorkflowService(store repository.VerificationWorkflowStore) *VerificationWorkflowService {
+	return &VerificationWorkflowService{store: store, rules: policy.DefaultRules}
+}
+
+// validate merapikan request dan memastikan setiap tahap punya nama dan role
+// yang memang boleh memverifikasi menurut policy.
+func (s *VerificationWorkflowService) validate(req *models.SaveVerificationWorkflowRequest) error {
+	req.AchievementType = strings.TrimSpace(req.AchievementType)
+	req.CompetitionLevel = strings.TrimSpace(req.CompetitionLevel)
+	req.Name = strings.TrimSpace(req.Name)
+
+	if req.AchievementType == "" || req.Name == "" {
+		return errors.New("achievement_type and name are required")
+	}
+	if len(req.Stages) == 0 {
+		return errors.New("at least one stage is required")
+	}
+
+	for i := range req.Stages {
+		st := &req.Stages[i]
+		st.Position = i
+		st.Name = strings.TrimSpace(st.Name)
+		st.RoleName = strings.TrimSpace(st.RoleName)
+
+		if st.Name == "" || st.RoleName == "" {
+			return errors.New("every stage needs a name and a role_name")
+		}
+		if !s.rules.Allows(st.RoleName, policy.ActionVerify) {
+			return errors.New("role cannot verify achievements: " + st.RoleName)
+		}
+	}
+
+	return nil
+}
+
+// List godoc
+// @Summary Daftar workflow verifikasi
+// @Tags Verification Workflow
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Daftar workflow"
+// @Security Bearer
+// @Router /api/v1/verification-workflows [get]
+func (s *VerificationWorkflowService) List(c *fiber.Ctx) error {
+	list, err := s.store.List(c.UserContext())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "data": list})
+}
+
+// Create godoc
+// @Summary Membuat workflow verifikasi
+// @Description Menentukan tahap dan role penyetuju untuk satu jenis prestasi dan tingkat kompetisi. competition_level kosong berlaku untuk semua tingkat jenis tersebut.
+// @Tags Verification Workflow
+// @Accept json
+// @Produce json
+// @Param body body models.SaveVerificationWorkflowRequest true "Workflow"
+// @Success 201 {object} map[string]interface{} "Workflow dibuat"
+// @Failure 400 {object} map[string]interface{} "Input tidak valid"
+// @Failure 409 {object} map[string]interface{} "Workflow untuk jenis dan tingkat ini sudah ada"
+// @Security Bearer
+// @Router /api/v1/verification-workflows [post]
+func (s *VerificationWorkflowService) Create(c *fiber.Ctx) error {
+	var req models.SaveVerificationWorkflowRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
+	}
+	if err := s.validate(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if _, err := s.store.GetByKey(c.UserContext(), req.AchievementType, req.CompetitionLevel); err == nil {
+		return c.Status(409).JSON(fiber.Map{"error": "workflow already exists for this achievement type and level"})
+	} else if err != sql.ErrNoRows {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	w := &models.VerificationWorkflow{
+		AchievementType:  req.AchievementType,
+		CompetitionLevel: req.CompetitionLevel,
+		Name:             req.Name,
+		Stages:           req.Stages,
+	}
+	if err := s.store.Create(c.UserContext(), w); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to create workflow"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{"success": true, "data": w})
+}
+
+// Update godoc
+// @Summary Mengubah workflow verifikasi
+// @Description Mengganti nama dan seluruh tahap. Jenis dan tingkat tidak bisa diubah. Achievement yang sedang berjalan melanjutkan dari nomor tahapnya saat ini.
+// @Tags Verification Workflow
+// @Accept json
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Param body body models.SaveVerificationWorkflowRequest true "Workflow"
+// @Success 200 {object} map[string]interface{} "Workflow diubah"
+// @Failure 400 {object} map[string]interface{} "Input tidak valid"
+// @Failure 404 {object} map[string]interface{} "Workflow tidak ditemukan"
+// @Security Bearer
+// @Router /api/v1/verification-workflows/{id} [put]
+func (s *VerificationWorkflowService) Update(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "workflow not found"})
+	}
+
+	w, err := s.store.Get(c.UserContext(), id)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "workflow not found"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "database error"})
+	}
+
+	var req models.SaveVerificationWorkflowRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
+	}
+	req.AchievementType = w.AchievementType
+	req.CompetitionLevel = w.CompetitionLevel
+	if err := s.validate(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	w.Name = req.Name
+	w.Stages = req.Stages
+	if err := s.store.Update(c.UserContext(), w); err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "workflow not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed to update workflow"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "data": w})
+}
+
+// Delete godoc
+// @Summary Menghapus workflow verifikasi
+// @Description Achievement jenis tersebut kembali memakai workflow yang lebih umum atau satu tahap default.
+// @Tags Verification Workflow
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Success 200 {object} map[string]interface{} "Workflow dihapus"
+// @Failure 404 {object} map[string]interface{} "Workflow tidak ditemukan"
+// @Security Bearer
+// @Router /api/v1/verification-workflows/{id} [delete]
+func (s *VerificationWorkflowService) Delete(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "workflow not found"})
+	}
+
+	if err := s.store.Delete(c.UserContext(), id); err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "workflow not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed to delete workflow"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "workflow deleted"})
+}
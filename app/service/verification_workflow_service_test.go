@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupWorkflowService() (*fiber.App, repository.VerificationWorkflowStore) {
+	store := repository.NewMemoryVerificationWorkflowStore()
+	svc := NewVerificationWorkflowService(store)
+
+	app := fiber.New()
+	app.Get("/workflows", svc.List)
+	app.Post("/workflows", svc.Create)
+	app.Put("/workflows/:id", svc.Update)
+	app.Delete("/workflows/:id", svc.Delete)
+
+	return app, store
+}
+
+func twoStageWorkflow() models.SaveVerificationWorkflowRequest {
+	return models.SaveVerificationWorkflowRequest{
+		AchievementType:  "competition",
+		CompetitionLevel: "international",
+		Name:             "Kompetisi internasional",
+		Stages: []models.VerificationStage{
+			{Name: "Pemeriksaan dosen wali", RoleName: "Dosen Wali"},
+			{Name: "Persetujuan kemahasiswaan", RoleName: "Kemahasiswaan"},
+		},
+	}
+}
+
+func TestVerificationWorkflow_CreateAndDuplicate(t *testing.T) {
+	app, store := setupWorkflowService()
+
+	resp := postJSON(t, app, "/workflows", twoStageWorkflow())
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	w, err := store.GetByKey(context.Background(), "competition", "international")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, w.Stages[1].Position)
+	assert.Equal(t, "Kemahasiswaan", w.Stages[1].RoleName)
+
+	resp = postJSON(t, app, "/workflows", twoStageWorkflow())
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+}
+
+func TestVerificationWorkflow_RejectsRoleThatCannotVerify(t *testing.T) {
+	app, _ := setupWorkflowService()
+
+	req := twoStageWorkflow()
+	req.Stages[1].RoleName = "Mahasiswa"
+	assert.Equal(t, fiber.StatusBadRequest, postJSON(t, app, "/workflows", req).StatusCode)
+
+	req.Stages = nil
+	assert.Equal(t, fiber.StatusBadRequest, postJSON(t, app, "/workflows", req).StatusCode)
+}
+
+func TestVerificationWorkflow_UpdateAndDelete(t *testing.T) {
+	app, store := setupWorkflowService()
+
+	assert.Equal(t, fiber.StatusCreated, postJSON(t, app, "/workflows", twoStageWorkflow()).StatusCode)
+	w, _ := store.GetByKey(context.Background(), "competition", "international")
+
+	req := twoStageWorkflow()
+	req.Stages = req.Stages[:1]
+	put := httptest.NewRequest(http.MethodPut, "/workflows/"+w.ID, jsonBody(req))
+	put.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(put)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	w, _ = store.Get(context.Background(), w.ID)
+	assert.Len(t, w.Stages, 1)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodDelete, "/workflows/"+w.ID, nil))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodDelete, "/workflows/"+w.ID, nil))
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
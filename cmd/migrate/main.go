@@ -0,0 +1,32 @@
+// Command migrate menjalankan database/migrations (001, 002, ...) yang belum
+// tercatat di tabel schema_migrations, berurutan:
+//
+//	go run ./cmd/migrate
+//
+// Semua migrasi idempotent, sehingga aman dijalankan pada database yang
+// sebagian migrasinya sudah diterapkan manual sebelum runner ini ada.
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"achievement_backend/config"
+	"achievement_backend/database"
+)
+
+func main() {
+	config.LoadEnv()
+	database.ConnectPostgre()
+
+	applied, err := database.Migrate(database.PostgreDB, database.Migrations, "migrations")
+	for _, v := range applied {
+		fmt.Println("applied", v)
+	}
+	if err != nil {
+		log.Fatal("Migrasi gagal: ", err)
+	}
+	if len(applied) == 0 {
+		fmt.Println("database sudah up to date")
+	}
+}
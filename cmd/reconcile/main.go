@@ -0,0 +1,57 @@
+// Command reconcile membandingkan achievement_references (Postgres) dengan
+// dokumen prestasi (Mongo) dan mencetak laporan JSON. Dengan -repair,
+// ketidaksesuaian diperbaiki:
+//
+//	go run ./cmd/reconcile            # hanya laporan
+//	go run ./cmd/reconcile -repair    # laporan + perbaikan
+//
+// Exit code 1 berarti masih ada temuan yang belum diperbaiki.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"achievement_backend/app/repository"
+	"achievement_backend/app/service"
+	"achievement_backend/config"
+	"achievement_backend/database"
+)
+
+func main() {
+	repair := flag.Bool("repair", false, "perbaiki ketidaksesuaian yang ditemukan")
+	grace := flag.Duration("grace", 10*time.Minute, "abaikan dokumen tanpa reference yang lebih muda dari durasi ini")
+	flag.Parse()
+
+	config.LoadEnv()
+	database.ConnectPostgre()
+	database.ConnectMongo()
+
+	refRepo := repository.NewAchievementReferenceRepository(database.PostgreDB)
+	outboxRepo := repository.NewAchievementOutboxRepository(database.PostgreDB)
+	mongoRepo := repository.NewMongoAchievementRepository(database.MongoDB)
+
+	relay := service.NewAchievementOutboxRelay(outboxRepo, refRepo, mongoRepo, service.DefaultOutboxRelayConfig())
+	reconciler := service.NewAchievementReconciler(refRepo, mongoRepo, outboxRepo, relay, *grace)
+
+	report, err := reconciler.Run(context.Background(), *repair)
+	if err != nil {
+		log.Fatal("Rekonsiliasi gagal: ", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, f := range report.Findings {
+		if !f.Repaired {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"achievement_backend/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// LoadJWTKeys membaca kunci JWT dari environment.
+//
+// Konfigurasi rotasi:
+//
+//	JWT_KEY_IDS=2026-10,2026-04         daftar kid yang diterima saat verifikasi
+//	JWT_ACTIVE_KID=2026-10              kid yang dipakai untuk menandatangani
+//	JWT_KEY_2026_10_ALG=RS256           HS256 (default), RS256, atau EdDSA
+//	JWT_KEY_2026_10_FILE=/keys/a.pem    private key PEM (RS256/EdDSA)
+//	JWT_KEY_2026_04_PUBLIC_FILE=...     public key PEM, untuk kunci lama yang hanya diverifikasi
+//	JWT_KEY_<KID>_SECRET=...            secret untuk HS256
+//
+// Tanpa JWT_KEY_IDS, JWT_SECRET dipakai sebagai satu kunci HS256 dengan kid "default".
+func LoadJWTKeys() (*utils.KeySet, error) {
+	ids := GetEnv("JWT_KEY_IDS", "")
+
+	if ids == "" {
+		secret := GetEnv("JWT_SECRET", "")
+		if secret == "" {
+			return nil, errors.New("JWT_SECRET or JWT_KEY_IDS must be set")
+		}
+		return utils.NewKeySet("default", &utils.SigningKey{
+			KID:     "default",
+			Method:  jwt.SigningMethodHS256,
+			Private: []byte(secret),
+			Public:  []byte(secret),
+		})
+	}
+
+	var keys []*utils.SigningKey
+	for _, kid := range strings.Split(ids, ",") {
+		kid = strings.TrimSpace(kid)
+		if kid == "" {
+			continue
+		}
+
+		key, err := loadSigningKey(kid)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", kid, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return utils.NewKeySet(GetEnv("JWT_ACTIVE_KID", ""), keys...)
+}
+
+func loadSigningKey(kid string) (*utils.SigningKey, error) {
+	prefix := "JWT_KEY_" + envName(kid) + "_"
+	alg := GetEnv(prefix+"ALG", "HS256")
+
+	switch alg {
+	case "HS256":
+		secret := GetEnv(prefix+"SECRET", "")
+		if secret == "" {
+			return nil, errors.New(prefix + "SECRET is empty")
+		}
+		return &utils.SigningKey{
+			KID:     kid,
+			Method:  jwt.SigningMethodHS256,
+			Private: []byte(secret),
+			Public:  []byte(secret),
+		}, nil
+
+	case "RS256":
+		key := &utils.SigningKey{KID: kid, Method: jwt.SigningMethodRS256}
+
+		if pem, err := readPEM(prefix + "FILE"); err != nil {
+			return nil, err
+		} else if pem != nil {
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.Private = priv
+			key.Public = &priv.PublicKey
+			return key, nil
+		}
+
+		pem, err := readPEM(prefix + "PUBLIC_FILE")
+		if err != nil {
+			return nil, err
+		}
+		if pem == nil {
+			return nil, errors.New(prefix + "FILE or " + prefix + "PUBLIC_FILE required")
+		}
+		pub, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+		key.Public = pub
+		return key, nil
+
+	case "EdDSA":
+		key := &utils.SigningKey{KID: kid, Method: jwt.SigningMethodEdDSA}
+
+		if pem, err := readPEM(prefix + "FILE"); err != nil {
+			return nil, err
+		} else if pem != nil {
+			priv, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			edPriv, ok := priv.(ed25519.PrivateKey)
+			if !ok {
+				return nil, errors.New("not an Ed25519 private key")
+			}
+			key.Private = edPriv
+			key.Public = edPriv.Public().(ed25519.PublicKey)
+			return key, nil
+		}
+
+		pem, err := readPEM(prefix + "PUBLIC_FILE")
+		if err != nil {
+			return nil, err
+		}
+		if pem == nil {
+			return nil, errors.New(prefix + "FILE or " + prefix + "PUBLIC_FILE required")
+		}
+		pub, err := jwt.ParseEdPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+		edPub, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return nil, errors.New("not an Ed25519 public key")
+		}
+		key.Public = edPub
+		return key, nil
+	}
+
+	return nil, errors.New("unsupported algorithm " + alg)
+}
+
+// readPEM membaca file yang path-nya ada di env var. Mengembalikan nil jika env kosong.
+func readPEM(envKey string) ([]byte, error) {
+	path := GetEnv(envKey, "")
+	if path == "" {
+		return nil, nil
+	}
+	return os.ReadFile(path)
+}
+
+// envName mengubah kid menjadi bagian nama env var, misal "2026-10" → "2026_10".
+func envName(kid string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return '_'
+	}, kid)
+}
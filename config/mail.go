@@ -0,0 +1,31 @@
+package config
+
+import (
+	"log"
+
+	"achievement_backend/utils"
+)
+
+// LoadMailer memilih implementasi Mailer dari environment.
+//
+//	SMTP_HOST, SMTP_PORT (default 587), SMTP_USERNAME, SMTP_PASSWORD
+//	MAIL_FROM                     alamat pengirim
+//	MAIL_OUTBOX_DIR               dipakai jika SMTP_HOST kosong (default ./mail_outbox)
+func LoadMailer() utils.Mailer {
+	from := GetEnv("MAIL_FROM", "no-reply@localhost")
+
+	host := GetEnv("SMTP_HOST", "")
+	if host == "" {
+		dir := GetEnv("MAIL_OUTBOX_DIR", "mail_outbox")
+		log.Printf("SMTP_HOST not set, writing outgoing mail to %s", dir)
+		return &utils.FileMailer{Dir: dir, From: from}
+	}
+
+	return &utils.SMTPMailer{
+		Host:     host,
+		Port:     GetEnv("SMTP_PORT", "587"),
+		Username: GetEnv("SMTP_USERNAME", ""),
+		Password: GetEnv("SMTP_PASSWORD", ""),
+		From:     from,
+	}
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Migrations berisi file database/migrations/NNN_nama.sql. Skema dasar
+// (users, roles, students, ...) harus sudah ada; migrasi hanya menambah
+// tabel dan kolom di atasnya.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// Migrate menjalankan file .sql di dir (urut nama) yang belum tercatat di
+// tabel schema_migrations. Setiap file berjalan dalam transaksi sendiri
+// bersama pencatatannya, dan tabel dikunci sehingga dua proses yang
+// bersamaan tidak menjalankan file yang sama. Mengembalikan nama file yang
+// baru dijalankan.
+func Migrate(db *sql.DB, migrations fs.FS, dir string) ([]string, error) {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	files, err := fs.Glob(migrations, path.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	applied := []string{}
+	for _, file := range files {
+		version := strings.TrimSuffix(path.Base(file), ".sql")
+
+		ran, err := migrateFile(db, migrations, file, version)
+		if err != nil {
+			return applied, fmt.Errorf("migration %s: %w", version, err)
+		}
+		if ran {
+			applied = append(applied, version)
+		}
+	}
+
+	return applied, nil
+}
+
+func migrateFile(db *sql.DB, migrations fs.FS, file, version string) (bool, error) {
+	body, err := fs.ReadFile(migrations, file)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`LOCK TABLE schema_migrations IN EXCLUSIVE MODE`); err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version=$1)`, version).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(string(body)); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
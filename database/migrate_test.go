@@ -0,0 +1,51 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrate_AppliesPendingInOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	files := fstest.MapFS{
+		"migrations/002_b.sql": {Data: []byte("CREATE TABLE b ()")},
+		"migrations/001_a.sql": {Data: []byte("CREATE TABLE a ()")},
+		"migrations/README":    {Data: []byte("bukan migrasi")},
+	}
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// 001 sudah tercatat
+	mock.ExpectBegin()
+	mock.ExpectExec(`LOCK TABLE schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FROM schema_migrations`).WithArgs("001_a").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`LOCK TABLE schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FROM schema_migrations`).WithArgs("002_b").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`CREATE TABLE b`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO schema_migrations`).WithArgs("002_b").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	applied, err := Migrate(db, files, "migrations")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"002_b"}, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrations_Embedded(t *testing.T) {
+	files, err := Migrations.ReadDir("migrations")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, files)
+	assert.Equal(t, "001_refresh_tokens.sql", files[0].Name())
+}
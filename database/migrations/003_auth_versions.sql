@@ -0,0 +1,4 @@
+-- Versi dinaikkan setiap kali status aktif/role user berubah atau
+-- permission sebuah role berubah, sehingga token lama bisa dikenali.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS auth_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS permission_version INTEGER NOT NULL DEFAULT 0;
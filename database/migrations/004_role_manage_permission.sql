@@ -0,0 +1,11 @@
+INSERT INTO permissions (name, resource, action, description)
+SELECT 'role:manage', 'role', 'manage', 'Mengelola role dan permission'
+WHERE NOT EXISTS (SELECT 1 FROM permissions WHERE name = 'role:manage');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'Admin' AND p.name = 'role:manage'
+ON CONFLICT DO NOTHING;
+
+UPDATE roles SET permission_version = permission_version + 1 WHERE name = 'Admin';
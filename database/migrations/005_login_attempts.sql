@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS login_attempts (
+    kind             VARCHAR(16)  NOT NULL,
+    key              VARCHAR(255) NOT NULL,
+    failures         INT          NOT NULL DEFAULT 0,
+    first_failure_at TIMESTAMP    NOT NULL,
+    next_attempt_at  TIMESTAMP,
+    locked_until     TIMESTAMP,
+    updated_at       TIMESTAMP    NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (kind, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_locked_until ON login_attempts(locked_until);
+
+CREATE TABLE IF NOT EXISTS login_lock_events (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind         VARCHAR(16)  NOT NULL,
+    key          VARCHAR(255) NOT NULL,
+    user_id      UUID REFERENCES users(id) ON DELETE CASCADE,
+    failures     INT          NOT NULL,
+    locked_until TIMESTAMP    NOT NULL,
+    created_at   TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_lock_events_user_id ON login_lock_events(user_id, created_at DESC);
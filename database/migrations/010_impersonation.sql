@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS impersonations (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    admin_id       UUID NOT NULL REFERENCES users(id),
+    target_user_id UUID NOT NULL REFERENCES users(id),
+    reason         TEXT NOT NULL,
+    ip             VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent     TEXT NOT NULL DEFAULT '',
+    started_at     TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at     TIMESTAMP NOT NULL,
+    ended_at       TIMESTAMP,
+    ended_by       UUID REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonations_admin_id ON impersonations(admin_id);
+CREATE INDEX IF NOT EXISTS idx_impersonations_target_user_id ON impersonations(target_user_id);
+
+-- Setiap request yang dibuat dengan token impersonation.
+CREATE TABLE IF NOT EXISTS impersonation_actions (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    impersonation_id UUID NOT NULL REFERENCES impersonations(id) ON DELETE CASCADE,
+    method           VARCHAR(10) NOT NULL,
+    path             TEXT NOT NULL,
+    status           INT NOT NULL,
+    created_at       TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_actions_impersonation_id ON impersonation_actions(impersonation_id);
@@ -0,0 +1,27 @@
+-- Akun mesin untuk integrasi (mis. SIAKAD), terpisah dari tabel users.
+CREATE TABLE IF NOT EXISTS service_accounts (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    is_active   BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Hanya hash SHA-256 key yang disimpan; prefix dipakai untuk mencari baris
+-- dan untuk mengenali key di log tanpa membuka rahasianya.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_account_id UUID NOT NULL REFERENCES service_accounts(id) ON DELETE CASCADE,
+    name               VARCHAR(100) NOT NULL DEFAULT '',
+    prefix             VARCHAR(16) NOT NULL UNIQUE,
+    key_hash           VARCHAR(64) NOT NULL,
+    permissions        TEXT[] NOT NULL DEFAULT '{}',
+    expires_at         TIMESTAMP NOT NULL,
+    last_used_at       TIMESTAMP,
+    created_by         UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at         TIMESTAMP NOT NULL DEFAULT NOW(),
+    revoked_at         TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_service_account_id ON api_keys(service_account_id);
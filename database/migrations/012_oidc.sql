@@ -0,0 +1,19 @@
+-- Percobaan login SSO yang belum kembali dari IdP. Sekali pakai.
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    state_hash    VARCHAR(64) PRIMARY KEY,
+    nonce         VARCHAR(128) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    expires_at    TIMESTAMP NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Akun IdP (issuer + sub) yang terhubung ke user lokal.
+CREATE TABLE IF NOT EXISTS user_identities (
+    issuer     TEXT NOT NULL,
+    subject    TEXT NOT NULL,
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
@@ -0,0 +1,40 @@
+-- Log perubahan status achievement_references, append-only. Ditulis dalam
+-- transaksi yang sama dengan UPDATE status-nya.
+CREATE TABLE IF NOT EXISTS achievement_status_history (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reference_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    from_status  VARCHAR(20),
+    to_status    VARCHAR(20) NOT NULL,
+    actor_id     UUID REFERENCES users(id) ON DELETE SET NULL,
+    note         TEXT,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_achievement_status_history_reference_id
+    ON achievement_status_history(reference_id, created_at);
+
+-- Backfill dari kolom lama. Hanya transisi terakhir yang masih tersimpan,
+-- aktor draft/submit/delete tidak diketahui.
+INSERT INTO achievement_status_history (reference_id, from_status, to_status, created_at)
+SELECT r.id, NULL, 'draft', r.created_at
+FROM achievement_references r
+WHERE NOT EXISTS (SELECT 1 FROM achievement_status_history h WHERE h.reference_id = r.id);
+
+INSERT INTO achievement_status_history (reference_id, from_status, to_status, created_at)
+SELECT r.id, 'draft', 'submitted', r.submitted_at
+FROM achievement_references r
+WHERE r.submitted_at IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM achievement_status_history h WHERE h.reference_id = r.id AND h.to_status = 'submitted');
+
+INSERT INTO achievement_status_history (reference_id, from_status, to_status, actor_id, note, created_at)
+SELECT r.id, 'submitted', r.status, r.verified_by, r.rejection_note, r.verified_at
+FROM achievement_references r
+WHERE r.status IN ('verified', 'rejected')
+  AND r.verified_at IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM achievement_status_history h WHERE h.reference_id = r.id AND h.to_status = r.status);
+
+INSERT INTO achievement_status_history (reference_id, from_status, to_status, created_at)
+SELECT r.id, 'draft', 'deleted', r.updated_at
+FROM achievement_references r
+WHERE r.status = 'deleted'
+  AND NOT EXISTS (SELECT 1 FROM achievement_status_history h WHERE h.reference_id = r.id AND h.to_status = 'deleted');
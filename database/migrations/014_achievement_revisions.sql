@@ -0,0 +1,17 @@
+-- Nomor revisi bertambah setiap prestasi yang ditolak dibuka lagi (rejected → draft).
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS revision INT NOT NULL DEFAULT 0;
+
+-- Salinan dokumen Mongo persis seperti yang ditolak reviewer, satu per revisi,
+-- untuk menampilkan apa yang berubah sejak review terakhir.
+CREATE TABLE IF NOT EXISTS achievement_revisions (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reference_id   UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    revision       INT NOT NULL,
+    snapshot       JSONB NOT NULL,
+    rejection_note TEXT,
+    reviewed_by    UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at    TIMESTAMP,
+    created_by     UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at     TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (reference_id, revision)
+);
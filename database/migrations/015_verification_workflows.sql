@@ -0,0 +1,63 @@
+-- Role bagian kemahasiswaan untuk tahap persetujuan kedua.
+INSERT INTO roles (name, description, created_at)
+SELECT 'Kemahasiswaan', 'Bagian kemahasiswaan fakultas', NOW()
+WHERE NOT EXISTS (SELECT 1 FROM roles WHERE name = 'Kemahasiswaan');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'Kemahasiswaan' AND p.name IN ('achievement:read', 'achievement:verify')
+ON CONFLICT DO NOTHING;
+
+-- Alur verifikasi per jenis prestasi dan tingkat kompetisi. competition_level
+-- kosong berlaku untuk semua tingkat jenis tersebut.
+CREATE TABLE IF NOT EXISTS verification_workflows (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    achievement_type  VARCHAR(50) NOT NULL,
+    competition_level VARCHAR(50) NOT NULL DEFAULT '',
+    name              TEXT NOT NULL,
+    created_at        TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (achievement_type, competition_level)
+);
+
+CREATE TABLE IF NOT EXISTS verification_stages (
+    workflow_id UUID NOT NULL REFERENCES verification_workflows(id) ON DELETE CASCADE,
+    position    INT NOT NULL,
+    name        TEXT NOT NULL,
+    role_name   VARCHAR(50) NOT NULL,
+    PRIMARY KEY (workflow_id, position)
+);
+
+-- Tahap yang sedang menunggu keputusan (0 = tahap pertama).
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS current_stage INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS achievement_stage_decisions (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reference_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    revision     INT NOT NULL DEFAULT 0,
+    stage        INT NOT NULL,
+    stage_name   TEXT NOT NULL,
+    role_name    VARCHAR(50) NOT NULL DEFAULT '',
+    decision     VARCHAR(10) NOT NULL CHECK (decision IN ('approved', 'rejected')),
+    actor_id     UUID REFERENCES users(id) ON DELETE SET NULL,
+    note         TEXT,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_achievement_stage_decisions_reference_id
+    ON achievement_stage_decisions(reference_id, created_at);
+
+-- Kompetisi nasional dan internasional: Dosen Wali lalu Kemahasiswaan.
+INSERT INTO verification_workflows (achievement_type, competition_level, name)
+VALUES ('competition', 'national', 'Kompetisi nasional'),
+       ('competition', 'international', 'Kompetisi internasional')
+ON CONFLICT (achievement_type, competition_level) DO NOTHING;
+
+INSERT INTO verification_stages (workflow_id, position, name, role_name)
+SELECT w.id, s.position, s.name, s.role_name
+FROM verification_workflows w
+CROSS JOIN (VALUES (0, 'Pemeriksaan dosen wali', 'Dosen Wali'),
+                   (1, 'Persetujuan kemahasiswaan', 'Kemahasiswaan')) AS s(position, name, role_name)
+WHERE w.achievement_type = 'competition' AND w.competition_level IN ('national', 'international')
+ON CONFLICT DO NOTHING;
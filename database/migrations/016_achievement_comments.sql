@@ -0,0 +1,17 @@
+-- Diskusi per prestasi antara mahasiswa, dosen wali dan admin. Balasan
+-- menunjuk komentar induk lewat parent_id; komentar yang dihapus hanya
+-- ditandai deleted_at agar balasannya tetap punya induk.
+CREATE TABLE IF NOT EXISTS achievement_comments (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reference_id   UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    parent_id      UUID REFERENCES achievement_comments(id) ON DELETE CASCADE,
+    author_id      UUID REFERENCES users(id) ON DELETE SET NULL,
+    body           TEXT NOT NULL,
+    attachment_url TEXT,
+    created_at     TIMESTAMP NOT NULL DEFAULT NOW(),
+    edited_at      TIMESTAMP,
+    deleted_at     TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_achievement_comments_reference_id
+    ON achievement_comments(reference_id, created_at);
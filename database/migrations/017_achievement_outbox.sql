@@ -0,0 +1,18 @@
+-- Outbox perubahan status achievement_references. Satu baris ditulis dalam
+-- transaksi yang sama dengan UPDATE status-nya; relay di aplikasi lalu
+-- menyalin status terkini ke dokumen Mongo dan menandai processed_at.
+CREATE TABLE IF NOT EXISTS achievement_outbox (
+    id                   BIGSERIAL PRIMARY KEY,
+    reference_id         UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    mongo_achievement_id TEXT NOT NULL,
+    to_status            VARCHAR(20) NOT NULL,
+    attempts             INT NOT NULL DEFAULT 0,
+    last_error           TEXT,
+    available_at         TIMESTAMP NOT NULL DEFAULT NOW(),
+    processed_at         TIMESTAMP,
+    created_at           TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_achievement_outbox_pending
+    ON achievement_outbox(available_at)
+    WHERE processed_at IS NULL;
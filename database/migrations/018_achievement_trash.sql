@@ -0,0 +1,5 @@
+-- Purge recycle bin mencari reference berstatus deleted berdasarkan waktu
+-- penghapusan (updated_at, karena deleted hanya bisa berubah lewat restore).
+CREATE INDEX IF NOT EXISTS idx_achievement_references_deleted_at
+    ON achievement_references(updated_at)
+    WHERE status = 'deleted';
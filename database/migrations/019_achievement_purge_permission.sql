@@ -0,0 +1,13 @@
+-- Purge recycle bin menghapus permanen dan tidak bisa dibatalkan, jadi
+-- dipisah dari user:manage.
+INSERT INTO permissions (name, resource, action, description)
+SELECT 'achievement:purge', 'achievement', 'purge', 'Menghapus permanen isi recycle bin prestasi'
+WHERE NOT EXISTS (SELECT 1 FROM permissions WHERE name = 'achievement:purge');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'Admin' AND p.name = 'achievement:purge'
+ON CONFLICT DO NOTHING;
+
+UPDATE roles SET permission_version = permission_version + 1 WHERE name = 'Admin';
@@ -0,0 +1,21 @@
+-- Menulis komentar dipisah dari achievement:read agar API key (service
+-- account) yang hanya menarik data tidak bisa ikut berdiskusi.
+INSERT INTO permissions (name, resource, action, description)
+SELECT 'achievement:comment', 'achievement', 'comment', 'Menulis, mengubah dan menghapus komentar pada diskusi prestasi'
+WHERE NOT EXISTS (SELECT 1 FROM permissions WHERE name = 'achievement:comment');
+
+-- role yang sudah bisa membaca prestasi tetap bisa berdiskusi seperti sebelumnya
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT rp.role_id, p.id
+FROM role_permissions rp
+JOIN permissions rd ON rd.id = rp.permission_id AND rd.name = 'achievement:read'
+CROSS JOIN permissions p
+WHERE p.name = 'achievement:comment'
+ON CONFLICT DO NOTHING;
+
+UPDATE roles SET permission_version = permission_version + 1
+WHERE id IN (
+    SELECT rp.role_id FROM role_permissions rp
+    JOIN permissions p ON p.id = rp.permission_id
+    WHERE p.name = 'achievement:comment'
+);
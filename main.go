@@ -2,6 +2,8 @@ package main
 
 import (
 	"log"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	fiberSwagger "github.com/swaggo/fiber-swagger"
@@ -11,8 +13,11 @@ import (
 
 	"achievement_backend/app/repository"
 	"achievement_backend/app/service"
+	"achievement_backend/config"
 	"achievement_backend/database"
+	"achievement_backend/middleware"
 	"achievement_backend/route"
+	"achievement_backend/utils"
 )
 
 // ============================================================
@@ -37,6 +42,13 @@ import (
 // @description JWT Token dengan format: Bearer <token>
 
 func main() {
+	config.LoadEnv()
+
+	keys, err := config.LoadJWTKeys()
+	if err != nil {
+		log.Fatal("Gagal memuat JWT signing keys: ", err)
+	}
+	utils.SetKeySet(keys)
 
 	// ============================================================
 	// 1. CONNECT DATABASES
@@ -49,26 +61,161 @@ func main() {
 	// ============================================================
 	// 2. INIT REPOSITORIES
 	// ============================================================
-	userRepo := repository.NewUserRepository(database.PostgreDB)
-	roleRepo := repository.NewRoleRepository(database.PostgreDB)
-	// permissionRepo := repository.NewPermissionRepository(database.PostgreDB)
-	rolePermissionRepo := repository.NewRolePermissionRepository(database.PostgreDB)
+	// auth state dibaca middleware di setiap request; perubahan role, status
+	// aktif, sesi dan permission lewat repository di bawah langsung membuang
+	// cache-nya, perubahan dari proses lain terlihat setelah TTL
+	authStateRepo := repository.NewAuthStateRepository(database.PostgreDB)
+	authStateCache := repository.NewCachedAuthStateRepository(
+		authStateRepo,
+		config.GetEnvDuration("AUTH_STATE_CACHE_TTL", 15*time.Second),
+		config.GetEnvInt("AUTH_STATE_CACHE_SIZE", 10000),
+	)
+
+	userRepo := repository.NewAuthStateInvalidatingUserRepository(repository.NewUserRepository(database.PostgreDB), authStateCache)
+	roleRepo := repository.NewAuthStateInvalidatingRoleRepository(repository.NewRoleRepository(database.PostgreDB), authStateCache)
+	permissionRepo := repository.NewPermissionRepository(database.PostgreDB)
+	rolePermissionRepo := repository.NewAuthStateInvalidatingRolePermissionRepository(repository.NewRolePermissionRepository(database.PostgreDB), authStateCache)
 
 	studentRepo := repository.NewStudentRepository(database.PostgreDB)
 	lecturerRepo := repository.NewLecturerRepository(database.PostgreDB)
 	achievementRefRepo := repository.NewAchievementReferenceRepository(database.PostgreDB)
+	achievementRevisionRepo := repository.NewAchievementRevisionRepository(database.PostgreDB)
+	verificationWorkflowRepo := repository.NewVerificationWorkflowRepository(database.PostgreDB)
+	achievementCommentRepo := repository.NewAchievementCommentRepository(database.PostgreDB)
+	achievementOutboxRepo := repository.NewAchievementOutboxRepository(database.PostgreDB)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(database.PostgreDB)
+	tokenRevocationRepo := repository.NewTokenRevocationRepository(database.PostgreDB)
+	authRepo := repository.NewAuthRepository(database.PostgreDB)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(database.PostgreDB)
+	passwordResetRepo := repository.NewPasswordResetRepository(database.PostgreDB)
+	passwordHistoryRepo := repository.NewPasswordHistoryRepository(database.PostgreDB)
+	mfaRepo := repository.NewMFARepository(database.PostgreDB)
+	sessionRepo := repository.NewSessionRepository(database.PostgreDB)
+	impersonationRepo := repository.NewImpersonationRepository(database.PostgreDB)
+	apiKeyRepo := repository.NewAPIKeyRepository(database.PostgreDB)
+	oidcRepo := repository.NewOIDCRepository(database.PostgreDB)
 
 	achievementMongoRepo := repository.NewMongoAchievementRepository(database.MongoDB)
 
+	unitOfWork := repository.NewUnitOfWork(database.PostgreDB)
+
 	// ============================================================
 	// 3. INIT SERVICES
 	// ============================================================
+	throttle := service.DefaultLoginThrottleConfig()
+	loginGuard := service.NewLoginGuard(loginAttemptRepo, service.LoginThrottleConfig{
+		MaxAccountFailures: config.GetEnvInt("LOGIN_MAX_ACCOUNT_FAILURES", throttle.MaxAccountFailures),
+		MaxIPFailures:      config.GetEnvInt("LOGIN_MAX_IP_FAILURES", throttle.MaxIPFailures),
+		BaseDelay:          config.GetEnvDuration("LOGIN_BASE_DELAY", throttle.BaseDelay),
+		MaxDelay:           config.GetEnvDuration("LOGIN_MAX_DELAY", throttle.MaxDelay),
+		LockDuration:       config.GetEnvDuration("LOGIN_LOCK_DURATION", throttle.LockDuration),
+		Window:             config.GetEnvDuration("LOGIN_FAILURE_WINDOW", throttle.Window),
+	})
+
+	pwPolicy := utils.DefaultPasswordPolicy()
+	pwPolicy.MinLength = config.GetEnvInt("PASSWORD_MIN_LENGTH", pwPolicy.MinLength)
+	pwPolicy.RequireSymbol = config.GetEnvBool("PASSWORD_REQUIRE_SYMBOL", pwPolicy.RequireSymbol)
+	pwPolicy.HistorySize = config.GetEnvInt("PASSWORD_HISTORY_SIZE", pwPolicy.HistorySize)
+	passwordManager := service.NewPasswordManager(
+		pwPolicy,
+		passwordHistoryRepo,
+		userRepo,
+		studentRepo,
+		lecturerRepo,
+	)
+
+	mfaService := service.NewMFAService(
+		mfaRepo,
+		userRepo,
+		roleRepo,
+		config.GetEnv("MFA_ISSUER", "Sistem Pelaporan Prestasi Mahasiswa"),
+	)
+
+	sessionService := service.NewSessionService(
+		sessionRepo,
+		refreshTokenRepo,
+		tokenRevocationRepo,
+		userRepo,
+	)
+
+	impersonationService := service.NewImpersonationService(
+		impersonationRepo,
+		userRepo,
+		authStateCache,
+		tokenRevocationRepo,
+		config.GetEnvDuration("IMPERSONATION_TTL", 15*time.Minute),
+	)
+
+	serviceAccountService := service.NewServiceAccountService(apiKeyRepo)
+
 	authService := service.NewAuthService(
 		userRepo,
 		roleRepo,
 		rolePermissionRepo,
 		studentRepo,
 		lecturerRepo,
+		refreshTokenRepo,
+		tokenRevocationRepo,
+		authStateRepo,
+		authRepo,
+		loginGuard,
+		passwordManager,
+		mfaService,
+		sessionService,
+	)
+
+	// SSO aktif hanya jika OIDC_ISSUER_URL diisi
+	var oidcProvider *utils.OIDCProvider
+	if issuer := config.GetEnv("OIDC_ISSUER_URL", ""); issuer != "" {
+		oidcProvider = utils.NewOIDCProvider(utils.OIDCConfig{
+			IssuerURL:    issuer,
+			ClientID:     config.GetEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret: config.GetEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:  config.GetEnv("OIDC_REDIRECT_URL", "http://localhost:3000/sso/callback"),
+			Scopes:       strings.Fields(config.GetEnv("OIDC_SCOPES", "openid email profile")),
+		}, nil)
+	}
+
+	ssoConfig := service.DefaultSSOConfig()
+	ssoConfig.AutoProvision = config.GetEnvBool("OIDC_AUTO_PROVISION", ssoConfig.AutoProvision)
+	ssoConfig.ProvisionRole = config.GetEnv("OIDC_PROVISION_ROLE", ssoConfig.ProvisionRole)
+	ssoConfig.StudentIDClaim = config.GetEnv("OIDC_STUDENT_ID_CLAIM", ssoConfig.StudentIDClaim)
+	// daftar role dipisah koma, misal "Mahasiswa,Dosen Wali"
+	if roles := config.GetEnv("OIDC_EMAIL_LINK_ROLES", ""); roles != "" {
+		ssoConfig.EmailLinkRoles = nil
+		for _, role := range strings.Split(roles, ",") {
+			ssoConfig.EmailLinkRoles = append(ssoConfig.EmailLinkRoles, strings.TrimSpace(role))
+		}
+	}
+
+	ssoService := service.NewSSOService(
+		oidcProvider,
+		oidcRepo,
+		userRepo,
+		roleRepo,
+		studentRepo,
+		authService,
+		unitOfWork,
+		ssoConfig,
+	)
+
+	loginLockService := service.NewLoginLockService(loginAttemptRepo)
+
+	// email dikirim di background agar respons lupa password tidak lebih
+	// lambat untuk akun yang ada
+	mailer := utils.NewAsyncMailer(config.LoadMailer(), config.GetEnvInt("MAIL_QUEUE_SIZE", 100))
+	defer mailer.Close()
+
+	passwordResetService := service.NewPasswordResetService(
+		authRepo,
+		userRepo,
+		passwordResetRepo,
+		sessionService,
+		mailer,
+		config.GetEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+		config.GetEnvDuration("PASSWORD_RESET_TTL", 30*time.Minute),
+		passwordManager,
+		unitOfWork,
 	)
 
 	userService := service.NewUserService(
@@ -76,9 +223,20 @@ func main() {
 		roleRepo,
 		studentRepo,
 		lecturerRepo,
+		passwordManager,
+		unitOfWork,
 	)
 
-	// permissionService := service.NewPermissionService(permissionRepo)
+	roleService := service.NewRoleService(
+		roleRepo,
+		rolePermissionRepo,
+		permissionRepo,
+	)
+
+	permissionService := service.NewPermissionService(
+		permissionRepo,
+		rolePermissionRepo,
+	)
 
 	studentService := service.NewStudentService(
 		studentRepo,
@@ -99,6 +257,7 @@ func main() {
 		achievementRefRepo,
 		studentRepo,
 		lecturerRepo,
+		unitOfWork,
 	)
 
 	achievementRefService := service.NewAchievementReferenceService(
@@ -106,6 +265,38 @@ func main() {
 		achievementMongoRepo,
 		studentRepo,
 		lecturerRepo,
+		achievementRevisionRepo,
+		verificationWorkflowRepo,
+		unitOfWork,
+	)
+
+	verificationWorkflowService := service.NewVerificationWorkflowService(verificationWorkflowRepo)
+
+	achievementCommentService := service.NewAchievementCommentService(
+		achievementRefRepo,
+		achievementMongoRepo,
+		studentRepo,
+		lecturerRepo,
+		achievementCommentRepo,
+		config.GetEnvDuration("COMMENT_EDIT_WINDOW", 15*time.Minute),
+	)
+
+	achievementVersionService := service.NewAchievementVersionService(
+		achievementRefRepo,
+		achievementMongoRepo,
+		studentRepo,
+		lecturerRepo,
+		unitOfWork,
+	)
+
+	achievementTrashService := service.NewAchievementTrashService(
+		achievementRefRepo,
+		achievementMongoRepo,
+		studentRepo,
+		lecturerRepo,
+		unitOfWork,
+		"./uploads",
+		time.Duration(config.GetEnvInt("TRASH_RETENTION_DAYS", 30))*24*time.Hour,
 	)
 
 	achievementHistoryService := service.NewAchievementHistoryService(
@@ -124,31 +315,88 @@ func main() {
 	)
 
 	// ============================================================
-	// 4. INIT FIBER
+	// 4. BACKGROUND JOBS
 	// ============================================================
-	app := fiber.New()
+	stopTokenSweeper := service.StartTokenRevocationSweeper(tokenRevocationRepo, time.Hour)
+	defer stopTokenSweeper()
+
+	outboxCfg := service.DefaultOutboxRelayConfig()
+	outboxCfg.MaxAttempts = config.GetEnvInt("OUTBOX_MAX_ATTEMPTS", outboxCfg.MaxAttempts)
+	outboxCfg.Retention = config.GetEnvDuration("OUTBOX_RETENTION", outboxCfg.Retention)
+	outboxCfg.ClaimLease = config.GetEnvDuration("OUTBOX_CLAIM_LEASE", outboxCfg.ClaimLease)
+	outboxRelay := service.NewAchievementOutboxRelay(achievementOutboxRepo, achievementRefRepo, achievementMongoRepo, outboxCfg)
+	stopOutboxRelay := service.StartAchievementOutboxRelay(
+		outboxRelay,
+		config.GetEnvDuration("OUTBOX_RELAY_INTERVAL", 30*time.Second),
+	)
+	defer stopOutboxRelay()
+
+	stopTrashPurger := service.StartAchievementTrashPurger(
+		achievementTrashService,
+		config.GetEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
+	)
+	defer stopTrashPurger()
+
+	// ============================================================
+	// 5. INIT FIBER
+	// ============================================================
+	// c.IP() dipakai untuk lockout login, daftar sesi dan audit impersonation.
+	// Di belakang reverse proxy, IP klien dibaca dari TRUSTED_PROXY_HEADER
+	// hanya untuk request yang datang dari alamat di TRUSTED_PROXIES (dipisah
+	// koma, boleh CIDR); tanpa TRUSTED_PROXIES header tersebut diabaikan agar
+	// klien tidak bisa memalsukan IP-nya.
+	fiberConfig := fiber.Config{}
+	if proxies := config.GetEnv("TRUSTED_PROXIES", ""); proxies != "" {
+		fiberConfig.EnableTrustedProxyCheck = true
+		fiberConfig.ProxyHeader = config.GetEnv("TRUSTED_PROXY_HEADER", "X-Real-IP")
+		fiberConfig.EnableIPValidation = true
+		for _, proxy := range strings.Split(proxies, ",") {
+			fiberConfig.TrustedProxies = append(fiberConfig.TrustedProxies, strings.TrimSpace(proxy))
+		}
+	}
+	app := fiber.New(fiberConfig)
+
+	// setiap request dibatasi waktunya; query yang memakai c.UserContext()
+	// ikut dibatalkan saat batas tercapai
+	app.Use(middleware.RequestTimeout(config.GetEnvDuration("REQUEST_TIMEOUT", 30*time.Second)))
 
 	// Serve uploaded files
 	app.Static("/uploads", "./uploads")
 
 	// ============================================================
-	// 5. SETUP ROUTES
+	// 6. SETUP ROUTES
 	// ============================================================
 	route.SetupRoutes(
 		app,
 		authService,
 		userService,
-		// permissionService,
+		roleService,
+		permissionService,
 		studentService,
 		lecturerService,
 		achievementService,
 		achievementRefService,
 		achievementHistoryService,
 		reportService,
+		loginLockService,
+		passwordResetService,
+		mfaService,
+		sessionService,
+		impersonationService,
+		serviceAccountService,
+		ssoService,
+		verificationWorkflowService,
+		achievementCommentService,
+		achievementVersionService,
+		achievementTrashService,
+		tokenRevocationRepo,
+		authStateCache,
+		impersonationRepo,
+		apiKeyRepo,
 	)
 
 	// ============================================================
-	// 6. SWAGGER ROUTE
+	// 7. SWAGGER ROUTE
 	// ============================================================
 	app.Get("/swagger/*", fiberSwagger.WrapHandler)
 
@@ -158,7 +406,7 @@ func main() {
 	})
 
 	// ============================================================
-	// 7. START SERVER
+	// 8. START SERVER
 	// ============================================================
 	log.Println("Server berjalan di port 8080")
 	log.Println("Swagger UI: http://localhost:8080/swagger/index.html")
@@ -1,13 +1,31 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"database/sql"
+	"log"
+
+	models "achievement_backend/app/model"
+	"achievement_backend/app/repository"
 	"achievement_backend/utils"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func AuthRequired() fiber.Handler {
+// AuthRequired memvalidasi bearer token lalu mencocokkan versi user/role di
+// token dengan kondisi terkini (lewat authState yang di-cache). Jika versi
+// berbeda, role dan permission diambil dari kondisi terkini dan response
+// diberi header X-Token-Stale agar client melakukan refresh.
+//
+// Token impersonation (klaim act terisi) hanya boleh membaca: request selain
+// GET/HEAD/OPTIONS ditolak kecuali untuk mengakhiri impersonation, dan setiap
+// request dicatat ke audit log impersonation.
+//
+// Bearer berawalan utils.APIKeyPrefix diperlakukan sebagai API key service
+// account, bukan JWT.
+func AuthRequired(revocations repository.TokenRevocationStore, authState repository.AuthStateRepository, impersonations repository.ImpersonationStore, apiKeys repository.APIKeyStore) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 
 		token := c.Get("Authorization")
@@ -22,26 +40,206 @@ func AuthRequired() fiber.Handler {
 
 		rawToken := parts[1]
 
-		// CEK BLACKLIST
-		if utils.IsBlacklisted(rawToken) {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "token blacklisted (logged out)",
-			})
+		if utils.IsAPIKey(rawToken) {
+			return apiKeyAuth(c, apiKeys, rawToken)
 		}
 
 		// VALIDATE TOKEN
 		claims, err := utils.ValidateToken(rawToken)
-		if err != nil {
+		if err != nil || claims.ID == "" {
 			return c.Status(401).JSON(fiber.Map{"error": "invalid token"})
 		}
 
+		// CEK REVOCATION
+		revoked, err := revocations.IsRevoked(c.UserContext(), claims.ID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "failed to check token revocation"})
+		}
+		if revoked {
+			return c.Status(401).JSON(fiber.Map{
+				"error": "token revoked (logged out)",
+			})
+		}
+
+		// sesi (perangkat) yang dicabut ikut mematikan semua access token-nya
+		if claims.SessionID != "" {
+			revoked, err = revocations.IsRevoked(c.UserContext(), claims.SessionID)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": "failed to check token revocation"})
+			}
+			if revoked {
+				return c.Status(401).JSON(fiber.Map{"error": "session revoked"})
+			}
+		}
+
+		// CEK STATUS & VERSI PERMISSION TERKINI
+		state, err := authState.GetAuthState(c.UserContext(), claims.UserID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return c.Status(401).JSON(fiber.Map{"error": "user not found"})
+			}
+			return c.Status(500).JSON(fiber.Map{"error": "failed to load user state"})
+		}
+
+		if !state.IsActive {
+			return c.Status(401).JSON(fiber.Map{"error": "user inactive"})
+		}
+
+		// token terbit sebelum semua sesi dicabut (mis. reset password).
+		// iat berpresisi detik, jadi batasnya dibulatkan ke bawah.
+		if state.SessionsRevokedAt != nil && claims.IssuedAt != nil &&
+			claims.IssuedAt.Time.Before(state.SessionsRevokedAt.Truncate(time.Second)) {
+			return c.Status(401).JSON(fiber.Map{"error": "session revoked"})
+		}
+
+		roleName := claims.RoleName
+		permissions := claims.Permissions
+
+		if state.UserVersion != claims.UserVersion ||
+			state.RoleVersion != claims.RoleVersion ||
+			state.RoleID != claims.RoleID {
+			roleName = state.RoleName
+			permissions = state.Permissions
+			c.Set("X-Token-Stale", "true")
+		}
+
+		// admin di balik token impersonation harus masih aktif
+		if claims.Actor != nil {
+			actor, err := authState.GetAuthState(c.UserContext(), claims.Actor.UserID)
+			if err != nil && err != sql.ErrNoRows {
+				return c.Status(500).JSON(fiber.Map{"error": "failed to load user state"})
+			}
+			if err != nil || !actor.IsActive {
+				return c.Status(401).JSON(fiber.Map{"error": "impersonator inactive"})
+			}
+		}
+
 		// SET CONTEXT
 		c.Locals("raw_token", rawToken)
+		c.Locals("jti", claims.ID)
+		c.Locals("session_id", claims.SessionID)
+		c.Locals("token_exp", claims.ExpiresAt.Time)
 		c.Locals("user_id", claims.UserID)
 		c.Locals("username", claims.Username)
-		c.Locals("role_name", claims.RoleName)
-		c.Locals("permissions", claims.Permissions)
+		c.Locals("role_name", roleName)
+		c.Locals("permissions", permissions)
+
+		if claims.Actor == nil {
+			return c.Next()
+		}
+
+		c.Locals("impersonator_id", claims.Actor.UserID)
+		c.Locals("impersonation_id", claims.SessionID)
+		c.Set("X-Impersonated-By", claims.Actor.Username)
+
+		return impersonated(c, impersonations, claims.SessionID)
+	}
+}
+
+// apiKeyAuth mengautentikasi request dengan API key service account.
+// Permission diambil dari key itu sendiri, bukan dari role.
+func apiKeyAuth(c *fiber.Ctx, apiKeys repository.APIKeyStore, rawKey string) error {
+	prefix, ok := utils.ParseAPIKeyPrefix(rawKey)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "invalid api key"})
+	}
 
+	key, err := apiKeys.GetKeyByPrefix(c.UserContext(), prefix)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(401).JSON(fiber.Map{"error": "invalid api key"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed to load api key"})
+	}
+
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(rawKey)), []byte(key.KeyHash)) != 1 {
+		return c.Status(401).JSON(fiber.Map{"error": "invalid api key"})
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "api key revoked"})
+	}
+	if !now.Before(key.ExpiresAt) {
+		return c.Status(401).JSON(fiber.Map{"error": "api key expired"})
+	}
+
+	account, err := apiKeys.GetServiceAccount(c.UserContext(), key.ServiceAccountID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to load service account"})
+	}
+	if !account.IsActive {
+		return c.Status(401).JSON(fiber.Map{"error": "service account inactive"})
+	}
+
+	// last_used_at cukup berpresisi menit agar tidak menulis di setiap request
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= time.Minute {
+		if err := apiKeys.TouchKey(c.UserContext(), key.ID, now); err != nil {
+			log.Printf("[AuthRequired] touch api key %s error: %v", key.Prefix, err)
+		}
+	}
+
+	c.Locals("service_account_id", account.ID)
+	c.Locals("api_key_id", key.ID)
+	c.Locals("user_id", account.ID)
+	c.Locals("username", account.Name)
+	c.Locals("role_name", models.ServiceAccountRole)
+	// key yang diterbitkan sebelum ada allowlist bisa membawa permission lain
+	perms := make([]string, 0, len(key.Permissions))
+	for _, p := range key.Permissions {
+		if models.APIKeyPermissionAllowed(p) {
+			perms = append(perms, p)
+		}
+	}
+	c.Locals("permissions", perms)
+
+	return c.Next()
+}
+
+// NoAPIKey menolak request yang diautentikasi dengan API key. Dipasang di
+// depan grup admin (user, role, service account, ...) sehingga service
+// account tidak bisa mengelola akun apa pun permission key-nya.
+func NoAPIKey() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if id, _ := c.Locals("service_account_id").(string); id != "" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "forbidden: not allowed for service accounts",
+			})
+		}
 		return c.Next()
 	}
 }
+
+// ImpersonationEndPath adalah satu-satunya endpoint tulis yang boleh dipanggil
+// dengan token impersonation.
+const ImpersonationEndPath = "/auth/impersonation/end"
+
+func impersonated(c *fiber.Ctx, impersonations repository.ImpersonationStore, impersonationID string) error {
+	var err error
+	switch {
+	case c.Method() == fiber.MethodGet, c.Method() == fiber.MethodHead, c.Method() == fiber.MethodOptions,
+		strings.HasSuffix(c.Path(), ImpersonationEndPath):
+		err = c.Next()
+	default:
+		err = c.Status(403).JSON(fiber.Map{"error": "write operations are not allowed while impersonating"})
+	}
+
+	// error handler fiber baru berjalan setelah middleware ini selesai,
+	// jadi kode dari *fiber.Error (mis. 404 route) diambil langsung
+	status := c.Response().StatusCode()
+	if fe, ok := err.(*fiber.Error); ok {
+		status = fe.Code
+	}
+
+	action := &models.ImpersonationAction{
+		ImpersonationID: impersonationID,
+		Method:          strings.Clone(c.Method()),
+		Path:            strings.Clone(c.Path()),
+		Status:          status,
+	}
+	if rerr := impersonations.RecordAction(c.UserContext(), action); rerr != nil {
+		log.Printf("[AuthRequired] record impersonation action %s error: %v", impersonationID, rerr)
+	}
+
+	return err
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestTimeout memasang batas waktu pada c.UserContext() sehingga query
+// repository yang memakai context tersebut dibatalkan jika request terlalu
+// lama. c.Context() (fasthttp) tidak pernah punya deadline, jadi handler
+// harus meneruskan c.UserContext() ke repository.
+func RequestTimeout(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
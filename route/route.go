@@ -3,6 +3,7 @@ package route
 import (
 	"github.com/gofiber/fiber/v2"
 
+	"achievement_backend/app/repository"
 	"achievement_backend/app/service"
 	"achievement_backend/middleware"
 )
@@ -12,42 +13,129 @@ func SetupRoutes(
 
 	authService *service.AuthService,
 	userService *service.UserService,
-	// permissionService *service.PermissionService,
+	roleService *service.RoleService,
+	permissionService *service.PermissionService,
 	studentService *service.StudentService,
 	lecturerService *service.LecturerService,
 	achievementService *service.AchievementMongoService,
 	achievementRefService *service.AchievementReferenceService,
 	achievementHistoryService *service.AchievementHistoryService,
 	reportService *service.ReportService,
+	loginLockService *service.LoginLockService,
+	passwordResetService *service.PasswordResetService,
+	mfaService *service.MFAService,
+	sessionService *service.SessionService,
+	impersonationService *service.ImpersonationService,
+	serviceAccountService *service.ServiceAccountService,
+	ssoService *service.SSOService,
+	verificationWorkflowService *service.VerificationWorkflowService,
+	achievementCommentService *service.AchievementCommentService,
+	achievementVersionService *service.AchievementVersionService,
+	achievementTrashService *service.AchievementTrashService,
+	tokenRevocations repository.TokenRevocationStore,
+	authState repository.AuthStateRepository,
+	impersonations repository.ImpersonationStore,
+	apiKeys repository.APIKeyStore,
 ) {
 
+	authRequired := middleware.AuthRequired(tokenRevocations, authState, impersonations, apiKeys)
+
+	// public key untuk layanan lain (tanpa auth)
+	app.Get("/.well-known/jwks.json", authService.JWKS)
+
 	api := app.Group("/api/v1")
 
 	// AUTH
 	auth := api.Group("/auth")
-	auth.Post("/login", authService.Login)                                  // all roles
-	auth.Post("/refresh", authService.RefreshToken)                         // all roles
-	auth.Post("/logout", middleware.AuthRequired(), authService.Logout)     // all roles
-	auth.Get("/profile", middleware.AuthRequired(), authService.GetProfile) // all roles
-
-	v1 := api.Use(middleware.AuthRequired())
+	auth.Post("/login", authService.Login)                                             // all roles
+	auth.Post("/password/forgot", passwordResetService.RequestReset)                   // public
+	auth.Post("/password/reset", passwordResetService.Reset)                           // public
+	auth.Post("/mfa/setup", authService.SetupMFA)                                      // public (token tantangan MFA)
+	auth.Post("/mfa/verify", authService.VerifyMFA)                                    // public (token tantangan MFA)
+	auth.Get("/sso/authorize", ssoService.Authorize)                                   // public
+	auth.Post("/sso/callback", ssoService.Callback)                                    // public
+	auth.Post("/refresh", authService.RefreshToken)                                    // all roles
+	auth.Post("/logout", authRequired, authService.Logout)                             // all roles
+	auth.Get("/profile", authRequired, authService.GetProfile)                         // all roles
+	auth.Put("/password", authRequired, authService.ChangePassword)                    // all roles
+	auth.Get("/lock-events", authRequired, loginLockService.MyEvents)                  // all roles
+	auth.Get("/mfa", authRequired, mfaService.GetStatus)                               // all roles
+	auth.Post("/mfa/enroll", authRequired, mfaService.Enroll)                          // all roles
+	auth.Post("/mfa/enable", authRequired, mfaService.Enable)                          // all roles
+	auth.Post("/mfa/disable", authRequired, mfaService.Disable)                        // all roles
+	auth.Post("/mfa/recovery-codes", authRequired, mfaService.RegenerateRecoveryCodes) // all roles
+	auth.Get("/sessions", authRequired, sessionService.ListMine)                       // all roles
+	auth.Delete("/sessions", authRequired, sessionService.RevokeOthers)                // all roles
+	auth.Delete("/sessions/:id", authRequired, sessionService.RevokeMine)              // all roles
+	auth.Post("/impersonation/end", authRequired, impersonationService.EndCurrent)     // token impersonation
+
+	v1 := api.Use(authRequired)
+
+	// grup admin tidak bisa dipanggil dengan API key service account
+	noAPIKey := middleware.NoAPIKey()
 
 	// USERS
-	users := v1.Group("/users")
-	users.Get("/", middleware.PermissionRequired("user:manage"), userService.GetAll)                     // only admin
-	users.Get("/:id", middleware.PermissionRequired("user:manage"), userService.GetByID)                 // only admin
-	users.Post("/", middleware.PermissionRequired("user:manage"), userService.Create)                    // only admin
-	users.Put("/:id", middleware.PermissionRequired("user:manage"), userService.Update)                  // only admin
-	users.Delete("/:id", middleware.PermissionRequired("user:manage"), userService.Delete)               // only admin
-	users.Put("/:id/password", middleware.PermissionRequired("user:manage"), userService.UpdatePassword) // only admin
+	users := v1.Group("/users", noAPIKey)
+	users.Get("/", middleware.PermissionRequired("user:manage"), userService.GetAll)                                     // only admin
+	users.Get("/:id", middleware.PermissionRequired("user:manage"), userService.GetByID)                                 // only admin
+	users.Post("/", middleware.PermissionRequired("user:manage"), userService.Create)                                    // only admin
+	users.Put("/:id", middleware.PermissionRequired("user:manage"), userService.Update)                                  // only admin
+	users.Delete("/:id", middleware.PermissionRequired("user:manage"), userService.Delete)                               // only admin
+	users.Put("/:id/password", middleware.PermissionRequired("user:manage"), userService.UpdatePassword)                 // only admin
+	users.Get("/:id/sessions", middleware.PermissionRequired("user:manage"), sessionService.ListForUser)                 // only admin
+	users.Delete("/:id/sessions", middleware.PermissionRequired("user:manage"), sessionService.RevokeAllForUser)         // only admin
+	users.Delete("/:id/sessions/:sessionId", middleware.PermissionRequired("user:manage"), sessionService.RevokeForUser) // only admin
+	users.Post("/:id/impersonate", middleware.PermissionRequired("user:manage"), impersonationService.Start)             // only admin
+
+	// IMPERSONATIONS (audit log)
+	impersonationLog := v1.Group("/impersonations", noAPIKey)
+	impersonationLog.Get("/", middleware.PermissionRequired("user:manage"), impersonationService.List)                   // only admin
+	impersonationLog.Get("/:id/actions", middleware.PermissionRequired("user:manage"), impersonationService.ListActions) // only admin
+	impersonationLog.Delete("/:id", middleware.PermissionRequired("user:manage"), impersonationService.End)              // only admin
+
+	// SERVICE ACCOUNTS (API key integrasi)
+	serviceAccounts := v1.Group("/service-accounts", noAPIKey)
+	serviceAccounts.Get("/", middleware.PermissionRequired("user:manage"), serviceAccountService.ListServiceAccounts)         // only admin
+	serviceAccounts.Post("/", middleware.PermissionRequired("user:manage"), serviceAccountService.CreateServiceAccount)       // only admin
+	serviceAccounts.Get("/:id/keys", middleware.PermissionRequired("user:manage"), serviceAccountService.ListKeys)            // only admin
+	serviceAccounts.Post("/:id/keys", middleware.PermissionRequired("user:manage"), serviceAccountService.IssueKey)           // only admin
+	serviceAccounts.Delete("/:id/keys/:keyId", middleware.PermissionRequired("user:manage"), serviceAccountService.RevokeKey) // only admin
+
+	// ROLES
+	roles := v1.Group("/roles", noAPIKey)
+	roles.Get("/", middleware.PermissionRequired("role:manage"), roleService.GetAll)                                           // only admin
+	roles.Get("/:id", middleware.PermissionRequired("role:manage"), roleService.GetByID)                                       // only admin
+	roles.Post("/", middleware.PermissionRequired("role:manage"), roleService.Create)                                          // only admin
+	roles.Put("/:id", middleware.PermissionRequired("role:manage"), roleService.Update)                                        // only admin
+	roles.Delete("/:id", middleware.PermissionRequired("role:manage"), roleService.Delete)                                     // only admin
+	roles.Post("/:id/permissions", middleware.PermissionRequired("role:manage"), roleService.AssignPermission)                 // only admin
+	roles.Delete("/:id/permissions/:permissionId", middleware.PermissionRequired("role:manage"), roleService.RemovePermission) // only admin
+	roles.Put("/:id/mfa", middleware.PermissionRequired("role:manage"), mfaService.SetRoleRequirement)                         // only admin
+
+	// PERMISSIONS
+	permissions := v1.Group("/permissions", noAPIKey)
+	permissions.Get("/", middleware.PermissionRequired("role:manage"), permissionService.GetAll)     // only admin
+	permissions.Get("/:id", middleware.PermissionRequired("role:manage"), permissionService.GetByID) // only admin
+
+	// LOGIN LOCKS
+	loginLocks := v1.Group("/login-locks", noAPIKey)
+	loginLocks.Get("/", middleware.PermissionRequired("user:manage"), loginLockService.ListLocks)              // only admin
+	loginLocks.Get("/events", middleware.PermissionRequired("user:manage"), loginLockService.ListEvents)       // only admin
+	loginLocks.Delete("/:kind/:key", middleware.PermissionRequired("user:manage"), loginLockService.ClearLock) // only admin
 
 	// ============= ACHIEVEMENTS (Mongo) =============
 	ach := v1.Group("/achievements")
 
+	// RECYCLE BIN (sebelum /:id agar "trash" tidak terbaca sebagai id)
+	ach.Get("/trash", middleware.PermissionRequired("achievement:delete"), achievementTrashService.List)           // only admin and student
+	ach.Delete("/trash", middleware.PermissionRequired("achievement:purge"), achievementTrashService.PurgeTrash)   // only admin
+	ach.Post("/:id/restore", middleware.PermissionRequired("achievement:delete"), achievementTrashService.Restore) // only admin and student
+
 	// READ ACHIEVEMENTS
-	ach.Get("/", middleware.PermissionRequired("achievement:read"), achievementService.ListByRole)                   // all roles
-	ach.Get("/:id", middleware.PermissionRequired("achievement:read"), achievementService.GetDetail)                 // all roles
-	ach.Get("/:id/history", middleware.PermissionRequired("achievement:read"), achievementHistoryService.GetHistory) // all roles
+	ach.Get("/", middleware.PermissionRequired("achievement:read"), achievementService.ListByRole)                       // all roles
+	ach.Get("/:id", middleware.PermissionRequired("achievement:read"), achievementService.GetDetail)                     // all roles
+	ach.Get("/:id/history", middleware.PermissionRequired("achievement:read"), achievementHistoryService.GetHistory)     // all roles
+	ach.Get("/:id/decisions", middleware.PermissionRequired("achievement:read"), achievementHistoryService.GetDecisions) // all roles
 
 	// CRUD ACHIEVEEMNTS (MAHASISWA)
 	ach.Post("/", middleware.PermissionRequired("achievement:create"), achievementService.CreateDraft)     // only admin and student
@@ -58,9 +146,33 @@ func SetupRoutes(
 	ach.Post("/:id/attachments", middleware.PermissionRequired("achievement:update"), achievementService.UpdateAttachments) // only admin and student
 
 	// Workflow
-	ach.Post("/:id/submit", middleware.PermissionRequired("achievement:update"), achievementRefService.Submit) // only admin and student
-	ach.Post("/:id/verify", middleware.PermissionRequired("achievement:verify"), achievementRefService.Verify) // only admin and lecturer
-	ach.Post("/:id/reject", middleware.PermissionRequired("achievement:verify"), achievementRefService.Reject) // only admin and lecturer
+	ach.Post("/:id/submit", middleware.PermissionRequired("achievement:update"), achievementRefService.Submit)      // only admin and student
+	ach.Post("/bulk-verify", middleware.PermissionRequired("achievement:verify"), achievementRefService.BulkVerify) // only admin and lecturer
+	ach.Post("/bulk-reject", middleware.PermissionRequired("achievement:verify"), achievementRefService.BulkReject) // only admin and lecturer
+	ach.Post("/:id/verify", middleware.PermissionRequired("achievement:verify"), achievementRefService.Verify)      // only admin and lecturer
+	ach.Post("/:id/reject", middleware.PermissionRequired("achievement:verify"), achievementRefService.Reject)      // only admin and lecturer
+	ach.Post("/:id/revise", middleware.PermissionRequired("achievement:update"), achievementRefService.Revise)      // only admin and student
+	ach.Post("/:id/withdraw", middleware.PermissionRequired("achievement:update"), achievementRefService.Withdraw)  // only admin and student
+	ach.Get("/:id/changes", middleware.PermissionRequired("achievement:read"), achievementRefService.Changes)       // all roles
+
+	// Comments (baca sama dengan detail prestasi, menulis butuh achievement:comment)
+	ach.Get("/:id/comments", middleware.PermissionRequired("achievement:read"), achievementCommentService.List)
+	ach.Post("/:id/comments", middleware.PermissionRequired("achievement:comment"), achievementCommentService.Create)
+	ach.Put("/:id/comments/:commentId", middleware.PermissionRequired("achievement:comment"), achievementCommentService.Update)
+	ach.Delete("/:id/comments/:commentId", middleware.PermissionRequired("achievement:comment"), achievementCommentService.Delete)
+
+	// Versions (isi draft sebelum setiap perubahan)
+	ach.Get("/:id/versions", middleware.PermissionRequired("achievement:read"), achievementVersionService.List)
+	ach.Get("/:id/versions/diff", middleware.PermissionRequired("achievement:read"), achievementVersionService.Diff)
+	ach.Get("/:id/versions/:version", middleware.PermissionRequired("achievement:read"), achievementVersionService.Get)
+	ach.Post("/:id/versions/:version/restore", middleware.PermissionRequired("achievement:update"), achievementVersionService.Restore)
+
+	// VERIFICATION WORKFLOWS
+	workflows := v1.Group("/verification-workflows", noAPIKey)
+	workflows.Get("/", middleware.PermissionRequired("user:manage"), verificationWorkflowService.List)         // only admin
+	workflows.Post("/", middleware.PermissionRequired("user:manage"), verificationWorkflowService.Create)      // only admin
+	workflows.Put("/:id", middleware.PermissionRequired("user:manage"), verificationWorkflowService.Update)    // only admin
+	workflows.Delete("/:id", middleware.PermissionRequired("user:manage"), verificationWorkflowService.Delete) // only admin
 
 	// REPORTS
 	reports := v1.Group("/reports")
@@ -69,13 +181,13 @@ func SetupRoutes(
 
 	// STUDENTS
 	students := v1.Group("/students")
-	students.Get("/", middleware.PermissionRequired("user:manage"), studentService.GetAll)                                // only admin
-	students.Get("/:id", middleware.PermissionRequired("user:manage"), studentService.GetByID)                            // only admin
-	students.Get("/:id/achievements", middleware.PermissionRequired("achievement:read"), achievementService.GetByStudent) // only admin and lecturer
-	students.Put("/:id/advisor", middleware.PermissionRequired("user:manage"), studentService.UpdateAdvisor)              // only admin
+	students.Get("/", noAPIKey, middleware.PermissionRequired("user:manage"), studentService.GetAll)                      // only admin
+	students.Get("/:id", noAPIKey, middleware.PermissionRequired("user:manage"), studentService.GetByID)                  // only admin
+	students.Get("/:id/achievements", middleware.PermissionRequired("achievement:read"), achievementService.GetByStudent) // scoped by policy
+	students.Put("/:id/advisor", noAPIKey, middleware.PermissionRequired("user:manage"), studentService.UpdateAdvisor)    // only admin
 
 	// LECTURERS
-	lecturers := v1.Group("/lecturers")
+	lecturers := v1.Group("/lecturers", noAPIKey)
 	lecturers.Get("/", middleware.PermissionRequired("user:manage"), lecturerService.GetAll)                  // only admin
 	lecturers.Get("/:id/advisees", middleware.PermissionRequired("user:manage"), lecturerService.GetAdvisees) // only admin
 }
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+// APIKeyPrefix menandai kredensial sebagai API key (bukan JWT) sehingga
+// middleware bisa membedakannya dari bearer token biasa.
+const APIKeyPrefix = "ak_"
+
+// apiKeyIDLength adalah panjang prefix pencarian setelah APIKeyPrefix.
+const apiKeyIDLength = 8
+
+// GenerateAPIKey menghasilkan key berformat ak_<prefix>_<rahasia>. Prefix
+// disimpan apa adanya untuk mencari key; key lengkap hanya disimpan hash-nya.
+func GenerateAPIKey() (key, prefix string, err error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	prefix = strings.ToLower(totpEncoding.EncodeToString(b))[:apiKeyIDLength]
+
+	secret, err := GenerateSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	return APIKeyPrefix + prefix + "_" + secret, prefix, nil
+}
+
+// IsAPIKey melaporkan apakah kredensial berbentuk API key.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+
+// ParseAPIKeyPrefix mengambil prefix pencarian dari API key.
+func ParseAPIKeyPrefix(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, APIKeyPrefix)
+	if !ok || len(rest) <= apiKeyIDLength+1 || rest[apiKeyIDLength] != '_' {
+		return "", false
+	}
+	return rest[:apiKeyIDLength], true
+}
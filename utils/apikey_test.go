@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateAPIKey_PrefixRoundTrip(t *testing.T) {
+	key, prefix, err := GenerateAPIKey()
+	assert.NoError(t, err)
+	assert.True(t, IsAPIKey(key))
+	assert.Len(t, prefix, apiKeyIDLength)
+
+	got, ok := ParseAPIKeyPrefix(key)
+	assert.True(t, ok)
+	assert.Equal(t, prefix, got)
+
+	other, _, _ := GenerateAPIKey()
+	assert.NotEqual(t, key, other)
+}
+
+func TestParseAPIKeyPrefix_Invalid(t *testing.T) {
+	for _, key := range []string{"", "eyJhbGciOi", "ak_", "ak_short", "ak_abcdefgh", "ak_abcdefgh-secret"} {
+		_, ok := ParseAPIKeyPrefix(key)
+		assert.False(t, ok, key)
+	}
+}
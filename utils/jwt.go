@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"time"
-	"sync"
 
 	models "achievement_backend/app/model"
 
@@ -10,31 +14,102 @@ import (
 	"github.com/google/uuid"
 )
 
-var JwtSecret = []byte("mysupersecretkey_1234567890!@#$%^&")
+var ErrNoSigningKeys = errors.New("jwt signing keys not configured")
 
-func GenerateToken(user models.User, roleName string, permissions []string) (string, error) {
-	claims := models.JWTClaims{
+// MFAChallengeAudience menandai token tantangan MFA agar tidak bisa dipakai
+// sebagai access token.
+const MFAChallengeAudience = "mfa-challenge"
+
+// AccessTokenTTL adalah masa berlaku access token.
+var AccessTokenTTL = 24 * time.Hour
+
+// GenerateToken membuat access token; sessionID (sid) mengikat token ke sesi
+// login agar bisa dicabut per perangkat.
+func GenerateToken(user models.User, roleName string, permissions []string, version models.AuthVersion, sessionID string) (string, error) {
+	claims := accessClaims(user, roleName, permissions, version, sessionID, AccessTokenTTL)
+	return signClaims(claims)
+}
+
+// GenerateImpersonationToken membuat access token atas nama user target
+// dengan klaim act berisi admin yang melakukan impersonation. sid diisi ID
+// catatan impersonation sehingga token bisa diakhiri lewat revocation store.
+func GenerateImpersonationToken(target models.User, roleName string, permissions []string, version models.AuthVersion, actor models.Actor, impersonationID string, ttl time.Duration) (string, time.Time, error) {
+	claims := accessClaims(target, roleName, permissions, version, impersonationID, ttl)
+	claims.Actor = &actor
+
+	token, err := signClaims(claims)
+	return token, claims.ExpiresAt.Time, err
+}
+
+func accessClaims(user models.User, roleName string, permissions []string, version models.AuthVersion, sessionID string, ttl time.Duration) models.JWTClaims {
+	roleID := ""
+	if user.RoleID != nil {
+		roleID = *user.RoleID
+	}
+
+	now := time.Now()
+	return models.JWTClaims{
 		UserID:      user.ID,
 		Username:    user.Username,
-		RoleName:    roleName, 
+		RoleName:    roleName,
 		Permissions: permissions,
+		RoleID:      roleID,
+		UserVersion: version.User,
+		RoleVersion: version.Role,
+		SessionID:   sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
+}
+
+// signClaims menandatangani claims dengan kunci aktif dan menaruh kid di header.
+func signClaims(claims jwt.Claims) (string, error) {
+	ks := CurrentKeySet()
+	if ks == nil {
+		return "", ErrNoSigningKeys
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(JwtSecret)
+	key := ks.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.KID
+
+	return token.SignedString(key.Private)
+}
+
+// keyFunc memilih kunci verifikasi berdasarkan kid di header token.
+func keyFunc(t *jwt.Token) (interface{}, error) {
+	ks := CurrentKeySet()
+	if ks == nil {
+		return nil, ErrNoSigningKeys
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	key, ok := ks.Lookup(kid)
+	if !ok {
+		return nil, errors.New("unknown kid")
+	}
+
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+
+	return key.Public, nil
 }
 
 func ValidateToken(tokenString string) (*models.JWTClaims, error) {
+	ks := CurrentKeySet()
+	if ks == nil {
+		return nil, ErrNoSigningKeys
+	}
+
 	token, err := jwt.ParseWithClaims(
 		tokenString,
 		&models.JWTClaims{},
-		func(t *jwt.Token) (interface{}, error) {
-			return JwtSecret, nil
-		},
+		keyFunc,
+		jwt.WithValidMethods(ks.methods()),
 	)
 
 	if err != nil {
@@ -42,23 +117,71 @@ func ValidateToken(tokenString string) (*models.JWTClaims, error) {
 	}
 
 	claims := token.Claims.(*models.JWTClaims)
+	for _, aud := range claims.Audience {
+		if aud == MFAChallengeAudience {
+			return nil, errors.New("not an access token")
+		}
+	}
+
 	return claims, nil
 }
 
+// GenerateMFAChallengeToken membuat token berumur pendek yang hanya bisa
+// ditukar di endpoint verifikasi MFA.
+func GenerateMFAChallengeToken(userID string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	exp := now.Add(ttl)
+
+	token, err := signClaims(jwt.RegisteredClaims{
+		ID:        uuid.New().String(),
+		Subject:   userID,
+		Audience:  jwt.ClaimStrings{MFAChallengeAudience},
+		ExpiresAt: jwt.NewNumericDate(exp),
+		IssuedAt:  jwt.NewNumericDate(now),
+	})
+
+	return token, exp, err
+}
+
+func ValidateMFAChallengeToken(tokenString string) (*jwt.RegisteredClaims, error) {
+	ks := CurrentKeySet()
+	if ks == nil {
+		return nil, ErrNoSigningKeys
+	}
+
+	token, err := jwt.ParseWithClaims(
+		tokenString,
+		&jwt.RegisteredClaims{},
+		keyFunc,
+		jwt.WithValidMethods(ks.methods()),
+		jwt.WithAudience(MFAChallengeAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return token.Claims.(*jwt.RegisteredClaims), nil
+}
+
 func GenerateRefreshToken() string {
 	// token untuk logout & refresh → aman disimpan di database/redis
 	return uuid.New().String()
 }
 
-var (
-    TokenBlacklist = make(map[string]time.Time)
-    BlacklistMutex sync.RWMutex
-)
-
-func IsBlacklisted(token string) bool {
-    BlacklistMutex.RLock()
-    exp, exists := TokenBlacklist[token]
-    BlacklistMutex.RUnlock()
+// HashToken menghasilkan SHA-256 hex dari token, dipakai agar refresh token
+// tidak disimpan dalam bentuk asli.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
 
-    return exists && time.Now().Before(exp)
+// GenerateSecureToken menghasilkan token acak 32 byte (base64url) untuk
+// tautan sekali pakai seperti reset password.
+func GenerateSecureToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	models "achievement_backend/app/model"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRSAKey(t *testing.T, kid string) *SigningKey {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	return &SigningKey{KID: kid, Method: jwt.SigningMethodRS256, Private: priv, Public: &priv.PublicKey}
+}
+
+func newEdKey(t *testing.T, kid string) *SigningKey {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	return &SigningKey{KID: kid, Method: jwt.SigningMethodEdDSA, Private: priv, Public: pub}
+}
+
+// =======================================================
+// ROTASI: TOKEN LAMA TETAP VALID SELAMA KID MASIH DITERIMA
+// =======================================================
+
+func TestValidateToken_KeyRotation(t *testing.T) {
+	oldKey := newRSAKey(t, "old")
+	newKey := newEdKey(t, "new")
+
+	ks, err := NewKeySet("old", oldKey)
+	assert.NoError(t, err)
+	SetKeySet(ks)
+
+	oldToken, err := GenerateToken(models.User{ID: "1", Username: "john"}, "Admin", nil, models.AuthVersion{}, "")
+	assert.NoError(t, err)
+
+	// rotasi: kunci baru aktif, kunci lama hanya untuk verifikasi
+	verifyOnly := &SigningKey{KID: "old", Method: oldKey.Method, Public: oldKey.Public}
+	ks, err = NewKeySet("new", newKey, verifyOnly)
+	assert.NoError(t, err)
+	SetKeySet(ks)
+
+	claims, err := ValidateToken(oldToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", claims.UserID)
+
+	newToken, err := GenerateToken(models.User{ID: "2"}, "Admin", nil, models.AuthVersion{}, "")
+	assert.NoError(t, err)
+	_, err = ValidateToken(newToken)
+	assert.NoError(t, err)
+
+	// kunci lama dikeluarkan → token lama ditolak
+	ks, _ = NewKeySet("new", newKey)
+	SetKeySet(ks)
+
+	_, err = ValidateToken(oldToken)
+	assert.Error(t, err)
+
+	assert.Len(t, ks.JWKS().Keys, 1)
+	assert.Equal(t, "OKP", ks.JWKS().Keys[0].Kty)
+}
+
+func TestNewKeySet_ActiveNeedsPrivateKey(t *testing.T) {
+	key := newRSAKey(t, "k1")
+	_, err := NewKeySet("k1", &SigningKey{KID: "k1", Method: key.Method, Public: key.Public})
+	assert.Error(t, err)
+}
@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey adalah satu kunci JWT yang diidentifikasi lewat kid.
+// Untuk HS256, Private dan Public berisi secret yang sama ([]byte).
+// Kunci lama yang hanya dipakai untuk verifikasi boleh tidak punya Private.
+type SigningKey struct {
+	KID     string
+	Method  jwt.SigningMethod
+	Private interface{}
+	Public  interface{}
+}
+
+// KeySet berisi kunci aktif untuk menandatangani token dan semua kunci
+// yang masih diterima saat verifikasi (selama masa rotasi).
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+func NewKeySet(activeKID string, keys ...*SigningKey) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*SigningKey)}
+
+	for _, k := range keys {
+		if k.KID == "" {
+			return nil, errors.New("signing key without kid")
+		}
+		if _, dup := ks.keys[k.KID]; dup {
+			return nil, errors.New("duplicate kid: " + k.KID)
+		}
+		ks.keys[k.KID] = k
+	}
+
+	active, ok := ks.keys[activeKID]
+	if !ok {
+		return nil, errors.New("active kid not found: " + activeKID)
+	}
+	if active.Private == nil {
+		return nil, errors.New("active key has no private key: " + activeKID)
+	}
+	ks.active = active
+
+	return ks, nil
+}
+
+func (ks *KeySet) Active() *SigningKey {
+	return ks.active
+}
+
+func (ks *KeySet) Lookup(kid string) (*SigningKey, bool) {
+	k, ok := ks.keys[kid]
+	return k, ok
+}
+
+func (ks *KeySet) methods() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, k := range ks.keys {
+		alg := k.Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			out = append(out, alg)
+		}
+	}
+	return out
+}
+
+// JWK mengikuti RFC 7517. Hanya field yang dipakai RSA, EC dan Ed25519.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// PublicKey mengubah JWK milik pihak lain (mis. IdP OIDC) menjadi public key
+// yang bisa dipakai jwt untuk verifikasi.
+func (k JWK) PublicKey() (interface{}, error) {
+	b64 := base64.RawURLEncoding
+
+	switch k.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, errors.New("unsupported curve " + k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errors.New("unsupported curve " + k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, errors.New("unsupported key type " + k.Kty)
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS mengembalikan public key dari semua kunci asimetris.
+// Kunci HS256 tidak pernah dipublikasikan.
+func (ks *KeySet) JWKS() JWKS {
+	out := JWKS{Keys: []JWK{}}
+
+	for _, k := range ks.keys {
+		switch pub := k.Public.(type) {
+		case *rsa.PublicKey:
+			out.Keys = append(out.Keys, JWK{
+				Kty: "RSA",
+				Use: "sig",
+				Alg: k.Method.Alg(),
+				Kid: k.KID,
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			out.Keys = append(out.Keys, JWK{
+				Kty: "OKP",
+				Use: "sig",
+				Alg: k.Method.Alg(),
+				Kid: k.KID,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+
+	return out
+}
+
+var (
+	keySet   *KeySet
+	keySetMu sync.RWMutex
+)
+
+// SetKeySet mengganti kunci yang dipakai GenerateToken dan ValidateToken.
+func SetKeySet(ks *KeySet) {
+	keySetMu.Lock()
+	keySet = ks
+	keySetMu.Unlock()
+}
+
+func CurrentKeySet() *KeySet {
+	keySetMu.RLock()
+	defer keySetMu.RUnlock()
+	return keySet
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer mengirim email. Implementasi: SMTPMailer untuk produksi,
+// FileMailer untuk development dan MemoryMailer untuk test.
+type Mailer interface {
+	Send(msg MailMessage) error
+}
+
+// ================= SMTP =================
+
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(msg MailMessage) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{msg.To}, formatMail(m.From, msg))
+}
+
+// ================= FILE =================
+
+// FileMailer menulis setiap email sebagai file .eml ke Dir.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (m *FileMailer) Send(msg MailMessage) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	safeTo := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), safeTo)
+
+	return os.WriteFile(filepath.Join(m.Dir, name), formatMail(m.From, msg), 0o600)
+}
+
+// ================= ASYNC =================
+
+// ErrMailQueueFull dikembalikan AsyncMailer jika antrean penuh.
+var ErrMailQueueFull = errors.New("mail queue full")
+
+// AsyncMailer mengantrekan email dan mengirimnya lewat Inner di goroutine
+// terpisah, sehingga handler tidak menunggu server SMTP. Ini juga menjaga
+// waktu respons endpoint seperti lupa password tetap sama, ada atau tidaknya
+// email yang dikirim. Gagal kirim hanya dicatat ke log.
+type AsyncMailer struct {
+	inner Mailer
+	queue chan MailMessage
+	done  chan struct{}
+}
+
+// NewAsyncMailer menjalankan worker pengirim dengan antrean sebesar queueSize.
+// Panggil Close saat shutdown agar email yang masih antre tetap terkirim.
+func NewAsyncMailer(inner Mailer, queueSize int) *AsyncMailer {
+	m := &AsyncMailer{
+		inner: inner,
+		queue: make(chan MailMessage, queueSize),
+		done:  make(chan struct{}),
+	}
+
+	go func() {
+		defer close(m.done)
+		for msg := range m.queue {
+			if err := m.inner.Send(msg); err != nil {
+				log.Printf("[Mail] send to %s error: %v", msg.To, err)
+			}
+		}
+	}()
+
+	return m
+}
+
+func (m *AsyncMailer) Send(msg MailMessage) error {
+	select {
+	case m.queue <- msg:
+		return nil
+	default:
+		return ErrMailQueueFull
+	}
+}
+
+// Close menunggu antrean habis terkirim. Send tidak boleh dipanggil lagi.
+func (m *AsyncMailer) Close() {
+	close(m.queue)
+	<-m.done
+}
+
+// ================= IN-MEMORY (testing) =================
+
+type MemoryMailer struct {
+	mu   sync.Mutex
+	sent []MailMessage
+}
+
+func (m *MemoryMailer) Send(msg MailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// Sent mengembalikan salinan email yang sudah dikirim.
+func (m *MemoryMailer) Sent() []MailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]MailMessage(nil), m.sent...)
+}
+
+func formatMail(from string, msg MailMessage) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+	return []byte(b.String())
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsyncMailer_CloseDrainsQueue(t *testing.T) {
+	inner := &MemoryMailer{}
+	m := NewAsyncMailer(inner, 10)
+
+	assert.NoError(t, m.Send(MailMessage{To: "a@mail.com"}))
+	assert.NoError(t, m.Send(MailMessage{To: "b@mail.com"}))
+	m.Close()
+
+	sent := inner.Sent()
+	assert.Len(t, sent, 2)
+	assert.Equal(t, "a@mail.com", sent[0].To)
+	assert.Equal(t, "b@mail.com", sent[1].To)
+}
+
+func TestAsyncMailer_QueueFull(t *testing.T) {
+	release := make(chan struct{})
+	m := NewAsyncMailer(mailerFunc(func(MailMessage) error {
+		<-release
+		return nil
+	}), 1)
+
+	// satu diambil worker dan tertahan, satu mengisi antrean
+	assert.NoError(t, m.Send(MailMessage{To: "a@mail.com"}))
+	assert.Eventually(t, func() bool { return len(m.queue) == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, m.Send(MailMessage{To: "b@mail.com"}))
+
+	assert.ErrorIs(t, m.Send(MailMessage{To: "c@mail.com"}), ErrMailQueueFull)
+
+	close(release)
+	m.Close()
+}
+
+type mailerFunc func(MailMessage) error
+
+func (f mailerFunc) Send(msg MailMessage) error { return f(msg) }
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Contoh dari RFC 7636 Appendix B.
+func TestPKCEChallenge_RFC7636(t *testing.T) {
+	assert.Equal(t,
+		"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		PKCEChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"),
+	)
+}
+
+func TestJWK_PublicKeyRoundTrip(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa")
+	edKey := newEdKey(t, "ed")
+
+	ks, err := NewKeySet("rsa", rsaKey, edKey)
+	assert.NoError(t, err)
+
+	for _, jwk := range ks.JWKS().Keys {
+		pub, err := jwk.PublicKey()
+		assert.NoError(t, err)
+
+		want, _ := ks.Lookup(jwk.Kid)
+		assert.Equal(t, want.Public, pub, jwk.Kid)
+	}
+
+	_, err = JWK{Kty: "oct"}.PublicKey()
+	assert.Error(t, err)
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	p := DefaultPasswordPolicy()
+
+	assert.NoError(t, p.Validate("Str0ng-Pass"))
+
+	err := p.Validate("short")
+	pe, ok := err.(*PasswordPolicyError)
+	assert.True(t, ok)
+	assert.Contains(t, pe.Violations, "must be at least 8 characters")
+	assert.Contains(t, pe.Violations, "must contain an uppercase letter")
+	assert.Contains(t, pe.Violations, "must contain a digit")
+}
+
+func TestPasswordPolicy_RequireSymbol(t *testing.T) {
+	p := DefaultPasswordPolicy()
+	p.RequireSymbol = true
+
+	assert.Error(t, p.Validate("Str0ngPass"))
+	assert.NoError(t, p.Validate("Str0ng-Pass"))
+}
+
+func TestPasswordPolicy_ForbiddenValues(t *testing.T) {
+	p := DefaultPasswordPolicy()
+
+	// NIM dan username ditolak tanpa membedakan huruf besar/kecil
+	assert.Error(t, p.Validate("John1234", "john1234"))
+	assert.Error(t, p.Validate("M2024001x", "", "m2024001X"))
+	assert.NoError(t, p.Validate("John12345", "john1234"))
+}